@@ -1,36 +1,68 @@
 package main
 
 import (
-	"fmt"
-	"log"
+	"context"
+	"log/slog"
+	"os"
+	"os/signal"
+	"syscall"
 
 	"github.com/chrisabs/cadence/internal/api"
 	"github.com/chrisabs/cadence/internal/config"
 	"github.com/chrisabs/cadence/internal/platform/database"
+	"github.com/chrisabs/cadence/internal/platform/logging"
+	"github.com/chrisabs/cadence/internal/platform/tracing"
 )
 
 func main() {
-	fmt.Println("\n=== Loading Configuration ===")
+	slog.SetDefault(logging.New())
+
+	slog.Info("loading configuration")
 	cfg, err := config.LoadConfig()
 	if err != nil {
-		log.Fatal("Configuration loading failed:", err)
+		slog.Error("configuration loading failed", "error", err)
+		os.Exit(1)
 	}
-	fmt.Println("Configuration loaded successfully!")
+	slog.Info("configuration loaded successfully")
 
-	fmt.Println("\n=== Initializing Database ===")
+	shutdownTracing, err := tracing.Init(context.Background())
+	if err != nil {
+		slog.Error("tracing initialization failed", "error", err)
+		os.Exit(1)
+	}
+
+	slog.Info("initializing database")
 	db, err := database.NewPostgresDB()
 	if err != nil {
-		log.Fatal("Database connection failed:", err)
+		slog.Error("database connection failed", "error", err)
+		os.Exit(1)
 	}
-	fmt.Println("Database connected successfully!")
+	slog.Info("database connected successfully")
 
 	if err := db.Init(); err != nil {
-		log.Fatal("Database initialization failed:", err)
+		slog.Error("database initialization failed", "error", err)
+		os.Exit(1)
 	}
-	fmt.Println("Database tables initialized successfully!")
+	slog.Info("database tables initialized successfully")
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
 
-	fmt.Println("\n=== Starting Server ===")
 	server := api.NewServer(":3000", db, cfg)
-	fmt.Println("Server starting on port 3000...")
-	server.Run()
+	slog.Info("starting server", "port", 3000)
+	runErr := server.Run(ctx)
+	if runErr != nil {
+		slog.Error("server stopped", "error", runErr)
+	}
+
+	// Flushed explicitly rather than deferred so buffered spans are not lost
+	// when exiting with an error.
+	if err := shutdownTracing(context.Background()); err != nil {
+		slog.Error("tracing shutdown failed", "error", err)
+	}
+
+	if runErr != nil {
+		os.Exit(1)
+	}
+	slog.Info("server stopped")
 }
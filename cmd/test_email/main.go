@@ -1,8 +1,10 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"os"
+	"time"
 
 	"github.com/chrisabs/cadence/internal/email"
 )
@@ -22,9 +24,11 @@ func main() {
 	}
 	
 	err = emailService.SendInviteEmail(
+		context.Background(),
 		recipientEmail,
 		"TEST_TOKEN_1234567890",
 		"Test Family",
+		time.Now().Add(7*24*time.Hour),
 	)
 	
 	if err != nil {
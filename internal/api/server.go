@@ -1,25 +1,44 @@
 package api
 
 import (
-	"log"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
 	"net/http"
+	"time"
 
+	"github.com/chrisabs/cadence/internal/auth"
 	"github.com/chrisabs/cadence/internal/chores"
+	"github.com/chrisabs/cadence/internal/cloud"
 	"github.com/chrisabs/cadence/internal/config"
+	"github.com/chrisabs/cadence/internal/email"
+	"github.com/chrisabs/cadence/internal/export"
 	"github.com/chrisabs/cadence/internal/family"
+	"github.com/chrisabs/cadence/internal/invitation"
 	"github.com/chrisabs/cadence/internal/middleware"
+	"github.com/chrisabs/cadence/internal/notification"
 	"github.com/chrisabs/cadence/internal/platform/database"
+	"github.com/chrisabs/cadence/internal/platform/metrics"
+	"github.com/chrisabs/cadence/internal/platform/tracing"
 	"github.com/chrisabs/cadence/internal/profile"
 	"github.com/chrisabs/cadence/internal/storage/container"
+	"github.com/chrisabs/cadence/internal/storage/inventory"
 	"github.com/chrisabs/cadence/internal/storage/item"
 	"github.com/chrisabs/cadence/internal/storage/recent"
 	"github.com/chrisabs/cadence/internal/storage/search"
 	"github.com/chrisabs/cadence/internal/storage/tag"
 	"github.com/chrisabs/cadence/internal/storage/workspace"
+	"github.com/chrisabs/cadence/internal/trash"
 	"github.com/gorilla/mux"
 	"github.com/rs/cors"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gorilla/mux/otelmux"
 )
 
+// shutdownTimeout is how long in-flight requests get to finish once the
+// server is asked to stop.
+const shutdownTimeout = 15 * time.Second
+
 type Server struct {
 	listenAddr string
 	db         *database.PostgresDB
@@ -34,95 +53,197 @@ func NewServer(listenAddr string, db *database.PostgresDB, config *config.Config
 	}
 }
 
-func (s *Server) Run() {
+// Run serves the API until ctx is cancelled, then lets in-flight requests
+// finish and stops the background jobs. It returns an error only if the
+// server could not run or shut down cleanly.
+func (s *Server) Run(ctx context.Context) error {
 	router := mux.NewRouter()
+	router.Use(otelmux.Middleware(tracing.ServiceName), middleware.MatchedRoute)
+
+	if err := metrics.RegisterDBStats("postgres", s.db.DB); err != nil {
+		slog.Warn("failed to register database metrics", "error", err)
+	}
+
+	metricsRouter := mux.NewRouter()
+	metricsRouter.Handle("/metrics", metrics.Handler()).Methods("GET")
+	metricsServer := &http.Server{Addr: s.config.MetricsAddr, Handler: metricsRouter}
 
 	// CORS setup
 	c := cors.New(cors.Options{
 		AllowedOrigins:   []string{"*"},
 		AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
 		AllowedHeaders:   []string{"*"},
-		ExposedHeaders:   []string{"Content-Length"},
+		ExposedHeaders:   []string{"Content-Length", middleware.RequestIDHeader},
 		AllowCredentials: true,
 		Debug:            true,
 	})
 
+	if err := auth.SetTrustedProxies(s.config.TrustedProxies); err != nil {
+		return fmt.Errorf("invalid TRUSTED_PROXIES: %w", err)
+	}
+
 	// Initialise repositories
+	authRepo := auth.NewRepository(s.db.DB)
 	familyRepo := family.NewRepository(s.db.DB)
 	profileRepo := profile.NewRepository(s.db.DB)
+	notificationRepo := notification.NewRepository(s.db.DB)
+	invitationRepo := invitation.NewRepository(s.db.DB)
+	exportRepo := export.NewRepository(s.db.DB)
+	trashRepo := trash.NewRepository(s.db.DB)
 	containerRepo := container.NewRepository(s.db.DB)
 	workspaceRepo := workspace.NewRepository(s.db.DB)
 	itemRepo := item.NewRepository(s.db.DB)
 	tagRepo := tag.NewRepository(s.db.DB)
 	searchRepo := search.NewRepository(s.db.DB)
 	recentRepo := recent.NewRepository(s.db.DB)
-	choreRepo := chores.NewRepository(s.db.DB)  
+	inventoryRepo := inventory.NewRepository(s.db.DB)
+	choreRepo := chores.NewRepository(s.db.DB)
 
 	// Initialise core services
+	authService := auth.NewService(
+		authRepo,
+		s.config.JWTSecret,
+	)
+
 	familyService := family.NewService(
 		familyRepo,
-		s.config.JWTSecret,
+		authService,
 	)
-	
+
 	profileService := profile.NewService(
 		profileRepo,
-		s.config.JWTSecret,
+		authService,
 	)
-	
+
+	notificationService := notification.NewService(notificationRepo)
+	invitationService := invitation.NewService(invitationRepo, familyService, profileService)
+	exportService := export.NewService(exportRepo)
+	trashService := trash.NewService(trashRepo)
+
+	// Email is optional so local setups without SES still start
+	emailService, err := email.NewService()
+	if err != nil {
+		slog.Warn("email service unavailable", "error", err)
+	}
+
 	// Set cross-service dependencies
+	authService.SetProfileService(profileService)
 	familyService.SetProfileService(profileService)
-	
+	if emailService != nil {
+		familyService.SetEmailService(emailService)
+		invitationService.SetEmailService(emailService)
+		exportService.SetEmailService(emailService)
+	}
+
+	// Exports and purged images live in S3; without it exports are refused
+	// and trash holding images is kept until storage is configured
+	s3Handler, err := cloud.NewS3Handler()
+	if err != nil {
+		slog.Warn("file storage unavailable, exports disabled and trash images kept", "error", err)
+	} else {
+		exportService.SetObjectStore(s3Handler)
+		trashService.SetObjectStore(s3Handler)
+		go exportService.Run(ctx)
+	}
+	go trashService.Run(ctx)
+	profileService.SetNotificationService(notificationService)
+
 	// Initialise auth middleware
 	authMiddleware := middleware.NewAuthMiddleware(
 		s.config.JWTSecret,
 		s.db.DB,
 		familyService,
 		profileService,
+		authService,
 	)
-	
+
 	// Initialise module services
 	workspaceService := workspace.NewService(workspaceRepo)
 	containerService := container.NewService(containerRepo)
 	itemService := item.NewService(itemRepo)
+	itemService.SetNotificationService(notificationService)
 	tagService := tag.NewService(tagRepo)
 	searchService := search.NewService(searchRepo)
 	recentService := recent.NewService(recentRepo)
-	choreService := chores.NewService(choreRepo) 
+	inventoryService := inventory.NewService(inventoryRepo, containerRepo, itemRepo)
+	choreService := chores.NewService(choreRepo)
 
 	// Initialise handlers
 	familyHandler := family.NewHandler(
 		familyService,
 		authMiddleware,
 	)
-	
+
+	authHandler := auth.NewHandler(authService, authMiddleware)
+
 	profileHandler := profile.NewHandler(
-		profileService, 
+		profileService,
 		authMiddleware,
 	)
-	
+
+	notificationHandler := notification.NewHandler(notificationService, authMiddleware)
+	invitationHandler := invitation.NewHandler(invitationService, authMiddleware)
+	exportHandler := export.NewHandler(exportService, authMiddleware)
+	trashHandler := trash.NewHandler(trashService, authMiddleware)
 	workspaceHandler := workspace.NewHandler(workspaceService, authMiddleware)
 	containerHandler := container.NewHandler(containerService, authMiddleware)
-	itemHandler := item.NewHandler(itemService, containerService, authMiddleware)
+	itemHandler := item.NewHandler(itemService, authMiddleware)
 	tagHandler := tag.NewHandler(tagService, authMiddleware)
 	searchHandler := search.NewHandler(searchService, authMiddleware)
 	recentHandler := recent.NewHandler(recentService, authMiddleware)
-	choreHandler := chores.NewHandler(choreService, authMiddleware)  
+	inventoryHandler := inventory.NewHandler(inventoryService, authMiddleware)
+	choreHandler := chores.NewHandler(choreService, authMiddleware)
 
 	// Register routes
+	authHandler.RegisterRoutes(router)
 	familyHandler.RegisterRoutes(router)
 	profileHandler.RegisterRoutes(router)
+	notificationHandler.RegisterRoutes(router)
+	invitationHandler.RegisterRoutes(router)
+	exportHandler.RegisterRoutes(router)
+	trashHandler.RegisterRoutes(router)
 	workspaceHandler.RegisterRoutes(router)
 	containerHandler.RegisterRoutes(router)
 	itemHandler.RegisterRoutes(router)
 	tagHandler.RegisterRoutes(router)
 	searchHandler.RegisterRoutes(router)
 	recentHandler.RegisterRoutes(router)
-	choreHandler.RegisterRoutes(router)  
+	inventoryHandler.RegisterRoutes(router)
+	choreHandler.RegisterRoutes(router)
 
-	handler := c.Handler(router)
+	// Wrapped around the router rather than added with router.Use so that
+	// unmatched routes and CORS preflights are logged, counted and recovered too
+	handler := middleware.RequestID(middleware.RequestLogger(middleware.Metrics(middleware.Recoverer(c.Handler(router)))))
 
-	log.Printf("JSON API server running on port: %s", s.listenAddr)
-	if err := http.ListenAndServe(s.listenAddr, handler); err != nil {
-		log.Fatal("Server failed to start:", err)
+	httpServer := &http.Server{Addr: s.listenAddr, Handler: handler}
+
+	serveErr := make(chan error, 2)
+	go func() {
+		serveErr <- httpServer.ListenAndServe()
+	}()
+	go func() {
+		serveErr <- metricsServer.ListenAndServe()
+	}()
+
+	slog.Info("JSON API server running", "addr", s.listenAddr)
+	slog.Info("metrics server running", "addr", s.config.MetricsAddr)
+
+	select {
+	case err := <-serveErr:
+		return fmt.Errorf("server failed: %w", err)
+	case <-ctx.Done():
+	}
+
+	slog.Info("shutting down server")
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+
+	if err := httpServer.Shutdown(shutdownCtx); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return fmt.Errorf("error shutting down server: %w", err)
 	}
+	if err := metricsServer.Shutdown(shutdownCtx); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return fmt.Errorf("error shutting down metrics server: %w", err)
+	}
+
+	return nil
 }
\ No newline at end of file
@@ -0,0 +1,289 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/chrisabs/cadence/internal/apperror"
+	"github.com/chrisabs/cadence/internal/family"
+	"github.com/chrisabs/cadence/internal/middleware"
+	"github.com/chrisabs/cadence/internal/models"
+	"github.com/chrisabs/cadence/internal/storage/container"
+	"github.com/chrisabs/cadence/internal/storage/inventory"
+	"github.com/chrisabs/cadence/internal/storage/item"
+	"github.com/chrisabs/cadence/internal/storage/recent"
+	"github.com/chrisabs/cadence/internal/storage/search"
+	"github.com/chrisabs/cadence/internal/storage/tag"
+	"github.com/chrisabs/cadence/internal/storage/workspace"
+	"github.com/golang-jwt/jwt"
+	"github.com/gorilla/mux"
+)
+
+const testJWTSecret = "test-secret"
+
+// storageRoutes is every storage route with the permission it requires.
+var storageRoutes = []struct {
+	method     string
+	path       string
+	permission models.Permission
+}{
+	{"GET", "/workspaces", models.PermissionRead},
+	{"POST", "/workspaces", models.PermissionWrite},
+	{"GET", "/workspaces/1", models.PermissionRead},
+	{"PUT", "/workspaces/1", models.PermissionWrite},
+	{"DELETE", "/workspaces/1", models.PermissionManage},
+	{"PUT", "/workspaces/1/restore", models.PermissionManage},
+
+	{"GET", "/containers", models.PermissionRead},
+	{"POST", "/containers", models.PermissionWrite},
+	{"POST", "/containers/labels", models.PermissionRead},
+	{"GET", "/containers/1", models.PermissionRead},
+	{"PUT", "/containers/1", models.PermissionWrite},
+	{"DELETE", "/containers/1", models.PermissionManage},
+	{"GET", "/containers/1/items", models.PermissionRead},
+	{"GET", "/containers/1/activity", models.PermissionRead},
+	{"PUT", "/containers/1/restore", models.PermissionManage},
+	{"POST", "/containers/1/qr", models.PermissionManage},
+	{"GET", "/containers/qr/abc", models.PermissionRead},
+
+	{"GET", "/items", models.PermissionRead},
+	{"POST", "/items", models.PermissionWrite},
+	{"GET", "/items/alerts", models.PermissionRead},
+	{"POST", "/items/bulk", models.PermissionWrite},
+	{"GET", "/items/1", models.PermissionRead},
+	{"PUT", "/items/1", models.PermissionWrite},
+	{"DELETE", "/items/1", models.PermissionManage},
+	{"GET", "/items/1/history", models.PermissionRead},
+	{"PUT", "/items/1/restore", models.PermissionManage},
+
+	{"GET", "/tags", models.PermissionRead},
+	{"POST", "/tags", models.PermissionWrite},
+	{"GET", "/tags/1", models.PermissionRead},
+	{"PUT", "/tags/1", models.PermissionWrite},
+	{"DELETE", "/tags/1", models.PermissionManage},
+	{"PUT", "/tags/1/restore", models.PermissionManage},
+	{"POST", "/tags/assign", models.PermissionWrite},
+
+	{"GET", "/search?q=box", models.PermissionRead},
+	{"GET", "/search/workspaces?q=box", models.PermissionRead},
+	{"GET", "/search/containers?q=box", models.PermissionRead},
+	{"GET", "/search/items?q=box", models.PermissionRead},
+	{"GET", "/search/tags?q=box", models.PermissionRead},
+	{"GET", "/search/containers/qr/abc", models.PermissionRead},
+	{"GET", "/recent", models.PermissionRead},
+
+	{"GET", "/inventory/export", models.PermissionRead},
+	{"POST", "/inventory/import", models.PermissionWrite},
+}
+
+// stubFamilyService answers permission checks the way family.Service does,
+// from an enabled flag and a permission matrix instead of the database.
+type stubFamilyService struct {
+	moduleEnabled bool
+	permissions   *family.Permissions
+	unverified    bool
+}
+
+func (s *stubFamilyService) IsModuleEnabled(ctx context.Context, familyID int, moduleID models.ModuleID) (bool, error) {
+	return s.moduleEnabled, nil
+}
+
+func (s *stubFamilyService) HasModulePermission(ctx context.Context, familyID int, profileID int, role models.ProfileRole, moduleID models.ModuleID, permission models.Permission) (bool, error) {
+	if !s.moduleEnabled {
+		return false, nil
+	}
+	return s.permissions.Allows(profileID, role, moduleID, permission), nil
+}
+
+func (s *stubFamilyService) IsEmailVerified(ctx context.Context, familyID int) (bool, error) {
+	return !s.unverified, nil
+}
+
+type stubProfileService struct{}
+
+func (stubProfileService) GetProfileByID(ctx context.Context, id int) (*models.Profile, error) {
+	return nil, apperror.NotFound("profile not found")
+}
+
+type stubTokenService struct{}
+
+func (stubTokenService) IsTokenRevoked(ctx context.Context, jti string, sessionID string, familyID int, issuedAt time.Time) (bool, error) {
+	return false, nil
+}
+
+// unavailableDB fails every query, so a request that gets past the guards
+// ends in an error from the handler rather than touching a real database.
+type unavailableDB struct{}
+
+var errDatabaseUnavailable = errors.New("database unavailable")
+
+func (unavailableDB) Connect(context.Context) (driver.Conn, error) {
+	return nil, errDatabaseUnavailable
+}
+func (unavailableDB) Driver() driver.Driver            { return unavailableDB{} }
+func (unavailableDB) Open(string) (driver.Conn, error) { return nil, errDatabaseUnavailable }
+
+func newStorageRouter(t *testing.T, familyService *stubFamilyService) (http.Handler, *middleware.AuthMiddleware) {
+	t.Helper()
+
+	db := sql.OpenDB(unavailableDB{})
+	t.Cleanup(func() { db.Close() })
+
+	authMiddleware := middleware.NewAuthMiddleware(testJWTSecret, db, familyService, stubProfileService{}, stubTokenService{})
+
+	containerRepo := container.NewRepository(db)
+	itemRepo := item.NewRepository(db)
+
+	router := mux.NewRouter()
+	workspace.NewHandler(workspace.NewService(workspace.NewRepository(db)), authMiddleware).RegisterRoutes(router)
+	container.NewHandler(container.NewService(containerRepo), authMiddleware).RegisterRoutes(router)
+	item.NewHandler(item.NewService(itemRepo), authMiddleware).RegisterRoutes(router)
+	tag.NewHandler(tag.NewService(tag.NewRepository(db)), authMiddleware).RegisterRoutes(router)
+	search.NewHandler(search.NewService(search.NewRepository(db)), authMiddleware).RegisterRoutes(router)
+	recent.NewHandler(recent.NewService(recent.NewRepository(db)), authMiddleware).RegisterRoutes(router)
+	inventory.NewHandler(inventory.NewService(inventory.NewRepository(db), containerRepo, itemRepo), authMiddleware).RegisterRoutes(router)
+
+	return middleware.Recoverer(router), authMiddleware
+}
+
+func signToken(t *testing.T, claims jwt.MapClaims) string {
+	t.Helper()
+
+	claims["familyId"] = 1
+	claims["jti"] = "test-token"
+	claims["iat"] = time.Now().Unix()
+
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(testJWTSecret))
+	if err != nil {
+		t.Fatalf("signing token: %v", err)
+	}
+	return token
+}
+
+func profileToken(t *testing.T, profileID int, role models.ProfileRole) string {
+	return signToken(t, jwt.MapClaims{"profileId": profileID, "role": string(role)})
+}
+
+func serve(handler http.Handler, method, path, token string) int {
+	req := httptest.NewRequest(method, path, nil)
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	return rec.Code
+}
+
+func isDenied(status int) bool {
+	return status == http.StatusUnauthorized || status == http.StatusForbidden
+}
+
+func TestStorageRoutesByRole(t *testing.T) {
+	const overrideProfileID = 7
+
+	permissions := family.DefaultPermissions()
+	permissions.Profiles[overrideProfileID] = map[models.ModuleID][]models.Permission{
+		models.ModuleStorage: {models.PermissionRead, models.PermissionWrite},
+	}
+
+	handler, _ := newStorageRouter(t, &stubFamilyService{moduleEnabled: true, permissions: permissions})
+
+	profiles := []struct {
+		name    string
+		token   string
+		granted map[models.Permission]bool
+	}{
+		{
+			name:    "parent",
+			token:   profileToken(t, 1, models.RoleParent),
+			granted: map[models.Permission]bool{models.PermissionRead: true, models.PermissionWrite: true, models.PermissionManage: true},
+		},
+		{
+			name:    "child",
+			token:   profileToken(t, 2, models.RoleChild),
+			granted: map[models.Permission]bool{models.PermissionRead: true},
+		},
+		{
+			name:    "child with write override",
+			token:   profileToken(t, overrideProfileID, models.RoleChild),
+			granted: map[models.Permission]bool{models.PermissionRead: true, models.PermissionWrite: true},
+		},
+	}
+
+	for _, profile := range profiles {
+		for _, route := range storageRoutes {
+			t.Run(profile.name+" "+route.method+" "+route.path, func(t *testing.T) {
+				status := serve(handler, route.method, route.path, profile.token)
+
+				if profile.granted[route.permission] {
+					if isDenied(status) {
+						t.Errorf("expected access with %s, got %d", route.permission, status)
+					}
+				} else if status != http.StatusForbidden {
+					t.Errorf("expected 403 without %s, got %d", route.permission, status)
+				}
+			})
+		}
+	}
+}
+
+func TestStorageRoutesModuleDisabled(t *testing.T) {
+	handler, _ := newStorageRouter(t, &stubFamilyService{moduleEnabled: false, permissions: family.DefaultPermissions()})
+	token := profileToken(t, 1, models.RoleParent)
+
+	for _, route := range storageRoutes {
+		t.Run(route.method+" "+route.path, func(t *testing.T) {
+			if status := serve(handler, route.method, route.path, token); status != http.StatusForbidden {
+				t.Errorf("expected 403, got %d", status)
+			}
+		})
+	}
+}
+
+func TestStorageRoutesUnverifiedEmail(t *testing.T) {
+	handler, _ := newStorageRouter(t, &stubFamilyService{moduleEnabled: true, permissions: family.DefaultPermissions(), unverified: true})
+	token := profileToken(t, 1, models.RoleParent)
+
+	for _, route := range storageRoutes {
+		t.Run(route.method+" "+route.path, func(t *testing.T) {
+			status := serve(handler, route.method, route.path, token)
+
+			if route.permission != models.PermissionRead && route.method != http.MethodGet {
+				if status != http.StatusForbidden {
+					t.Errorf("expected 403 for a write without a verified email, got %d", status)
+				}
+			} else if isDenied(status) {
+				t.Errorf("expected reads to stay available, got %d", status)
+			}
+		})
+	}
+}
+
+func TestStorageRoutesWithoutProfile(t *testing.T) {
+	handler, authMiddleware := newStorageRouter(t, &stubFamilyService{moduleEnabled: true, permissions: family.DefaultPermissions()})
+
+	// A family token has no profile, so it cannot reach profile routes.
+	familyToken := signToken(t, jwt.MapClaims{})
+
+	for _, route := range storageRoutes {
+		t.Run(route.method+" "+route.path, func(t *testing.T) {
+			if status := serve(handler, route.method, route.path, ""); status != http.StatusUnauthorized {
+				t.Errorf("without a token: expected 401, got %d", status)
+			}
+			if status := serve(handler, route.method, route.path, familyToken); status != http.StatusUnauthorized {
+				t.Errorf("with a family token: expected 401, got %d", status)
+			}
+		})
+	}
+
+	err := authMiddleware.CheckModulePermission(context.Background(), models.ModuleStorage, models.PermissionRead)
+	if !errors.Is(err, apperror.ErrUnauthorized) {
+		t.Errorf("expected unauthorized without a profile context, got %v", err)
+	}
+}
@@ -0,0 +1,118 @@
+package apperror
+
+import (
+	"errors"
+	"net/http"
+)
+
+type Code string
+
+const (
+	CodeBadRequest   Code = "bad_request"
+	CodeValidation   Code = "validation_failed"
+	CodeUnauthorized Code = "unauthorized"
+	CodeForbidden    Code = "forbidden"
+	CodeNotFound     Code = "not_found"
+	CodeConflict     Code = "conflict"
+	CodeRateLimited  Code = "rate_limited"
+	CodeInternal     Code = "internal_error"
+)
+
+// Error is a domain error carrying enough information to be rendered as an
+// API response. Repositories and services return it (optionally wrapped with
+// %w) and the HTTP layer derives the status code from Code.
+type Error struct {
+	Code    Code
+	Message string
+	Details map[string]string
+	Err     error
+}
+
+var (
+	ErrNotFound     = &Error{Code: CodeNotFound}
+	ErrValidation   = &Error{Code: CodeValidation}
+	ErrConflict     = &Error{Code: CodeConflict}
+	ErrForbidden    = &Error{Code: CodeForbidden}
+	ErrUnauthorized = &Error{Code: CodeUnauthorized}
+)
+
+func (e *Error) Error() string {
+	if e.Err != nil {
+		return e.Message + ": " + e.Err.Error()
+	}
+	return e.Message
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Is matches on Code, so errors.Is(err, apperror.ErrNotFound) holds for any
+// not found error regardless of its message.
+func (e *Error) Is(target error) bool {
+	t, ok := target.(*Error)
+	return ok && t.Code == e.Code
+}
+
+func (e *Error) Status() int {
+	switch e.Code {
+	case CodeBadRequest, CodeValidation:
+		return http.StatusBadRequest
+	case CodeUnauthorized:
+		return http.StatusUnauthorized
+	case CodeForbidden:
+		return http.StatusForbidden
+	case CodeNotFound:
+		return http.StatusNotFound
+	case CodeConflict:
+		return http.StatusConflict
+	case CodeRateLimited:
+		return http.StatusTooManyRequests
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+func BadRequest(message string) *Error {
+	return &Error{Code: CodeBadRequest, Message: message}
+}
+
+func Validation(message string, details map[string]string) *Error {
+	return &Error{Code: CodeValidation, Message: message, Details: details}
+}
+
+func Unauthorized(message string) *Error {
+	return &Error{Code: CodeUnauthorized, Message: message}
+}
+
+func Forbidden(message string) *Error {
+	return &Error{Code: CodeForbidden, Message: message}
+}
+
+func NotFound(message string) *Error {
+	return &Error{Code: CodeNotFound, Message: message}
+}
+
+func Conflict(message string) *Error {
+	return &Error{Code: CodeConflict, Message: message}
+}
+
+func RateLimited(message string, details map[string]string) *Error {
+	return &Error{Code: CodeRateLimited, Message: message, Details: details}
+}
+
+// Internal hides the cause from clients; it is still available through
+// Unwrap for logging.
+func Internal(err error) *Error {
+	return &Error{Code: CodeInternal, Message: "internal server error", Err: err}
+}
+
+// As returns the first *Error in err's chain, or an internal error wrapping
+// err when there is none.
+func As(err error) *Error {
+	var appErr *Error
+	if errors.As(err, &appErr) {
+		return appErr
+	}
+	return Internal(err)
+}
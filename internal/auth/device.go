@@ -0,0 +1,102 @@
+package auth
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+)
+
+const maxUserAgentLength = 512
+
+// trustedProxies are the reverse proxies whose X-Forwarded-For header is
+// believed. It is set once at startup.
+var trustedProxies []*net.IPNet
+
+// SetTrustedProxies sets the proxies, as IPs or CIDR ranges, allowed to report
+// the client address in X-Forwarded-For. With none set the header is ignored.
+func SetTrustedProxies(proxies []string) error {
+	nets := make([]*net.IPNet, 0, len(proxies))
+	for _, proxy := range proxies {
+		if !strings.Contains(proxy, "/") {
+			ip := net.ParseIP(proxy)
+			if ip == nil {
+				return fmt.Errorf("invalid trusted proxy %q", proxy)
+			}
+			if ip.To4() != nil {
+				proxy += "/32"
+			} else {
+				proxy += "/128"
+			}
+		}
+
+		_, network, err := net.ParseCIDR(proxy)
+		if err != nil {
+			return fmt.Errorf("invalid trusted proxy %q: %w", proxy, err)
+		}
+		nets = append(nets, network)
+	}
+
+	trustedProxies = nets
+	return nil
+}
+
+// DeviceFromRequest describes the device making r. The client IP is taken from
+// X-Forwarded-For only when the request came through a trusted proxy.
+func DeviceFromRequest(r *http.Request, name string) *DeviceInfo {
+	userAgent := r.UserAgent()
+	if len(userAgent) > maxUserAgentLength {
+		userAgent = userAgent[:maxUserAgentLength]
+	}
+
+	return &DeviceInfo{
+		Name:      name,
+		UserAgent: userAgent,
+		IPAddress: clientIP(r),
+	}
+}
+
+// clientIP walks X-Forwarded-For from the right, skipping trusted proxies, and
+// returns the first hop that is not one. Hops further left were written by
+// the client and cannot be believed.
+func clientIP(r *http.Request) string {
+	remote := r.RemoteAddr
+	if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		remote = host
+	}
+
+	if !isTrustedProxy(net.ParseIP(remote)) {
+		return remote
+	}
+
+	hops := strings.Split(strings.Join(r.Header.Values("X-Forwarded-For"), ","), ",")
+	client := remote
+	for i := len(hops) - 1; i >= 0; i-- {
+		hop := strings.TrimSpace(hops[i])
+		if hop == "" {
+			continue
+		}
+		ip := net.ParseIP(hop)
+		if ip == nil {
+			break
+		}
+		client = ip.String()
+		if !isTrustedProxy(ip) {
+			break
+		}
+	}
+
+	return client
+}
+
+func isTrustedProxy(ip net.IP) bool {
+	if ip == nil {
+		return false
+	}
+	for _, network := range trustedProxies {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
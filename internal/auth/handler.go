@@ -0,0 +1,139 @@
+package auth
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/chrisabs/cadence/internal/apperror"
+	"github.com/chrisabs/cadence/internal/middleware"
+	"github.com/chrisabs/cadence/internal/models"
+	"github.com/chrisabs/cadence/internal/platform/respond"
+	"github.com/gorilla/mux"
+)
+
+type Handler struct {
+	service        *Service
+	authMiddleware *middleware.AuthMiddleware
+}
+
+func NewHandler(service *Service, authMiddleware *middleware.AuthMiddleware) *Handler {
+	return &Handler{
+		service:        service,
+		authMiddleware: authMiddleware,
+	}
+}
+
+func (h *Handler) RegisterRoutes(router *mux.Router) {
+	router.HandleFunc("/auth/refresh", h.handleRefresh).Methods("POST")
+	router.HandleFunc("/auth/logout", h.handleLogout).Methods("POST")
+	router.HandleFunc("/auth/sign-out-all", h.authMiddleware.ProfileAuthHandler(h.authMiddleware.RequireOwner(h.handleSignOutAll))).Methods("POST")
+
+	router.HandleFunc("/auth/sessions", h.authMiddleware.ProfileAuthHandler(h.authMiddleware.RequireRole(models.RoleParent)(h.handleGetSessions))).Methods("GET")
+	router.HandleFunc("/auth/sessions/{id}", h.authMiddleware.ProfileAuthHandler(h.authMiddleware.RequireRole(models.RoleParent)(h.handleRevokeSession))).Methods("DELETE")
+}
+
+func (h *Handler) handleRefresh(w http.ResponseWriter, r *http.Request) {
+	var req RefreshRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respond.Error(w, r, apperror.BadRequest("invalid request body"))
+		return
+	}
+
+	pair, err := h.service.Refresh(r.Context(), &req, DeviceFromRequest(r, ""))
+	if err != nil {
+		respond.Error(w, r, err)
+		return
+	}
+
+	respond.JSON(w, http.StatusOK, pair)
+}
+
+func (h *Handler) handleLogout(w http.ResponseWriter, r *http.Request) {
+	var req LogoutRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			respond.Error(w, r, apperror.BadRequest("invalid request body"))
+			return
+		}
+	}
+
+	var accessToken string
+	if authHeader := r.Header.Get("Authorization"); authHeader != "" {
+		bearerToken := strings.Split(authHeader, " ")
+		if len(bearerToken) != 2 || bearerToken[0] != "Bearer" {
+			respond.Error(w, r, apperror.Unauthorized("invalid authorization format"))
+			return
+		}
+		accessToken = bearerToken[1]
+	}
+
+	if err := h.service.Logout(r.Context(), accessToken, &req); err != nil {
+		respond.Error(w, r, err)
+		return
+	}
+
+	respond.JSON(w, http.StatusOK, map[string]bool{"loggedOut": true})
+}
+
+func (h *Handler) handleSignOutAll(w http.ResponseWriter, r *http.Request) {
+	profileCtx, ok := middleware.ProfileFrom(r.Context())
+	if !ok {
+		respond.Error(w, r, apperror.Unauthorized("profile authentication required"))
+		return
+	}
+
+	if err := h.service.SignOutAll(r.Context(), profileCtx.FamilyID); err != nil {
+		respond.Error(w, r, err)
+		return
+	}
+
+	respond.JSON(w, http.StatusOK, map[string]bool{"signedOut": true})
+}
+
+func (h *Handler) handleGetSessions(w http.ResponseWriter, r *http.Request) {
+	profileCtx, ok := middleware.ProfileFrom(r.Context())
+	if !ok {
+		respond.Error(w, r, apperror.Unauthorized("profile authentication required"))
+		return
+	}
+
+	sessions, err := h.service.GetSessions(r.Context(), profileCtx.FamilyID, profileCtx.SessionID)
+	if err != nil {
+		respond.Error(w, r, err)
+		return
+	}
+
+	respond.JSON(w, http.StatusOK, sessions)
+}
+
+func (h *Handler) handleRevokeSession(w http.ResponseWriter, r *http.Request) {
+	profileCtx, ok := middleware.ProfileFrom(r.Context())
+	if !ok {
+		respond.Error(w, r, apperror.Unauthorized("profile authentication required"))
+		return
+	}
+
+	sessionID, err := getIDFromRequest(r)
+	if err != nil {
+		respond.Error(w, r, err)
+		return
+	}
+
+	if err := h.service.RevokeSession(r.Context(), sessionID, profileCtx.FamilyID); err != nil {
+		respond.Error(w, r, err)
+		return
+	}
+
+	respond.JSON(w, http.StatusOK, map[string]int{"revoked": sessionID})
+}
+
+func getIDFromRequest(r *http.Request) (int, error) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		return 0, apperror.BadRequest("invalid id")
+	}
+	return id, nil
+}
@@ -0,0 +1,56 @@
+package auth
+
+import "time"
+
+// RefreshToken is the server-side record of an issued refresh token. Only the
+// SHA-256 hash of the token is stored. Tokens rotated from the same login share
+// a ChainID so reuse of a rotated token can revoke the whole chain.
+type RefreshToken struct {
+	ID         int
+	FamilyID   int
+	ProfileID  *int
+	ChainID    string
+	TokenHash  string
+	ExpiresAt  time.Time
+	RevokedAt  *time.Time
+	ReplacedBy *int
+	CreatedAt  time.Time
+}
+
+type TokenPair struct {
+	Token        string    `json:"token"`
+	RefreshToken string    `json:"refreshToken"`
+	ExpiresAt    time.Time `json:"expiresAt"`
+}
+
+type RefreshRequest struct {
+	RefreshToken string `json:"refreshToken" validate:"required"`
+}
+
+type LogoutRequest struct {
+	RefreshToken string `json:"refreshToken"`
+}
+
+// Session is a device signed in to the family account. Its ChainID ties it to
+// the refresh token chain started at login and to the "sid" claim of every
+// access token issued on that device. ProfileID is set when an adult signed in
+// directly with their own profile login.
+type Session struct {
+	ID         int        `json:"id"`
+	FamilyID   int        `json:"familyId"`
+	ProfileID  *int       `json:"profileId,omitempty"`
+	ChainID    string     `json:"-"`
+	DeviceName string     `json:"deviceName"`
+	UserAgent  string     `json:"userAgent"`
+	IPAddress  string     `json:"ipAddress"`
+	Current    bool       `json:"current"`
+	CreatedAt  time.Time  `json:"createdAt"`
+	LastSeenAt time.Time  `json:"lastSeenAt"`
+	RevokedAt  *time.Time `json:"-"`
+}
+
+type DeviceInfo struct {
+	Name      string
+	UserAgent string
+	IPAddress string
+}
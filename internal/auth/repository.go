@@ -0,0 +1,399 @@
+package auth
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/chrisabs/cadence/internal/apperror"
+)
+
+type Repository struct {
+	db *sql.DB
+}
+
+func NewRepository(db *sql.DB) *Repository {
+	return &Repository{db: db}
+}
+
+func (r *Repository) CreateRefreshToken(ctx context.Context, token *RefreshToken) error {
+	query := `
+		INSERT INTO refresh_token (
+			family_id, profile_id, chain_id, token_hash, expires_at, created_at
+		) VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id, created_at`
+
+	err := r.db.QueryRowContext(ctx,
+		query,
+		token.FamilyID,
+		token.ProfileID,
+		token.ChainID,
+		token.TokenHash,
+		token.ExpiresAt,
+		time.Now().UTC(),
+	).Scan(&token.ID, &token.CreatedAt)
+
+	if err != nil {
+		return fmt.Errorf("error creating refresh token: %w", err)
+	}
+
+	return nil
+}
+
+func (r *Repository) GetRefreshTokenByHash(ctx context.Context, tokenHash string) (*RefreshToken, error) {
+	query := `
+		SELECT id, family_id, profile_id, chain_id, token_hash, expires_at, revoked_at, replaced_by, created_at
+		FROM refresh_token
+		WHERE token_hash = $1`
+
+	token := new(RefreshToken)
+	err := r.db.QueryRowContext(ctx, query, tokenHash).Scan(
+		&token.ID,
+		&token.FamilyID,
+		&token.ProfileID,
+		&token.ChainID,
+		&token.TokenHash,
+		&token.ExpiresAt,
+		&token.RevokedAt,
+		&token.ReplacedBy,
+		&token.CreatedAt,
+	)
+
+	if err == sql.ErrNoRows {
+		return nil, apperror.NotFound("refresh token not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error getting refresh token: %w", err)
+	}
+
+	return token, nil
+}
+
+// RotateRefreshToken revokes the token identified by oldID and stores next in
+// its place. It fails with a conflict if oldID was already revoked, which
+// happens when two requests race to use the same refresh token.
+func (r *Repository) RotateRefreshToken(ctx context.Context, oldID int, next *RefreshToken) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("error starting transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	now := time.Now().UTC()
+
+	err = tx.QueryRowContext(ctx, `
+		INSERT INTO refresh_token (
+			family_id, profile_id, chain_id, token_hash, expires_at, created_at
+		) VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id, created_at`,
+		next.FamilyID,
+		next.ProfileID,
+		next.ChainID,
+		next.TokenHash,
+		next.ExpiresAt,
+		now,
+	).Scan(&next.ID, &next.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("error creating refresh token: %w", err)
+	}
+
+	result, err := tx.ExecContext(ctx, `
+		UPDATE refresh_token
+		SET revoked_at = $2, replaced_by = $3
+		WHERE id = $1 AND revoked_at IS NULL`,
+		oldID, now, next.ID)
+	if err != nil {
+		return fmt.Errorf("error revoking refresh token: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("error checking revoke result: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return apperror.Conflict("refresh token already used")
+	}
+
+	return tx.Commit()
+}
+
+// RevokeChain revokes every refresh token in the chain and ends the device
+// session it belongs to.
+func (r *Repository) RevokeChain(ctx context.Context, chainID string) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("error starting transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := revokeChain(ctx, tx, chainID, time.Now().UTC()); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func revokeChain(ctx context.Context, tx *sql.Tx, chainID string, now time.Time) error {
+	if _, err := tx.ExecContext(ctx, `
+		UPDATE refresh_token
+		SET revoked_at = $2
+		WHERE chain_id = $1 AND revoked_at IS NULL`,
+		chainID, now); err != nil {
+		return fmt.Errorf("error revoking refresh token chain: %w", err)
+	}
+
+	if _, err := tx.ExecContext(ctx, `
+		UPDATE device_session
+		SET revoked_at = $2
+		WHERE chain_id = $1 AND revoked_at IS NULL`,
+		chainID, now); err != nil {
+		return fmt.Errorf("error revoking session: %w", err)
+	}
+
+	return nil
+}
+
+// RevokeFamily revokes every refresh token of the family and marks all access
+// tokens issued up to the current second as revoked.
+func (r *Repository) RevokeFamily(ctx context.Context, familyID int) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("error starting transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	now := time.Now().UTC()
+
+	if _, err := tx.ExecContext(ctx, `
+		UPDATE refresh_token
+		SET revoked_at = $2
+		WHERE family_id = $1 AND revoked_at IS NULL`,
+		familyID, now); err != nil {
+		return fmt.Errorf("error revoking family refresh tokens: %w", err)
+	}
+
+	if _, err := tx.ExecContext(ctx, `
+		UPDATE device_session
+		SET revoked_at = $2
+		WHERE family_id = $1 AND revoked_at IS NULL`,
+		familyID, now); err != nil {
+		return fmt.Errorf("error revoking family sessions: %w", err)
+	}
+
+	// Access tokens carry their issue time in whole seconds, so the cut-off is
+	// stored the same way and only tokens issued in a later second survive.
+	if _, err := tx.ExecContext(ctx, `
+		UPDATE family_account
+		SET tokens_revoked_at = $2
+		WHERE id = $1`,
+		familyID, now.Truncate(time.Second)); err != nil {
+		return fmt.Errorf("error revoking family access tokens: %w", err)
+	}
+
+	return tx.Commit()
+}
+
+func (r *Repository) RevokeAccessToken(ctx context.Context, jti string, familyID int, expiresAt time.Time) error {
+	now := time.Now().UTC()
+
+	query := `
+		INSERT INTO revoked_token (jti, family_id, expires_at, revoked_at)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (jti) DO NOTHING`
+
+	if _, err := r.db.ExecContext(ctx, query, jti, familyID, expiresAt, now); err != nil {
+		return fmt.Errorf("error revoking access token: %w", err)
+	}
+
+	// Revoked entries are only needed until the token would have expired.
+	if _, err := r.db.ExecContext(ctx, `DELETE FROM revoked_token WHERE expires_at < $1`, now); err != nil {
+		return fmt.Errorf("error pruning revoked tokens: %w", err)
+	}
+
+	return nil
+}
+
+// IsAccessTokenRevoked reports whether the token was revoked individually,
+// belongs to a revoked device session or was issued before the family signed
+// out of all devices.
+func (r *Repository) IsAccessTokenRevoked(ctx context.Context, jti string, sessionID string, familyID int, issuedAt time.Time) (bool, error) {
+	query := `
+		SELECT EXISTS (SELECT 1 FROM revoked_token WHERE jti = $1)
+			OR EXISTS (
+				SELECT 1 FROM device_session
+				WHERE chain_id = $2 AND revoked_at IS NOT NULL
+			)
+			OR EXISTS (
+				SELECT 1 FROM family_account
+				WHERE id = $3 AND tokens_revoked_at IS NOT NULL AND tokens_revoked_at > $4
+			)`
+
+	var revoked bool
+	if err := r.db.QueryRowContext(ctx, query, jti, sessionID, familyID, issuedAt).Scan(&revoked); err != nil {
+		return false, fmt.Errorf("error checking token revocation: %w", err)
+	}
+
+	return revoked, nil
+}
+
+// CreateSession records a new device session together with the first refresh
+// token of its chain.
+func (r *Repository) CreateSession(ctx context.Context, session *Session, token *RefreshToken) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("error starting transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	now := time.Now().UTC()
+
+	err = tx.QueryRowContext(ctx, `
+		INSERT INTO device_session (
+			family_id, profile_id, chain_id, device_name, user_agent, ip_address, created_at, last_seen_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $7)
+		RETURNING id, created_at, last_seen_at`,
+		session.FamilyID,
+		session.ProfileID,
+		session.ChainID,
+		session.DeviceName,
+		session.UserAgent,
+		session.IPAddress,
+		now,
+	).Scan(&session.ID, &session.CreatedAt, &session.LastSeenAt)
+	if err != nil {
+		return fmt.Errorf("error creating session: %w", err)
+	}
+
+	err = tx.QueryRowContext(ctx, `
+		INSERT INTO refresh_token (
+			family_id, profile_id, chain_id, token_hash, expires_at, created_at
+		) VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id, created_at`,
+		token.FamilyID,
+		token.ProfileID,
+		token.ChainID,
+		token.TokenHash,
+		token.ExpiresAt,
+		now,
+	).Scan(&token.ID, &token.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("error creating refresh token: %w", err)
+	}
+
+	return tx.Commit()
+}
+
+func (r *Repository) GetSessionByChainID(ctx context.Context, chainID string) (*Session, error) {
+	query := `
+		SELECT id, family_id, profile_id, chain_id, device_name, user_agent, ip_address, created_at, last_seen_at, revoked_at
+		FROM device_session
+		WHERE chain_id = $1`
+
+	session := new(Session)
+	err := r.db.QueryRowContext(ctx, query, chainID).Scan(
+		&session.ID,
+		&session.FamilyID,
+		&session.ProfileID,
+		&session.ChainID,
+		&session.DeviceName,
+		&session.UserAgent,
+		&session.IPAddress,
+		&session.CreatedAt,
+		&session.LastSeenAt,
+		&session.RevokedAt,
+	)
+
+	if err == sql.ErrNoRows {
+		return nil, apperror.NotFound("session not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error getting session: %w", err)
+	}
+
+	return session, nil
+}
+
+func (r *Repository) GetActiveSessions(ctx context.Context, familyID int) ([]*Session, error) {
+	query := `
+		SELECT id, family_id, profile_id, chain_id, device_name, user_agent, ip_address, created_at, last_seen_at, revoked_at
+		FROM device_session
+		WHERE family_id = $1 AND revoked_at IS NULL
+		  AND EXISTS (
+			SELECT 1 FROM refresh_token
+			WHERE refresh_token.chain_id = device_session.chain_id
+			  AND refresh_token.revoked_at IS NULL
+			  AND refresh_token.expires_at > $2
+		  )
+		ORDER BY last_seen_at DESC`
+
+	rows, err := r.db.QueryContext(ctx, query, familyID, time.Now().UTC())
+	if err != nil {
+		return nil, fmt.Errorf("error querying sessions: %w", err)
+	}
+	defer rows.Close()
+
+	var sessions []*Session
+	for rows.Next() {
+		session := new(Session)
+		err := rows.Scan(
+			&session.ID,
+			&session.FamilyID,
+			&session.ProfileID,
+			&session.ChainID,
+			&session.DeviceName,
+			&session.UserAgent,
+			&session.IPAddress,
+			&session.CreatedAt,
+			&session.LastSeenAt,
+			&session.RevokedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning session: %w", err)
+		}
+		sessions = append(sessions, session)
+	}
+
+	return sessions, nil
+}
+
+func (r *Repository) TouchSession(ctx context.Context, chainID string, ipAddress string, userAgent string) error {
+	query := `
+		UPDATE device_session
+		SET last_seen_at = $2,
+			ip_address = COALESCE(NULLIF($3, ''), ip_address),
+			user_agent = COALESCE(NULLIF($4, ''), user_agent)
+		WHERE chain_id = $1 AND revoked_at IS NULL`
+
+	if _, err := r.db.ExecContext(ctx, query, chainID, time.Now().UTC(), ipAddress, userAgent); err != nil {
+		return fmt.Errorf("error updating session: %w", err)
+	}
+	return nil
+}
+
+func (r *Repository) RevokeSession(ctx context.Context, id int, familyID int) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("error starting transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var chainID string
+	err = tx.QueryRowContext(ctx, `
+		SELECT chain_id FROM device_session
+		WHERE id = $1 AND family_id = $2 AND revoked_at IS NULL`,
+		id, familyID).Scan(&chainID)
+	if err == sql.ErrNoRows {
+		return apperror.NotFound("session not found")
+	}
+	if err != nil {
+		return fmt.Errorf("error getting session: %w", err)
+	}
+
+	if err := revokeChain(ctx, tx, chainID, time.Now().UTC()); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
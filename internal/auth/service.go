@@ -0,0 +1,356 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/chrisabs/cadence/internal/apperror"
+	"github.com/chrisabs/cadence/internal/models"
+	"github.com/chrisabs/cadence/internal/platform/securetoken"
+	"github.com/chrisabs/cadence/internal/platform/tracing"
+	"github.com/chrisabs/cadence/internal/platform/validate"
+	"github.com/golang-jwt/jwt"
+)
+
+const (
+	accessTokenTTL  = 15 * time.Minute
+	refreshTokenTTL = 30 * 24 * time.Hour
+)
+
+type Service struct {
+	repo           *Repository
+	jwtSecret      string
+	profileService interface {
+		GetProfileByID(ctx context.Context, id int) (*models.Profile, error)
+	}
+}
+
+func NewService(repo *Repository, jwtSecret string) *Service {
+	return &Service{
+		repo:      repo,
+		jwtSecret: jwtSecret,
+	}
+}
+
+func (s *Service) SetProfileService(profileService interface {
+	GetProfileByID(ctx context.Context, id int) (*models.Profile, error)
+}) {
+	s.profileService = profileService
+}
+
+// StartSession records a device session for a family login and issues its
+// first family token pair.
+func (s *Service) StartSession(ctx context.Context, familyID int, device *DeviceInfo) (*TokenPair, error) {
+	ctx, span := tracing.Start(ctx, "auth.Service.StartSession")
+	defer span.End()
+
+	return s.startSession(ctx, familyID, nil, device)
+}
+
+// StartProfileSession records a device session for an adult signing in with
+// their own profile login and issues a profile token pair straight away.
+func (s *Service) StartProfileSession(ctx context.Context, profile *models.Profile, device *DeviceInfo) (*TokenPair, error) {
+	ctx, span := tracing.Start(ctx, "auth.Service.StartProfileSession")
+	defer span.End()
+
+	return s.startSession(ctx, profile.FamilyID, profile, device)
+}
+
+func (s *Service) startSession(ctx context.Context, familyID int, profile *models.Profile, device *DeviceInfo) (*TokenPair, error) {
+	chainID, err := securetoken.Generate(16)
+	if err != nil {
+		return nil, err
+	}
+
+	pair, refreshToken, err := s.newTokens(familyID, profile, chainID)
+	if err != nil {
+		return nil, err
+	}
+
+	session := &Session{
+		FamilyID:   familyID,
+		ProfileID:  refreshToken.ProfileID,
+		ChainID:    chainID,
+		DeviceName: device.Name,
+		UserAgent:  device.UserAgent,
+		IPAddress:  device.IPAddress,
+	}
+	if session.DeviceName == "" {
+		session.DeviceName = "Unknown device"
+	}
+
+	if err := s.repo.CreateSession(ctx, session, refreshToken); err != nil {
+		return nil, err
+	}
+
+	return pair, nil
+}
+
+// IssueProfileTokens issues a profile token pair on the device session the
+// family signed in with, so revoking the session signs out its profiles too.
+func (s *Service) IssueProfileTokens(ctx context.Context, sessionID string, profile *models.Profile) (*TokenPair, error) {
+	ctx, span := tracing.Start(ctx, "auth.Service.IssueProfileTokens")
+	defer span.End()
+
+	if sessionID == "" {
+		return nil, apperror.Unauthorized("session expired, please sign in again")
+	}
+
+	session, err := s.repo.GetSessionByChainID(ctx, sessionID)
+	if errors.Is(err, apperror.ErrNotFound) {
+		return nil, apperror.Unauthorized("session expired, please sign in again")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if session.RevokedAt != nil || session.FamilyID != profile.FamilyID {
+		return nil, apperror.Unauthorized("session expired, please sign in again")
+	}
+
+	pair, refreshToken, err := s.newTokens(profile.FamilyID, profile, sessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.CreateRefreshToken(ctx, refreshToken); err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.TouchSession(ctx, sessionID, "", ""); err != nil {
+		return nil, err
+	}
+
+	return pair, nil
+}
+
+// Refresh exchanges a refresh token for a new token pair and revokes the old
+// refresh token. Presenting a token that was already rotated means it has
+// leaked, so the whole chain is revoked.
+func (s *Service) Refresh(ctx context.Context, req *RefreshRequest, device *DeviceInfo) (*TokenPair, error) {
+	ctx, span := tracing.Start(ctx, "auth.Service.Refresh")
+	defer span.End()
+
+	if err := validate.Struct(req); err != nil {
+		return nil, err
+	}
+
+	current, err := s.repo.GetRefreshTokenByHash(ctx, securetoken.Hash(req.RefreshToken))
+	if errors.Is(err, apperror.ErrNotFound) {
+		return nil, apperror.Unauthorized("invalid refresh token")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if current.RevokedAt != nil {
+		if current.ReplacedBy != nil {
+			if err := s.repo.RevokeChain(ctx, current.ChainID); err != nil {
+				return nil, err
+			}
+		}
+		return nil, apperror.Unauthorized("refresh token revoked")
+	}
+
+	if time.Now().After(current.ExpiresAt) {
+		return nil, apperror.Unauthorized("refresh token expired")
+	}
+
+	var profile *models.Profile
+	if current.ProfileID != nil {
+		profile, err = s.profileService.GetProfileByID(ctx, *current.ProfileID)
+		if err != nil || profile.FamilyID != current.FamilyID {
+			if err := s.repo.RevokeChain(ctx, current.ChainID); err != nil {
+				return nil, err
+			}
+			return nil, apperror.Unauthorized("profile no longer available")
+		}
+	}
+
+	pair, next, err := s.newTokens(current.FamilyID, profile, current.ChainID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.RotateRefreshToken(ctx, current.ID, next); err != nil {
+		if errors.Is(err, apperror.ErrConflict) {
+			if err := s.repo.RevokeChain(ctx, current.ChainID); err != nil {
+				return nil, err
+			}
+			return nil, apperror.Unauthorized("refresh token revoked")
+		}
+		return nil, err
+	}
+
+	if err := s.repo.TouchSession(ctx, current.ChainID, device.IPAddress, device.UserAgent); err != nil {
+		return nil, err
+	}
+
+	return pair, nil
+}
+
+// Logout revokes the presented access token and the refresh token chain it
+// belongs to. Either token may be omitted, but not both. Without a refresh
+// token the chain is found from the access token's session ID. When a refresh
+// token is supplied an invalid or expired access token is ignored, so a client
+// can still sign out after its access token has lapsed.
+func (s *Service) Logout(ctx context.Context, accessToken string, req *LogoutRequest) error {
+	ctx, span := tracing.Start(ctx, "auth.Service.Logout")
+	defer span.End()
+
+	if accessToken == "" && req.RefreshToken == "" {
+		return apperror.BadRequest("access or refresh token required")
+	}
+
+	if accessToken != "" {
+		claims, err := s.parseAccessToken(accessToken)
+		if err != nil && req.RefreshToken == "" {
+			return err
+		}
+
+		jti, _ := claims["jti"].(string)
+		sessionID, _ := claims["sid"].(string)
+		familyID, _ := claims["familyId"].(float64)
+		exp, _ := claims["exp"].(float64)
+		if err == nil && jti != "" {
+			if err := s.repo.RevokeAccessToken(ctx, jti, int(familyID), time.Unix(int64(exp), 0).UTC()); err != nil {
+				return err
+			}
+		}
+		if err == nil && sessionID != "" && req.RefreshToken == "" {
+			if err := s.repo.RevokeChain(ctx, sessionID); err != nil {
+				return err
+			}
+		}
+	}
+
+	if req.RefreshToken != "" {
+		token, err := s.repo.GetRefreshTokenByHash(ctx, securetoken.Hash(req.RefreshToken))
+		if err != nil && !errors.Is(err, apperror.ErrNotFound) {
+			return err
+		}
+		if token != nil {
+			if err := s.repo.RevokeChain(ctx, token.ChainID); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// SignOutAll revokes every refresh token and every outstanding access token of
+// the family.
+func (s *Service) SignOutAll(ctx context.Context, familyID int) error {
+	ctx, span := tracing.Start(ctx, "auth.Service.SignOutAll")
+	defer span.End()
+
+	return s.repo.RevokeFamily(ctx, familyID)
+}
+
+func (s *Service) GetSessions(ctx context.Context, familyID int, currentSessionID string) ([]*Session, error) {
+	ctx, span := tracing.Start(ctx, "auth.Service.GetSessions")
+	defer span.End()
+
+	sessions, err := s.repo.GetActiveSessions(ctx, familyID)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, session := range sessions {
+		session.Current = session.ChainID == currentSessionID
+	}
+
+	return sessions, nil
+}
+
+func (s *Service) RevokeSession(ctx context.Context, id int, familyID int) error {
+	ctx, span := tracing.Start(ctx, "auth.Service.RevokeSession")
+	defer span.End()
+
+	return s.repo.RevokeSession(ctx, id, familyID)
+}
+
+func (s *Service) IsTokenRevoked(ctx context.Context, jti string, sessionID string, familyID int, issuedAt time.Time) (bool, error) {
+	ctx, span := tracing.Start(ctx, "auth.Service.IsTokenRevoked")
+	defer span.End()
+
+	return s.repo.IsAccessTokenRevoked(ctx, jti, sessionID, familyID, issuedAt)
+}
+
+func (s *Service) newTokens(familyID int, profile *models.Profile, chainID string) (*TokenPair, *RefreshToken, error) {
+	accessToken, expiresAt, err := s.newAccessToken(familyID, profile, chainID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	rawRefresh, err := securetoken.Generate(32)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	refreshToken := &RefreshToken{
+		FamilyID:  familyID,
+		ChainID:   chainID,
+		TokenHash: securetoken.Hash(rawRefresh),
+		ExpiresAt: time.Now().UTC().Add(refreshTokenTTL),
+	}
+	if profile != nil {
+		profileID := profile.ID
+		refreshToken.ProfileID = &profileID
+	}
+
+	return &TokenPair{
+		Token:        accessToken,
+		RefreshToken: rawRefresh,
+		ExpiresAt:    expiresAt,
+	}, refreshToken, nil
+}
+
+func (s *Service) newAccessToken(familyID int, profile *models.Profile, sessionID string) (string, time.Time, error) {
+	jti, err := securetoken.Generate(16)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	now := time.Now().UTC()
+	expiresAt := now.Add(accessTokenTTL)
+
+	token := jwt.New(jwt.SigningMethodHS256)
+	claims := token.Claims.(jwt.MapClaims)
+	claims["jti"] = jti
+	claims["sid"] = sessionID
+	claims["familyId"] = familyID
+	if profile != nil {
+		claims["profileId"] = profile.ID
+		claims["role"] = string(profile.Role)
+		claims["isOwner"] = profile.IsOwner
+	}
+	claims["iat"] = now.Unix()
+	claims["exp"] = expiresAt.Unix()
+
+	signed, err := token.SignedString([]byte(s.jwtSecret))
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("error signing token: %w", err)
+	}
+
+	return signed, expiresAt, nil
+}
+
+func (s *Service) parseAccessToken(tokenString string) (jwt.MapClaims, error) {
+	claims := jwt.MapClaims{}
+	token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+		}
+		return []byte(s.jwtSecret), nil
+	})
+
+	if err != nil || !token.Valid {
+		return nil, apperror.Unauthorized("invalid token")
+	}
+
+	return claims, nil
+}
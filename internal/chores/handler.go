@@ -6,9 +6,11 @@ import (
 	"strconv"
 	"time"
 
+	"github.com/chrisabs/cadence/internal/apperror"
 	"github.com/chrisabs/cadence/internal/chores/entities"
 	"github.com/chrisabs/cadence/internal/middleware"
 	"github.com/chrisabs/cadence/internal/models"
+	"github.com/chrisabs/cadence/internal/platform/respond"
 	"github.com/gorilla/mux"
 )
 
@@ -52,7 +54,11 @@ func (h *Handler) RegisterRoutes(router *mux.Router) {
 }
 
 func (h *Handler) handleGetChores(w http.ResponseWriter, r *http.Request) {
-	profileCtx := r.Context().Value("profile").(*models.ProfileContext)
+	profileCtx, ok := middleware.ProfileFrom(r.Context())
+	if !ok {
+		respond.Error(w, r, apperror.Unauthorized("profile authentication required"))
+		return
+	}
 	
 	assigneeIDStr := r.URL.Query().Get("assigneeId")
 	
@@ -62,127 +68,151 @@ func (h *Handler) handleGetChores(w http.ResponseWriter, r *http.Request) {
 	if assigneeIDStr != "" {
 		assigneeID, err := strconv.Atoi(assigneeIDStr)
 		if err != nil {
-			writeError(w, http.StatusBadRequest, "invalid assignee ID")
+			respond.Error(w, r, apperror.BadRequest("invalid assignee ID"))
 			return
 		}
 		
-		chores, err = h.service.GetChoresByAssigneeID(assigneeID, profileCtx.FamilyID)
+		chores, err = h.service.GetChoresByAssigneeID(r.Context(), assigneeID, profileCtx.FamilyID)
 		if err != nil {
-			writeError(w, http.StatusInternalServerError, err.Error())
+			respond.Error(w, r, err)
 			return
 		}
 	} else {
-		chores, err = h.service.GetChoresByFamilyID(profileCtx.FamilyID)
+		chores, err = h.service.GetChoresByFamilyID(r.Context(), profileCtx.FamilyID)
 		if err != nil {
-			writeError(w, http.StatusInternalServerError, err.Error())
+			respond.Error(w, r, err)
 			return
 		}
 	}
 	
 	if err != nil {
-		writeError(w, http.StatusInternalServerError, err.Error())
+		respond.Error(w, r, err)
 		return
 	}
 	
-	writeJSON(w, http.StatusOK, chores)
+	respond.JSON(w, http.StatusOK, chores)
 }
 
 func (h *Handler) handleCreateChore(w http.ResponseWriter, r *http.Request) {
-	profileCtx := r.Context().Value("profile").(*models.ProfileContext)
+	profileCtx, ok := middleware.ProfileFrom(r.Context())
+	if !ok {
+		respond.Error(w, r, apperror.Unauthorized("profile authentication required"))
+		return
+	}
 	
 	var req CreateChoreRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		writeError(w, http.StatusBadRequest, "invalid request body")
+		respond.Error(w, r, apperror.BadRequest("invalid request body"))
 		return
 	}
 	
-	chore, err := h.service.CreateChore(profileCtx.ProfileID, profileCtx.FamilyID, &req)
+	chore, err := h.service.CreateChore(r.Context(), profileCtx.ProfileID, profileCtx.FamilyID, &req)
 	if err != nil {
-		writeError(w, http.StatusInternalServerError, err.Error())
+		respond.Error(w, r, err)
 		return
 	}
 	
-	writeJSON(w, http.StatusCreated, chore)
+	respond.JSON(w, http.StatusCreated, chore)
 }
 
 func (h *Handler) handleGetChore(w http.ResponseWriter, r *http.Request) {
-	profileCtx := r.Context().Value("profile").(*models.ProfileContext)
+	profileCtx, ok := middleware.ProfileFrom(r.Context())
+	if !ok {
+		respond.Error(w, r, apperror.Unauthorized("profile authentication required"))
+		return
+	}
 	
 	id, err := getIDFromRequest(r)
 	if err != nil {
-		writeError(w, http.StatusBadRequest, err.Error())
+		respond.Error(w, r, err)
 		return
 	}
 	
-	chore, err := h.service.GetChoreByID(id, profileCtx.FamilyID)
+	chore, err := h.service.GetChoreByID(r.Context(), id, profileCtx.FamilyID)
 	if err != nil {
-		writeError(w, http.StatusNotFound, err.Error())
+		respond.Error(w, r, err)
 		return
 	}
 	
-	writeJSON(w, http.StatusOK, chore)
+	respond.JSON(w, http.StatusOK, chore)
 }
 
 func (h *Handler) handleUpdateChore(w http.ResponseWriter, r *http.Request) {
-	profileCtx := r.Context().Value("profile").(*models.ProfileContext)
+	profileCtx, ok := middleware.ProfileFrom(r.Context())
+	if !ok {
+		respond.Error(w, r, apperror.Unauthorized("profile authentication required"))
+		return
+	}
 	
 	id, err := getIDFromRequest(r)
 	if err != nil {
-		writeError(w, http.StatusBadRequest, err.Error())
+		respond.Error(w, r, err)
 		return
 	}
 	
 	var req UpdateChoreRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		writeError(w, http.StatusBadRequest, "invalid request body")
+		respond.Error(w, r, apperror.BadRequest("invalid request body"))
 		return
 	}
 	
-	chore, err := h.service.UpdateChore(id, profileCtx.FamilyID, &req)
+	chore, err := h.service.UpdateChore(r.Context(), id, profileCtx.FamilyID, &req)
 	if err != nil {
-		writeError(w, http.StatusInternalServerError, err.Error())
+		respond.Error(w, r, err)
 		return
 	}
 	
-	writeJSON(w, http.StatusOK, chore)
+	respond.JSON(w, http.StatusOK, chore)
 }
 
 func (h *Handler) handleDeleteChore(w http.ResponseWriter, r *http.Request) {
-    profileCtx := r.Context().Value("profile").(*models.ProfileContext)
+    profileCtx, ok := middleware.ProfileFrom(r.Context())
+    if !ok {
+        respond.Error(w, r, apperror.Unauthorized("profile authentication required"))
+        return
+    }
     
     id, err := getIDFromRequest(r)
     if err != nil {
-        writeError(w, http.StatusBadRequest, err.Error())
+        respond.Error(w, r, err)
         return
     }
     
-    if err := h.service.DeleteChore(id, profileCtx.FamilyID, profileCtx.ProfileID); err != nil {
-        writeError(w, http.StatusInternalServerError, err.Error())
+    if err := h.service.DeleteChore(r.Context(), id, profileCtx.FamilyID, profileCtx.ProfileID); err != nil {
+        respond.Error(w, r, err)
         return
     }
     
-    writeJSON(w, http.StatusOK, map[string]string{"message": "chore deleted successfully"})
+    respond.JSON(w, http.StatusOK, map[string]string{"message": "chore deleted successfully"})
 }
 
 func (h *Handler) handleRestoreChore(w http.ResponseWriter, r *http.Request) {
-    profileCtx := r.Context().Value("profile").(*models.ProfileContext)
+    profileCtx, ok := middleware.ProfileFrom(r.Context())
+    if !ok {
+        respond.Error(w, r, apperror.Unauthorized("profile authentication required"))
+        return
+    }
     
     id, err := getIDFromRequest(r)
     if err != nil {
-        writeError(w, http.StatusBadRequest, err.Error())
+        respond.Error(w, r, err)
         return
     }
     
-    if err := h.service.RestoreChore(id, profileCtx.FamilyID); err != nil {
-        writeError(w, http.StatusInternalServerError, err.Error())
+    if err := h.service.RestoreChore(r.Context(), id, profileCtx.FamilyID); err != nil {
+        respond.Error(w, r, err)
         return
     }
     
-    writeJSON(w, http.StatusOK, map[string]string{"message": "chore restored successfully"})
+    respond.JSON(w, http.StatusOK, map[string]string{"message": "chore restored successfully"})
 }
 
 func (h *Handler) handleGetChoreInstances(w http.ResponseWriter, r *http.Request) {
-	profileCtx := r.Context().Value("profile").(*models.ProfileContext)
+	profileCtx, ok := middleware.ProfileFrom(r.Context())
+	if !ok {
+		respond.Error(w, r, apperror.Unauthorized("profile authentication required"))
+		return
+	}
 	
 	dateStr := r.URL.Query().Get("date")
 	assigneeIDStr := r.URL.Query().Get("assigneeId")
@@ -192,205 +222,229 @@ func (h *Handler) handleGetChoreInstances(w http.ResponseWriter, r *http.Request
 	if dateStr != "" {
 		date, err := time.Parse("2006-01-02", dateStr)
 		if err != nil {
-			writeError(w, http.StatusBadRequest, "invalid date format (use YYYY-MM-DD)")
+			respond.Error(w, r, apperror.BadRequest("invalid date format (use YYYY-MM-DD)"))
 			return
 		}
 		
-		instances, err := h.service.GetInstancesByDueDate(date, profileCtx.FamilyID)
+		instances, err := h.service.GetInstancesByDueDate(r.Context(), date, profileCtx.FamilyID)
 		if err != nil {
-			writeError(w, http.StatusInternalServerError, err.Error())
+			respond.Error(w, r, err)
 			return
 		}
 		
-		writeJSON(w, http.StatusOK, instances)
+		respond.JSON(w, http.StatusOK, instances)
 		return
 	}
 	
 	if assigneeIDStr != "" && startDateStr != "" && endDateStr != "" {
 		assigneeID, err := strconv.Atoi(assigneeIDStr)
 		if err != nil {
-			writeError(w, http.StatusBadRequest, "invalid assignee ID")
+			respond.Error(w, r, apperror.BadRequest("invalid assignee ID"))
 			return
 		}
 		
 		startDate, err := time.Parse("2006-01-02", startDateStr)
 		if err != nil {
-			writeError(w, http.StatusBadRequest, "invalid start date format (use YYYY-MM-DD)")
+			respond.Error(w, r, apperror.BadRequest("invalid start date format (use YYYY-MM-DD)"))
 			return
 		}
 		
 		endDate, err := time.Parse("2006-01-02", endDateStr)
 		if err != nil {
-			writeError(w, http.StatusBadRequest, "invalid end date format (use YYYY-MM-DD)")
+			respond.Error(w, r, apperror.BadRequest("invalid end date format (use YYYY-MM-DD)"))
 			return
 		}
 		
-		instances, err := h.service.GetInstancesByAssignee(assigneeID, profileCtx.FamilyID, startDate, endDate)
+		instances, err := h.service.GetInstancesByAssignee(r.Context(), assigneeID, profileCtx.FamilyID, startDate, endDate)
 		if err != nil {
-			writeError(w, http.StatusInternalServerError, err.Error())
+			respond.Error(w, r, err)
 			return
 		}
 		
-		writeJSON(w, http.StatusOK, instances)
+		respond.JSON(w, http.StatusOK, instances)
 		return
 	}
 	
 	today := time.Now().UTC().Truncate(24 * time.Hour)
-	instances, err := h.service.GetInstancesByDueDate(today, profileCtx.FamilyID)
+	instances, err := h.service.GetInstancesByDueDate(r.Context(), today, profileCtx.FamilyID)
 	if err != nil {
-		writeError(w, http.StatusInternalServerError, err.Error())
+		respond.Error(w, r, err)
 		return
 	}
 	
-	writeJSON(w, http.StatusOK, instances)
+	respond.JSON(w, http.StatusOK, instances)
 }
 
 func (h *Handler) handleGetChoreInstance(w http.ResponseWriter, r *http.Request) {
-	profileCtx := r.Context().Value("profile").(*models.ProfileContext)
+	profileCtx, ok := middleware.ProfileFrom(r.Context())
+	if !ok {
+		respond.Error(w, r, apperror.Unauthorized("profile authentication required"))
+		return
+	}
 	
 	id, err := getIDFromRequest(r)
 	if err != nil {
-		writeError(w, http.StatusBadRequest, err.Error())
+		respond.Error(w, r, err)
 		return
 	}
 	
-	instance, err := h.service.GetInstanceByID(id, profileCtx.FamilyID)
+	instance, err := h.service.GetInstanceByID(r.Context(), id, profileCtx.FamilyID)
 	if err != nil {
-		writeError(w, http.StatusNotFound, err.Error())
+		respond.Error(w, r, err)
 		return
 	}
 	
-	writeJSON(w, http.StatusOK, instance)
+	respond.JSON(w, http.StatusOK, instance)
 }
 
 func (h *Handler) handleCompleteChoreInstance(w http.ResponseWriter, r *http.Request) {
-	profileCtx := r.Context().Value("profile").(*models.ProfileContext)
+	profileCtx, ok := middleware.ProfileFrom(r.Context())
+	if !ok {
+		respond.Error(w, r, apperror.Unauthorized("profile authentication required"))
+		return
+	}
 	
 	id, err := getIDFromRequest(r)
 	if err != nil {
-		writeError(w, http.StatusBadRequest, err.Error())
+		respond.Error(w, r, err)
 		return
 	}
 	
 	var req UpdateChoreInstanceRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		writeError(w, http.StatusBadRequest, "invalid request body")
+		respond.Error(w, r, apperror.BadRequest("invalid request body"))
 		return
 	}
 	
-	instance, err := h.service.CompleteChoreInstance(id, profileCtx.ProfileID, profileCtx.FamilyID, &req)
+	instance, err := h.service.CompleteChoreInstance(r.Context(), id, profileCtx.ProfileID, profileCtx.FamilyID, &req)
 	if err != nil {
-		writeError(w, http.StatusInternalServerError, err.Error())
+		respond.Error(w, r, err)
 		return
 	}
 	
-	writeJSON(w, http.StatusOK, instance)
+	respond.JSON(w, http.StatusOK, instance)
 }
 
 func (h *Handler) handleVerifyDay(w http.ResponseWriter, r *http.Request) {
-	profileCtx := r.Context().Value("profile").(*models.ProfileContext)
+	profileCtx, ok := middleware.ProfileFrom(r.Context())
+	if !ok {
+		respond.Error(w, r, apperror.Unauthorized("profile authentication required"))
+		return
+	}
 	
 	if profileCtx.Role != models.RoleParent {
-        writeError(w, http.StatusForbidden, "only parents can verify chores")
+        respond.Error(w, r, apperror.Forbidden("only parents can verify chores"))
         return
     }
 	
 	var req VerifyDayRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		writeError(w, http.StatusBadRequest, "invalid request body")
+		respond.Error(w, r, apperror.BadRequest("invalid request body"))
 		return
 	}
 	
-	if err := h.service.VerifyDay(profileCtx.ProfileID, profileCtx.FamilyID, &req); err != nil {
-		writeError(w, http.StatusInternalServerError, err.Error())
+	if err := h.service.VerifyDay(r.Context(), profileCtx.ProfileID, profileCtx.FamilyID, &req); err != nil {
+		respond.Error(w, r, err)
 		return
 	}
 	
-	writeJSON(w, http.StatusOK, map[string]string{"message": "day verified successfully"})
+	respond.JSON(w, http.StatusOK, map[string]string{"message": "day verified successfully"})
 }
 
 func (h *Handler) handleReviewChore(w http.ResponseWriter, r *http.Request) {
-	profileCtx := r.Context().Value("profile").(*models.ProfileContext)
+	profileCtx, ok := middleware.ProfileFrom(r.Context())
+	if !ok {
+		respond.Error(w, r, apperror.Unauthorized("profile authentication required"))
+		return
+	}
 	
 	if profileCtx.Role != models.RoleParent {
-        writeError(w, http.StatusForbidden, "only parents can review chores")
+        respond.Error(w, r, apperror.Forbidden("only parents can review chores"))
         return
     }
 	
 	id, err := getIDFromRequest(r)
 	if err != nil {
-		writeError(w, http.StatusBadRequest, err.Error())
+		respond.Error(w, r, err)
 		return
 	}
 	
 	var req ReviewChoreRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		writeError(w, http.StatusBadRequest, "invalid request body")
+		respond.Error(w, r, apperror.BadRequest("invalid request body"))
 		return
 	}
 	
-	instance, err := h.service.ReviewChore(id, profileCtx.ProfileID, profileCtx.FamilyID, &req)
+	instance, err := h.service.ReviewChore(r.Context(), id, profileCtx.ProfileID, profileCtx.FamilyID, &req)
 	if err != nil {
-		writeError(w, http.StatusInternalServerError, err.Error())
+		respond.Error(w, r, err)
 		return
 	}
 	
-	writeJSON(w, http.StatusOK, instance)
+	respond.JSON(w, http.StatusOK, instance)
 }
 
 func (h *Handler) handleGetDailyVerification(w http.ResponseWriter, r *http.Request) {
-	profileCtx := r.Context().Value("profile").(*models.ProfileContext)
+	profileCtx, ok := middleware.ProfileFrom(r.Context())
+	if !ok {
+		respond.Error(w, r, apperror.Unauthorized("profile authentication required"))
+		return
+	}
 	
 	dateStr := r.URL.Query().Get("date")
 	assigneeIDStr := r.URL.Query().Get("assigneeId")
 	
 	if dateStr == "" || assigneeIDStr == "" {
-		writeError(w, http.StatusBadRequest, "date and assigneeId are required")
+		respond.Error(w, r, apperror.BadRequest("date and assigneeId are required"))
 		return
 	}
 	
 	date, err := time.Parse("2006-01-02", dateStr)
 	if err != nil {
-		writeError(w, http.StatusBadRequest, "invalid date format")
+		respond.Error(w, r, apperror.BadRequest("invalid date format"))
 		return
 	}
 	
 	assigneeID, err := strconv.Atoi(assigneeIDStr)
 	if err != nil {
-		writeError(w, http.StatusBadRequest, "invalid assigneeId")
+		respond.Error(w, r, apperror.BadRequest("invalid assigneeId"))
 		return
 	}
 	
-	verification, err := h.service.GetDailyVerification(date, assigneeID, profileCtx.FamilyID)
+	verification, err := h.service.GetDailyVerification(r.Context(), date, assigneeID, profileCtx.FamilyID)
 	if err != nil {
-		writeError(w, http.StatusInternalServerError, err.Error())
+		respond.Error(w, r, err)
 		return
 	}
 	
-	writeJSON(w, http.StatusOK, verification)
+	respond.JSON(w, http.StatusOK, verification)
 }
 
 func (h *Handler) handleGetChoreStats(w http.ResponseWriter, r *http.Request) {
-	profileCtx := r.Context().Value("profile").(*models.ProfileContext)
+	profileCtx, ok := middleware.ProfileFrom(r.Context())
+	if !ok {
+		respond.Error(w, r, apperror.Unauthorized("profile authentication required"))
+		return
+	}
 	
 	profileIdStr := r.URL.Query().Get("profileId")
 	startDateStr := r.URL.Query().Get("startDate")
 	endDateStr := r.URL.Query().Get("endDate")
 	
 	if startDateStr == "" || endDateStr == "" {
-		writeError(w, http.StatusBadRequest, "startDate and endDate are required")
+		respond.Error(w, r, apperror.BadRequest("startDate and endDate are required"))
 		return
 	}
 	
 	startDate, err := time.Parse("2006-01-02", startDateStr)
 	if err != nil {
-		writeError(w, http.StatusBadRequest, "invalid startDate format (use YYYY-MM-DD)")
+		respond.Error(w, r, apperror.BadRequest("invalid startDate format (use YYYY-MM-DD)"))
 		return
 	}
 	
 	endDate, err := time.Parse("2006-01-02", endDateStr)
 	if err != nil {
-		writeError(w, http.StatusBadRequest, "invalid endDate format (use YYYY-MM-DD)")
+		respond.Error(w, r, apperror.BadRequest("invalid endDate format (use YYYY-MM-DD)"))
 		return
 	}
 	
@@ -398,49 +452,47 @@ func (h *Handler) handleGetChoreStats(w http.ResponseWriter, r *http.Request) {
 	if profileIdStr != "" {
 		profileId, err = strconv.Atoi(profileIdStr)
 		if err != nil {
-			writeError(w, http.StatusBadRequest, "invalid profileId")
+			respond.Error(w, r, apperror.BadRequest("invalid profileId"))
 			return
 		}
 	} else {
 		profileId = profileCtx.ProfileID
 	}
 	
-	stats, err := h.service.GetChoreStats(profileId, profileCtx.FamilyID, startDate, endDate)
+	stats, err := h.service.GetChoreStats(r.Context(), profileId, profileCtx.FamilyID, startDate, endDate)
 	if err != nil {
-		writeError(w, http.StatusInternalServerError, err.Error())
+		respond.Error(w, r, err)
 		return
 	}
 	
-	writeJSON(w, http.StatusOK, stats)
+	respond.JSON(w, http.StatusOK, stats)
 }
 
 func (h *Handler) handleGenerateChoreInstances(w http.ResponseWriter, r *http.Request) {
-	profileCtx := r.Context().Value("profile").(*models.ProfileContext)
+	profileCtx, ok := middleware.ProfileFrom(r.Context())
+	if !ok {
+		respond.Error(w, r, apperror.Unauthorized("profile authentication required"))
+		return
+	}
 	
 	if profileCtx.Role != models.RoleParent {
-        writeError(w, http.StatusForbidden, "only parents can generate chore instances")
+        respond.Error(w, r, apperror.Forbidden("only parents can generate chore instances"))
         return
     }
 	
-	if err := h.service.GenerateDailyChoreInstances(profileCtx.FamilyID); err != nil {
-		writeError(w, http.StatusInternalServerError, err.Error())
+	if err := h.service.GenerateDailyChoreInstances(r.Context(), profileCtx.FamilyID); err != nil {
+		respond.Error(w, r, err)
 		return
 	}
 	
-	writeJSON(w, http.StatusOK, map[string]string{"message": "chore instances generated successfully"})
+	respond.JSON(w, http.StatusOK, map[string]string{"message": "chore instances generated successfully"})
 }
 
 func getIDFromRequest(r *http.Request) (int, error) {
 	vars := mux.Vars(r)
-	return strconv.Atoi(vars["id"])
-}
-
-func writeJSON(w http.ResponseWriter, status int, v interface{}) {
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(status)
-	json.NewEncoder(w).Encode(v)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		return 0, apperror.BadRequest("invalid id")
+	}
+	return id, nil
 }
-
-func writeError(w http.ResponseWriter, status int, message string) {
-	writeJSON(w, status, map[string]string{"error": message})
-}
\ No newline at end of file
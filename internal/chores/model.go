@@ -4,40 +4,49 @@ import (
 	"time"
 
 	"github.com/chrisabs/cadence/internal/chores/entities"
+	"github.com/chrisabs/cadence/internal/platform/validate"
 )
 
 type CreateChoreRequest struct {
-	Name           string                  `json:"name"`
-	Description    string                  `json:"description"`
-	AssigneeID     int                     `json:"assigneeId"`
-	Points         int                     `json:"points"`
-	OccurrenceType entities.OccurrenceType `json:"occurrenceType"`
+	Name           string                  `json:"name" validate:"required,max=255"`
+	Description    string                  `json:"description" validate:"max=1000"`
+	AssigneeID     int                     `json:"assigneeId" validate:"required"`
+	Points         int                     `json:"points" validate:"min=0,max=1000"`
+	OccurrenceType entities.OccurrenceType `json:"occurrenceType" validate:"required,oneof=daily weekly monthly custom"`
 	OccurrenceData entities.OccurrenceData `json:"occurrenceData"`
 }
 
+func (r *CreateChoreRequest) Check(errs validate.Errors) {
+	checkOccurrence(r.OccurrenceType, r.OccurrenceData, errs)
+}
+
 type UpdateChoreRequest struct {
-	Name           string                  `json:"name"`
-	Description    string                  `json:"description"`
-	AssigneeID     int                     `json:"assigneeId"`
-	Points         int                     `json:"points"`
-	OccurrenceType entities.OccurrenceType `json:"occurrenceType"`
+	Name           string                  `json:"name" validate:"required,max=255"`
+	Description    string                  `json:"description" validate:"max=1000"`
+	AssigneeID     int                     `json:"assigneeId" validate:"required"`
+	Points         int                     `json:"points" validate:"min=0,max=1000"`
+	OccurrenceType entities.OccurrenceType `json:"occurrenceType" validate:"required,oneof=daily weekly monthly custom"`
 	OccurrenceData entities.OccurrenceData `json:"occurrenceData"`
 }
 
+func (r *UpdateChoreRequest) Check(errs validate.Errors) {
+	checkOccurrence(r.OccurrenceType, r.OccurrenceData, errs)
+}
+
 type UpdateChoreInstanceRequest struct {
 	Status      entities.ChoreStatus `json:"status"`
-	Notes       string               `json:"notes"`
+	Notes       string               `json:"notes" validate:"max=1000"`
 }
 
 type ReviewChoreRequest struct {
-	Status      entities.ChoreStatus `json:"status"`
-	Notes       string               `json:"notes"`
+	Status      entities.ChoreStatus `json:"status" validate:"required,oneof=verified rejected"`
+	Notes       string               `json:"notes" validate:"max=1000"`
 }
 
 type VerifyDayRequest struct {
-	Date        string `json:"date"`
-	AssigneeID  int    `json:"assigneeId"`
-	Notes       string `json:"notes"`
+	Date        string `json:"date" validate:"required,date"`
+	AssigneeID  int    `json:"assigneeId" validate:"required"`
+	Notes       string `json:"notes" validate:"max=1000"`
 }
 
 type ChoreStats struct {
@@ -50,7 +59,48 @@ type ChoreStats struct {
 }
 
 type ChoreStatsRequest struct {
-	profileId    int        `json:"profileId"`
+	ProfileID    int        `json:"profileId"`
 	StartDate    time.Time  `json:"startDate"`
 	EndDate      time.Time  `json:"endDate"`
-}
\ No newline at end of file
+}
+
+// checkOccurrence makes sure the occurrence data carries what
+// shouldCreateInstanceForDate needs for the chosen occurrence type.
+func checkOccurrence(occurrenceType entities.OccurrenceType, data entities.OccurrenceData, errs validate.Errors) {
+	if data.StartDate.IsZero() {
+		errs.Add("occurrenceData.startDate", "is required")
+	}
+	if data.EndDate != nil && data.EndDate.Before(data.StartDate) {
+		errs.Add("occurrenceData.endDate", "must not be before startDate")
+	}
+
+	switch occurrenceType {
+	case entities.OccurrenceWeekly:
+		if len(data.DaysOfWeek) == 0 {
+			errs.Add("occurrenceData.daysOfWeek", "is required for weekly chores")
+		}
+		for _, day := range data.DaysOfWeek {
+			if day < time.Sunday || day > time.Saturday {
+				errs.Add("occurrenceData.daysOfWeek", "must contain days between 0 (Sunday) and 6 (Saturday)")
+			}
+		}
+	case entities.OccurrenceMonthly:
+		if len(data.DaysOfMonth) == 0 {
+			errs.Add("occurrenceData.daysOfMonth", "is required for monthly chores")
+		}
+		for _, day := range data.DaysOfMonth {
+			if day < 1 || day > 31 {
+				errs.Add("occurrenceData.daysOfMonth", "must contain days between 1 and 31")
+			}
+		}
+	case entities.OccurrenceCustom:
+		if data.Interval < 1 {
+			errs.Add("occurrenceData.interval", "must be at least 1 for custom chores")
+		}
+		switch data.IntervalUnit {
+		case "day", "week", "month":
+		default:
+			errs.Add("occurrenceData.intervalUnit", "must be one of: day, week, month")
+		}
+	}
+}
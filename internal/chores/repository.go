@@ -1,11 +1,13 @@
 package chores
 
 import (
+	"context"
 	"database/sql"
 	"encoding/json"
 	"fmt"
 	"time"
 
+	"github.com/chrisabs/cadence/internal/apperror"
 	"github.com/chrisabs/cadence/internal/chores/entities"
 	"github.com/chrisabs/cadence/internal/models"
 )
@@ -18,10 +20,10 @@ func NewRepository(db *sql.DB) *Repository {
 	return &Repository{db: db}
 }
 
-func (r *Repository) CreateChore(chore *entities.Chore) error {
+func (r *Repository) CreateChore(ctx context.Context, chore *entities.Chore) error {
 	occurrenceData, err := json.Marshal(chore.OccurrenceData)
 	if err != nil {
-		return fmt.Errorf("error marshaling occurrence data: %v", err)
+		return fmt.Errorf("error marshaling occurrence data: %w", err)
 	}
 
 	query := `
@@ -31,7 +33,7 @@ func (r *Repository) CreateChore(chore *entities.Chore) error {
 		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
 		RETURNING id`
 
-	err = r.db.QueryRow(
+	err = r.db.QueryRowContext(ctx,
 		query,
 		chore.Name,
 		chore.Description,
@@ -46,13 +48,13 @@ func (r *Repository) CreateChore(chore *entities.Chore) error {
 	).Scan(&chore.ID)
 
 	if err != nil {
-		return fmt.Errorf("error creating chore: %v", err)
+		return fmt.Errorf("error creating chore: %w", err)
 	}
 
 	return nil
 }
 
-func (r *Repository) GetChoreByID(id int, familyID int) (*entities.Chore, error) {
+func (r *Repository) GetChoreByID(ctx context.Context, id int, familyID int) (*entities.Chore, error) {
     query := `
         SELECT c.id, c.name, c.description, c.creator_id, c.assignee_id, c.family_id,
                c.points, c.occurrence_type, c.occurrence_data, c.created_at, c.updated_at,
@@ -68,7 +70,7 @@ func (r *Repository) GetChoreByID(id int, familyID int) (*entities.Chore, error)
 	assignee := &models.Profile{}
 	var occurrenceDataJSON []byte
 
-	err := r.db.QueryRow(query, id, familyID).Scan(
+	err := r.db.QueryRowContext(ctx, query, id, familyID).Scan(
 		&chore.ID, &chore.Name, &chore.Description, &chore.CreatorID, &chore.AssigneeID, &chore.FamilyID,
 		&chore.Points, &chore.OccurrenceType, &occurrenceDataJSON, &chore.CreatedAt, &chore.UpdatedAt,
 	    &creator.ID, &creator.Name, &creator.ImageURL,
@@ -76,29 +78,29 @@ func (r *Repository) GetChoreByID(id int, familyID int) (*entities.Chore, error)
 	)
 
 	if err == sql.ErrNoRows {
-		return nil, fmt.Errorf("chore not found")
+		return nil, apperror.NotFound("chore not found")
 	}
 	if err != nil {
-		return nil, fmt.Errorf("error getting chore: %v", err)
+		return nil, fmt.Errorf("error getting chore: %w", err)
 	}
 
 	if err := json.Unmarshal(occurrenceDataJSON, &chore.OccurrenceData); err != nil {
-		return nil, fmt.Errorf("error unmarshaling occurrence data: %v", err)
+		return nil, fmt.Errorf("error unmarshaling occurrence data: %w", err)
 	}
 
 	chore.Creator = creator
 	chore.Assignee = assignee
 
-	instances, err := r.GetInstancesByChoreID(chore.ID, familyID)
+	instances, err := r.GetInstancesByChoreID(ctx, chore.ID, familyID)
 	if err != nil {
-		return nil, fmt.Errorf("error getting chore instances: %v", err)
+		return nil, fmt.Errorf("error getting chore instances: %w", err)
 	}
 	chore.Instances = instances
 
 	return chore, nil
 }
 
-func (r *Repository) GetChoresByFamilyID(familyID int) ([]*entities.Chore, error) {
+func (r *Repository) GetChoresByFamilyID(ctx context.Context, familyID int) ([]*entities.Chore, error) {
     query := `
         SELECT c.id, c.name, c.description, c.creator_id, c.assignee_id, c.family_id,
                c.points, c.occurrence_type, c.occurrence_data, c.created_at, c.updated_at,
@@ -110,9 +112,9 @@ func (r *Repository) GetChoresByFamilyID(familyID int) ([]*entities.Chore, error
         WHERE c.family_id = $1 AND c.is_deleted = false
         ORDER BY c.created_at DESC`
 
-	rows, err := r.db.Query(query, familyID)
+	rows, err := r.db.QueryContext(ctx, query, familyID)
 	if err != nil {
-		return nil, fmt.Errorf("error getting chores: %v", err)
+		return nil, fmt.Errorf("error getting chores: %w", err)
 	}
 	defer rows.Close()
 
@@ -130,11 +132,11 @@ func (r *Repository) GetChoresByFamilyID(familyID int) ([]*entities.Chore, error
 			&assignee.ID, &assignee.Name, &assignee.ImageURL,
 		)
 		if err != nil {
-			return nil, fmt.Errorf("error scanning chore: %v", err)
+			return nil, fmt.Errorf("error scanning chore: %w", err)
 		}
 
 		if err := json.Unmarshal(occurrenceDataJSON, &chore.OccurrenceData); err != nil {
-			return nil, fmt.Errorf("error unmarshaling occurrence data: %v", err)
+			return nil, fmt.Errorf("error unmarshaling occurrence data: %w", err)
 		}
 
 		chore.Creator = creator
@@ -145,7 +147,7 @@ func (r *Repository) GetChoresByFamilyID(familyID int) ([]*entities.Chore, error
 	return chores, nil
 }
 
-func (r *Repository) GetChoresByAssigneeID(assigneeID int, familyID int) ([]*entities.Chore, error) {
+func (r *Repository) GetChoresByAssigneeID(ctx context.Context, assigneeID int, familyID int) ([]*entities.Chore, error) {
     query := `
         SELECT c.id, c.name, c.description, c.creator_id, c.assignee_id, c.family_id,
                c.points, c.occurrence_type, c.occurrence_data, c.created_at, c.updated_at
@@ -153,9 +155,9 @@ func (r *Repository) GetChoresByAssigneeID(assigneeID int, familyID int) ([]*ent
         WHERE c.assignee_id = $1 AND c.family_id = $2 AND c.is_deleted = false
         ORDER BY c.created_at DESC`
 
-	rows, err := r.db.Query(query, assigneeID, familyID)
+	rows, err := r.db.QueryContext(ctx, query, assigneeID, familyID)
 	if err != nil {
-		return nil, fmt.Errorf("error getting chores: %v", err)
+		return nil, fmt.Errorf("error getting chores: %w", err)
 	}
 	defer rows.Close()
 
@@ -169,11 +171,11 @@ func (r *Repository) GetChoresByAssigneeID(assigneeID int, familyID int) ([]*ent
 			&chore.Points, &chore.OccurrenceType, &occurrenceDataJSON, &chore.CreatedAt, &chore.UpdatedAt,
 		)
 		if err != nil {
-			return nil, fmt.Errorf("error scanning chore: %v", err)
+			return nil, fmt.Errorf("error scanning chore: %w", err)
 		}
 
 		if err := json.Unmarshal(occurrenceDataJSON, &chore.OccurrenceData); err != nil {
-			return nil, fmt.Errorf("error unmarshaling occurrence data: %v", err)
+			return nil, fmt.Errorf("error unmarshaling occurrence data: %w", err)
 		}
 
 		chores = append(chores, chore)
@@ -182,10 +184,10 @@ func (r *Repository) GetChoresByAssigneeID(assigneeID int, familyID int) ([]*ent
 	return chores, nil
 }
 
-func (r *Repository) UpdateChore(chore *entities.Chore) error {
+func (r *Repository) UpdateChore(ctx context.Context, chore *entities.Chore) error {
 	occurrenceData, err := json.Marshal(chore.OccurrenceData)
 	if err != nil {
-		return fmt.Errorf("error marshaling occurrence data: %v", err)
+		return fmt.Errorf("error marshaling occurrence data: %w", err)
 	}
 
 	query := `
@@ -194,7 +196,7 @@ func (r *Repository) UpdateChore(chore *entities.Chore) error {
 			occurrence_type = $6, occurrence_data = $7, updated_at = $8
 		WHERE id = $1 AND family_id = $9 AND is_deleted = false`
 
-	result, err := r.db.Exec(
+	result, err := r.db.ExecContext(ctx,
 		query,
 		chore.ID,
 		chore.Name,
@@ -208,25 +210,25 @@ func (r *Repository) UpdateChore(chore *entities.Chore) error {
 	)
 
 	if err != nil {
-		return fmt.Errorf("error updating chore: %v", err)
+		return fmt.Errorf("error updating chore: %w", err)
 	}
 
 	rowsAffected, err := result.RowsAffected()
 	if err != nil {
-		return fmt.Errorf("error checking update result: %v", err)
+		return fmt.Errorf("error checking update result: %w", err)
 	}
 
 	if rowsAffected == 0 {
-		return fmt.Errorf("chore not found")
+		return apperror.NotFound("chore not found")
 	}
 
 	return nil
 }
 
-func (r *Repository) DeleteChore(id int, familyID int, deletedBy int) error {
-    tx, err := r.db.Begin()
+func (r *Repository) DeleteChore(ctx context.Context, id int, familyID int, deletedBy int) error {
+    tx, err := r.db.BeginTx(ctx, nil)
     if err != nil {
-        return fmt.Errorf("error starting transaction: %v", err)
+        return fmt.Errorf("error starting transaction: %w", err)
     }
     defer tx.Rollback()
 
@@ -235,9 +237,9 @@ func (r *Repository) DeleteChore(id int, familyID int, deletedBy int) error {
         SET is_deleted = true, deleted_at = $3, deleted_by = $4, updated_at = $3
         WHERE chore_id = $1 AND family_id = $2 AND is_deleted = false`
     
-    _, err = tx.Exec(instanceQuery, id, familyID, time.Now().UTC(), deletedBy)
+    _, err = tx.ExecContext(ctx, instanceQuery, id, familyID, time.Now().UTC(), deletedBy)
     if err != nil {
-        return fmt.Errorf("error soft deleting chore instances: %v", err)
+        return fmt.Errorf("error soft deleting chore instances: %w", err)
     }
 
     choreQuery := `
@@ -245,27 +247,27 @@ func (r *Repository) DeleteChore(id int, familyID int, deletedBy int) error {
         SET is_deleted = true, deleted_at = $3, deleted_by = $4, updated_at = $3
         WHERE id = $1 AND family_id = $2 AND is_deleted = false`
     
-    result, err := tx.Exec(choreQuery, id, familyID, time.Now().UTC(), deletedBy)
+    result, err := tx.ExecContext(ctx, choreQuery, id, familyID, time.Now().UTC(), deletedBy)
     if err != nil {
-        return fmt.Errorf("error soft deleting chore: %v", err)
+        return fmt.Errorf("error soft deleting chore: %w", err)
     }
 
     rowsAffected, err := result.RowsAffected()
     if err != nil {
-        return fmt.Errorf("error checking delete result: %v", err)
+        return fmt.Errorf("error checking delete result: %w", err)
     }
 
     if rowsAffected == 0 {
-        return fmt.Errorf("chore not found")
+        return apperror.NotFound("chore not found")
     }
 
     return tx.Commit()
 }
 
-func (r *Repository) RestoreChore(id int, familyID int) error {
-    tx, err := r.db.Begin()
+func (r *Repository) RestoreChore(ctx context.Context, id int, familyID int) error {
+    tx, err := r.db.BeginTx(ctx, nil)
     if err != nil {
-        return fmt.Errorf("error starting transaction: %v", err)
+        return fmt.Errorf("error starting transaction: %w", err)
     }
     defer tx.Rollback()
 
@@ -274,18 +276,18 @@ func (r *Repository) RestoreChore(id int, familyID int) error {
         SET is_deleted = false, deleted_at = NULL, deleted_by = NULL, updated_at = $3
         WHERE id = $1 AND family_id = $2 AND is_deleted = true`
     
-    result, err := tx.Exec(choreQuery, id, familyID, time.Now().UTC())
+    result, err := tx.ExecContext(ctx, choreQuery, id, familyID, time.Now().UTC())
     if err != nil {
-        return fmt.Errorf("error restoring chore: %v", err)
+        return fmt.Errorf("error restoring chore: %w", err)
     }
 
     rowsAffected, err := result.RowsAffected()
     if err != nil {
-        return fmt.Errorf("error checking restore result: %v", err)
+        return fmt.Errorf("error checking restore result: %w", err)
     }
 
     if rowsAffected == 0 {
-        return fmt.Errorf("chore not found or not deleted")
+        return apperror.NotFound("chore not found or not deleted")
     }
 
     instanceQuery := `
@@ -293,15 +295,15 @@ func (r *Repository) RestoreChore(id int, familyID int) error {
         SET is_deleted = false, deleted_at = NULL, deleted_by = NULL, updated_at = $3
         WHERE chore_id = $1 AND family_id = $2 AND is_deleted = true`
     
-    _, err = tx.Exec(instanceQuery, id, familyID, time.Now().UTC())
+    _, err = tx.ExecContext(ctx, instanceQuery, id, familyID, time.Now().UTC())
     if err != nil {
-        return fmt.Errorf("error restoring chore instances: %v", err)
+        return fmt.Errorf("error restoring chore instances: %w", err)
     }
 
     return tx.Commit()
 }
 
-func (r *Repository) CreateChoreInstance(instance *entities.ChoreInstance) error {
+func (r *Repository) CreateChoreInstance(ctx context.Context, instance *entities.ChoreInstance) error {
 	query := `
 		INSERT INTO chore_instance (
 			chore_id, assignee_id, family_id, due_date, status, 
@@ -309,7 +311,7 @@ func (r *Repository) CreateChoreInstance(instance *entities.ChoreInstance) error
 		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
 		RETURNING id, created_at, updated_at`
 
-	err := r.db.QueryRow(
+	err := r.db.QueryRowContext(ctx,
 		query,
 		instance.ChoreID,
 		instance.AssigneeID,
@@ -322,13 +324,13 @@ func (r *Repository) CreateChoreInstance(instance *entities.ChoreInstance) error
 	).Scan(&instance.ID, &instance.CreatedAt, &instance.UpdatedAt)
 
 	if err != nil {
-		return fmt.Errorf("error creating chore instance: %v", err)
+		return fmt.Errorf("error creating chore instance: %w", err)
 	}
 
 	return nil
 }
 
-func (r *Repository) GetInstanceByID(id int, familyID int) (*entities.ChoreInstance, error) {
+func (r *Repository) GetInstanceByID(ctx context.Context, id int, familyID int) (*entities.ChoreInstance, error) {
     query := `
         SELECT ci.id, ci.chore_id, ci.assignee_id, ci.family_id, ci.due_date,
                ci.status, ci.completed_at, ci.verified_by, ci.notes, 
@@ -346,7 +348,7 @@ func (r *Repository) GetInstanceByID(id int, familyID int) (*entities.ChoreInsta
 	var verifiedBy sql.NullInt64
 	var completedAt sql.NullTime
 
-	err := r.db.QueryRow(query, id, familyID).Scan(
+	err := r.db.QueryRowContext(ctx, query, id, familyID).Scan(
 		&instance.ID, &instance.ChoreID, &instance.AssigneeID, &instance.FamilyID, &instance.DueDate,
 		&instance.Status, &completedAt, &verifiedBy, &instance.Notes,
 		&instance.CreatedAt, &instance.UpdatedAt,
@@ -355,10 +357,10 @@ func (r *Repository) GetInstanceByID(id int, familyID int) (*entities.ChoreInsta
 	)
 
 	if err == sql.ErrNoRows {
-		return nil, fmt.Errorf("chore instance not found")
+		return nil, apperror.NotFound("chore instance not found")
 	}
 	if err != nil {
-		return nil, fmt.Errorf("error getting chore instance: %v", err)
+		return nil, fmt.Errorf("error getting chore instance: %w", err)
 	}
 
 	instance.Assignee = assignee
@@ -373,16 +375,16 @@ func (r *Repository) GetInstanceByID(id int, familyID int) (*entities.ChoreInsta
 		instance.Verifier = verifier
 	}
 
-	chore, err := r.GetChoreByID(instance.ChoreID, familyID)
+	chore, err := r.GetChoreByID(ctx, instance.ChoreID, familyID)
 	if err != nil {
-		return nil, fmt.Errorf("error getting chore: %v", err)
+		return nil, fmt.Errorf("error getting chore: %w", err)
 	}
 	instance.Chore = chore
 
 	return instance, nil
 }
 
-func (r *Repository) GetInstancesByChoreID(choreID int, familyID int) ([]entities.ChoreInstance, error) {
+func (r *Repository) GetInstancesByChoreID(ctx context.Context, choreID int, familyID int) ([]entities.ChoreInstance, error) {
     query := `
         SELECT ci.id, ci.chore_id, ci.assignee_id, ci.family_id, ci.due_date,
                ci.status, ci.completed_at, ci.verified_by, ci.notes, 
@@ -391,9 +393,9 @@ func (r *Repository) GetInstancesByChoreID(choreID int, familyID int) ([]entitie
         WHERE ci.chore_id = $1 AND ci.family_id = $2 AND ci.is_deleted = false
         ORDER BY ci.due_date DESC`
 
-	rows, err := r.db.Query(query, choreID, familyID)
+	rows, err := r.db.QueryContext(ctx, query, choreID, familyID)
 	if err != nil {
-		return nil, fmt.Errorf("error getting chore instances: %v", err)
+		return nil, fmt.Errorf("error getting chore instances: %w", err)
 	}
 	defer rows.Close()
 
@@ -409,7 +411,7 @@ func (r *Repository) GetInstancesByChoreID(choreID int, familyID int) ([]entitie
 			&instance.CreatedAt, &instance.UpdatedAt,
 		)
 		if err != nil {
-			return nil, fmt.Errorf("error scanning chore instance: %v", err)
+			return nil, fmt.Errorf("error scanning chore instance: %w", err)
 		}
 
 		if completedAt.Valid {
@@ -427,7 +429,7 @@ func (r *Repository) GetInstancesByChoreID(choreID int, familyID int) ([]entitie
 	return instances, nil
 }
 
-func (r *Repository) GetInstancesByDueDate(dueDate time.Time, familyID int) ([]*entities.ChoreInstance, error) {
+func (r *Repository) GetInstancesByDueDate(ctx context.Context, dueDate time.Time, familyID int) ([]*entities.ChoreInstance, error) {
     startOfDay := time.Date(dueDate.Year(), dueDate.Month(), dueDate.Day(), 0, 0, 0, 0, dueDate.Location())
     endOfDay := startOfDay.Add(24 * time.Hour)
 
@@ -441,9 +443,9 @@ func (r *Repository) GetInstancesByDueDate(dueDate time.Time, familyID int) ([]*
         WHERE ci.due_date >= $1 AND ci.due_date < $2 AND ci.family_id = $3 AND ci.is_deleted = false
         ORDER BY ci.due_date ASC`
 
-	rows, err := r.db.Query(query, startOfDay, endOfDay, familyID)
+	rows, err := r.db.QueryContext(ctx, query, startOfDay, endOfDay, familyID)
 	if err != nil {
-		return nil, fmt.Errorf("error getting chore instances: %v", err)
+		return nil, fmt.Errorf("error getting chore instances: %w", err)
 	}
 	defer rows.Close()
 
@@ -461,7 +463,7 @@ func (r *Repository) GetInstancesByDueDate(dueDate time.Time, familyID int) ([]*
 			&chore.Name, &chore.Points,
 		)
 		if err != nil {
-			return nil, fmt.Errorf("error scanning chore instance: %v", err)
+			return nil, fmt.Errorf("error scanning chore instance: %w", err)
 		}
 
 		if completedAt.Valid {
@@ -481,7 +483,7 @@ func (r *Repository) GetInstancesByDueDate(dueDate time.Time, familyID int) ([]*
 	return instances, nil
 }
 
-func (r *Repository) GetInstancesByAssignee(assigneeID int, familyID int, startDate, endDate time.Time) ([]*entities.ChoreInstance, error) {
+func (r *Repository) GetInstancesByAssignee(ctx context.Context, assigneeID int, familyID int, startDate, endDate time.Time) ([]*entities.ChoreInstance, error) {
 	query := `
     SELECT ci.id, ci.chore_id, ci.assignee_id, ci.family_id, ci.due_date,
         ci.status, ci.completed_at, ci.verified_by, ci.notes, 
@@ -494,9 +496,9 @@ func (r *Repository) GetInstancesByAssignee(assigneeID int, familyID int, startD
     AND ci.is_deleted = false
     ORDER BY ci.due_date ASC`
 
-	rows, err := r.db.Query(query, assigneeID, familyID, startDate, endDate)
+	rows, err := r.db.QueryContext(ctx, query, assigneeID, familyID, startDate, endDate)
 	if err != nil {
-		return nil, fmt.Errorf("error getting chore instances: %v", err)
+		return nil, fmt.Errorf("error getting chore instances: %w", err)
 	}
 	defer rows.Close()
 
@@ -514,7 +516,7 @@ func (r *Repository) GetInstancesByAssignee(assigneeID int, familyID int, startD
 			&chore.Name, &chore.Points,
 		)
 		if err != nil {
-			return nil, fmt.Errorf("error scanning chore instance: %v", err)
+			return nil, fmt.Errorf("error scanning chore instance: %w", err)
 		}
 
 		if completedAt.Valid {
@@ -534,7 +536,7 @@ func (r *Repository) GetInstancesByAssignee(assigneeID int, familyID int, startD
 	return instances, nil
 }
 
-func (r *Repository) GetInstancesByAssigneeAndDate(assigneeID int, familyID int, date time.Time) ([]*entities.ChoreInstance, error) {
+func (r *Repository) GetInstancesByAssigneeAndDate(ctx context.Context, assigneeID int, familyID int, date time.Time) ([]*entities.ChoreInstance, error) {
 	startOfDay := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, date.Location())
 	endOfDay := startOfDay.Add(24 * time.Hour)
 
@@ -549,9 +551,9 @@ func (r *Repository) GetInstancesByAssigneeAndDate(assigneeID int, familyID int,
 		AND ci.due_date >= $3 AND ci.due_date < $4
 		ORDER BY ci.due_date ASC`
 
-	rows, err := r.db.Query(query, assigneeID, familyID, startOfDay, endOfDay)
+	rows, err := r.db.QueryContext(ctx, query, assigneeID, familyID, startOfDay, endOfDay)
 	if err != nil {
-		return nil, fmt.Errorf("error getting chore instances: %v", err)
+		return nil, fmt.Errorf("error getting chore instances: %w", err)
 	}
 	defer rows.Close()
 
@@ -569,7 +571,7 @@ func (r *Repository) GetInstancesByAssigneeAndDate(assigneeID int, familyID int,
 			&chore.Name, &chore.Points,
 		)
 		if err != nil {
-			return nil, fmt.Errorf("error scanning chore instance: %v", err)
+			return nil, fmt.Errorf("error scanning chore instance: %w", err)
 		}
 
 		if completedAt.Valid {
@@ -589,7 +591,7 @@ func (r *Repository) GetInstancesByAssigneeAndDate(assigneeID int, familyID int,
 	return instances, nil
 }
 
-func (r *Repository) CheckInstanceExists(choreID int, dueDate time.Time) (bool, error) {
+func (r *Repository) CheckInstanceExists(ctx context.Context, choreID int, dueDate time.Time) (bool, error) {
 	startOfDay := time.Date(dueDate.Year(), dueDate.Month(), dueDate.Day(), 0, 0, 0, 0, dueDate.Location())
 	endOfDay := startOfDay.Add(24 * time.Hour)
 
@@ -600,15 +602,15 @@ func (r *Repository) CheckInstanceExists(choreID int, dueDate time.Time) (bool,
 		)`
 
 	var exists bool
-	err := r.db.QueryRow(query, choreID, startOfDay, endOfDay).Scan(&exists)
+	err := r.db.QueryRowContext(ctx, query, choreID, startOfDay, endOfDay).Scan(&exists)
 	if err != nil {
-		return false, fmt.Errorf("error checking if instance exists: %v", err)
+		return false, fmt.Errorf("error checking if instance exists: %w", err)
 	}
 
 	return exists, nil
 }
 
-func (r *Repository) UpdateChoreInstance(instance *entities.ChoreInstance) error {
+func (r *Repository) UpdateChoreInstance(ctx context.Context, instance *entities.ChoreInstance) error {
     query := `
         UPDATE chore_instance
         SET status = $2, completed_at = $3, verified_by = $4, notes = $5, updated_at = $6
@@ -630,7 +632,7 @@ func (r *Repository) UpdateChoreInstance(instance *entities.ChoreInstance) error
 		verifiedBy = instance.VerifiedBy
 	}
 
-	result, err := r.db.Exec(
+	result, err := r.db.ExecContext(ctx,
 		query,
 		instance.ID,
 		instance.Status,
@@ -642,22 +644,22 @@ func (r *Repository) UpdateChoreInstance(instance *entities.ChoreInstance) error
 	)
 
 	if err != nil {
-		return fmt.Errorf("error updating chore instance: %v", err)
+		return fmt.Errorf("error updating chore instance: %w", err)
 	}
 
 	rowsAffected, err := result.RowsAffected()
 	if err != nil {
-		return fmt.Errorf("error checking update result: %v", err)
+		return fmt.Errorf("error checking update result: %w", err)
 	}
 
 	if rowsAffected == 0 {
-		return fmt.Errorf("chore instance not found")
+		return apperror.NotFound("chore instance not found")
 	}
 
 	return nil
 }
 
-func (r *Repository) GetChoreStats(profileId int, familyID int, startDate, endDate time.Time) (*ChoreStats, error) {
+func (r *Repository) GetChoreStats(ctx context.Context, profileId int, familyID int, startDate, endDate time.Time) (*ChoreStats, error) {
 	query := `
     SELECT 
         COUNT(*) as total_assigned,
@@ -676,7 +678,7 @@ func (r *Repository) GetChoreStats(profileId int, familyID int, startDate, endDa
 	var totalCompleted, totalVerified, totalMissed sql.NullInt64
 	var pointsEarned sql.NullInt64
 
-	err := r.db.QueryRow(query, profileId, familyID, startDate, endDate).Scan(
+	err := r.db.QueryRowContext(ctx, query, profileId, familyID, startDate, endDate).Scan(
 		&stats.TotalAssigned,
 		&totalCompleted,
 		&totalVerified,
@@ -685,7 +687,7 @@ func (r *Repository) GetChoreStats(profileId int, familyID int, startDate, endDa
 	)
 
 	if err != nil {
-		return nil, fmt.Errorf("error getting chore stats: %v", err)
+		return nil, fmt.Errorf("error getting chore stats: %w", err)
 	}
 
 	if totalCompleted.Valid {
@@ -708,7 +710,7 @@ func (r *Repository) GetChoreStats(profileId int, familyID int, startDate, endDa
 	return stats, nil
 }
 
-func (r *Repository) SaveDailyVerification(verification *entities.DailyVerification) error {
+func (r *Repository) SaveDailyVerification(ctx context.Context, verification *entities.DailyVerification) error {
 	existingQuery := `
 		SELECT EXISTS(
 			SELECT 1 FROM daily_verification 
@@ -716,9 +718,9 @@ func (r *Repository) SaveDailyVerification(verification *entities.DailyVerificat
 		)`
 	
 	var exists bool
-	err := r.db.QueryRow(existingQuery, verification.Date, verification.AssigneeID, verification.FamilyID).Scan(&exists)
+	err := r.db.QueryRowContext(ctx, existingQuery, verification.Date, verification.AssigneeID, verification.FamilyID).Scan(&exists)
 	if err != nil {
-		return fmt.Errorf("error checking if verification exists: %v", err)
+		return fmt.Errorf("error checking if verification exists: %w", err)
 	}
 
 	if exists {
@@ -738,7 +740,7 @@ func (r *Repository) SaveDailyVerification(verification *entities.DailyVerificat
 			verifiedAt = sql.NullTime{Time: *verification.VerifiedAt, Valid: true}
 		}
 		
-		_, err = r.db.Exec(
+		_, err = r.db.ExecContext(ctx,
 			query,
 			verification.Date,
 			verification.AssigneeID,
@@ -751,7 +753,7 @@ func (r *Repository) SaveDailyVerification(verification *entities.DailyVerificat
 		)
 		
 		if err != nil {
-			return fmt.Errorf("error updating verification: %v", err)
+			return fmt.Errorf("error updating verification: %w", err)
 		}
 	} else {
 		query := `
@@ -771,7 +773,7 @@ func (r *Repository) SaveDailyVerification(verification *entities.DailyVerificat
 		}
 		
 		now := time.Now().UTC()
-		_, err = r.db.Exec(
+		_, err = r.db.ExecContext(ctx,
 			query,
 			verification.Date,
 			verification.AssigneeID,
@@ -784,7 +786,7 @@ func (r *Repository) SaveDailyVerification(verification *entities.DailyVerificat
 		)
 		
 		if err != nil {
-			return fmt.Errorf("error creating verification: %v", err)
+			return fmt.Errorf("error creating verification: %w", err)
 		}
 		
 		verification.CreatedAt = now
@@ -794,7 +796,7 @@ func (r *Repository) SaveDailyVerification(verification *entities.DailyVerificat
 	return nil
 }
 
-func (r *Repository) GetDailyVerification(date time.Time, assigneeID int, familyID int) (*entities.DailyVerification, error) {
+func (r *Repository) GetDailyVerification(ctx context.Context, date time.Time, assigneeID int, familyID int) (*entities.DailyVerification, error) {
 	query := `
 		SELECT date, assignee_id, family_id, is_verified, verified_by, verified_at, notes, created_at, updated_at
 		FROM daily_verification
@@ -804,7 +806,7 @@ func (r *Repository) GetDailyVerification(date time.Time, assigneeID int, family
 	var verifiedBy sql.NullInt64
 	var verifiedAt sql.NullTime
 	
-	err := r.db.QueryRow(query, date, assigneeID, familyID).Scan(
+	err := r.db.QueryRowContext(ctx, query, date, assigneeID, familyID).Scan(
 		&verification.Date,
 		&verification.AssigneeID,
 		&verification.FamilyID,
@@ -825,7 +827,7 @@ func (r *Repository) GetDailyVerification(date time.Time, assigneeID int, family
 		}, nil
 	}
 	if err != nil {
-		return nil, fmt.Errorf("error getting verification: %v", err)
+		return nil, fmt.Errorf("error getting verification: %w", err)
 	}
 	
 	if verifiedBy.Valid {
@@ -838,4 +840,17 @@ func (r *Repository) GetDailyVerification(date time.Time, assigneeID int, family
 	}
 	
 	return verification, nil
-}
\ No newline at end of file
+}
+func (r *Repository) IsFamilyProfile(ctx context.Context, profileID int, familyID int) (bool, error) {
+	query := `
+		SELECT EXISTS (
+			SELECT 1 FROM profile
+			WHERE id = $1 AND family_id = $2 AND is_deleted = false
+		)`
+
+	var exists bool
+	if err := r.db.QueryRowContext(ctx, query, profileID, familyID).Scan(&exists); err != nil {
+		return false, fmt.Errorf("error checking profile: %w", err)
+	}
+	return exists, nil
+}
@@ -1,10 +1,16 @@
 package chores
 
 import (
+	"context"
 	"fmt"
 	"time"
 
+	"github.com/chrisabs/cadence/internal/apperror"
 	"github.com/chrisabs/cadence/internal/chores/entities"
+	"github.com/chrisabs/cadence/internal/platform/logging"
+	"github.com/chrisabs/cadence/internal/platform/metrics"
+	"github.com/chrisabs/cadence/internal/platform/tracing"
+	"github.com/chrisabs/cadence/internal/platform/validate"
 )
 
 type CalendarService interface {
@@ -28,13 +34,12 @@ func (s *Service) SetCalendarService(calendarService CalendarService) {
 	s.calendarService = calendarService
 }
 
-func (s *Service) CreateChore(profileId int, familyID int, req *CreateChoreRequest) (*entities.Chore, error) {
-	if req.Name == "" {
-		return nil, fmt.Errorf("chore name is required")
-	}
+func (s *Service) CreateChore(ctx context.Context, profileId int, familyID int, req *CreateChoreRequest) (*entities.Chore, error) {
+	ctx, span := tracing.Start(ctx, "chores.Service.CreateChore")
+	defer span.End()
 
-	if req.AssigneeID == 0 {
-		return nil, fmt.Errorf("assignee is required")
+	if err := s.validateAssignedRequest(ctx, familyID, req.AssigneeID, req); err != nil {
+		return nil, err
 	}
 
 	chore := &entities.Chore{
@@ -48,40 +53,56 @@ func (s *Service) CreateChore(profileId int, familyID int, req *CreateChoreReque
 		OccurrenceData: req.OccurrenceData,
 	}
 
-	if err := s.repo.CreateChore(chore); err != nil {
-		return nil, fmt.Errorf("failed to create chore: %v", err)
+	if err := s.repo.CreateChore(ctx, chore); err != nil {
+		return nil, fmt.Errorf("failed to create chore: %w", err)
 	}
 
 	if !chore.OccurrenceData.StartDate.After(time.Now()) {
-		if err := s.generateInitialInstances(chore); err != nil {
-			fmt.Printf("Warning: failed to generate initial instances: %v\n", err)
+		if err := s.generateInitialInstances(ctx, chore); err != nil {
+			logging.FromContext(ctx).Warn("failed to generate initial instances", "chore_id", chore.ID, "error", err)
 		}
 	}
 
-	fullChore, err := s.repo.GetChoreByID(chore.ID, familyID)
+	fullChore, err := s.repo.GetChoreByID(ctx, chore.ID, familyID)
 	if err != nil {
-		return nil, fmt.Errorf("chore created but failed to retrieve it: %v", err)
+		return nil, fmt.Errorf("chore created but failed to retrieve it: %w", err)
 	}
 
 	return fullChore, nil
 }
 
-func (s *Service) GetChoreByID(id int, familyID int) (*entities.Chore, error) {
-	return s.repo.GetChoreByID(id, familyID)
+func (s *Service) GetChoreByID(ctx context.Context, id int, familyID int) (*entities.Chore, error) {
+	ctx, span := tracing.Start(ctx, "chores.Service.GetChoreByID")
+	defer span.End()
+
+	return s.repo.GetChoreByID(ctx, id, familyID)
 }
 
-func (s *Service) GetChoresByFamilyID(familyID int) ([]*entities.Chore, error) {
-	return s.repo.GetChoresByFamilyID(familyID)
+func (s *Service) GetChoresByFamilyID(ctx context.Context, familyID int) ([]*entities.Chore, error) {
+	ctx, span := tracing.Start(ctx, "chores.Service.GetChoresByFamilyID")
+	defer span.End()
+
+	return s.repo.GetChoresByFamilyID(ctx, familyID)
 }
 
-func (s *Service) GetChoresByAssigneeID(assigneeID int, familyID int) ([]*entities.Chore, error) {
-	return s.repo.GetChoresByAssigneeID(assigneeID, familyID)
+func (s *Service) GetChoresByAssigneeID(ctx context.Context, assigneeID int, familyID int) ([]*entities.Chore, error) {
+	ctx, span := tracing.Start(ctx, "chores.Service.GetChoresByAssigneeID")
+	defer span.End()
+
+	return s.repo.GetChoresByAssigneeID(ctx, assigneeID, familyID)
 }
 
-func (s *Service) UpdateChore(id int, familyID int, req *UpdateChoreRequest) (*entities.Chore, error) {
-	chore, err := s.repo.GetChoreByID(id, familyID)
+func (s *Service) UpdateChore(ctx context.Context, id int, familyID int, req *UpdateChoreRequest) (*entities.Chore, error) {
+	ctx, span := tracing.Start(ctx, "chores.Service.UpdateChore")
+	defer span.End()
+
+	if err := s.validateAssignedRequest(ctx, familyID, req.AssigneeID, req); err != nil {
+		return nil, err
+	}
+
+	chore, err := s.repo.GetChoreByID(ctx, id, familyID)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get chore: %v", err)
+		return nil, fmt.Errorf("failed to get chore: %w", err)
 	}
 
 	chore.Name = req.Name
@@ -91,71 +112,93 @@ func (s *Service) UpdateChore(id int, familyID int, req *UpdateChoreRequest) (*e
 	chore.OccurrenceType = req.OccurrenceType
 	chore.OccurrenceData = req.OccurrenceData
 
-	if err := s.repo.UpdateChore(chore); err != nil {
-		return nil, fmt.Errorf("failed to update chore: %v", err)
+	if err := s.repo.UpdateChore(ctx, chore); err != nil {
+		return nil, fmt.Errorf("failed to update chore: %w", err)
 	}
 
 	if s.calendarService != nil {
 		// Todo: update when we have calendar structure - this should be used to update calendar events for future instances
 	}
 
-	updatedChore, err := s.repo.GetChoreByID(id, familyID)
+	updatedChore, err := s.repo.GetChoreByID(ctx, id, familyID)
 	if err != nil {
-		return nil, fmt.Errorf("chore updated but failed to retrieve it: %v", err)
+		return nil, fmt.Errorf("chore updated but failed to retrieve it: %w", err)
 	}
 
 	return updatedChore, nil
 }
 
-func (s *Service) DeleteChore(id int, familyID int, deletedBy int) error {
-	chore, err := s.repo.GetChoreByID(id, familyID)
+func (s *Service) DeleteChore(ctx context.Context, id int, familyID int, deletedBy int) error {
+	ctx, span := tracing.Start(ctx, "chores.Service.DeleteChore")
+	defer span.End()
+
+	chore, err := s.repo.GetChoreByID(ctx, id, familyID)
 	if err != nil {
-		return fmt.Errorf("failed to get chore: %v", err)
+		return fmt.Errorf("failed to get chore: %w", err)
 	}
 
 	if s.calendarService != nil {
 		// Todo: update when we have calendar structure - this should be used to delete calendar events for all instances
 		for _, instance := range chore.Instances {
 			if err := s.calendarService.DeleteEvent("chores", instance.ID); err != nil {
-				fmt.Printf("Warning: failed to delete calendar event: %v\n", err)
+				logging.FromContext(ctx).Warn("failed to delete calendar event", "chore_instance_id", instance.ID, "error", err)
 			}
 		}
 	}
 
-    if err := s.repo.DeleteChore(id, familyID, deletedBy); err != nil {
-        return fmt.Errorf("failed to delete chore: %v", err)
+    if err := s.repo.DeleteChore(ctx, id, familyID, deletedBy); err != nil {
+        return fmt.Errorf("failed to delete chore: %w", err)
     }
     return nil
 
 }
 
-func (s *Service) RestoreChore(id int, familyID int) error {
-    if err := s.repo.RestoreChore(id, familyID); err != nil {
-        return fmt.Errorf("failed to restore chore: %v", err)
+func (s *Service) RestoreChore(ctx context.Context, id int, familyID int) error {
+    ctx, span := tracing.Start(ctx, "chores.Service.RestoreChore")
+    defer span.End()
+
+    if err := s.repo.RestoreChore(ctx, id, familyID); err != nil {
+        return fmt.Errorf("failed to restore chore: %w", err)
     }
     return nil
 }
 
-func (s *Service) GetInstanceByID(id int, familyID int) (*entities.ChoreInstance, error) {
-	return s.repo.GetInstanceByID(id, familyID)
+func (s *Service) GetInstanceByID(ctx context.Context, id int, familyID int) (*entities.ChoreInstance, error) {
+	ctx, span := tracing.Start(ctx, "chores.Service.GetInstanceByID")
+	defer span.End()
+
+	return s.repo.GetInstanceByID(ctx, id, familyID)
 }
 
-func (s *Service) GetInstancesByDueDate(date time.Time, familyID int) ([]*entities.ChoreInstance, error) {
-	return s.repo.GetInstancesByDueDate(date, familyID)
+func (s *Service) GetInstancesByDueDate(ctx context.Context, date time.Time, familyID int) ([]*entities.ChoreInstance, error) {
+	ctx, span := tracing.Start(ctx, "chores.Service.GetInstancesByDueDate")
+	defer span.End()
+
+	return s.repo.GetInstancesByDueDate(ctx, date, familyID)
 }
 
-func (s *Service) GetInstancesByAssignee(assigneeID int, familyID int, startDate, endDate time.Time) ([]*entities.ChoreInstance, error) {
-	return s.repo.GetInstancesByAssignee(assigneeID, familyID, startDate, endDate)
+func (s *Service) GetInstancesByAssignee(ctx context.Context, assigneeID int, familyID int, startDate, endDate time.Time) ([]*entities.ChoreInstance, error) {
+	ctx, span := tracing.Start(ctx, "chores.Service.GetInstancesByAssignee")
+	defer span.End()
+
+	return s.repo.GetInstancesByAssignee(ctx, assigneeID, familyID, startDate, endDate)
 }
 
-func (s *Service) CompleteChoreInstance(id int, profileId int, familyID int, req *UpdateChoreInstanceRequest) (*entities.ChoreInstance, error) {
-	instance, err := s.repo.GetInstanceByID(id, familyID)
+func (s *Service) CompleteChoreInstance(ctx context.Context, id int, profileId int, familyID int, req *UpdateChoreInstanceRequest) (*entities.ChoreInstance, error) {
+	ctx, span := tracing.Start(ctx, "chores.Service.CompleteChoreInstance")
+	defer span.End()
+
+	if err := validate.Struct(req); err != nil {
+		return nil, err
+	}
+
+	instance, err := s.repo.GetInstanceByID(ctx, id, familyID)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get chore instance: %v", err)
+		return nil, fmt.Errorf("failed to get chore instance: %w", err)
 	}
 
 	if instance.AssigneeID != profileId {
-		return nil, fmt.Errorf("only the assignee can mark this chore as completed")
+		return nil, apperror.Forbidden("only the assignee can mark this chore as completed")
 	}
 
 	now := time.Now().UTC()
@@ -163,25 +206,32 @@ func (s *Service) CompleteChoreInstance(id int, profileId int, familyID int, req
 	instance.CompletedAt = &now
 	instance.Notes = req.Notes
 	
-	if err := s.repo.UpdateChoreInstance(instance); err != nil {
-		return nil, fmt.Errorf("failed to update chore instance: %v", err)
+	if err := s.repo.UpdateChoreInstance(ctx, instance); err != nil {
+		return nil, fmt.Errorf("failed to update chore instance: %w", err)
 	}
 
 	if s.calendarService != nil {
 		// Todo: update when we have calendar structure - this should be used to update the calendar event
 	}
 
-	return s.repo.GetInstanceByID(id, familyID)
+	return s.repo.GetInstanceByID(ctx, id, familyID)
 }
 
-func (s *Service) ReviewChore(id int, parentID int, familyID int, req *ReviewChoreRequest) (*entities.ChoreInstance, error) {
-	instance, err := s.repo.GetInstanceByID(id, familyID)
+func (s *Service) ReviewChore(ctx context.Context, id int, parentID int, familyID int, req *ReviewChoreRequest) (*entities.ChoreInstance, error) {
+	ctx, span := tracing.Start(ctx, "chores.Service.ReviewChore")
+	defer span.End()
+
+	if err := validate.Struct(req); err != nil {
+		return nil, err
+	}
+
+	instance, err := s.repo.GetInstanceByID(ctx, id, familyID)
 	if err != nil {
 		return nil, err
 	}
 	
 	if !(instance.Status == entities.StatusCompleted && (req.Status == entities.StatusVerified || req.Status == entities.StatusRejected)) {
-		return nil, fmt.Errorf("invalid status transition: can only review completed chores")
+		return nil, apperror.Conflict("invalid status transition: can only review completed chores")
 	}
 	
 	instance.Status = req.Status
@@ -193,7 +243,7 @@ func (s *Service) ReviewChore(id int, parentID int, familyID int, req *ReviewCho
 		instance.CompletedAt = &now
 	}
 	
-	if err := s.repo.UpdateChoreInstance(instance); err != nil {
+	if err := s.repo.UpdateChoreInstance(ctx, instance); err != nil {
 		return nil, err
 	}
 	
@@ -201,26 +251,32 @@ func (s *Service) ReviewChore(id int, parentID int, familyID int, req *ReviewCho
 		// Todo: update when we have calendar structure - this should be used to update the calendar event status
 	}
 	
-	return s.repo.GetInstanceByID(id, familyID)
+	return s.repo.GetInstanceByID(ctx, id, familyID)
 }
 
-func (s *Service) GetChoreStats(profileId int, familyID int, startDate, endDate time.Time) (*ChoreStats, error) {
-	return s.repo.GetChoreStats(profileId, familyID, startDate, endDate)
+func (s *Service) GetChoreStats(ctx context.Context, profileId int, familyID int, startDate, endDate time.Time) (*ChoreStats, error) {
+	ctx, span := tracing.Start(ctx, "chores.Service.GetChoreStats")
+	defer span.End()
+
+	return s.repo.GetChoreStats(ctx, profileId, familyID, startDate, endDate)
 }
 
-func (s *Service) GenerateDailyChoreInstances(familyID int) error {
+func (s *Service) GenerateDailyChoreInstances(ctx context.Context, familyID int) error {
+	ctx, span := tracing.Start(ctx, "chores.Service.GenerateDailyChoreInstances")
+	defer span.End()
+
 	today := time.Now().UTC().Truncate(24 * time.Hour)
 	
-	chores, err := s.repo.GetChoresByFamilyID(familyID)
+	chores, err := s.repo.GetChoresByFamilyID(ctx, familyID)
 	if err != nil {
-		return fmt.Errorf("failed to get chores: %v", err)
+		return fmt.Errorf("failed to get chores: %w", err)
 	}
 
 	for _, chore := range chores {
 		if s.shouldCreateInstanceForDate(chore, today) {
-			exists, err := s.repo.CheckInstanceExists(chore.ID, today)
+			exists, err := s.repo.CheckInstanceExists(ctx, chore.ID, today)
 			if err != nil {
-				fmt.Printf("Error checking if instance exists: %v\n", err)
+				logging.FromContext(ctx).Error("failed to check if chore instance exists", "chore_id", chore.ID, "error", err)
 				continue
 			}
 
@@ -233,10 +289,11 @@ func (s *Service) GenerateDailyChoreInstances(familyID int) error {
 					Status:     entities.StatusPending,
 				}
 
-				if err := s.repo.CreateChoreInstance(instance); err != nil {
-					fmt.Printf("Error creating chore instance: %v\n", err)
+				if err := s.repo.CreateChoreInstance(ctx, instance); err != nil {
+					logging.FromContext(ctx).Error("failed to create chore instance", "chore_id", chore.ID, "error", err)
 					continue
 				}
+				metrics.ChoreInstancesGenerated.Inc()
 
 				if s.calendarService != nil {
 					err := s.calendarService.CreateEvent(
@@ -250,7 +307,7 @@ func (s *Service) GenerateDailyChoreInstances(familyID int) error {
 						chore.FamilyID,
 					)
 					if err != nil {
-						fmt.Printf("Error creating calendar event: %v\n", err)
+						logging.FromContext(ctx).Error("failed to create calendar event", "chore_instance_id", instance.ID, "error", err)
 					}
 				}
 			}
@@ -260,13 +317,20 @@ func (s *Service) GenerateDailyChoreInstances(familyID int) error {
 	return nil
 }
 
-func (s *Service) VerifyDay(parentID int, familyID int, req *VerifyDayRequest) error {
+func (s *Service) VerifyDay(ctx context.Context, parentID int, familyID int, req *VerifyDayRequest) error {
+	ctx, span := tracing.Start(ctx, "chores.Service.VerifyDay")
+	defer span.End()
+
+	if err := s.validateAssignedRequest(ctx, familyID, req.AssigneeID, req); err != nil {
+		return err
+	}
+
 	date, err := time.Parse("2006-01-02", req.Date)
 	if err != nil {
-		return fmt.Errorf("invalid date format: %v", err)
+		return fmt.Errorf("error parsing date: %w", err)
 	}
 
-	instances, err := s.repo.GetInstancesByAssigneeAndDate(req.AssigneeID, familyID, date)
+	instances, err := s.repo.GetInstancesByAssigneeAndDate(ctx, req.AssigneeID, familyID, date)
 	if err != nil {
     	return err
 	}
@@ -278,7 +342,7 @@ func (s *Service) VerifyDay(parentID int, familyID int, req *VerifyDayRequest) e
         now := time.Now().UTC()
         instance.CompletedAt = &now
         
-        if err := s.repo.UpdateChoreInstance(instance); err != nil {
+        if err := s.repo.UpdateChoreInstance(ctx, instance); err != nil {
             return err
         }
     }
@@ -294,14 +358,17 @@ func (s *Service) VerifyDay(parentID int, familyID int, req *VerifyDayRequest) e
 		Notes:      req.Notes,
 	}
 	
-	return s.repo.SaveDailyVerification(verification)
+	return s.repo.SaveDailyVerification(ctx, verification)
 }
 
-func (s *Service) GetDailyVerification(date time.Time, assigneeID int, familyID int) (*entities.DailyVerification, error) {
-	return s.repo.GetDailyVerification(date, assigneeID, familyID)
+func (s *Service) GetDailyVerification(ctx context.Context, date time.Time, assigneeID int, familyID int) (*entities.DailyVerification, error) {
+	ctx, span := tracing.Start(ctx, "chores.Service.GetDailyVerification")
+	defer span.End()
+
+	return s.repo.GetDailyVerification(ctx, date, assigneeID, familyID)
 }
 
-func (s *Service) generateInitialInstances(chore *entities.Chore) error {
+func (s *Service) generateInitialInstances(ctx context.Context, chore *entities.Chore) error {
 	startDate := chore.OccurrenceData.StartDate.Truncate(24 * time.Hour)
 	today := time.Now().UTC().Truncate(24 * time.Hour)
 
@@ -312,9 +379,9 @@ func (s *Service) generateInitialInstances(chore *entities.Chore) error {
 
 	for date := startDate; !date.After(endDate); date = date.AddDate(0, 0, 1) {
 		if s.shouldCreateInstanceForDate(chore, date) {
-			exists, err := s.repo.CheckInstanceExists(chore.ID, date)
+			exists, err := s.repo.CheckInstanceExists(ctx, chore.ID, date)
 			if err != nil {
-				return fmt.Errorf("error checking if instance exists: %v", err)
+				return fmt.Errorf("error checking if instance exists: %w", err)
 			}
 
 			if !exists {
@@ -326,9 +393,10 @@ func (s *Service) generateInitialInstances(chore *entities.Chore) error {
 					Status:     entities.StatusPending,
 				}
 
-				if err := s.repo.CreateChoreInstance(instance); err != nil {
-					return fmt.Errorf("error creating chore instance: %v", err)
+				if err := s.repo.CreateChoreInstance(ctx, instance); err != nil {
+					return fmt.Errorf("error creating chore instance: %w", err)
 				}
+				metrics.ChoreInstancesGenerated.Inc()
 
 				if s.calendarService != nil {
 					err := s.calendarService.CreateEvent(
@@ -342,7 +410,7 @@ func (s *Service) generateInitialInstances(chore *entities.Chore) error {
 						chore.FamilyID,
 					)
 					if err != nil {
-						fmt.Printf("Error creating calendar event: %v\n", err)
+						logging.FromContext(ctx).Error("failed to create calendar event", "chore_instance_id", instance.ID, "error", err)
 					}
 				}
 			}
@@ -352,6 +420,25 @@ func (s *Service) generateInitialInstances(chore *entities.Chore) error {
 	return nil
 }
 
+// validateAssignedRequest runs the request's field rules and checks that the
+// assignee is an active profile in the caller's family.
+func (s *Service) validateAssignedRequest(ctx context.Context, familyID int, assigneeID int, req interface{}) error {
+	errs := validate.Errors{}
+	validate.Collect(req, errs)
+
+	if _, invalid := errs["assigneeId"]; !invalid {
+		isMember, err := s.repo.IsFamilyProfile(ctx, assigneeID, familyID)
+		if err != nil {
+			return err
+		}
+		if !isMember {
+			errs.Add("assigneeId", "must be a profile in this family")
+		}
+	}
+
+	return errs.Err()
+}
+
 func (s *Service) shouldCreateInstanceForDate(chore *entities.Chore, date time.Time) bool {
 	if date.Before(chore.OccurrenceData.StartDate) {
 		return false
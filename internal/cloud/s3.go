@@ -3,13 +3,17 @@ package cloud
 import (
 	"context"
 	"fmt"
+	"io"
 	"mime/multipart"
 	"path/filepath"
+	"strings"
 	"time"
 
 	"github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/service/s3"
 	appconfig "github.com/chrisabs/cadence/internal/config"
+	"github.com/chrisabs/cadence/internal/platform/metrics"
+	"github.com/chrisabs/cadence/internal/platform/tracing"
 )
 
 type S3Handler struct {
@@ -21,14 +25,14 @@ type S3Handler struct {
 func NewS3Handler() (*S3Handler, error) {
     cfg, err := appconfig.LoadConfig()
     if err != nil {
-        return nil, fmt.Errorf("unable to load app config: %v", err)
+        return nil, fmt.Errorf("unable to load app config: %w", err)
     }
 
     awsCfg, err := config.LoadDefaultConfig(context.Background(),
         config.WithRegion(cfg.AWSRegion),
     )
     if err != nil {
-        return nil, fmt.Errorf("unable to load SDK config: %v", err)
+        return nil, fmt.Errorf("unable to load SDK config: %w", err)
     }
 
     client := s3.NewFromConfig(awsCfg)
@@ -39,30 +43,114 @@ func NewS3Handler() (*S3Handler, error) {
     }, nil
 }
 
-func (h *S3Handler) UploadFile(file *multipart.FileHeader, prefix string) (string, error) {
+func (h *S3Handler) UploadFile(ctx context.Context, file *multipart.FileHeader, prefix string) (string, error) {
+    ctx, span := tracing.Start(ctx, "cloud.S3Handler.UploadFile")
+    defer span.End()
+
     src, err := file.Open()
     if err != nil {
-        return "", fmt.Errorf("error opening file: %v", err)
+        return "", fmt.Errorf("error opening file: %w", err)
     }
     defer src.Close()
 
     filename := generateFilename(prefix, file.Filename)
 
     contentType := file.Header.Get("Content-Type")
-    _, err = h.client.PutObject(context.Background(), &s3.PutObjectInput{
+    _, err = h.client.PutObject(ctx, &s3.PutObjectInput{
         Bucket:      &h.bucket,
         Key:         &filename,
         Body:        src,
         ContentType: &contentType,
     })
 
+    folder, _, _ := strings.Cut(prefix, "/")
+    metrics.S3Uploads.WithLabelValues(folder, metrics.Result(err)).Inc()
+
     if err != nil {
-        return "", fmt.Errorf("error uploading to S3: %v", err)
+        return "", fmt.Errorf("error uploading to S3: %w", err)
     }
 
     return fmt.Sprintf("https://%s.s3.%s.amazonaws.com/%s", h.bucket, h.region, filename), nil
 }
 
+// KeyFromURL returns the object key for a URL returned by UploadFile. URLs
+// that point anywhere other than this bucket are reported as not ours.
+func (h *S3Handler) KeyFromURL(url string) (string, bool) {
+    prefix := fmt.Sprintf("https://%s.s3.%s.amazonaws.com/", h.bucket, h.region)
+    if !strings.HasPrefix(url, prefix) || len(url) == len(prefix) {
+        return "", false
+    }
+    return strings.TrimPrefix(url, prefix), true
+}
+
+// PutObject stores body under key. Unlike UploadFile the key is used as given.
+func (h *S3Handler) PutObject(ctx context.Context, key string, contentType string, body io.Reader) error {
+    ctx, span := tracing.Start(ctx, "cloud.S3Handler.PutObject")
+    defer span.End()
+
+    _, err := h.client.PutObject(ctx, &s3.PutObjectInput{
+        Bucket:      &h.bucket,
+        Key:         &key,
+        Body:        body,
+        ContentType: &contentType,
+    })
+
+    folder, _, _ := strings.Cut(key, "/")
+    metrics.S3Uploads.WithLabelValues(folder, metrics.Result(err)).Inc()
+
+    if err != nil {
+        return fmt.Errorf("error uploading to S3: %w", err)
+    }
+    return nil
+}
+
+// GetObject opens the object stored under key. The caller closes the body.
+func (h *S3Handler) GetObject(ctx context.Context, key string) (io.ReadCloser, error) {
+    ctx, span := tracing.Start(ctx, "cloud.S3Handler.GetObject")
+    defer span.End()
+
+    output, err := h.client.GetObject(ctx, &s3.GetObjectInput{
+        Bucket: &h.bucket,
+        Key:    &key,
+    })
+    if err != nil {
+        return nil, fmt.Errorf("error downloading from S3: %w", err)
+    }
+    return output.Body, nil
+}
+
+func (h *S3Handler) DeleteObject(ctx context.Context, key string) error {
+    ctx, span := tracing.Start(ctx, "cloud.S3Handler.DeleteObject")
+    defer span.End()
+
+    _, err := h.client.DeleteObject(ctx, &s3.DeleteObjectInput{
+        Bucket: &h.bucket,
+        Key:    &key,
+    })
+    if err != nil {
+        return fmt.Errorf("error deleting from S3: %w", err)
+    }
+    return nil
+}
+
+// PresignGet returns a URL that downloads key without credentials until ttl
+// has passed. The browser saves the file as filename.
+func (h *S3Handler) PresignGet(ctx context.Context, key string, filename string, ttl time.Duration) (string, error) {
+    ctx, span := tracing.Start(ctx, "cloud.S3Handler.PresignGet")
+    defer span.End()
+
+    disposition := fmt.Sprintf(`attachment; filename="%s"`, filename)
+    request, err := s3.NewPresignClient(h.client).PresignGetObject(ctx, &s3.GetObjectInput{
+        Bucket:                     &h.bucket,
+        Key:                        &key,
+        ResponseContentDisposition: &disposition,
+    }, s3.WithPresignExpires(ttl))
+    if err != nil {
+        return "", fmt.Errorf("error signing S3 URL: %w", err)
+    }
+    return request.URL, nil
+}
+
 func generateFilename(prefix, originalName string) string {
     ext := filepath.Ext(originalName)
     timestamp := time.Now().UnixNano()
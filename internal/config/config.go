@@ -3,6 +3,7 @@ package config
 import (
 	"fmt"
 	"os"
+	"strings"
 
 	"github.com/joho/godotenv"
 )
@@ -16,12 +17,18 @@ type Config struct {
     S3Bucket           string
     SenderEmail        string
     AppBaseURL         string
+    // TrustedProxies are the IPs or CIDR ranges of reverse proxies allowed to
+    // report the client address in X-Forwarded-For.
+    TrustedProxies     []string
+    // MetricsAddr is the internal address Prometheus metrics are served on,
+    // kept off the public API listener.
+    MetricsAddr        string
 }
 
 func LoadConfig() (*Config, error) {
     err := godotenv.Load()
     if err != nil && !os.IsNotExist(err) {
-        return nil, fmt.Errorf("error loading .env file: %v", err)
+        return nil, fmt.Errorf("error loading .env file: %w", err)
     }
 
     jwtSecret := os.Getenv("JWT_SECRET")
@@ -56,6 +63,17 @@ func LoadConfig() (*Config, error) {
         appBaseURL = "http://localhost:3000"
     }
 
+    var trustedProxies []string
+    for _, proxy := range strings.Split(os.Getenv("TRUSTED_PROXIES"), ",") {
+        if proxy = strings.TrimSpace(proxy); proxy != "" {
+            trustedProxies = append(trustedProxies, proxy)
+        }
+    }
+
+    metricsAddr := os.Getenv("METRICS_ADDR")
+    if metricsAddr == "" {
+        metricsAddr = "127.0.0.1:9090"
+    }
 
     return &Config{
         JWTSecret:          jwtSecret,
@@ -65,5 +83,7 @@ func LoadConfig() (*Config, error) {
         S3Bucket:           s3Bucket,
         SenderEmail:        senderEmail,
         AppBaseURL:         appBaseURL,
+        TrustedProxies:     trustedProxies,
+        MetricsAddr:        metricsAddr,
     }, nil
 }
\ No newline at end of file
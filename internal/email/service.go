@@ -3,12 +3,16 @@ package email
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/service/ses"
 	"github.com/aws/aws-sdk-go-v2/service/ses/types"
 	appconfig "github.com/chrisabs/cadence/internal/config"
+	"github.com/chrisabs/cadence/internal/platform/logging"
+	"github.com/chrisabs/cadence/internal/platform/metrics"
+	"github.com/chrisabs/cadence/internal/platform/tracing"
 )
 
 type Service struct {
@@ -21,14 +25,14 @@ type Service struct {
 func NewService() (*Service, error) {
 	cfg, err := appconfig.LoadConfig()
 	if err != nil {
-		return nil, fmt.Errorf("unable to load app config: %v", err)
+		return nil, fmt.Errorf("unable to load app config: %w", err)
 	}
 
 	awsCfg, err := config.LoadDefaultConfig(context.Background(),
 		config.WithRegion(cfg.AWSRegion),
 	)
 	if err != nil {
-		return nil, fmt.Errorf("unable to load AWS SDK config: %v", err)
+		return nil, fmt.Errorf("unable to load AWS SDK config: %w", err)
 	}
 
 	client := ses.NewFromConfig(awsCfg)
@@ -55,10 +59,14 @@ func NewService() (*Service, error) {
 	}, nil
 }
 
-func (s *Service) SendInviteEmail(recipientEmail, inviteToken string, familyName string) error {
+func (s *Service) SendInviteEmail(ctx context.Context, recipientEmail, inviteToken string, familyName string, expiresAt time.Time) error {
+	ctx, span := tracing.Start(ctx, "email.Service.SendInviteEmail")
+	defer span.End()
+
 	subject := fmt.Sprintf("You've been invited to join %s on Cadence", familyName)
 	
 	inviteURL := fmt.Sprintf("%s/invite?token=%s", s.appBaseURL, inviteToken)
+	expiryDate := expiresAt.UTC().Format("2 January 2006")
 	
 	if s.environment != "production" {
 		subject = fmt.Sprintf("[%s] %s", s.environment, subject)
@@ -101,7 +109,7 @@ func (s *Service) SendInviteEmail(recipientEmail, inviteToken string, familyName
                     </div>
                     
                     <p>If you don't have an account yet, you'll be able to create one.</p>
-                    <p>This invitation will expire in 7 days.</p>
+                    <p>This invitation will expire on %s.</p>
                 </td>
             </tr>
         </table>
@@ -111,7 +119,8 @@ func (s *Service) SendInviteEmail(recipientEmail, inviteToken string, familyName
     s.getEnvironmentNotice(),
     inviteURL,
     inviteURL, inviteURL,
-    inviteToken)
+    inviteToken,
+    expiryDate)
 	
 	textBody := fmt.Sprintf(`
 		You've been invited to join %s on Cadence
@@ -127,13 +136,42 @@ func (s *Service) SendInviteEmail(recipientEmail, inviteToken string, familyName
 		
 		If you don't have an account yet, you'll be able to create one.
 		
-		This invitation will expire in 7 days.
+		This invitation will expire on %s.
 	`, 
 	familyName,
 	s.getEnvironmentNoticeText(),
 	inviteURL,
-	inviteToken)
+	inviteToken,
+	expiryDate)
+
+	return s.send(ctx, "invite", recipientEmail, subject, htmlBody, textBody)
+}
+
+func (s *Service) getEnvironmentNotice() string {
+	if s.environment == "production" {
+		return ""
+	}
+	
+	return fmt.Sprintf(`
+		<div class="env-notice">
+			<strong>%s Environment</strong>
+			<p>This is a %s environment email. In case the links don't work, you can use the invitation token shown below.</p>
+		</div>
+	`, s.environment, s.environment)
+}
 
+func (s *Service) getEnvironmentNoticeText() string {
+	if s.environment == "production" {
+		return ""
+	}
+	
+	return fmt.Sprintf("[%s ENVIRONMENT] This is a %s environment email.", 
+		s.environment, s.environment)
+}
+
+// send delivers a rendered email through SES. emailType labels the log lines
+// and the emails sent metric.
+func (s *Service) send(ctx context.Context, emailType, recipientEmail, subject, htmlBody, textBody string) error {
 	input := &ses.SendEmailInput{
 		Destination: &types.Destination{
 			ToAddresses: []string{recipientEmail},
@@ -157,34 +195,228 @@ func (s *Service) SendInviteEmail(recipientEmail, inviteToken string, familyName
 		Source: aws.String(s.sender),
 	}
 
-	fmt.Printf("DEBUG: Final HTML email content: %s\n", htmlBody[:200])
+	logger := logging.FromContext(ctx).With("email_type", emailType, "environment", s.environment)
+	logger.Debug("sending email", "subject", subject)
 
-	_, err := s.client.SendEmail(context.Background(), input)
+	_, err := s.client.SendEmail(ctx, input)
+	metrics.EmailsSent.WithLabelValues(emailType, metrics.Result(err)).Inc()
 	if err != nil {
-		return fmt.Errorf("failed to send email: %v", err)
+		logger.Error("failed to send email", "error", err)
+		return fmt.Errorf("failed to send email: %w", err)
 	}
 
+	logger.Info("email sent")
+
 	return nil
 }
 
-func (s *Service) getEnvironmentNotice() string {
-	if s.environment == "production" {
-		return ""
+func (s *Service) SendVerificationEmail(ctx context.Context, recipientEmail, verifyToken string, familyName string) error {
+	ctx, span := tracing.Start(ctx, "email.Service.SendVerificationEmail")
+	defer span.End()
+
+	subject := "Verify your email for Cadence"
+	verifyURL := fmt.Sprintf("%s/verify-email?token=%s", s.appBaseURL, verifyToken)
+
+	if s.environment != "production" {
+		subject = fmt.Sprintf("[%s] %s", s.environment, subject)
 	}
-	
-	return fmt.Sprintf(`
-		<div class="env-notice">
-			<strong>%s Environment</strong>
-			<p>This is a %s environment email. In case the links don't work, you can use the invitation token shown below.</p>
-		</div>
-	`, s.environment, s.environment)
+
+	htmlBody := fmt.Sprintf(`
+    <html>
+    <head>
+        <title>Verify your email</title>
+    </head>
+    <body style="font-family: Arial, sans-serif; line-height: 1.6; color: #333;">
+        <table width="100%%" cellpadding="0" cellspacing="0" border="0">
+            <tr>
+                <td bgcolor="#4a86e8" style="padding: 20px; color: white;">
+                    <h1 style="margin: 0;">Welcome to Cadence</h1>
+                </td>
+            </tr>
+            <tr>
+                <td style="padding: 20px;">
+                    <h2>Confirm the email address for %s</h2>
+
+                    %s
+
+                    <p>Please confirm your email address to unlock every feature of your family account.</p>
+
+                    <table cellpadding="0" cellspacing="0" border="0">
+                        <tr>
+                            <td style="padding: 10px 0;">
+                                <a href="%s" style="background-color: #4a86e8; color: white; padding: 10px 20px; text-decoration: none; display: inline-block;">Verify Email</a>
+                            </td>
+                        </tr>
+                    </table>
+
+                    <p>If the button doesn't work, copy and paste this URL:</p>
+                    <p><a href="%s">%s</a></p>
+
+                    <p>This link will expire in 24 hours.</p>
+                </td>
+            </tr>
+        </table>
+    </body>
+    </html>`,
+	familyName,
+	s.getEnvironmentNotice(),
+	verifyURL,
+	verifyURL, verifyURL)
+
+	textBody := fmt.Sprintf(`
+		Confirm the email address for %s
+
+		%s
+
+		Please confirm your email address to unlock every feature of your family account:
+		%s
+
+		This link will expire in 24 hours.
+	`,
+	familyName,
+	s.getEnvironmentNoticeText(),
+	verifyURL)
+
+	return s.send(ctx, "verify_email", recipientEmail, subject, htmlBody, textBody)
 }
 
-func (s *Service) getEnvironmentNoticeText() string {
-	if s.environment == "production" {
-		return ""
+func (s *Service) SendPasswordResetEmail(ctx context.Context, recipientEmail, resetToken string) error {
+	ctx, span := tracing.Start(ctx, "email.Service.SendPasswordResetEmail")
+	defer span.End()
+
+	subject := "Reset your Cadence password"
+	resetURL := fmt.Sprintf("%s/reset-password?token=%s", s.appBaseURL, resetToken)
+
+	if s.environment != "production" {
+		subject = fmt.Sprintf("[%s] %s", s.environment, subject)
 	}
-	
-	return fmt.Sprintf("[%s ENVIRONMENT] This is a %s environment email.", 
-		s.environment, s.environment)
-}
\ No newline at end of file
+
+	htmlBody := fmt.Sprintf(`
+    <html>
+    <head>
+        <title>Reset your password</title>
+    </head>
+    <body style="font-family: Arial, sans-serif; line-height: 1.6; color: #333;">
+        <table width="100%%" cellpadding="0" cellspacing="0" border="0">
+            <tr>
+                <td bgcolor="#4a86e8" style="padding: 20px; color: white;">
+                    <h1 style="margin: 0;">Cadence Password Reset</h1>
+                </td>
+            </tr>
+            <tr>
+                <td style="padding: 20px;">
+                    <h2>Reset your password</h2>
+
+                    %s
+
+                    <p>We received a request to reset the password for your family account. Click the link below to choose a new one:</p>
+
+                    <table cellpadding="0" cellspacing="0" border="0">
+                        <tr>
+                            <td style="padding: 10px 0;">
+                                <a href="%s" style="background-color: #4a86e8; color: white; padding: 10px 20px; text-decoration: none; display: inline-block;">Reset Password</a>
+                            </td>
+                        </tr>
+                    </table>
+
+                    <p>If the button doesn't work, copy and paste this URL:</p>
+                    <p><a href="%s">%s</a></p>
+
+                    <p>This link will expire in 1 hour and can only be used once. If you didn't ask for a reset, you can ignore this email.</p>
+                </td>
+            </tr>
+        </table>
+    </body>
+    </html>`,
+	s.getEnvironmentNotice(),
+	resetURL,
+	resetURL, resetURL)
+
+	textBody := fmt.Sprintf(`
+		Reset your Cadence password
+
+		%s
+
+		We received a request to reset the password for your family account. Copy and paste this link into your browser to choose a new one:
+		%s
+
+		This link will expire in 1 hour and can only be used once. If you didn't ask for a reset, you can ignore this email.
+	`,
+	s.getEnvironmentNoticeText(),
+	resetURL)
+
+	return s.send(ctx, "password_reset", recipientEmail, subject, htmlBody, textBody)
+}
+
+func (s *Service) SendExportReadyEmail(ctx context.Context, recipientEmail, downloadToken string, familyName string, expiresAt time.Time) error {
+	ctx, span := tracing.Start(ctx, "email.Service.SendExportReadyEmail")
+	defer span.End()
+
+	subject := fmt.Sprintf("Your %s data export is ready", familyName)
+	downloadURL := fmt.Sprintf("%s/export/download?token=%s", s.appBaseURL, downloadToken)
+	expiryDate := expiresAt.UTC().Format("2 January 2006")
+
+	if s.environment != "production" {
+		subject = fmt.Sprintf("[%s] %s", s.environment, subject)
+	}
+
+	htmlBody := fmt.Sprintf(`
+    <html>
+    <head>
+        <title>Your data export is ready</title>
+    </head>
+    <body style="font-family: Arial, sans-serif; line-height: 1.6; color: #333;">
+        <table width="100%%" cellpadding="0" cellspacing="0" border="0">
+            <tr>
+                <td bgcolor="#4a86e8" style="padding: 20px; color: white;">
+                    <h1 style="margin: 0;">Cadence Data Export</h1>
+                </td>
+            </tr>
+            <tr>
+                <td style="padding: 20px;">
+                    <h2>The export of %s is ready</h2>
+
+                    %s
+
+                    <p>We've packaged everything your family has stored in Cadence into a zip archive. Click the link below to download it:</p>
+
+                    <table cellpadding="0" cellspacing="0" border="0">
+                        <tr>
+                            <td style="padding: 10px 0;">
+                                <a href="%s" style="background-color: #4a86e8; color: white; padding: 10px 20px; text-decoration: none; display: inline-block;">Download Export</a>
+                            </td>
+                        </tr>
+                    </table>
+
+                    <p>If the button doesn't work, copy and paste this URL:</p>
+                    <p><a href="%s">%s</a></p>
+
+                    <p>The archive will be deleted on %s. If you didn't ask for an export, please change your password.</p>
+                </td>
+            </tr>
+        </table>
+    </body>
+    </html>`,
+	familyName,
+	s.getEnvironmentNotice(),
+	downloadURL,
+	downloadURL, downloadURL,
+	expiryDate)
+
+	textBody := fmt.Sprintf(`
+		The export of %s is ready
+
+		%s
+
+		We've packaged everything your family has stored in Cadence into a zip archive. Copy and paste this link into your browser to download it:
+		%s
+
+		The archive will be deleted on %s. If you didn't ask for an export, please change your password.
+	`,
+	familyName,
+	s.getEnvironmentNoticeText(),
+	downloadURL,
+	expiryDate)
+
+	return s.send(ctx, "export_ready", recipientEmail, subject, htmlBody, textBody)
+}
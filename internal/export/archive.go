@@ -0,0 +1,142 @@
+package export
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path"
+	"time"
+
+	"github.com/chrisabs/cadence/internal/platform/logging"
+)
+
+// writeArchive writes the zip for export to w: one JSON array per section,
+// every uploaded image under images/, and manifest.json describing both.
+func (s *Service) writeArchive(ctx context.Context, export *Export, w io.Writer) error {
+	archive := zip.NewWriter(w)
+
+	m := manifest{
+		ExportID:    export.ID,
+		FamilyID:    export.FamilyID,
+		GeneratedAt: time.Now().UTC(),
+		Files:       make([]manifestFile, 0, len(sections)),
+		Images:      make([]manifestImage, 0),
+	}
+
+	for _, section := range sections {
+		records, ok, err := s.writeSection(ctx, archive, section, export.FamilyID)
+		if err != nil {
+			return err
+		}
+		if ok {
+			m.Files = append(m.Files, manifestFile{Path: section.path, Records: records})
+		}
+	}
+
+	urls, err := s.repo.GetImageURLs(ctx, export.FamilyID)
+	if err != nil {
+		return err
+	}
+
+	for _, url := range urls {
+		key, ok := s.store.KeyFromURL(url)
+		if !ok {
+			m.MissingImages = append(m.MissingImages, url)
+			continue
+		}
+
+		body, err := s.store.GetObject(ctx, key)
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			logging.FromContext(ctx).Warn("image missing from export", "export_id", export.ID, "key", key, "error", err)
+			m.MissingImages = append(m.MissingImages, url)
+			continue
+		}
+
+		entry := path.Join("images", key)
+		if err := copyImage(archive, entry, body); err != nil {
+			return err
+		}
+
+		m.Images = append(m.Images, manifestImage{URL: url, Path: entry})
+	}
+
+	file, err := archive.Create("manifest.json")
+	if err != nil {
+		return fmt.Errorf("error adding manifest: %w", err)
+	}
+
+	encoder := json.NewEncoder(file)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(m); err != nil {
+		return fmt.Errorf("error writing manifest: %w", err)
+	}
+
+	if err := archive.Close(); err != nil {
+		return fmt.Errorf("error finishing archive: %w", err)
+	}
+	return nil
+}
+
+// writeSection streams a section's rows into the archive as a JSON array,
+// one row per line. It reports false when the section's table does not exist
+// and so nothing was written.
+func (s *Service) writeSection(ctx context.Context, archive *zip.Writer, section section, familyID int) (int, bool, error) {
+	var file io.Writer
+	records := 0
+
+	ok, err := s.repo.EachRow(ctx, section, familyID, func(row []byte) error {
+		if file == nil {
+			created, err := archive.Create(section.path)
+			if err != nil {
+				return fmt.Errorf("error adding %s: %w", section.path, err)
+			}
+			file = created
+			if _, err := io.WriteString(file, "[\n"); err != nil {
+				return err
+			}
+		} else if _, err := io.WriteString(file, ",\n"); err != nil {
+			return err
+		}
+
+		records++
+		_, err := file.Write(row)
+		return err
+	})
+	if err != nil || !ok {
+		return 0, false, err
+	}
+
+	if file == nil {
+		created, err := archive.Create(section.path)
+		if err != nil {
+			return 0, false, fmt.Errorf("error adding %s: %w", section.path, err)
+		}
+		_, err = io.WriteString(created, "[]\n")
+		return 0, true, err
+	}
+
+	_, err = io.WriteString(file, "\n]\n")
+	return records, true, err
+}
+
+// copyImage adds an image to the archive and closes body. A failure part way
+// through leaves a truncated entry, so it fails the whole build.
+func copyImage(archive *zip.Writer, entry string, body io.ReadCloser) error {
+	defer body.Close()
+
+	// Images are already compressed, so store them as they are.
+	file, err := archive.CreateHeader(&zip.FileHeader{Name: entry, Method: zip.Store})
+	if err != nil {
+		return fmt.Errorf("error adding %s: %w", entry, err)
+	}
+
+	if _, err := io.Copy(file, body); err != nil {
+		return fmt.Errorf("error copying %s: %w", entry, err)
+	}
+	return nil
+}
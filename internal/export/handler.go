@@ -0,0 +1,99 @@
+package export
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/chrisabs/cadence/internal/apperror"
+	"github.com/chrisabs/cadence/internal/middleware"
+	"github.com/chrisabs/cadence/internal/models"
+	"github.com/chrisabs/cadence/internal/platform/respond"
+	"github.com/gorilla/mux"
+)
+
+type Handler struct {
+	service        *Service
+	authMiddleware *middleware.AuthMiddleware
+}
+
+func NewHandler(service *Service, authMiddleware *middleware.AuthMiddleware) *Handler {
+	return &Handler{
+		service:        service,
+		authMiddleware: authMiddleware,
+	}
+}
+
+func (h *Handler) RegisterRoutes(router *mux.Router) {
+	router.HandleFunc("/family/exports", h.authMiddleware.ProfileAuthHandler(h.authMiddleware.RequireRole(models.RoleParent)(h.handleGetExports))).Methods("GET")
+	router.HandleFunc("/family/exports", h.authMiddleware.ProfileAuthHandler(h.authMiddleware.RequireRole(models.RoleParent)(h.authMiddleware.RequireVerifiedEmail(h.handleRequestExport)))).Methods("POST")
+	router.HandleFunc("/family/exports/download", h.handleDownload).Methods("GET")
+	router.HandleFunc("/family/exports/{id}", h.authMiddleware.ProfileAuthHandler(h.authMiddleware.RequireRole(models.RoleParent)(h.handleGetExport))).Methods("GET")
+}
+
+// handleRequestExport queues an export and answers straight away; poll
+// GET /family/exports/{id} or wait for the email.
+func (h *Handler) handleRequestExport(w http.ResponseWriter, r *http.Request) {
+	profileCtx, ok := middleware.ProfileFrom(r.Context())
+	if !ok {
+		respond.Error(w, r, apperror.Unauthorized("profile authentication required"))
+		return
+	}
+
+	export, err := h.service.RequestExport(r.Context(), profileCtx.FamilyID, profileCtx.ProfileID)
+	if err != nil {
+		respond.Error(w, r, err)
+		return
+	}
+
+	respond.JSON(w, http.StatusAccepted, export)
+}
+
+func (h *Handler) handleGetExports(w http.ResponseWriter, r *http.Request) {
+	profileCtx, ok := middleware.ProfileFrom(r.Context())
+	if !ok {
+		respond.Error(w, r, apperror.Unauthorized("profile authentication required"))
+		return
+	}
+
+	exports, err := h.service.GetExports(r.Context(), profileCtx.FamilyID)
+	if err != nil {
+		respond.Error(w, r, err)
+		return
+	}
+
+	respond.JSON(w, http.StatusOK, exports)
+}
+
+func (h *Handler) handleGetExport(w http.ResponseWriter, r *http.Request) {
+	profileCtx, ok := middleware.ProfileFrom(r.Context())
+	if !ok {
+		respond.Error(w, r, apperror.Unauthorized("profile authentication required"))
+		return
+	}
+
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		respond.Error(w, r, apperror.BadRequest("invalid export ID"))
+		return
+	}
+
+	export, err := h.service.GetExport(r.Context(), id, profileCtx.FamilyID)
+	if err != nil {
+		respond.Error(w, r, err)
+		return
+	}
+
+	respond.JSON(w, http.StatusOK, export)
+}
+
+// handleDownload is the target of the link in the ready email, so it needs
+// no session: the token alone proves access.
+func (h *Handler) handleDownload(w http.ResponseWriter, r *http.Request) {
+	download, err := h.service.Download(r.Context(), r.URL.Query().Get("token"))
+	if err != nil {
+		respond.Error(w, r, err)
+		return
+	}
+
+	respond.JSON(w, http.StatusOK, download)
+}
@@ -0,0 +1,64 @@
+package export
+
+import "time"
+
+type Status string
+
+const (
+	StatusPending Status = "PENDING"
+	StatusRunning Status = "RUNNING"
+	StatusReady   Status = "READY"
+	StatusFailed  Status = "FAILED"
+	StatusExpired Status = "EXPIRED"
+)
+
+// Export is a request for a zip archive of everything a family has stored.
+// The archive is built in the background, kept until ExpiresAt and then
+// deleted. The token in the ready email is never stored, only its hash.
+type Export struct {
+	ID          int        `json:"id"`
+	FamilyID    int        `json:"familyId"`
+	RequestedBy *int       `json:"requestedBy,omitempty"`
+	Status      Status     `json:"status"`
+	Attempts    int        `json:"-"`
+	ObjectKey   string     `json:"-"`
+	SizeBytes   *int64     `json:"sizeBytes,omitempty"`
+	TokenHash   string     `json:"-"`
+	Error       string     `json:"error,omitempty"`
+	CreatedAt   time.Time  `json:"createdAt"`
+	StartedAt   *time.Time `json:"startedAt,omitempty"`
+	CompletedAt *time.Time `json:"completedAt,omitempty"`
+	ExpiresAt   *time.Time `json:"expiresAt,omitempty"`
+
+	// DownloadURL is a short-lived signed link, set only on ready exports.
+	DownloadURL string `json:"downloadUrl,omitempty"`
+}
+
+// Download is a signed link to a ready archive.
+type Download struct {
+	URL       string    `json:"url"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+// manifest is written to the root of every archive so the files can be read
+// without knowing the schema they came from.
+type manifest struct {
+	ExportID      int             `json:"exportId"`
+	FamilyID      int             `json:"familyId"`
+	GeneratedAt   time.Time       `json:"generatedAt"`
+	Files         []manifestFile  `json:"files"`
+	Images        []manifestImage `json:"images"`
+	MissingImages []string        `json:"missingImages,omitempty"`
+}
+
+type manifestFile struct {
+	Path    string `json:"path"`
+	Records int    `json:"records"`
+}
+
+// manifestImage maps an image URL found in the data to its copy in the
+// archive.
+type manifestImage struct {
+	URL  string `json:"url"`
+	Path string `json:"path"`
+}
@@ -0,0 +1,421 @@
+package export
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/chrisabs/cadence/internal/apperror"
+)
+
+// section is one JSON file in the archive. Each query returns one jsonb
+// column per row for the family in $1. Tables that do not exist yet, such as
+// those of modules still being built, are skipped rather than failing the
+// export.
+type section struct {
+	path  string
+	table string
+	query string
+}
+
+var sections = []section{
+	{"family.json", "family_account", `
+		SELECT to_jsonb(f) - 'password' FROM family_account f WHERE f.id = $1`},
+	{"family_settings.json", "family_settings", `
+		SELECT to_jsonb(s) FROM family_settings s WHERE s.family_id = $1`},
+	{"profiles.json", "profile", `
+		SELECT to_jsonb(p) - 'password' - 'pin' - 'pin_failed_attempts' - 'pin_locked_until'
+		FROM profile p WHERE p.family_id = $1 ORDER BY p.id`},
+	{"calendar_events.json", "calendar_event", `
+		SELECT to_jsonb(e) FROM calendar_event e WHERE e.family_id = $1 ORDER BY e.id`},
+	{"notifications.json", "notification", `
+		SELECT to_jsonb(n) FROM notification n WHERE n.family_id = $1 ORDER BY n.id`},
+
+	{"storage/workspaces.json", "workspace", `
+		SELECT to_jsonb(w) FROM workspace w WHERE w.family_id = $1 ORDER BY w.id`},
+	{"storage/containers.json", "container", `
+		SELECT to_jsonb(c) FROM container c WHERE c.family_id = $1 ORDER BY c.id`},
+	{"storage/tags.json", "tag", `
+		SELECT to_jsonb(t) FROM tag t WHERE t.family_id = $1 ORDER BY t.id`},
+	{"storage/items.json", "item", `
+		SELECT to_jsonb(i) FROM item i WHERE i.family_id = $1 ORDER BY i.id`},
+	{"storage/item_tags.json", "item_tag", `
+		SELECT to_jsonb(it) FROM item_tag it
+		JOIN item i ON i.id = it.item_id
+		WHERE i.family_id = $1 ORDER BY it.item_id, it.tag_id`},
+	{"storage/item_images.json", "item_image", `
+		SELECT to_jsonb(img) FROM item_image img
+		JOIN item i ON i.id = img.item_id
+		WHERE i.family_id = $1 ORDER BY img.item_id, img.display_order, img.id`},
+	{"storage/item_history.json", "item_event", `
+		SELECT to_jsonb(e) FROM item_event e WHERE e.family_id = $1 ORDER BY e.id`},
+
+	{"chores/chores.json", "chore", `
+		SELECT to_jsonb(c) FROM chore c WHERE c.family_id = $1 ORDER BY c.id`},
+	{"chores/instances.json", "chore_instance", `
+		SELECT to_jsonb(ci) FROM chore_instance ci WHERE ci.family_id = $1 ORDER BY ci.id`},
+	{"chores/verifications.json", "daily_verification", `
+		SELECT to_jsonb(v) FROM daily_verification v WHERE v.family_id = $1 ORDER BY v.date, v.assignee_id`},
+
+	{"meals/recipes.json", "recipe", `
+		SELECT to_jsonb(r) FROM recipe r WHERE r.family_id = $1 ORDER BY r.id`},
+	{"meals/meal_plans.json", "meal_plan", `
+		SELECT to_jsonb(m) FROM meal_plan m WHERE m.family_id = $1 ORDER BY m.id`},
+	{"meals/meal_plan_assignees.json", "meal_plan_assignee", `
+		SELECT to_jsonb(a) FROM meal_plan_assignee a
+		JOIN meal_plan m ON m.id = a.meal_plan_id
+		WHERE m.family_id = $1 ORDER BY a.meal_plan_id, a.profile_id`},
+	{"meals/shopping_lists.json", "shopping_list", `
+		SELECT to_jsonb(l) FROM shopping_list l WHERE l.family_id = $1 ORDER BY l.id`},
+	{"meals/shopping_list_items.json", "shopping_list_item", `
+		SELECT to_jsonb(li) FROM shopping_list_item li
+		JOIN shopping_list l ON l.id = li.shopping_list_id
+		WHERE l.family_id = $1 ORDER BY li.id`},
+
+	{"services/services.json", "service", `
+		SELECT to_jsonb(s) FROM service s WHERE s.family_id = $1 ORDER BY s.id`},
+	{"services/payments.json", "service_payment", `
+		SELECT to_jsonb(p) FROM service_payment p
+		JOIN service s ON s.id = p.service_id
+		WHERE s.family_id = $1 ORDER BY p.id`},
+}
+
+// imageSources list the uploaded images referenced by a family's data.
+var imageSources = []section{
+	{"", "item_image", `
+		SELECT img.url FROM item_image img
+		JOIN item i ON i.id = img.item_id
+		WHERE i.family_id = $1`},
+	{"", "profile", `
+		SELECT p.image_url FROM profile p
+		WHERE p.family_id = $1 AND p.image_url IS NOT NULL AND p.image_url <> ''`},
+	{"", "recipe", `
+		SELECT r.image_url FROM recipe r
+		WHERE r.family_id = $1 AND r.image_url IS NOT NULL AND r.image_url <> ''`},
+}
+
+type Repository struct {
+	db *sql.DB
+}
+
+func NewRepository(db *sql.DB) *Repository {
+	return &Repository{db: db}
+}
+
+const exportColumns = `
+	id, family_id, requested_by, status, attempts, COALESCE(object_key, ''), size_bytes,
+	COALESCE(token_hash, ''), COALESCE(error, ''), created_at, started_at, completed_at, expires_at`
+
+func scanExport(row interface{ Scan(dest ...any) error }) (*Export, error) {
+	export := new(Export)
+	err := row.Scan(
+		&export.ID,
+		&export.FamilyID,
+		&export.RequestedBy,
+		&export.Status,
+		&export.Attempts,
+		&export.ObjectKey,
+		&export.SizeBytes,
+		&export.TokenHash,
+		&export.Error,
+		&export.CreatedAt,
+		&export.StartedAt,
+		&export.CompletedAt,
+		&export.ExpiresAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return export, nil
+}
+
+// Create queues an export. A family can only have one export waiting or
+// being built at a time.
+func (r *Repository) Create(ctx context.Context, familyID int, requestedBy int) (*Export, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("error starting transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `SELECT pg_advisory_xact_lock(hashtext('family_export'), $1)`, familyID); err != nil {
+		return nil, fmt.Errorf("error locking family exports: %w", err)
+	}
+
+	var active bool
+	err = tx.QueryRowContext(ctx, `
+		SELECT EXISTS(
+			SELECT 1 FROM family_export
+			WHERE family_id = $1 AND status IN ($2, $3)
+		)`, familyID, StatusPending, StatusRunning).Scan(&active)
+	if err != nil {
+		return nil, fmt.Errorf("error checking active exports: %w", err)
+	}
+	if active {
+		return nil, apperror.Conflict("an export is already in progress")
+	}
+
+	export, err := scanExport(tx.QueryRowContext(ctx, `
+		INSERT INTO family_export (family_id, requested_by, status, created_at)
+		VALUES ($1, $2, $3, $4)
+		RETURNING `+exportColumns,
+		familyID, requestedBy, StatusPending, time.Now().UTC()))
+	if err != nil {
+		return nil, fmt.Errorf("error creating export: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("error committing export: %w", err)
+	}
+
+	return export, nil
+}
+
+func (r *Repository) GetByID(ctx context.Context, id int, familyID int) (*Export, error) {
+	export, err := scanExport(r.db.QueryRowContext(ctx, `
+		SELECT `+exportColumns+`
+		FROM family_export
+		WHERE id = $1 AND family_id = $2`, id, familyID))
+	if err == sql.ErrNoRows {
+		return nil, apperror.NotFound("export not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error getting export: %w", err)
+	}
+	return export, nil
+}
+
+func (r *Repository) GetByTokenHash(ctx context.Context, tokenHash string) (*Export, error) {
+	export, err := scanExport(r.db.QueryRowContext(ctx, `
+		SELECT `+exportColumns+`
+		FROM family_export
+		WHERE token_hash = $1`, tokenHash))
+	if err == sql.ErrNoRows {
+		return nil, apperror.NotFound("export not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error getting export: %w", err)
+	}
+	return export, nil
+}
+
+func (r *Repository) GetByFamilyID(ctx context.Context, familyID int, limit int) ([]*Export, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT `+exportColumns+`
+		FROM family_export
+		WHERE family_id = $1
+		ORDER BY created_at DESC, id DESC
+		LIMIT $2`, familyID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("error getting exports: %w", err)
+	}
+	defer rows.Close()
+
+	exports := make([]*Export, 0)
+	for rows.Next() {
+		export, err := scanExport(rows)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning export: %w", err)
+		}
+		exports = append(exports, export)
+	}
+
+	return exports, rows.Err()
+}
+
+// Claim marks the oldest pending export as running and returns it, or nil
+// when there is nothing to do. SKIP LOCKED lets several API instances share
+// the queue.
+func (r *Repository) Claim(ctx context.Context) (*Export, error) {
+	export, err := scanExport(r.db.QueryRowContext(ctx, `
+		UPDATE family_export
+		SET status = $1, attempts = attempts + 1, started_at = $2
+		WHERE id = (
+			SELECT id FROM family_export
+			WHERE status = $3
+			ORDER BY created_at, id
+			LIMIT 1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING `+exportColumns,
+		StatusRunning, time.Now().UTC(), StatusPending))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error claiming export: %w", err)
+	}
+	return export, nil
+}
+
+// RequeueStale puts back exports whose worker stopped before finishing, for
+// example because the server restarted, and fails those out of attempts.
+func (r *Repository) RequeueStale(ctx context.Context, startedBefore time.Time, maxAttempts int) error {
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE family_export
+		SET status = CASE WHEN attempts >= $3 THEN $4 ELSE $5 END,
+		    error = CASE WHEN attempts >= $3 THEN 'export timed out' ELSE error END,
+		    completed_at = CASE WHEN attempts >= $3 THEN $6 ELSE NULL END
+		WHERE status = $1 AND started_at < $2`,
+		StatusRunning, startedBefore, maxAttempts, StatusFailed, StatusPending, time.Now().UTC())
+	if err != nil {
+		return fmt.Errorf("error requeueing stale exports: %w", err)
+	}
+	return nil
+}
+
+func (r *Repository) MarkReady(ctx context.Context, export *Export) error {
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE family_export
+		SET status = $2, object_key = $3, size_bytes = $4, token_hash = $5,
+		    error = NULL, completed_at = $6, expires_at = $7
+		WHERE id = $1`,
+		export.ID, StatusReady, export.ObjectKey, export.SizeBytes, export.TokenHash,
+		export.CompletedAt, export.ExpiresAt)
+	if err != nil {
+		return fmt.Errorf("error marking export ready: %w", err)
+	}
+	return nil
+}
+
+// MarkFailed records why a build failed. With retry set the export goes back
+// on the queue, otherwise it is finished.
+func (r *Repository) MarkFailed(ctx context.Context, id int, message string, retry bool) error {
+	status := StatusFailed
+	var completedAt *time.Time
+	if retry {
+		status = StatusPending
+	} else {
+		now := time.Now().UTC()
+		completedAt = &now
+	}
+
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE family_export
+		SET status = $2, error = $3, completed_at = $4
+		WHERE id = $1`,
+		id, status, message, completedAt)
+	if err != nil {
+		return fmt.Errorf("error marking export failed: %w", err)
+	}
+	return nil
+}
+
+// GetExpired returns ready exports whose archive should now be deleted.
+func (r *Repository) GetExpired(ctx context.Context, now time.Time) ([]*Export, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT `+exportColumns+`
+		FROM family_export
+		WHERE status = $1 AND expires_at <= $2
+		ORDER BY id`, StatusReady, now)
+	if err != nil {
+		return nil, fmt.Errorf("error getting expired exports: %w", err)
+	}
+	defer rows.Close()
+
+	var exports []*Export
+	for rows.Next() {
+		export, err := scanExport(rows)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning export: %w", err)
+		}
+		exports = append(exports, export)
+	}
+
+	return exports, rows.Err()
+}
+
+// MarkExpired forgets the archive and its download token once the object
+// has been deleted.
+func (r *Repository) MarkExpired(ctx context.Context, id int) error {
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE family_export
+		SET status = $2, object_key = NULL, token_hash = NULL
+		WHERE id = $1`, id, StatusExpired)
+	if err != nil {
+		return fmt.Errorf("error marking export expired: %w", err)
+	}
+	return nil
+}
+
+// GetRecipient returns the family name and the address the ready email goes
+// to: the requester's own email if they have one, otherwise the account's.
+func (r *Repository) GetRecipient(ctx context.Context, familyID int, profileID *int) (string, string, error) {
+	var familyName, email string
+	err := r.db.QueryRowContext(ctx, `
+		SELECT f.family_name, COALESCE(NULLIF(p.email, ''), f.email)
+		FROM family_account f
+		LEFT JOIN profile p ON p.id = $2 AND p.family_id = f.id AND p.is_deleted = false
+		WHERE f.id = $1`, familyID, profileID).Scan(&familyName, &email)
+	if err == sql.ErrNoRows {
+		return "", "", apperror.NotFound("family account not found")
+	}
+	if err != nil {
+		return "", "", fmt.Errorf("error getting export recipient: %w", err)
+	}
+	return familyName, email, nil
+}
+
+func (r *Repository) tableExists(ctx context.Context, table string) (bool, error) {
+	var exists bool
+	err := r.db.QueryRowContext(ctx, `
+		SELECT EXISTS(
+			SELECT 1 FROM information_schema.tables
+			WHERE table_schema = 'public' AND table_name = $1
+		)`, table).Scan(&exists)
+	if err != nil {
+		return false, fmt.Errorf("error checking table %s: %w", table, err)
+	}
+	return exists, nil
+}
+
+// EachRow calls fn with the JSON of every row in section. It reports false,
+// without calling fn, when the section's table does not exist.
+func (r *Repository) EachRow(ctx context.Context, section section, familyID int, fn func(row []byte) error) (bool, error) {
+	exists, err := r.tableExists(ctx, section.table)
+	if err != nil || !exists {
+		return false, err
+	}
+
+	rows, err := r.db.QueryContext(ctx, section.query, familyID)
+	if err != nil {
+		return false, fmt.Errorf("error querying %s: %w", section.table, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var row []byte
+		if err := rows.Scan(&row); err != nil {
+			return false, fmt.Errorf("error scanning %s: %w", section.table, err)
+		}
+		if err := fn(row); err != nil {
+			return false, err
+		}
+	}
+
+	return true, rows.Err()
+}
+
+// GetImageURLs returns every distinct uploaded image URL in the family's
+// data, including those of soft-deleted rows.
+func (r *Repository) GetImageURLs(ctx context.Context, familyID int) ([]string, error) {
+	seen := make(map[string]bool)
+	var urls []string
+
+	for _, source := range imageSources {
+		_, err := r.EachRow(ctx, source, familyID, func(row []byte) error {
+			url := string(row)
+			if !seen[url] {
+				seen[url] = true
+				urls = append(urls, url)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return urls, nil
+}
@@ -0,0 +1,303 @@
+package export
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/chrisabs/cadence/internal/apperror"
+	"github.com/chrisabs/cadence/internal/platform/logging"
+	"github.com/chrisabs/cadence/internal/platform/securetoken"
+	"github.com/chrisabs/cadence/internal/platform/tracing"
+)
+
+const (
+	// archiveTTL is how long a finished archive can be downloaded.
+	archiveTTL = 7 * 24 * time.Hour
+	// downloadURLTTL is how long each signed S3 link works.
+	downloadURLTTL = 15 * time.Minute
+	// pollInterval is how often the worker looks for queued exports when it
+	// has not been woken by a new request.
+	pollInterval = time.Minute
+	// staleAfter is how long a build may run before it is assumed lost.
+	staleAfter  = time.Hour
+	maxAttempts = 3
+	listLimit   = 20
+)
+
+// ObjectStore holds the archives and the images copied into them.
+type ObjectStore interface {
+	PutObject(ctx context.Context, key string, contentType string, body io.Reader) error
+	GetObject(ctx context.Context, key string) (io.ReadCloser, error)
+	DeleteObject(ctx context.Context, key string) error
+	PresignGet(ctx context.Context, key string, filename string, ttl time.Duration) (string, error)
+	KeyFromURL(url string) (string, bool)
+}
+
+// EmailService tells the requester their archive is ready. It is optional;
+// without it exports can still be downloaded from the app.
+type EmailService interface {
+	SendExportReadyEmail(ctx context.Context, recipientEmail, downloadToken string, familyName string, expiresAt time.Time) error
+}
+
+type Service struct {
+	repo         *Repository
+	store        ObjectStore
+	emailService EmailService
+	wake         chan struct{}
+}
+
+func NewService(repo *Repository) *Service {
+	return &Service{
+		repo: repo,
+		wake: make(chan struct{}, 1),
+	}
+}
+
+func (s *Service) SetObjectStore(store ObjectStore) {
+	s.store = store
+}
+
+func (s *Service) SetEmailService(emailService EmailService) {
+	s.emailService = emailService
+}
+
+// RequestExport queues an export of the family's data and wakes the worker.
+func (s *Service) RequestExport(ctx context.Context, familyID int, profileID int) (*Export, error) {
+	ctx, span := tracing.Start(ctx, "export.Service.RequestExport")
+	defer span.End()
+
+	if s.store == nil {
+		return nil, fmt.Errorf("file storage not configured")
+	}
+
+	export, err := s.repo.Create(ctx, familyID, profileID)
+	if err != nil {
+		return nil, err
+	}
+
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+
+	return export, nil
+}
+
+func (s *Service) GetExports(ctx context.Context, familyID int) ([]*Export, error) {
+	ctx, span := tracing.Start(ctx, "export.Service.GetExports")
+	defer span.End()
+
+	return s.repo.GetByFamilyID(ctx, familyID, listLimit)
+}
+
+// GetExport returns an export with a fresh signed download link if it is
+// ready.
+func (s *Service) GetExport(ctx context.Context, id int, familyID int) (*Export, error) {
+	ctx, span := tracing.Start(ctx, "export.Service.GetExport")
+	defer span.End()
+
+	export, err := s.repo.GetByID(ctx, id, familyID)
+	if err != nil {
+		return nil, err
+	}
+
+	if isDownloadable(export, time.Now().UTC()) && s.store != nil {
+		url, err := s.store.PresignGet(ctx, export.ObjectKey, archiveFilename(export), downloadURLTTL)
+		if err != nil {
+			return nil, err
+		}
+		export.DownloadURL = url
+	}
+
+	return export, nil
+}
+
+// Download exchanges the token from the ready email for a signed link.
+func (s *Service) Download(ctx context.Context, token string) (*Download, error) {
+	ctx, span := tracing.Start(ctx, "export.Service.Download")
+	defer span.End()
+
+	if token == "" {
+		return nil, apperror.BadRequest("token is required")
+	}
+	if s.store == nil {
+		return nil, fmt.Errorf("file storage not configured")
+	}
+
+	export, err := s.repo.GetByTokenHash(ctx, securetoken.Hash(token))
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	if !isDownloadable(export, now) {
+		return nil, apperror.NotFound("export not found")
+	}
+
+	url, err := s.store.PresignGet(ctx, export.ObjectKey, archiveFilename(export), downloadURLTTL)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Download{URL: url, ExpiresAt: now.Add(downloadURLTTL)}, nil
+}
+
+// Run builds queued exports and deletes expired archives until ctx is
+// cancelled.
+func (s *Service) Run(ctx context.Context) {
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
+	for {
+		s.processQueue(ctx)
+		s.expireArchives(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-s.wake:
+		}
+	}
+}
+
+func (s *Service) processQueue(ctx context.Context) {
+	logger := logging.FromContext(ctx)
+
+	if err := s.repo.RequeueStale(ctx, time.Now().UTC().Add(-staleAfter), maxAttempts); err != nil {
+		logger.Error("failed to requeue stale exports", "error", err)
+	}
+
+	for ctx.Err() == nil {
+		export, err := s.repo.Claim(ctx)
+		if err != nil {
+			logger.Error("failed to claim export", "error", err)
+			return
+		}
+		if export == nil {
+			return
+		}
+
+		s.process(ctx, export)
+	}
+}
+
+func (s *Service) process(ctx context.Context, export *Export) {
+	ctx, span := tracing.Start(ctx, "export.Service.process")
+	defer span.End()
+
+	logger := logging.FromContext(ctx).With("export_id", export.ID, "family_id", export.FamilyID)
+
+	token, err := s.build(ctx, export)
+	if err != nil {
+		retry := export.Attempts < maxAttempts
+		logger.Error("failed to build export", "attempt", export.Attempts, "retry", retry, "error", err)
+		if err := s.repo.MarkFailed(ctx, export.ID, "export could not be built", retry); err != nil {
+			logger.Error("failed to record export failure", "error", err)
+		}
+		return
+	}
+
+	logger.Info("export ready", "size_bytes", *export.SizeBytes)
+
+	if s.emailService == nil {
+		return
+	}
+
+	familyName, recipient, err := s.repo.GetRecipient(ctx, export.FamilyID, export.RequestedBy)
+	if err != nil {
+		logger.Error("failed to find export recipient", "error", err)
+		return
+	}
+
+	if err := s.emailService.SendExportReadyEmail(ctx, recipient, token, familyName, *export.ExpiresAt); err != nil {
+		logger.Error("failed to send export ready email", "error", err)
+	}
+}
+
+// build writes the archive to a temporary file, uploads it and marks the
+// export ready. It returns the download token for the email.
+func (s *Service) build(ctx context.Context, export *Export) (string, error) {
+	file, err := os.CreateTemp("", "cadence-export-*.zip")
+	if err != nil {
+		return "", fmt.Errorf("error creating temporary file: %w", err)
+	}
+	defer os.Remove(file.Name())
+	defer file.Close()
+
+	if err := s.writeArchive(ctx, export, file); err != nil {
+		return "", err
+	}
+
+	size, err := file.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return "", fmt.Errorf("error sizing archive: %w", err)
+	}
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return "", fmt.Errorf("error rewinding archive: %w", err)
+	}
+
+	token, err := securetoken.Generate(32)
+	if err != nil {
+		return "", err
+	}
+
+	key := fmt.Sprintf("exports/%d/%d-%d.zip", export.FamilyID, export.ID, time.Now().UnixNano())
+	if err := s.store.PutObject(ctx, key, "application/zip", file); err != nil {
+		return "", err
+	}
+
+	now := time.Now().UTC()
+	expiresAt := now.Add(archiveTTL)
+	export.Status = StatusReady
+	export.ObjectKey = key
+	export.SizeBytes = &size
+	export.TokenHash = securetoken.Hash(token)
+	export.CompletedAt = &now
+	export.ExpiresAt = &expiresAt
+
+	if err := s.repo.MarkReady(ctx, export); err != nil {
+		if deleteErr := s.store.DeleteObject(ctx, key); deleteErr != nil {
+			logging.FromContext(ctx).Error("failed to delete orphaned archive", "key", key, "error", deleteErr)
+		}
+		return "", err
+	}
+
+	return token, nil
+}
+
+func (s *Service) expireArchives(ctx context.Context) {
+	logger := logging.FromContext(ctx)
+
+	exports, err := s.repo.GetExpired(ctx, time.Now().UTC())
+	if err != nil {
+		logger.Error("failed to get expired exports", "error", err)
+		return
+	}
+
+	for _, export := range exports {
+		if err := s.store.DeleteObject(ctx, export.ObjectKey); err != nil {
+			logger.Error("failed to delete expired archive", "export_id", export.ID, "error", err)
+			continue
+		}
+		if err := s.repo.MarkExpired(ctx, export.ID); err != nil {
+			logger.Error("failed to mark export expired", "export_id", export.ID, "error", err)
+		}
+	}
+}
+
+func isDownloadable(export *Export, now time.Time) bool {
+	return export.Status == StatusReady && export.ObjectKey != "" &&
+		export.ExpiresAt != nil && export.ExpiresAt.After(now)
+}
+
+func archiveFilename(export *Export) string {
+	created := export.CreatedAt
+	if export.CompletedAt != nil {
+		created = *export.CompletedAt
+	}
+	return fmt.Sprintf("cadence-export-%s.zip", created.UTC().Format("2006-01-02"))
+}
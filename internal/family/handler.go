@@ -5,8 +5,11 @@ import (
 	"net/http"
 	"strconv"
 
+	"github.com/chrisabs/cadence/internal/apperror"
+	"github.com/chrisabs/cadence/internal/auth"
 	"github.com/chrisabs/cadence/internal/middleware"
 	"github.com/chrisabs/cadence/internal/models"
+	"github.com/chrisabs/cadence/internal/platform/respond"
 	"github.com/gorilla/mux"
 )
 
@@ -25,89 +28,104 @@ func NewHandler(service *Service, authMiddleware *middleware.AuthMiddleware) *Ha
 func (h *Handler) RegisterRoutes(router *mux.Router) {
 	router.HandleFunc("/family/register", h.handleRegister).Methods("POST")
 	router.HandleFunc("/family/login", h.handleLogin).Methods("POST")
+	router.HandleFunc("/family/verify-email", h.handleVerifyEmail).Methods("POST")
+	router.HandleFunc("/family/verify-email/resend", h.authMiddleware.FamilyAuthHandler(h.handleResendVerificationEmail)).Methods("POST")
+	router.HandleFunc("/family/password-reset", h.handleRequestPasswordReset).Methods("POST")
+	router.HandleFunc("/family/password-reset/confirm", h.handleResetPassword).Methods("POST")
+	router.HandleFunc("/family/password", h.authMiddleware.ProfileAuthHandler(h.authMiddleware.RequireOwner(h.handleChangePassword))).Methods("PUT")
 
 	router.HandleFunc("/family", h.authMiddleware.FamilyAuthHandler(h.handleGetFamily)).Methods("GET")
-	router.HandleFunc("/family", h.authMiddleware.FamilyAuthHandler(h.handleUpdateFamily)).Methods("PUT")
+	router.HandleFunc("/family", h.authMiddleware.ProfileAuthHandler(h.authMiddleware.RequireRole(models.RoleParent)(h.handleUpdateFamily))).Methods("PUT")
 	
-	router.HandleFunc("/family/modules/{moduleId}", h.authMiddleware.ProfileAuthHandler(h.handleUpdateModule)).Methods("PUT")
+	router.HandleFunc("/family/modules/{moduleId}", h.authMiddleware.ProfileAuthHandler(h.authMiddleware.RequireRole(models.RoleParent)(h.authMiddleware.RequireVerifiedEmail(h.handleUpdateModule)))).Methods("PUT")
+	router.HandleFunc("/family/permissions", h.authMiddleware.ProfileAuthHandler(h.authMiddleware.RequireRole(models.RoleParent)(h.handleGetPermissions))).Methods("GET")
+	router.HandleFunc("/family/permissions", h.authMiddleware.ProfileAuthHandler(h.authMiddleware.RequireOwner(h.handleUpdatePermissions))).Methods("PUT")
+	router.HandleFunc("/family/permissions", h.authMiddleware.ProfileAuthHandler(h.authMiddleware.RequireOwner(h.handleResetPermissions))).Methods("DELETE")
     router.HandleFunc("/family/available-modules", h.authMiddleware.FamilyAuthHandler(h.handleGetAvailableModules)).Methods("GET")
-	router.HandleFunc("/family/delete", h.authMiddleware.ProfileAuthHandler(h.handleDeleteFamily)).Methods("DELETE")
-	router.HandleFunc("/family/restore", h.authMiddleware.ProfileAuthHandler(h.handleRestoreFamily)).Methods("PUT")
+	router.HandleFunc("/family/delete", h.authMiddleware.ProfileAuthHandler(h.authMiddleware.RequireOwner(h.handleDeleteFamily))).Methods("DELETE")
+	router.HandleFunc("/family/restore", h.authMiddleware.ProfileAuthHandler(h.authMiddleware.RequireOwner(h.handleRestoreFamily))).Methods("PUT")
 }
 
 func (h *Handler) handleRegister(w http.ResponseWriter, r *http.Request) {
 	var req RegisterRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		writeError(w, http.StatusBadRequest, "invalid request body")
+		respond.Error(w, r, apperror.BadRequest("invalid request body"))
 		return
 	}
 
-	response, err := h.service.Register(&req)
+	response, err := h.service.Register(r.Context(), &req, auth.DeviceFromRequest(r, req.DeviceName))
 	if err != nil {
-		writeError(w, http.StatusInternalServerError, err.Error())
+		respond.Error(w, r, err)
 		return
 	}
 
-	writeJSON(w, http.StatusCreated, response)
+	respond.JSON(w, http.StatusCreated, response)
 }
 
 func (h *Handler) handleLogin(w http.ResponseWriter, r *http.Request) {
 	var req LoginRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		writeError(w, http.StatusBadRequest, "invalid request body")
+		respond.Error(w, r, apperror.BadRequest("invalid request body"))
 		return
 	}
 
-	response, err := h.service.Login(&req)
+	response, err := h.service.Login(r.Context(), &req, auth.DeviceFromRequest(r, req.DeviceName))
 	if err != nil {
-		writeError(w, http.StatusUnauthorized, err.Error())
+		respond.Error(w, r, err)
 		return
 	}
 
-	writeJSON(w, http.StatusOK, response)
+	respond.JSON(w, http.StatusOK, response)
 }
 
 func (h *Handler) handleGetFamily(w http.ResponseWriter, r *http.Request) {
-    familyCtx := r.Context().Value("family").(*models.FamilyContext)
+    familyCtx, ok := middleware.FamilyFrom(r.Context())
+    if !ok {
+        respond.Error(w, r, apperror.Unauthorized("family authentication required"))
+        return
+    }
     
-    family, err := h.service.GetFamilyByID(familyCtx.FamilyID)
+    family, err := h.service.GetFamilyByID(r.Context(), familyCtx.FamilyID)
     if err != nil {
-        writeError(w, http.StatusNotFound, err.Error())
+        respond.Error(w, r, err)
         return
     }
     
-    writeJSON(w, http.StatusOK, family)
+    respond.JSON(w, http.StatusOK, family)
 }
 
 func (h *Handler) handleUpdateFamily(w http.ResponseWriter, r *http.Request) {
-	familyCtx := r.Context().Value("family").(*models.FamilyContext)
+	profileCtx, ok := middleware.ProfileFrom(r.Context())
+	if !ok {
+		respond.Error(w, r, apperror.Unauthorized("profile authentication required"))
+		return
+	}
 	
 	var req UpdateFamilyRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		writeError(w, http.StatusBadRequest, "invalid request body")
+		respond.Error(w, r, apperror.BadRequest("invalid request body"))
 		return
 	}
 	
-	family, err := h.service.UpdateFamily(familyCtx.FamilyID, &req)
+	family, err := h.service.UpdateFamily(r.Context(), profileCtx.FamilyID, &req)
 	if err != nil {
-		writeError(w, http.StatusInternalServerError, err.Error())
+		respond.Error(w, r, err)
 		return
 	}
 	
-	writeJSON(w, http.StatusOK, family)
+	respond.JSON(w, http.StatusOK, family)
 }
 
 func (h *Handler) handleGetAvailableModules(w http.ResponseWriter, r *http.Request) {
     availableModules := GetAvailableModules()
-    writeJSON(w, http.StatusOK, availableModules)
+    respond.JSON(w, http.StatusOK, availableModules)
 }
 
 
 func (h *Handler) handleUpdateModule(w http.ResponseWriter, r *http.Request) {
-    profileCtx := r.Context().Value("profile").(*models.ProfileContext)
-    
-    if !profileCtx.IsOwner && profileCtx.Role != models.RoleParent {
-        writeError(w, http.StatusForbidden, "only parents can update modules")
+    profileCtx, ok := middleware.ProfileFrom(r.Context())
+    if !ok {
+        respond.Error(w, r, apperror.Unauthorized("profile authentication required"))
         return
     }
     
@@ -116,73 +134,200 @@ func (h *Handler) handleUpdateModule(w http.ResponseWriter, r *http.Request) {
     
     moduleDefinition, exists := SystemModules[moduleID]
     if !exists {
-        writeError(w, http.StatusNotFound, "module not found")
+        respond.Error(w, r, apperror.NotFound("module not found"))
         return
     }
     
     if !moduleDefinition.IsAvailable {
-        writeError(w, http.StatusForbidden, "module not available")
+        respond.Error(w, r, apperror.Forbidden("module not available"))
         return
     }
 	
 	var req UpdateModuleRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		writeError(w, http.StatusBadRequest, "invalid request body")
+		respond.Error(w, r, apperror.BadRequest("invalid request body"))
 		return
 	}
 	req.ModuleID = moduleID
 	
-	if err := h.service.UpdateModule(profileCtx.FamilyID, &req); err != nil {
-		writeError(w, http.StatusInternalServerError, err.Error())
+	if err := h.service.UpdateModule(r.Context(), profileCtx.FamilyID, &req); err != nil {
+		respond.Error(w, r, err)
 		return
 	}
 	
-	writeJSON(w, http.StatusOK, map[string]string{"message": "module updated successfully"})
+	respond.JSON(w, http.StatusOK, map[string]string{"message": "module updated successfully"})
+}
+
+func (h *Handler) handleGetPermissions(w http.ResponseWriter, r *http.Request) {
+	profileCtx, ok := middleware.ProfileFrom(r.Context())
+	if !ok {
+		respond.Error(w, r, apperror.Unauthorized("profile authentication required"))
+		return
+	}
+
+	permissions, err := h.service.GetPermissions(r.Context(), profileCtx.FamilyID)
+	if err != nil {
+		respond.Error(w, r, err)
+		return
+	}
+
+	respond.JSON(w, http.StatusOK, permissions)
+}
+
+func (h *Handler) handleUpdatePermissions(w http.ResponseWriter, r *http.Request) {
+	profileCtx, ok := middleware.ProfileFrom(r.Context())
+	if !ok {
+		respond.Error(w, r, apperror.Unauthorized("profile authentication required"))
+		return
+	}
+
+	var req UpdatePermissionsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respond.Error(w, r, apperror.BadRequest("invalid request body"))
+		return
+	}
+
+	permissions, err := h.service.UpdatePermissions(r.Context(), profileCtx.FamilyID, &req)
+	if err != nil {
+		respond.Error(w, r, err)
+		return
+	}
+
+	respond.JSON(w, http.StatusOK, permissions)
+}
+
+func (h *Handler) handleResetPermissions(w http.ResponseWriter, r *http.Request) {
+	profileCtx, ok := middleware.ProfileFrom(r.Context())
+	if !ok {
+		respond.Error(w, r, apperror.Unauthorized("profile authentication required"))
+		return
+	}
+
+	permissions, err := h.service.ResetPermissions(r.Context(), profileCtx.FamilyID)
+	if err != nil {
+		respond.Error(w, r, err)
+		return
+	}
+
+	respond.JSON(w, http.StatusOK, permissions)
 }
 
 func (h *Handler) handleDeleteFamily(w http.ResponseWriter, r *http.Request) {
-	profileCtx := r.Context().Value("profile").(*models.ProfileContext)
-	
-	if !profileCtx.IsOwner {
-		writeError(w, http.StatusForbidden, "only the family owner can delete the family")
+	profileCtx, ok := middleware.ProfileFrom(r.Context())
+	if !ok {
+		respond.Error(w, r, apperror.Unauthorized("profile authentication required"))
 		return
 	}
 	
-	if err := h.service.DeleteFamily(profileCtx.FamilyID, profileCtx.ProfileID); err != nil {
-		writeError(w, http.StatusInternalServerError, err.Error())
+	if err := h.service.DeleteFamily(r.Context(), profileCtx.FamilyID, profileCtx.ProfileID); err != nil {
+		respond.Error(w, r, err)
 		return
 	}
 	
-	writeJSON(w, http.StatusOK, map[string]string{"message": "family deleted successfully"})
+	respond.JSON(w, http.StatusOK, map[string]string{"message": "family deleted successfully"})
 }
 
 func (h *Handler) handleRestoreFamily(w http.ResponseWriter, r *http.Request) {
-	profileCtx := r.Context().Value("profile").(*models.ProfileContext)
-	
-	if !profileCtx.IsOwner {
-		writeError(w, http.StatusForbidden, "only the family owner can restore the family")
+	profileCtx, ok := middleware.ProfileFrom(r.Context())
+	if !ok {
+		respond.Error(w, r, apperror.Unauthorized("profile authentication required"))
 		return
 	}
 	
-	if err := h.service.RestoreFamily(profileCtx.FamilyID); err != nil {
-		writeError(w, http.StatusInternalServerError, err.Error())
+	if err := h.service.RestoreFamily(r.Context(), profileCtx.FamilyID); err != nil {
+		respond.Error(w, r, err)
 		return
 	}
 	
-	writeJSON(w, http.StatusOK, map[string]string{"message": "family restored successfully"})
+	respond.JSON(w, http.StatusOK, map[string]string{"message": "family restored successfully"})
 }
 
 func getIDFromRequest(r *http.Request) (int, error) {
 	vars := mux.Vars(r)
-	return strconv.Atoi(vars["id"])
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		return 0, apperror.BadRequest("invalid id")
+	}
+	return id, nil
+}
+
+func (h *Handler) handleVerifyEmail(w http.ResponseWriter, r *http.Request) {
+	var req VerifyEmailRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respond.Error(w, r, apperror.BadRequest("invalid request body"))
+		return
+	}
+
+	if err := h.service.VerifyEmail(r.Context(), &req); err != nil {
+		respond.Error(w, r, err)
+		return
+	}
+
+	respond.JSON(w, http.StatusOK, map[string]bool{"emailVerified": true})
+}
+
+func (h *Handler) handleResendVerificationEmail(w http.ResponseWriter, r *http.Request) {
+	familyCtx, ok := middleware.FamilyFrom(r.Context())
+	if !ok {
+		respond.Error(w, r, apperror.Unauthorized("family authentication required"))
+		return
+	}
+
+	if err := h.service.ResendVerificationEmail(r.Context(), familyCtx.FamilyID); err != nil {
+		respond.Error(w, r, err)
+		return
+	}
+
+	respond.JSON(w, http.StatusOK, map[string]string{"message": "verification email sent"})
+}
+
+func (h *Handler) handleRequestPasswordReset(w http.ResponseWriter, r *http.Request) {
+	var req PasswordResetRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respond.Error(w, r, apperror.BadRequest("invalid request body"))
+		return
+	}
+
+	if err := h.service.RequestPasswordReset(r.Context(), &req); err != nil {
+		respond.Error(w, r, err)
+		return
+	}
+
+	respond.JSON(w, http.StatusOK, map[string]string{"message": "if the email is registered, a reset link has been sent"})
 }
 
-func writeJSON(w http.ResponseWriter, status int, v interface{}) {
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(status)
-	json.NewEncoder(w).Encode(v)
+func (h *Handler) handleResetPassword(w http.ResponseWriter, r *http.Request) {
+	var req ConfirmPasswordResetRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respond.Error(w, r, apperror.BadRequest("invalid request body"))
+		return
+	}
+
+	if err := h.service.ResetPassword(r.Context(), &req); err != nil {
+		respond.Error(w, r, err)
+		return
+	}
+
+	respond.JSON(w, http.StatusOK, map[string]string{"message": "password reset successfully"})
 }
 
-func writeError(w http.ResponseWriter, status int, message string) {
-	writeJSON(w, status, map[string]string{"error": message})
-}
\ No newline at end of file
+func (h *Handler) handleChangePassword(w http.ResponseWriter, r *http.Request) {
+	profileCtx, ok := middleware.ProfileFrom(r.Context())
+	if !ok {
+		respond.Error(w, r, apperror.Unauthorized("profile authentication required"))
+		return
+	}
+
+	var req ChangePasswordRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respond.Error(w, r, apperror.BadRequest("invalid request body"))
+		return
+	}
+
+	if err := h.service.ChangePassword(r.Context(), profileCtx.FamilyID, &req); err != nil {
+		respond.Error(w, r, err)
+		return
+	}
+
+	respond.JSON(w, http.StatusOK, map[string]string{"message": "password changed successfully"})
+}
@@ -3,6 +3,7 @@ package family
 import (
 	"time"
 
+	"github.com/chrisabs/cadence/internal/auth"
 	"github.com/chrisabs/cadence/internal/models"
 )
 
@@ -13,6 +14,7 @@ type FamilyAccount struct {
     FamilyName  string              `json:"familyName"`
     Modules     []models.Module     `json:"modules"`
     Status      models.FamilyStatus `json:"status"`
+    EmailVerified bool              `json:"emailVerified"`
     CreatedAt   time.Time           `json:"createdAt"`
     UpdatedAt   time.Time           `json:"updatedAt"`
 }
@@ -20,34 +22,79 @@ type FamilyAccount struct {
 type FamilySettings struct {
 	FamilyID  int                 `json:"familyId"`
 	Modules   []models.Module     `json:"modules"`
+	Permissions *Permissions      `json:"-"`
 	Status    models.FamilyStatus `json:"status"`
 	CreatedAt time.Time           `json:"createdAt"`
 	UpdatedAt time.Time           `json:"updatedAt"`
 }
 
 type RegisterRequest struct {
-	Email      string `json:"email"`
-	Password   string `json:"password"`
-	FamilyName string `json:"familyName"`
-	OwnerName  string `json:"ownerName"`
+	Email      string `json:"email" validate:"required,email,max=255"`
+	Password   string `json:"password" validate:"required,min=8,max=72"`
+	FamilyName string `json:"familyName" validate:"required,max=100"`
+	OwnerName  string `json:"ownerName" validate:"required,max=100"`
+	DeviceName string `json:"deviceName,omitempty" validate:"max=100"`
 }
 
 type LoginRequest struct {
-	Email    string `json:"email"`
-	Password string `json:"password"`
+	Email      string `json:"email" validate:"required"`
+	Password   string `json:"password" validate:"required"`
+	DeviceName string `json:"deviceName,omitempty" validate:"max=100"`
 }
 
 type FamilyAuthResponse struct {
-	Token    string        `json:"token"`
+	auth.TokenPair
 	Family   FamilyAccount `json:"family"`
 	Profiles []models.Profile `json:"profiles"`
 }
 
 type UpdateFamilyRequest struct {
-	FamilyName string `json:"familyName"`
+	FamilyName string `json:"familyName" validate:"required,max=100"`
 }
 
 type UpdateModuleRequest struct {
-	ModuleID  models.ModuleID `json:"moduleId"`
+	ModuleID  models.ModuleID `json:"moduleId" validate:"required,oneof=storage chores meals services"`
 	IsEnabled bool            `json:"isEnabled"`
-}
\ No newline at end of file
+}
+
+type UpdatePermissionsRequest struct {
+	Roles    PermissionMatrix                                `json:"roles" validate:"required"`
+	Profiles map[int]map[models.ModuleID][]models.Permission `json:"profiles"`
+}
+
+type AccountTokenPurpose string
+
+const (
+	TokenPurposeVerifyEmail   AccountTokenPurpose = "verify_email"
+	TokenPurposePasswordReset AccountTokenPurpose = "password_reset"
+)
+
+// AccountToken is a single-use token emailed to the family account owner.
+// Only the hash is stored.
+type AccountToken struct {
+	ID        int
+	FamilyID  int
+	Purpose   AccountTokenPurpose
+	TokenHash string
+	ExpiresAt time.Time
+	UsedAt    *time.Time
+	CreatedAt time.Time
+}
+
+type VerifyEmailRequest struct {
+	Token string `json:"token" validate:"required"`
+}
+
+type PasswordResetRequest struct {
+	Email string `json:"email" validate:"required,email"`
+}
+
+type ConfirmPasswordResetRequest struct {
+	Token       string `json:"token" validate:"required"`
+	NewPassword string `json:"newPassword" validate:"required,min=8,max=72"`
+}
+
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"currentPassword" validate:"required"`
+	NewPassword     string `json:"newPassword" validate:"required,min=8,max=72"`
+}
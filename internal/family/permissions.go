@@ -0,0 +1,133 @@
+package family
+
+import (
+	"sync"
+	"time"
+
+	"github.com/chrisabs/cadence/internal/models"
+)
+
+// permissionCacheTTL bounds how long another API instance can serve a matrix
+// that was changed elsewhere. Changes made through this instance invalidate
+// the entry immediately.
+const permissionCacheTTL = 5 * time.Minute
+
+// PermissionMatrix lists the permissions each role has on each module.
+type PermissionMatrix map[models.ModuleID]map[models.ProfileRole][]models.Permission
+
+// Permissions is a family's permission matrix. A profile override replaces the
+// role's permissions on a module for that one profile, e.g. to give a teenager
+// write access to storage.
+type Permissions struct {
+	Roles    PermissionMatrix                                `json:"roles"`
+	Profiles map[int]map[models.ModuleID][]models.Permission `json:"profiles"`
+}
+
+// DefaultPermissions returns the matrix every family starts with.
+func DefaultPermissions() *Permissions {
+	return &Permissions{
+		Roles: PermissionMatrix{
+			models.ModuleStorage: {
+				models.RoleParent: {models.PermissionRead, models.PermissionWrite, models.PermissionManage},
+				models.RoleChild:  {models.PermissionRead},
+			},
+			models.ModuleChores: {
+				models.RoleParent: {models.PermissionRead, models.PermissionWrite, models.PermissionManage},
+				models.RoleChild:  {models.PermissionRead, models.PermissionWrite},
+			},
+			models.ModuleMeals: {
+				models.RoleParent: {models.PermissionRead, models.PermissionWrite, models.PermissionManage},
+				models.RoleChild:  {models.PermissionRead},
+			},
+			models.ModuleServices: {
+				models.RoleParent: {models.PermissionRead, models.PermissionWrite, models.PermissionManage},
+				models.RoleChild:  {models.PermissionRead},
+			},
+		},
+		Profiles: map[int]map[models.ModuleID][]models.Permission{},
+	}
+}
+
+// withDefaults fills modules and roles missing from a stored matrix with the
+// defaults, so modules added later behave as before until configured.
+func (p *Permissions) withDefaults() *Permissions {
+	merged := DefaultPermissions()
+	if p == nil {
+		return merged
+	}
+
+	for moduleID, roles := range p.Roles {
+		if merged.Roles[moduleID] == nil {
+			merged.Roles[moduleID] = map[models.ProfileRole][]models.Permission{}
+		}
+		for role, permissions := range roles {
+			merged.Roles[moduleID][role] = permissions
+		}
+	}
+
+	for profileID, modules := range p.Profiles {
+		merged.Profiles[profileID] = modules
+	}
+
+	return merged
+}
+
+func (p *Permissions) Allows(profileID int, role models.ProfileRole, moduleID models.ModuleID, permission models.Permission) bool {
+	granted, ok := p.Profiles[profileID][moduleID]
+	if !ok {
+		granted = p.Roles[moduleID][role]
+	}
+
+	for _, g := range granted {
+		if g == permission {
+			return true
+		}
+	}
+
+	return false
+}
+
+// familyAccess is what HasModulePermission needs to answer without touching
+// the database.
+type familyAccess struct {
+	modules     map[models.ModuleID]bool
+	permissions *Permissions
+	expiresAt   time.Time
+}
+
+type permissionCache struct {
+	mu      sync.RWMutex
+	entries map[int]*familyAccess
+}
+
+func newPermissionCache() *permissionCache {
+	return &permissionCache{
+		entries: make(map[int]*familyAccess),
+	}
+}
+
+func (c *permissionCache) get(familyID int) (*familyAccess, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	entry, ok := c.entries[familyID]
+	if !ok || time.Now().After(entry.expiresAt) {
+		return nil, false
+	}
+	return entry, true
+}
+
+func (c *permissionCache) set(familyID int, entry *familyAccess) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry.expiresAt = time.Now().Add(permissionCacheTTL)
+	c.entries[familyID] = entry
+}
+
+func (c *permissionCache) invalidate(familyID int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	delete(c.entries, familyID)
+}
@@ -1,11 +1,13 @@
 package family
 
 import (
+	"context"
 	"database/sql"
 	"encoding/json"
 	"fmt"
 	"time"
 
+	"github.com/chrisabs/cadence/internal/apperror"
 	"github.com/chrisabs/cadence/internal/models"
 )
 
@@ -17,13 +19,13 @@ func NewRepository(db *sql.DB) *Repository {
 	return &Repository{db: db}
 }
 
-func (r *Repository) Create(family *FamilyAccount) error {
+func (r *Repository) Create(ctx context.Context, family *FamilyAccount) error {
 	query := `
 		INSERT INTO family_account (email, password, family_name, created_at, updated_at)
 		VALUES ($1, $2, $3, $4, $5)
 		RETURNING id, created_at, updated_at`
 
-	err := r.db.QueryRow(
+	err := r.db.QueryRowContext(ctx,
 		query,
 		family.Email,
 		family.Password,
@@ -33,16 +35,16 @@ func (r *Repository) Create(family *FamilyAccount) error {
 	).Scan(&family.ID, &family.CreatedAt, &family.UpdatedAt)
 
 	if err != nil {
-		return fmt.Errorf("error creating family account: %v", err)
+		return fmt.Errorf("error creating family account: %w", err)
 	}
 
 	return nil
 }
 
-func (r *Repository) CreateSettings(settings *FamilySettings) error {
+func (r *Repository) CreateSettings(ctx context.Context, settings *FamilySettings) error {
 	modulesJSON, err := json.Marshal(settings.Modules)
 	if err != nil {
-		return fmt.Errorf("error marshaling modules: %v", err)
+		return fmt.Errorf("error marshaling modules: %w", err)
 	}
 
 	query := `
@@ -50,7 +52,7 @@ func (r *Repository) CreateSettings(settings *FamilySettings) error {
 		VALUES ($1, $2, $3, $4, $5)
 		RETURNING created_at, updated_at`
 
-	err = r.db.QueryRow(
+	err = r.db.QueryRowContext(ctx,
 		query,
 		settings.FamilyID,
 		modulesJSON,
@@ -60,102 +62,152 @@ func (r *Repository) CreateSettings(settings *FamilySettings) error {
 	).Scan(&settings.CreatedAt, &settings.UpdatedAt)
 
 	if err != nil {
-		return fmt.Errorf("error creating family settings: %v", err)
+		return fmt.Errorf("error creating family settings: %w", err)
 	}
 
 	return nil
 }
 
-func (r *Repository) GetByID(id int) (*FamilyAccount, error) {
+func (r *Repository) GetByID(ctx context.Context, id int) (*FamilyAccount, error) {
 	query := `
-		SELECT id, email, password, family_name, created_at, updated_at
+		SELECT id, email, password, family_name, email_verified_at, created_at, updated_at
 		FROM family_account
 		WHERE id = $1 AND is_deleted = false`
 
 	family := new(FamilyAccount)
-	err := r.db.QueryRow(query, id).Scan(
+	var verifiedAt *time.Time
+	err := r.db.QueryRowContext(ctx, query, id).Scan(
 		&family.ID,
 		&family.Email,
 		&family.Password,
 		&family.FamilyName,
+		&verifiedAt,
 		&family.CreatedAt,
 		&family.UpdatedAt,
 	)
 
 	if err == sql.ErrNoRows {
-		return nil, fmt.Errorf("family account not found")
+		return nil, apperror.NotFound("family account not found")
 	}
 	if err != nil {
-		return nil, fmt.Errorf("error getting family account: %v", err)
+		return nil, fmt.Errorf("error getting family account: %w", err)
 	}
 
+	family.EmailVerified = verifiedAt != nil
+
 	return family, nil
 }
 
-func (r *Repository) GetByEmail(email string) (*FamilyAccount, error) {
+func (r *Repository) GetByEmail(ctx context.Context, email string) (*FamilyAccount, error) {
 	query := `
-		SELECT id, email, password, family_name, created_at, updated_at
+		SELECT id, email, password, family_name, email_verified_at, created_at, updated_at
 		FROM family_account
 		WHERE email = $1 AND is_deleted = false`
 
 	family := new(FamilyAccount)
-	err := r.db.QueryRow(query, email).Scan(
+	var verifiedAt *time.Time
+	err := r.db.QueryRowContext(ctx, query, email).Scan(
 		&family.ID,
 		&family.Email,
 		&family.Password,
 		&family.FamilyName,
+		&verifiedAt,
 		&family.CreatedAt,
 		&family.UpdatedAt,
 	)
 
 	if err == sql.ErrNoRows {
-		return nil, fmt.Errorf("family account not found")
+		return nil, apperror.NotFound("family account not found")
 	}
 	if err != nil {
-		return nil, fmt.Errorf("error getting family account: %v", err)
+		return nil, fmt.Errorf("error getting family account: %w", err)
 	}
 
+	family.EmailVerified = verifiedAt != nil
+
 	return family, nil
 }
 
-func (r *Repository) GetSettings(familyID int) (*FamilySettings, error) {
+func (r *Repository) GetSettings(ctx context.Context, familyID int) (*FamilySettings, error) {
 	query := `
-		SELECT family_id, modules, status, created_at, updated_at
+		SELECT family_id, modules, permissions, status, created_at, updated_at
 		FROM family_settings
 		WHERE family_id = $1 AND is_deleted = false`
 
 	settings := new(FamilySettings)
-	var modulesJSON []byte
+	var modulesJSON, permissionsJSON []byte
 
-	err := r.db.QueryRow(query, familyID).Scan(
+	err := r.db.QueryRowContext(ctx, query, familyID).Scan(
 		&settings.FamilyID,
 		&modulesJSON,
+		&permissionsJSON,
 		&settings.Status,
 		&settings.CreatedAt,
 		&settings.UpdatedAt,
 	)
 
 	if err == sql.ErrNoRows {
-		return nil, fmt.Errorf("family settings not found")
+		return nil, apperror.NotFound("family settings not found")
 	}
 	if err != nil {
-		return nil, fmt.Errorf("error getting family settings: %v", err)
+		return nil, fmt.Errorf("error getting family settings: %w", err)
 	}
 
 	if err := json.Unmarshal(modulesJSON, &settings.Modules); err != nil {
-		return nil, fmt.Errorf("error unmarshaling modules: %v", err)
+		return nil, fmt.Errorf("error unmarshaling modules: %w", err)
+	}
+
+	if permissionsJSON != nil {
+		settings.Permissions = new(Permissions)
+		if err := json.Unmarshal(permissionsJSON, settings.Permissions); err != nil {
+			return nil, fmt.Errorf("error unmarshaling permissions: %w", err)
+		}
 	}
 
 	return settings, nil
 }
 
-func (r *Repository) Update(family *FamilyAccount) error {
+// UpdatePermissions stores the family's permission matrix. A nil matrix resets
+// the family to the default permissions.
+func (r *Repository) UpdatePermissions(ctx context.Context, familyID int, permissions *Permissions) error {
+	var permissionsJSON []byte
+	if permissions != nil {
+		var err error
+		permissionsJSON, err = json.Marshal(permissions)
+		if err != nil {
+			return fmt.Errorf("error marshaling permissions: %w", err)
+		}
+	}
+
+	query := `
+		UPDATE family_settings
+		SET permissions = $2, updated_at = $3
+		WHERE family_id = $1 AND is_deleted = false`
+
+	result, err := r.db.ExecContext(ctx, query, familyID, permissionsJSON, time.Now().UTC())
+	if err != nil {
+		return fmt.Errorf("error updating family permissions: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("error checking update result: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return apperror.NotFound("family settings not found")
+	}
+
+	return nil
+}
+
+func (r *Repository) Update(ctx context.Context, family *FamilyAccount) error {
 	query := `
 		UPDATE family_account
 		SET family_name = $2, updated_at = $3
 		WHERE id = $1 AND is_deleted = false`
 
-	result, err := r.db.Exec(
+	result, err := r.db.ExecContext(ctx,
 		query,
 		family.ID,
 		family.FamilyName,
@@ -163,25 +215,25 @@ func (r *Repository) Update(family *FamilyAccount) error {
 	)
 
 	if err != nil {
-		return fmt.Errorf("error updating family account: %v", err)
+		return fmt.Errorf("error updating family account: %w", err)
 	}
 
 	rowsAffected, err := result.RowsAffected()
 	if err != nil {
-		return fmt.Errorf("error checking update result: %v", err)
+		return fmt.Errorf("error checking update result: %w", err)
 	}
 
 	if rowsAffected == 0 {
-		return fmt.Errorf("family account not found")
+		return apperror.NotFound("family account not found")
 	}
 
 	return nil
 }
 
-func (r *Repository) UpdateSettings(settings *FamilySettings) error {
+func (r *Repository) UpdateSettings(ctx context.Context, settings *FamilySettings) error {
 	modulesJSON, err := json.Marshal(settings.Modules)
 	if err != nil {
-		return fmt.Errorf("error marshaling modules: %v", err)
+		return fmt.Errorf("error marshaling modules: %w", err)
 	}
 
 	query := `
@@ -189,7 +241,7 @@ func (r *Repository) UpdateSettings(settings *FamilySettings) error {
 		SET modules = $2, status = $3, updated_at = $4
 		WHERE family_id = $1 AND is_deleted = false`
 
-	result, err := r.db.Exec(
+	result, err := r.db.ExecContext(ctx,
 		query,
 		settings.FamilyID,
 		modulesJSON,
@@ -198,23 +250,23 @@ func (r *Repository) UpdateSettings(settings *FamilySettings) error {
 	)
 
 	if err != nil {
-		return fmt.Errorf("error updating family settings: %v", err)
+		return fmt.Errorf("error updating family settings: %w", err)
 	}
 
 	rowsAffected, err := result.RowsAffected()
 	if err != nil {
-		return fmt.Errorf("error checking update result: %v", err)
+		return fmt.Errorf("error checking update result: %w", err)
 	}
 
 	if rowsAffected == 0 {
-		return fmt.Errorf("family settings not found")
+		return apperror.NotFound("family settings not found")
 	}
 
 	return nil
 }
 
-func (r *Repository) UpdateModule(familyID int, moduleID models.ModuleID, isEnabled bool) error {
-	settings, err := r.GetSettings(familyID)
+func (r *Repository) UpdateModule(ctx context.Context, familyID int, moduleID models.ModuleID, isEnabled bool) error {
+	settings, err := r.GetSettings(ctx, familyID)
 	if err != nil {
 		return err
 	}
@@ -235,57 +287,57 @@ func (r *Repository) UpdateModule(familyID int, moduleID models.ModuleID, isEnab
 		})
 	}
 
-	return r.UpdateSettings(settings)
+	return r.UpdateSettings(ctx, settings)
 }
 
-func (r *Repository) Delete(id int, deletedBy int) error {
+func (r *Repository) Delete(ctx context.Context, id int, deletedBy int) error {
 	query := `
 		UPDATE family_account
 		SET is_deleted = true, deleted_at = $2, deleted_by = $3, updated_at = $2
 		WHERE id = $1 AND is_deleted = false`
 
-	result, err := r.db.Exec(query, id, time.Now().UTC(), deletedBy)
+	result, err := r.db.ExecContext(ctx, query, id, time.Now().UTC(), deletedBy)
 	if err != nil {
-		return fmt.Errorf("error deleting family account: %v", err)
+		return fmt.Errorf("error deleting family account: %w", err)
 	}
 
 	rowsAffected, err := result.RowsAffected()
 	if err != nil {
-		return fmt.Errorf("error checking delete result: %v", err)
+		return fmt.Errorf("error checking delete result: %w", err)
 	}
 
 	if rowsAffected == 0 {
-		return fmt.Errorf("family account not found")
+		return apperror.NotFound("family account not found")
 	}
 
 	return nil
 }
 
-func (r *Repository) Restore(id int) error {
+func (r *Repository) Restore(ctx context.Context, id int) error {
 	query := `
 		UPDATE family_account
 		SET is_deleted = false, deleted_at = NULL, deleted_by = NULL, updated_at = $2
 		WHERE id = $1 AND is_deleted = true`
 
-	result, err := r.db.Exec(query, id, time.Now().UTC())
+	result, err := r.db.ExecContext(ctx, query, id, time.Now().UTC())
 	if err != nil {
-		return fmt.Errorf("error restoring family account: %v", err)
+		return fmt.Errorf("error restoring family account: %w", err)
 	}
 
 	rowsAffected, err := result.RowsAffected()
 	if err != nil {
-		return fmt.Errorf("error checking restore result: %v", err)
+		return fmt.Errorf("error checking restore result: %w", err)
 	}
 
 	if rowsAffected == 0 {
-		return fmt.Errorf("family account not found or not deleted")
+		return apperror.NotFound("family account not found or not deleted")
 	}
 
 	return nil
 }
 
-func (r *Repository) IsModuleEnabled(familyID int, moduleID models.ModuleID) (bool, error) {
-	settings, err := r.GetSettings(familyID)
+func (r *Repository) IsModuleEnabled(ctx context.Context, familyID int, moduleID models.ModuleID) (bool, error) {
+	settings, err := r.GetSettings(ctx, familyID)
 	if err != nil {
 		return false, err
 	}
@@ -297,4 +349,154 @@ func (r *Repository) IsModuleEnabled(familyID int, moduleID models.ModuleID) (bo
 	}
 
 	return false, nil
-}
\ No newline at end of file
+}
+func (r *Repository) IsEmailVerified(ctx context.Context, familyID int) (bool, error) {
+	query := `
+		SELECT email_verified_at IS NOT NULL
+		FROM family_account
+		WHERE id = $1 AND is_deleted = false`
+
+	var verified bool
+	err := r.db.QueryRowContext(ctx, query, familyID).Scan(&verified)
+	if err == sql.ErrNoRows {
+		return false, apperror.NotFound("family account not found")
+	}
+	if err != nil {
+		return false, fmt.Errorf("error checking email verification: %w", err)
+	}
+
+	return verified, nil
+}
+
+// CreateAccountToken stores token and invalidates any unused token the family
+// already had for the same purpose, so only the latest emailed link works.
+func (r *Repository) CreateAccountToken(ctx context.Context, token *AccountToken) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("error starting transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	now := time.Now().UTC()
+
+	if _, err := tx.ExecContext(ctx, `
+		UPDATE family_account_token
+		SET used_at = $3
+		WHERE family_id = $1 AND purpose = $2 AND used_at IS NULL`,
+		token.FamilyID, token.Purpose, now); err != nil {
+		return fmt.Errorf("error invalidating account tokens: %w", err)
+	}
+
+	err = tx.QueryRowContext(ctx, `
+		INSERT INTO family_account_token (family_id, purpose, token_hash, expires_at, created_at)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id, created_at`,
+		token.FamilyID,
+		token.Purpose,
+		token.TokenHash,
+		token.ExpiresAt,
+		now,
+	).Scan(&token.ID, &token.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("error creating account token: %w", err)
+	}
+
+	return tx.Commit()
+}
+
+// consumeAccountToken marks an unused, unexpired token as used and returns
+// the family it belongs to.
+func consumeAccountToken(ctx context.Context, tx *sql.Tx, tokenHash string, purpose AccountTokenPurpose, now time.Time) (int, error) {
+	var familyID int
+	err := tx.QueryRowContext(ctx, `
+		UPDATE family_account_token
+		SET used_at = $3
+		WHERE token_hash = $1 AND purpose = $2 AND used_at IS NULL AND expires_at > $3
+		RETURNING family_id`,
+		tokenHash, purpose, now).Scan(&familyID)
+
+	if err == sql.ErrNoRows {
+		return 0, apperror.NotFound("token not found or expired")
+	}
+	if err != nil {
+		return 0, fmt.Errorf("error consuming account token: %w", err)
+	}
+
+	return familyID, nil
+}
+
+func (r *Repository) VerifyEmail(ctx context.Context, tokenHash string) (int, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("error starting transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	now := time.Now().UTC()
+
+	familyID, err := consumeAccountToken(ctx, tx, tokenHash, TokenPurposeVerifyEmail, now)
+	if err != nil {
+		return 0, err
+	}
+
+	if _, err := tx.ExecContext(ctx, `
+		UPDATE family_account
+		SET email_verified_at = COALESCE(email_verified_at, $2), updated_at = $2
+		WHERE id = $1 AND is_deleted = false`,
+		familyID, now); err != nil {
+		return 0, fmt.Errorf("error verifying email: %w", err)
+	}
+
+	return familyID, tx.Commit()
+}
+
+// ResetPassword consumes a reset token and sets the new password hash. A
+// successful reset also proves control of the inbox, so the email is marked
+// verified.
+func (r *Repository) ResetPassword(ctx context.Context, tokenHash string, passwordHash string) (int, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("error starting transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	now := time.Now().UTC()
+
+	familyID, err := consumeAccountToken(ctx, tx, tokenHash, TokenPurposePasswordReset, now)
+	if err != nil {
+		return 0, err
+	}
+
+	if _, err := tx.ExecContext(ctx, `
+		UPDATE family_account
+		SET password = $2, email_verified_at = COALESCE(email_verified_at, $3), updated_at = $3
+		WHERE id = $1 AND is_deleted = false`,
+		familyID, passwordHash, now); err != nil {
+		return 0, fmt.Errorf("error resetting password: %w", err)
+	}
+
+	return familyID, tx.Commit()
+}
+
+func (r *Repository) UpdatePassword(ctx context.Context, familyID int, passwordHash string) error {
+	query := `
+		UPDATE family_account
+		SET password = $2, updated_at = $3
+		WHERE id = $1 AND is_deleted = false`
+
+	result, err := r.db.ExecContext(ctx, query, familyID, passwordHash, time.Now().UTC())
+	if err != nil {
+		return fmt.Errorf("error updating password: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("error checking update result: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return apperror.NotFound("family account not found")
+	}
+
+	return nil
+}
@@ -1,56 +1,87 @@
 package family
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"time"
 
+	"github.com/chrisabs/cadence/internal/apperror"
+	"github.com/chrisabs/cadence/internal/auth"
 	"github.com/chrisabs/cadence/internal/models"
+	"github.com/chrisabs/cadence/internal/platform/logging"
+	"github.com/chrisabs/cadence/internal/platform/securetoken"
+	"github.com/chrisabs/cadence/internal/platform/tracing"
+	"github.com/chrisabs/cadence/internal/platform/validate"
 	"github.com/chrisabs/cadence/internal/profile"
-	"github.com/golang-jwt/jwt"
 	"golang.org/x/crypto/bcrypt"
 )
 
+// TokenService issues the access and refresh tokens returned on login and
+// revokes them after a password reset.
+type TokenService interface {
+	StartSession(ctx context.Context, familyID int, device *auth.DeviceInfo) (*auth.TokenPair, error)
+	SignOutAll(ctx context.Context, familyID int) error
+}
+
+// EmailService sends the account emails. It is optional so the API still runs
+// where SES is not configured.
+type EmailService interface {
+	SendVerificationEmail(ctx context.Context, recipientEmail, verifyToken string, familyName string) error
+	SendPasswordResetEmail(ctx context.Context, recipientEmail, resetToken string) error
+}
+
+const (
+	verifyEmailTokenTTL   = 24 * time.Hour
+	passwordResetTokenTTL = time.Hour
+)
+
 type Service struct {
-	repo         *Repository
-	jwtSecret    string
+	repo            *Repository
+	tokenService    TokenService
+	emailService    EmailService
+	permissionCache *permissionCache
 	profileService interface {
-		CreateProfile(familyID int, req *profile.CreateProfileRequest) (*models.Profile, error)
-		GetProfilesByFamilyID(familyID int) ([]*models.Profile, error)
+		CreateProfile(ctx context.Context, familyID int, req *profile.CreateProfileRequest) (*models.Profile, error)
+		GetProfilesByFamilyID(ctx context.Context, familyID int) ([]*models.Profile, error)
 	}
 }
 
-func NewService(repo *Repository, jwtSecret string) *Service {
+func NewService(repo *Repository, tokenService TokenService) *Service {
 	return &Service{
-		repo:      repo,
-		jwtSecret: jwtSecret,
+		repo:            repo,
+		tokenService:    tokenService,
+		permissionCache: newPermissionCache(),
 	}
 }
 
 func (s *Service) SetProfileService(profileService interface {
-	CreateProfile(familyID int, req *profile.CreateProfileRequest) (*models.Profile, error)
-	GetProfilesByFamilyID(familyID int) ([]*models.Profile, error)
+	CreateProfile(ctx context.Context, familyID int, req *profile.CreateProfileRequest) (*models.Profile, error)
+	GetProfilesByFamilyID(ctx context.Context, familyID int) ([]*models.Profile, error)
 }) {
 	s.profileService = profileService
 }
 
-func (s *Service) GenerateFamilyJWT(familyID int) (string, error) {
-	token := jwt.New(jwt.SigningMethodHS256)
-	claims := token.Claims.(jwt.MapClaims)
-	claims["familyId"] = familyID
-	claims["exp"] = time.Now().Add(time.Hour * 24 * 7).Unix() // 7 days
-
-	return token.SignedString([]byte(s.jwtSecret))
+func (s *Service) SetEmailService(emailService EmailService) {
+	s.emailService = emailService
 }
 
-func (s *Service) Register(req *RegisterRequest) (*FamilyAuthResponse, error) {
-    existingFamily, err := s.repo.GetByEmail(req.Email)
+func (s *Service) Register(ctx context.Context, req *RegisterRequest, device *auth.DeviceInfo) (*FamilyAuthResponse, error) {
+    ctx, span := tracing.Start(ctx, "family.Service.Register")
+    defer span.End()
+
+    if err := validate.Struct(req); err != nil {
+        return nil, err
+    }
+
+    existingFamily, err := s.repo.GetByEmail(ctx, req.Email)
     if err == nil && existingFamily != nil {
-        return nil, fmt.Errorf("email already in use")
+        return nil, apperror.Conflict("email already in use")
     }
 
     hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
     if err != nil {
-        return nil, fmt.Errorf("error hashing password: %v", err)
+        return nil, fmt.Errorf("error hashing password: %w", err)
     }
 
     family := &FamilyAccount{
@@ -61,8 +92,8 @@ func (s *Service) Register(req *RegisterRequest) (*FamilyAuthResponse, error) {
         UpdatedAt:  time.Now().UTC(),
     }
 
-    if err := s.repo.Create(family); err != nil {
-        return nil, fmt.Errorf("failed to create family account: %v", err)
+    if err := s.repo.Create(ctx, family); err != nil {
+        return nil, fmt.Errorf("failed to create family account: %w", err)
     }
 
     settings := &FamilySettings{
@@ -78,49 +109,60 @@ func (s *Service) Register(req *RegisterRequest) (*FamilyAuthResponse, error) {
         UpdatedAt: time.Now().UTC(),
     }
 
-    if err := s.repo.CreateSettings(settings); err != nil {
-        return nil, fmt.Errorf("failed to create family settings: %v", err)
+    if err := s.repo.CreateSettings(ctx, settings); err != nil {
+        return nil, fmt.Errorf("failed to create family settings: %w", err)
     }
 
     var profiles []models.Profile
     if s.profileService != nil {
-        ownerProfile, err := s.profileService.CreateProfile(family.ID, &profile.CreateProfileRequest{
+        ownerProfile, err := s.profileService.CreateProfile(ctx, family.ID, &profile.CreateProfileRequest{
             Name:  req.OwnerName,
             Role:  models.RoleParent, 
             Pin:   "",
         })
         if err != nil {
-            return nil, fmt.Errorf("failed to create owner profile: %v", err)
+            return nil, fmt.Errorf("failed to create owner profile: %w", err)
         }
         
         profiles = append(profiles, *ownerProfile)
     }
 
-    token, err := s.GenerateFamilyJWT(family.ID)
+    if err := s.sendVerificationEmail(ctx, family); err != nil {
+        logging.FromContext(ctx).Error("failed to send verification email", "family_id", family.ID, "error", err)
+    }
+
+    tokens, err := s.tokenService.StartSession(ctx, family.ID, device)
     if err != nil {
-        return nil, fmt.Errorf("failed to generate token: %v", err)
+        return nil, fmt.Errorf("failed to generate token: %w", err)
     }
 
     return &FamilyAuthResponse{
-        Token:    token,
+        TokenPair: *tokens,
         Family:   *family,
         Profiles: profiles,
     }, nil
 }
 
-func (s *Service) Login(req *LoginRequest) (*FamilyAuthResponse, error) {
-	family, err := s.repo.GetByEmail(req.Email)
+func (s *Service) Login(ctx context.Context, req *LoginRequest, device *auth.DeviceInfo) (*FamilyAuthResponse, error) {
+	ctx, span := tracing.Start(ctx, "family.Service.Login")
+	defer span.End()
+
+	if err := validate.Struct(req); err != nil {
+		return nil, err
+	}
+
+	family, err := s.repo.GetByEmail(ctx, req.Email)
 	if err != nil {
-		return nil, fmt.Errorf("invalid email or password")
+		return nil, apperror.Unauthorized("invalid email or password")
 	}
 
 	if err := bcrypt.CompareHashAndPassword([]byte(family.Password), []byte(req.Password)); err != nil {
-		return nil, fmt.Errorf("invalid email or password")
+		return nil, apperror.Unauthorized("invalid email or password")
 	}
 
 	var profiles []models.Profile
 	if s.profileService != nil {
-		profilesPtr, err := s.profileService.GetProfilesByFamilyID(family.ID)
+		profilesPtr, err := s.profileService.GetProfilesByFamilyID(ctx, family.ID)
 		if err == nil {
 			for _, p := range profilesPtr {
 				profiles = append(profiles, *p)
@@ -128,27 +170,30 @@ func (s *Service) Login(req *LoginRequest) (*FamilyAuthResponse, error) {
 		}
 	}
 
-	token, err := s.GenerateFamilyJWT(family.ID)
+	tokens, err := s.tokenService.StartSession(ctx, family.ID, device)
 	if err != nil {
-		return nil, fmt.Errorf("failed to generate token: %v", err)
+		return nil, fmt.Errorf("failed to generate token: %w", err)
 	}
 
 	return &FamilyAuthResponse{
-		Token:    token,
+		TokenPair: *tokens,
 		Family:   *family,
 		Profiles: profiles,
 	}, nil
 }
 
-func (s *Service) GetFamilyByID(id int) (*FamilyAccount, error) {
-    family, err := s.repo.GetByID(id)
+func (s *Service) GetFamilyByID(ctx context.Context, id int) (*FamilyAccount, error) {
+    ctx, span := tracing.Start(ctx, "family.Service.GetFamilyByID")
+    defer span.End()
+
+    family, err := s.repo.GetByID(ctx, id)
     if err != nil {
-        return nil, fmt.Errorf("family not found: %v", err)
+        return nil, fmt.Errorf("family not found: %w", err)
     }
     
-    settings, err := s.repo.GetSettings(id)
+    settings, err := s.repo.GetSettings(ctx, id)
     if err != nil {
-        return nil, fmt.Errorf("family settings not found: %v", err)
+        return nil, fmt.Errorf("family settings not found: %w", err)
     }
     
     family.Modules = settings.Modules
@@ -157,86 +202,423 @@ func (s *Service) GetFamilyByID(id int) (*FamilyAccount, error) {
     return family, nil
 }
 
-func (s *Service) UpdateFamily(id int, req *UpdateFamilyRequest) (*FamilyAccount, error) {
-	family, err := s.repo.GetByID(id)
+func (s *Service) UpdateFamily(ctx context.Context, id int, req *UpdateFamilyRequest) (*FamilyAccount, error) {
+	ctx, span := tracing.Start(ctx, "family.Service.UpdateFamily")
+	defer span.End()
+
+	if err := validate.Struct(req); err != nil {
+		return nil, err
+	}
+
+	family, err := s.repo.GetByID(ctx, id)
 	if err != nil {
-		return nil, fmt.Errorf("family not found: %v", err)
+		return nil, fmt.Errorf("family not found: %w", err)
 	}
 
 	family.FamilyName = req.FamilyName
 	family.UpdatedAt = time.Now().UTC()
 
-	if err := s.repo.Update(family); err != nil {
-		return nil, fmt.Errorf("failed to update family: %v", err)
+	if err := s.repo.Update(ctx, family); err != nil {
+		return nil, fmt.Errorf("failed to update family: %w", err)
 	}
 
 	return family, nil
 }
 
-func (s *Service) GetFamilySettings(familyID int) (*FamilySettings, error) {
-	return s.repo.GetSettings(familyID)
+func (s *Service) GetFamilySettings(ctx context.Context, familyID int) (*FamilySettings, error) {
+	ctx, span := tracing.Start(ctx, "family.Service.GetFamilySettings")
+	defer span.End()
+
+	return s.repo.GetSettings(ctx, familyID)
 }
 
-func (s *Service) UpdateModule(familyID int, req *UpdateModuleRequest) error {
-	return s.repo.UpdateModule(familyID, req.ModuleID, req.IsEnabled)
+func (s *Service) UpdateModule(ctx context.Context, familyID int, req *UpdateModuleRequest) error {
+	ctx, span := tracing.Start(ctx, "family.Service.UpdateModule")
+	defer span.End()
+
+	if err := validate.Struct(req); err != nil {
+		return err
+	}
+
+	if err := s.repo.UpdateModule(ctx, familyID, req.ModuleID, req.IsEnabled); err != nil {
+		return err
+	}
+	s.permissionCache.invalidate(familyID)
+
+	return nil
 }
 
-func (s *Service) DeleteFamily(id int, deletedBy int) error {
-	return s.repo.Delete(id, deletedBy)
+func (s *Service) DeleteFamily(ctx context.Context, id int, deletedBy int) error {
+	ctx, span := tracing.Start(ctx, "family.Service.DeleteFamily")
+	defer span.End()
+
+	if err := s.repo.Delete(ctx, id, deletedBy); err != nil {
+		return err
+	}
+	s.permissionCache.invalidate(id)
+
+	return nil
 }
 
-func (s *Service) RestoreFamily(id int) error {
-	return s.repo.Restore(id)
+func (s *Service) RestoreFamily(ctx context.Context, id int) error {
+	ctx, span := tracing.Start(ctx, "family.Service.RestoreFamily")
+	defer span.End()
+
+	if err := s.repo.Restore(ctx, id); err != nil {
+		return err
+	}
+	s.permissionCache.invalidate(id)
+
+	return nil
 }
 
-func (s *Service) IsModuleEnabled(familyID int, moduleID models.ModuleID) (bool, error) {
-	return s.repo.IsModuleEnabled(familyID, moduleID)
+func (s *Service) IsModuleEnabled(ctx context.Context, familyID int, moduleID models.ModuleID) (bool, error) {
+	ctx, span := tracing.Start(ctx, "family.Service.IsModuleEnabled")
+	defer span.End()
+
+	return s.repo.IsModuleEnabled(ctx, familyID, moduleID)
 }
 
-func (s *Service) HasModulePermission(familyID int, role models.ProfileRole, moduleID models.ModuleID, permission models.Permission) (bool, error) {
-	isEnabled, err := s.IsModuleEnabled(familyID, moduleID)
+// HasModulePermission checks a profile against its family's permission matrix.
+// The matrix and enabled modules are cached per family because this runs on
+// every module route.
+func (s *Service) HasModulePermission(ctx context.Context, familyID int, profileID int, role models.ProfileRole, moduleID models.ModuleID, permission models.Permission) (bool, error) {
+	ctx, span := tracing.Start(ctx, "family.Service.HasModulePermission")
+	defer span.End()
+
+	access, err := s.getFamilyAccess(ctx, familyID)
 	if err != nil {
 		return false, err
 	}
 
-	if !isEnabled {
+	if !access.modules[moduleID] {
 		return false, nil
 	}
 
-	permissions := map[models.ModuleID]map[models.ProfileRole][]models.Permission{
-		models.ModuleStorage: {
-			models.RoleParent: {models.PermissionRead, models.PermissionWrite, models.PermissionManage},
-			models.RoleChild:  {models.PermissionRead},
-		},
-		models.ModuleChores: {
-			models.RoleParent: {models.PermissionRead, models.PermissionWrite, models.PermissionManage},
-			models.RoleChild:  {models.PermissionRead, models.PermissionWrite},
-		},
-		models.ModuleMeals: {
-			models.RoleParent: {models.PermissionRead, models.PermissionWrite, models.PermissionManage},
-			models.RoleChild:  {models.PermissionRead},
-		},
-		models.ModuleServices: {
-			models.RoleParent: {models.PermissionRead, models.PermissionWrite, models.PermissionManage},
-			models.RoleChild:  {models.PermissionRead},
-		},
-	}
-
-	modulePermissions, ok := permissions[moduleID]
-	if !ok {
-		return false, nil
+	return access.permissions.Allows(profileID, role, moduleID, permission), nil
+}
+
+func (s *Service) GetPermissions(ctx context.Context, familyID int) (*Permissions, error) {
+	ctx, span := tracing.Start(ctx, "family.Service.GetPermissions")
+	defer span.End()
+
+	access, err := s.getFamilyAccess(ctx, familyID)
+	if err != nil {
+		return nil, err
 	}
 
-	rolePermissions, ok := modulePermissions[role]
-	if !ok {
-		return false, nil
+	return access.permissions, nil
+}
+
+func (s *Service) UpdatePermissions(ctx context.Context, familyID int, req *UpdatePermissionsRequest) (*Permissions, error) {
+	ctx, span := tracing.Start(ctx, "family.Service.UpdatePermissions")
+	defer span.End()
+
+	if err := validate.Struct(req); err != nil {
+		return nil, err
+	}
+
+	permissions := &Permissions{
+		Roles:    req.Roles,
+		Profiles: req.Profiles,
+	}
+	if permissions.Profiles == nil {
+		permissions.Profiles = map[int]map[models.ModuleID][]models.Permission{}
+	}
+
+	if err := s.validatePermissions(ctx, familyID, permissions); err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.UpdatePermissions(ctx, familyID, permissions); err != nil {
+		return nil, err
+	}
+	s.permissionCache.invalidate(familyID)
+
+	return s.GetPermissions(ctx, familyID)
+}
+
+func (s *Service) ResetPermissions(ctx context.Context, familyID int) (*Permissions, error) {
+	ctx, span := tracing.Start(ctx, "family.Service.ResetPermissions")
+	defer span.End()
+
+	if err := s.repo.UpdatePermissions(ctx, familyID, nil); err != nil {
+		return nil, err
+	}
+	s.permissionCache.invalidate(familyID)
+
+	return DefaultPermissions(), nil
+}
+
+// validatePermissions rejects unknown modules, roles and permissions, stops a
+// family from taking MANAGE away from parents, and only allows overrides for
+// the family's own profiles other than the owner.
+func (s *Service) validatePermissions(ctx context.Context, familyID int, permissions *Permissions) error {
+	details := map[string]string{}
+
+	validPermissions := func(field string, granted []models.Permission) {
+		for _, p := range granted {
+			switch p {
+			case models.PermissionRead, models.PermissionWrite, models.PermissionManage:
+			default:
+				details[field] = fmt.Sprintf("unknown permission %q", p)
+			}
+		}
+	}
+
+	for moduleID, roles := range permissions.Roles {
+		if _, ok := SystemModules[moduleID]; !ok {
+			details["roles."+string(moduleID)] = "unknown module"
+			continue
+		}
+		for role, granted := range roles {
+			field := fmt.Sprintf("roles.%s.%s", moduleID, role)
+			if role != models.RoleParent && role != models.RoleChild {
+				details[field] = "unknown role"
+				continue
+			}
+			validPermissions(field, granted)
+			if role == models.RoleParent && !containsPermission(granted, models.PermissionManage) {
+				details[field] = "parents must keep MANAGE"
+			}
+		}
+	}
+
+	if len(permissions.Profiles) > 0 {
+		if s.profileService == nil {
+			return fmt.Errorf("profile service not configured")
+		}
+
+		profiles, err := s.profileService.GetProfilesByFamilyID(ctx, familyID)
+		if err != nil {
+			return fmt.Errorf("error loading profiles: %w", err)
+		}
+
+		familyProfiles := make(map[int]*models.Profile, len(profiles))
+		for _, p := range profiles {
+			familyProfiles[p.ID] = p
+		}
+
+		for profileID, modules := range permissions.Profiles {
+			field := fmt.Sprintf("profiles.%d", profileID)
+			p, ok := familyProfiles[profileID]
+			if !ok {
+				details[field] = "profile not found"
+				continue
+			}
+			if p.IsOwner {
+				details[field] = "the owner's permissions cannot be overridden"
+				continue
+			}
+			for moduleID, granted := range modules {
+				if _, ok := SystemModules[moduleID]; !ok {
+					details[field+"."+string(moduleID)] = "unknown module"
+					continue
+				}
+				validPermissions(field+"."+string(moduleID), granted)
+			}
+		}
+	}
+
+	if len(details) > 0 {
+		return apperror.Validation("invalid permissions", details)
+	}
+
+	return nil
+}
+
+func (s *Service) getFamilyAccess(ctx context.Context, familyID int) (*familyAccess, error) {
+	if access, ok := s.permissionCache.get(familyID); ok {
+		return access, nil
 	}
 
-	for _, p := range rolePermissions {
-		if p == permission {
-			return true, nil
+	settings, err := s.repo.GetSettings(ctx, familyID)
+	if err != nil {
+		return nil, err
+	}
+
+	access := &familyAccess{
+		modules:     make(map[models.ModuleID]bool, len(settings.Modules)),
+		permissions: settings.Permissions.withDefaults(),
+	}
+	for _, module := range settings.Modules {
+		access.modules[module.ID] = module.IsEnabled
+	}
+
+	s.permissionCache.set(familyID, access)
+
+	return access, nil
+}
+
+func containsPermission(granted []models.Permission, permission models.Permission) bool {
+	for _, g := range granted {
+		if g == permission {
+			return true
 		}
 	}
+	return false
+}
+
+func (s *Service) IsEmailVerified(ctx context.Context, familyID int) (bool, error) {
+	ctx, span := tracing.Start(ctx, "family.Service.IsEmailVerified")
+	defer span.End()
+
+	return s.repo.IsEmailVerified(ctx, familyID)
+}
+
+func (s *Service) VerifyEmail(ctx context.Context, req *VerifyEmailRequest) error {
+	ctx, span := tracing.Start(ctx, "family.Service.VerifyEmail")
+	defer span.End()
+
+	if err := validate.Struct(req); err != nil {
+		return err
+	}
+
+	_, err := s.repo.VerifyEmail(ctx, securetoken.Hash(req.Token))
+	if errors.Is(err, apperror.ErrNotFound) {
+		return apperror.Validation("invalid or expired token", map[string]string{"token": "is invalid or has expired"})
+	}
+	return err
+}
+
+func (s *Service) ResendVerificationEmail(ctx context.Context, familyID int) error {
+	ctx, span := tracing.Start(ctx, "family.Service.ResendVerificationEmail")
+	defer span.End()
+
+	family, err := s.repo.GetByID(ctx, familyID)
+	if err != nil {
+		return err
+	}
+
+	if family.EmailVerified {
+		return apperror.Conflict("email already verified")
+	}
+
+	return s.sendVerificationEmail(ctx, family)
+}
+
+// RequestPasswordReset emails a reset link when the address belongs to a
+// family account. It succeeds either way so the endpoint cannot be used to
+// discover which emails are registered.
+func (s *Service) RequestPasswordReset(ctx context.Context, req *PasswordResetRequest) error {
+	ctx, span := tracing.Start(ctx, "family.Service.RequestPasswordReset")
+	defer span.End()
+
+	if err := validate.Struct(req); err != nil {
+		return err
+	}
+
+	// Checked before the lookup, and send failures are only logged, so the
+	// response never reveals whether the address has an account.
+	if s.emailService == nil {
+		return fmt.Errorf("email service not configured")
+	}
+
+	family, err := s.repo.GetByEmail(ctx, req.Email)
+	if errors.Is(err, apperror.ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	token, err := s.createAccountToken(ctx, family.ID, TokenPurposePasswordReset, passwordResetTokenTTL)
+	if err != nil {
+		return err
+	}
+
+	if err := s.emailService.SendPasswordResetEmail(ctx, family.Email, token); err != nil {
+		logging.FromContext(ctx).Error("failed to send password reset email", "family_id", family.ID, "error", err)
+	}
+
+	return nil
+}
+
+// ResetPassword sets a new password from a reset token and signs the family
+// out of every device.
+func (s *Service) ResetPassword(ctx context.Context, req *ConfirmPasswordResetRequest) error {
+	ctx, span := tracing.Start(ctx, "family.Service.ResetPassword")
+	defer span.End()
+
+	if err := validate.Struct(req); err != nil {
+		return err
+	}
+
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.NewPassword), bcrypt.DefaultCost)
+	if err != nil {
+		return fmt.Errorf("error hashing password: %w", err)
+	}
+
+	familyID, err := s.repo.ResetPassword(ctx, securetoken.Hash(req.Token), string(hashedPassword))
+	if errors.Is(err, apperror.ErrNotFound) {
+		return apperror.Validation("invalid or expired token", map[string]string{"token": "is invalid or has expired"})
+	}
+	if err != nil {
+		return err
+	}
+
+	return s.tokenService.SignOutAll(ctx, familyID)
+}
+
+// ChangePassword replaces the password after checking the current one and
+// signs the family out of every device, including this one.
+func (s *Service) ChangePassword(ctx context.Context, familyID int, req *ChangePasswordRequest) error {
+	ctx, span := tracing.Start(ctx, "family.Service.ChangePassword")
+	defer span.End()
+
+	if err := validate.Struct(req); err != nil {
+		return err
+	}
+
+	family, err := s.repo.GetByID(ctx, familyID)
+	if err != nil {
+		return err
+	}
+
+	if err := bcrypt.CompareHashAndPassword([]byte(family.Password), []byte(req.CurrentPassword)); err != nil {
+		return apperror.Forbidden("invalid current password")
+	}
 
-	return false, nil
-}
\ No newline at end of file
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.NewPassword), bcrypt.DefaultCost)
+	if err != nil {
+		return fmt.Errorf("error hashing password: %w", err)
+	}
+
+	if err := s.repo.UpdatePassword(ctx, familyID, string(hashedPassword)); err != nil {
+		return err
+	}
+
+	return s.tokenService.SignOutAll(ctx, familyID)
+}
+
+func (s *Service) sendVerificationEmail(ctx context.Context, family *FamilyAccount) error {
+	if s.emailService == nil {
+		return fmt.Errorf("email service not configured")
+	}
+
+	token, err := s.createAccountToken(ctx, family.ID, TokenPurposeVerifyEmail, verifyEmailTokenTTL)
+	if err != nil {
+		return err
+	}
+
+	return s.emailService.SendVerificationEmail(ctx, family.Email, token, family.FamilyName)
+}
+
+func (s *Service) createAccountToken(ctx context.Context, familyID int, purpose AccountTokenPurpose, ttl time.Duration) (string, error) {
+	token, err := securetoken.Generate(32)
+	if err != nil {
+		return "", err
+	}
+
+	err = s.repo.CreateAccountToken(ctx, &AccountToken{
+		FamilyID:  familyID,
+		Purpose:   purpose,
+		TokenHash: securetoken.Hash(token),
+		ExpiresAt: time.Now().UTC().Add(ttl),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return token, nil
+}
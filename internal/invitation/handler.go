@@ -0,0 +1,127 @@
+package invitation
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/chrisabs/cadence/internal/apperror"
+	"github.com/chrisabs/cadence/internal/middleware"
+	"github.com/chrisabs/cadence/internal/models"
+	"github.com/chrisabs/cadence/internal/platform/respond"
+	"github.com/gorilla/mux"
+)
+
+type Handler struct {
+	service        *Service
+	authMiddleware *middleware.AuthMiddleware
+}
+
+func NewHandler(service *Service, authMiddleware *middleware.AuthMiddleware) *Handler {
+	return &Handler{
+		service:        service,
+		authMiddleware: authMiddleware,
+	}
+}
+
+func (h *Handler) RegisterRoutes(router *mux.Router) {
+	router.HandleFunc("/invitations", h.authMiddleware.ProfileAuthHandler(h.authMiddleware.RequireRole(models.RoleParent)(h.handleGetInvitations))).Methods("GET")
+	router.HandleFunc("/invitations", h.authMiddleware.ProfileAuthHandler(h.authMiddleware.RequireRole(models.RoleParent)(h.authMiddleware.RequireVerifiedEmail(h.handleCreateInvitation)))).Methods("POST")
+	router.HandleFunc("/invitations/preview", h.handlePreviewInvitation).Methods("GET")
+	router.HandleFunc("/invitations/accept", h.handleAcceptInvitation).Methods("POST")
+	router.HandleFunc("/invitations/{id}", h.authMiddleware.ProfileAuthHandler(h.authMiddleware.RequireRole(models.RoleParent)(h.handleRevokeInvitation))).Methods("DELETE")
+}
+
+func (h *Handler) handleGetInvitations(w http.ResponseWriter, r *http.Request) {
+	profileCtx, ok := middleware.ProfileFrom(r.Context())
+	if !ok {
+		respond.Error(w, r, apperror.Unauthorized("profile authentication required"))
+		return
+	}
+
+	invitations, err := h.service.GetInvitations(r.Context(), profileCtx.FamilyID)
+	if err != nil {
+		respond.Error(w, r, err)
+		return
+	}
+
+	respond.JSON(w, http.StatusOK, invitations)
+}
+
+func (h *Handler) handleCreateInvitation(w http.ResponseWriter, r *http.Request) {
+	profileCtx, ok := middleware.ProfileFrom(r.Context())
+	if !ok {
+		respond.Error(w, r, apperror.Unauthorized("profile authentication required"))
+		return
+	}
+
+	var req CreateInvitationRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respond.Error(w, r, apperror.BadRequest("invalid request body"))
+		return
+	}
+
+	invitation, err := h.service.CreateInvitation(r.Context(), profileCtx.FamilyID, profileCtx.ProfileID, &req)
+	if err != nil {
+		respond.Error(w, r, err)
+		return
+	}
+
+	respond.JSON(w, http.StatusCreated, invitation)
+}
+
+func (h *Handler) handleRevokeInvitation(w http.ResponseWriter, r *http.Request) {
+	profileCtx, ok := middleware.ProfileFrom(r.Context())
+	if !ok {
+		respond.Error(w, r, apperror.Unauthorized("profile authentication required"))
+		return
+	}
+
+	invitationID, err := getIDFromRequest(r)
+	if err != nil {
+		respond.Error(w, r, err)
+		return
+	}
+
+	if err := h.service.RevokeInvitation(r.Context(), invitationID, profileCtx.FamilyID); err != nil {
+		respond.Error(w, r, err)
+		return
+	}
+
+	respond.JSON(w, http.StatusOK, map[string]int{"revoked": invitationID})
+}
+
+func (h *Handler) handlePreviewInvitation(w http.ResponseWriter, r *http.Request) {
+	preview, err := h.service.PreviewInvitation(r.Context(), r.URL.Query().Get("token"))
+	if err != nil {
+		respond.Error(w, r, err)
+		return
+	}
+
+	respond.JSON(w, http.StatusOK, preview)
+}
+
+func (h *Handler) handleAcceptInvitation(w http.ResponseWriter, r *http.Request) {
+	var req AcceptInvitationRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respond.Error(w, r, apperror.BadRequest("invalid request body"))
+		return
+	}
+
+	profile, err := h.service.AcceptInvitation(r.Context(), &req)
+	if err != nil {
+		respond.Error(w, r, err)
+		return
+	}
+
+	respond.JSON(w, http.StatusCreated, profile)
+}
+
+func getIDFromRequest(r *http.Request) (int, error) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		return 0, apperror.BadRequest("invalid id")
+	}
+	return id, nil
+}
@@ -0,0 +1,71 @@
+package invitation
+
+import (
+	"time"
+
+	"github.com/chrisabs/cadence/internal/models"
+)
+
+type Status string
+
+const (
+	StatusPending  Status = "PENDING"
+	StatusAccepted Status = "ACCEPTED"
+	StatusRevoked  Status = "REVOKED"
+	StatusExpired  Status = "EXPIRED"
+)
+
+// Invitation asks someone to join a family by email. The token sent in the
+// email is never stored, only its hash.
+type Invitation struct {
+	ID                int                `json:"id"`
+	FamilyID          int                `json:"familyId"`
+	Email             string             `json:"email"`
+	Role              models.ProfileRole `json:"role"`
+	TokenHash         string             `json:"-"`
+	InvitedBy         int                `json:"invitedBy"`
+	ExpiresAt         time.Time          `json:"expiresAt"`
+	AcceptedAt        *time.Time         `json:"acceptedAt,omitempty"`
+	AcceptedProfileID *int               `json:"acceptedProfileId,omitempty"`
+	RevokedAt         *time.Time         `json:"revokedAt,omitempty"`
+	Status            Status             `json:"status"`
+	CreatedAt         time.Time          `json:"createdAt"`
+	UpdatedAt         time.Time          `json:"updatedAt"`
+}
+
+func (i *Invitation) setStatus(now time.Time) {
+	switch {
+	case i.AcceptedAt != nil:
+		i.Status = StatusAccepted
+	case i.RevokedAt != nil:
+		i.Status = StatusRevoked
+	case !i.ExpiresAt.After(now):
+		i.Status = StatusExpired
+	default:
+		i.Status = StatusPending
+	}
+}
+
+// CreateInvitationRequest only invites parents. Accepting an invite gives the
+// profile an email and password login, while children stay PIN-only profiles
+// that a parent creates.
+type CreateInvitationRequest struct {
+	Email         string             `json:"email" validate:"required,email,max=255"`
+	Role          models.ProfileRole `json:"role" validate:"required,oneof=PARENT"`
+	ExpiresInDays int                `json:"expiresInDays,omitempty" validate:"omitempty,min=1,max=30"`
+}
+
+type AcceptInvitationRequest struct {
+	Token    string `json:"token" validate:"required"`
+	Name     string `json:"name" validate:"required,max=100"`
+	Password string `json:"password" validate:"required,min=8,max=72"`
+	Pin      string `json:"pin,omitempty" validate:"omitempty,len=6,digits"`
+}
+
+// InvitationPreview is what an invitee sees before accepting.
+type InvitationPreview struct {
+	FamilyName string             `json:"familyName"`
+	Email      string             `json:"email"`
+	Role       models.ProfileRole `json:"role"`
+	ExpiresAt  time.Time          `json:"expiresAt"`
+}
@@ -0,0 +1,199 @@
+package invitation
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/chrisabs/cadence/internal/apperror"
+)
+
+type Repository struct {
+	db *sql.DB
+}
+
+func NewRepository(db *sql.DB) *Repository {
+	return &Repository{db: db}
+}
+
+const invitationColumns = `
+	id, family_id, email, role, token_hash, invited_by, expires_at,
+	accepted_at, accepted_profile_id, revoked_at, created_at, updated_at`
+
+func scanInvitation(row interface{ Scan(dest ...any) error }) (*Invitation, error) {
+	invitation := new(Invitation)
+	err := row.Scan(
+		&invitation.ID,
+		&invitation.FamilyID,
+		&invitation.Email,
+		&invitation.Role,
+		&invitation.TokenHash,
+		&invitation.InvitedBy,
+		&invitation.ExpiresAt,
+		&invitation.AcceptedAt,
+		&invitation.AcceptedProfileID,
+		&invitation.RevokedAt,
+		&invitation.CreatedAt,
+		&invitation.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	invitation.setStatus(time.Now().UTC())
+	return invitation, nil
+}
+
+// Create stores invitation and revokes any pending invitation the family
+// already sent to the same address, so only the latest email works.
+func (r *Repository) Create(ctx context.Context, invitation *Invitation) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("error starting transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	now := time.Now().UTC()
+
+	if _, err := tx.ExecContext(ctx, `
+		UPDATE family_invite
+		SET revoked_at = $3, updated_at = $3
+		WHERE family_id = $1 AND lower(email) = lower($2)
+		  AND accepted_at IS NULL AND revoked_at IS NULL`,
+		invitation.FamilyID, invitation.Email, now); err != nil {
+		return fmt.Errorf("error revoking previous invitations: %w", err)
+	}
+
+	err = tx.QueryRowContext(ctx, `
+		INSERT INTO family_invite (
+			family_id, email, role, token_hash, invited_by, expires_at, created_at, updated_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $7)
+		RETURNING id, created_at, updated_at`,
+		invitation.FamilyID,
+		invitation.Email,
+		invitation.Role,
+		invitation.TokenHash,
+		invitation.InvitedBy,
+		invitation.ExpiresAt,
+		now,
+	).Scan(&invitation.ID, &invitation.CreatedAt, &invitation.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("error creating invitation: %w", err)
+	}
+
+	invitation.setStatus(now)
+	return tx.Commit()
+}
+
+func (r *Repository) GetByFamilyID(ctx context.Context, familyID int) ([]*Invitation, error) {
+	query := `SELECT ` + invitationColumns + `
+		FROM family_invite
+		WHERE family_id = $1
+		ORDER BY created_at DESC`
+
+	rows, err := r.db.QueryContext(ctx, query, familyID)
+	if err != nil {
+		return nil, fmt.Errorf("error querying invitations: %w", err)
+	}
+	defer rows.Close()
+
+	var invitations []*Invitation
+	for rows.Next() {
+		invitation, err := scanInvitation(rows)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning invitation: %w", err)
+		}
+		invitations = append(invitations, invitation)
+	}
+
+	return invitations, nil
+}
+
+func (r *Repository) GetByTokenHash(ctx context.Context, tokenHash string) (*Invitation, error) {
+	query := `SELECT ` + invitationColumns + `
+		FROM family_invite
+		WHERE token_hash = $1`
+
+	invitation, err := scanInvitation(r.db.QueryRowContext(ctx, query, tokenHash))
+	if err == sql.ErrNoRows {
+		return nil, apperror.NotFound("invitation not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error getting invitation: %w", err)
+	}
+
+	return invitation, nil
+}
+
+// Claim marks a pending invitation as accepted so concurrent accepts of the
+// same token cannot both succeed. Release undoes the claim if creating and
+// linking the profile then fails.
+func (r *Repository) Claim(ctx context.Context, tokenHash string) (*Invitation, error) {
+	now := time.Now().UTC()
+
+	query := `
+		UPDATE family_invite
+		SET accepted_at = $2, updated_at = $2
+		WHERE token_hash = $1 AND accepted_at IS NULL AND revoked_at IS NULL AND expires_at > $2
+		RETURNING ` + invitationColumns
+
+	invitation, err := scanInvitation(r.db.QueryRowContext(ctx, query, tokenHash, now))
+	if err == sql.ErrNoRows {
+		return nil, apperror.NotFound("invitation not found or no longer valid")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error claiming invitation: %w", err)
+	}
+
+	return invitation, nil
+}
+
+func (r *Repository) Release(ctx context.Context, id int) error {
+	query := `
+		UPDATE family_invite
+		SET accepted_at = NULL, updated_at = $2
+		WHERE id = $1 AND accepted_profile_id IS NULL`
+
+	if _, err := r.db.ExecContext(ctx, query, id, time.Now().UTC()); err != nil {
+		return fmt.Errorf("error releasing invitation: %w", err)
+	}
+	return nil
+}
+
+// SetAcceptedProfile links the claimed invitation to the profile created for
+// it, inside the transaction that creates the profile.
+func (r *Repository) SetAcceptedProfile(ctx context.Context, tx *sql.Tx, id int, profileID int) error {
+	query := `
+		UPDATE family_invite
+		SET accepted_profile_id = $2, updated_at = $3
+		WHERE id = $1`
+
+	if _, err := tx.ExecContext(ctx, query, id, profileID, time.Now().UTC()); err != nil {
+		return fmt.Errorf("error updating invitation: %w", err)
+	}
+	return nil
+}
+
+func (r *Repository) Revoke(ctx context.Context, id int, familyID int) error {
+	query := `
+		UPDATE family_invite
+		SET revoked_at = $3, updated_at = $3
+		WHERE id = $1 AND family_id = $2 AND accepted_at IS NULL AND revoked_at IS NULL`
+
+	result, err := r.db.ExecContext(ctx, query, id, familyID, time.Now().UTC())
+	if err != nil {
+		return fmt.Errorf("error revoking invitation: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("error checking revoke result: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return apperror.NotFound("invitation not found or no longer pending")
+	}
+
+	return nil
+}
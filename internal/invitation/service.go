@@ -0,0 +1,181 @@
+package invitation
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/chrisabs/cadence/internal/apperror"
+	"github.com/chrisabs/cadence/internal/family"
+	"github.com/chrisabs/cadence/internal/models"
+	"github.com/chrisabs/cadence/internal/platform/logging"
+	"github.com/chrisabs/cadence/internal/platform/securetoken"
+	"github.com/chrisabs/cadence/internal/platform/tracing"
+	"github.com/chrisabs/cadence/internal/platform/validate"
+	"github.com/chrisabs/cadence/internal/profile"
+)
+
+const defaultExpiryDays = 7
+
+type FamilyService interface {
+	GetFamilyByID(ctx context.Context, id int) (*family.FamilyAccount, error)
+}
+
+type ProfileService interface {
+	CreateInvitedProfile(ctx context.Context, familyID int, req *profile.CreateProfileRequest, email string, password string, accept func(ctx context.Context, tx *sql.Tx, profileID int) error) (*models.Profile, error)
+}
+
+type EmailService interface {
+	SendInviteEmail(ctx context.Context, recipientEmail, inviteToken string, familyName string, expiresAt time.Time) error
+}
+
+type Service struct {
+	repo           *Repository
+	familyService  FamilyService
+	profileService ProfileService
+	emailService   EmailService
+}
+
+func NewService(repo *Repository, familyService FamilyService, profileService ProfileService) *Service {
+	return &Service{
+		repo:           repo,
+		familyService:  familyService,
+		profileService: profileService,
+	}
+}
+
+func (s *Service) SetEmailService(emailService EmailService) {
+	s.emailService = emailService
+}
+
+func (s *Service) CreateInvitation(ctx context.Context, familyID int, invitedBy int, req *CreateInvitationRequest) (*Invitation, error) {
+	ctx, span := tracing.Start(ctx, "invitation.Service.CreateInvitation")
+	defer span.End()
+
+	if err := validate.Struct(req); err != nil {
+		return nil, err
+	}
+
+	if s.emailService == nil {
+		return nil, fmt.Errorf("email service not configured")
+	}
+
+	familyAccount, err := s.familyService.GetFamilyByID(ctx, familyID)
+	if err != nil {
+		return nil, err
+	}
+
+	expiryDays := req.ExpiresInDays
+	if expiryDays == 0 {
+		expiryDays = defaultExpiryDays
+	}
+
+	token, err := securetoken.Generate(32)
+	if err != nil {
+		return nil, err
+	}
+
+	invitation := &Invitation{
+		FamilyID:  familyID,
+		Email:     strings.ToLower(req.Email),
+		Role:      req.Role,
+		TokenHash: securetoken.Hash(token),
+		InvitedBy: invitedBy,
+		ExpiresAt: time.Now().UTC().AddDate(0, 0, expiryDays),
+	}
+
+	if err := s.repo.Create(ctx, invitation); err != nil {
+		return nil, err
+	}
+
+	if err := s.emailService.SendInviteEmail(ctx, invitation.Email, token, familyAccount.FamilyName, invitation.ExpiresAt); err != nil {
+		if revokeErr := s.repo.Revoke(ctx, invitation.ID, familyID); revokeErr != nil {
+			logging.FromContext(ctx).Error("failed to revoke unsent invitation", "invitation_id", invitation.ID, "error", revokeErr)
+		}
+		return nil, fmt.Errorf("failed to send invitation: %w", err)
+	}
+
+	return invitation, nil
+}
+
+func (s *Service) GetInvitations(ctx context.Context, familyID int) ([]*Invitation, error) {
+	ctx, span := tracing.Start(ctx, "invitation.Service.GetInvitations")
+	defer span.End()
+
+	return s.repo.GetByFamilyID(ctx, familyID)
+}
+
+func (s *Service) RevokeInvitation(ctx context.Context, id int, familyID int) error {
+	ctx, span := tracing.Start(ctx, "invitation.Service.RevokeInvitation")
+	defer span.End()
+
+	return s.repo.Revoke(ctx, id, familyID)
+}
+
+// PreviewInvitation returns the details shown on the accept screen. Only
+// pending invitations can be previewed.
+func (s *Service) PreviewInvitation(ctx context.Context, token string) (*InvitationPreview, error) {
+	ctx, span := tracing.Start(ctx, "invitation.Service.PreviewInvitation")
+	defer span.End()
+
+	if token == "" {
+		return nil, apperror.Validation("validation failed", map[string]string{"token": "is required"})
+	}
+
+	invitation, err := s.repo.GetByTokenHash(ctx, securetoken.Hash(token))
+	if err != nil {
+		return nil, err
+	}
+
+	if invitation.Status != StatusPending {
+		return nil, apperror.NotFound("invitation not found or no longer valid")
+	}
+
+	familyAccount, err := s.familyService.GetFamilyByID(ctx, invitation.FamilyID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &InvitationPreview{
+		FamilyName: familyAccount.FamilyName,
+		Email:      invitation.Email,
+		Role:       invitation.Role,
+		ExpiresAt:  invitation.ExpiresAt,
+	}, nil
+}
+
+// AcceptInvitation redeems an invitation token and creates the invitee's
+// profile in the family, with the invited email and chosen password as its
+// own login.
+func (s *Service) AcceptInvitation(ctx context.Context, req *AcceptInvitationRequest) (*models.Profile, error) {
+	ctx, span := tracing.Start(ctx, "invitation.Service.AcceptInvitation")
+	defer span.End()
+
+	if err := validate.Struct(req); err != nil {
+		return nil, err
+	}
+
+	invitation, err := s.repo.Claim(ctx, securetoken.Hash(req.Token))
+	if err != nil {
+		return nil, err
+	}
+
+	newProfile, err := s.profileService.CreateInvitedProfile(ctx, invitation.FamilyID, &profile.CreateProfileRequest{
+		Name: req.Name,
+		Role: invitation.Role,
+		Pin:  req.Pin,
+	}, invitation.Email, req.Password, func(ctx context.Context, tx *sql.Tx, profileID int) error {
+		return s.repo.SetAcceptedProfile(ctx, tx, invitation.ID, profileID)
+	})
+	if err != nil {
+		if releaseErr := s.repo.Release(ctx, invitation.ID); releaseErr != nil {
+			return nil, errors.Join(err, releaseErr)
+		}
+		return nil, err
+	}
+
+	return newProfile, nil
+}
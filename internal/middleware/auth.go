@@ -6,8 +6,11 @@ import (
 	"fmt"
 	"net/http"
 	"strings"
+	"time"
 
+	"github.com/chrisabs/cadence/internal/apperror"
 	"github.com/chrisabs/cadence/internal/models"
+	"github.com/chrisabs/cadence/internal/platform/respond"
 	"github.com/golang-jwt/jwt"
 )
 
@@ -15,11 +18,15 @@ type AuthMiddleware struct {
 	jwtSecret       string
 	db              *sql.DB
 	familyService   interface {
-		IsModuleEnabled(familyID int, moduleID models.ModuleID) (bool, error)
-		HasModulePermission(familyID int, role models.ProfileRole, moduleID models.ModuleID, permission models.Permission) (bool, error)
+		IsModuleEnabled(ctx context.Context, familyID int, moduleID models.ModuleID) (bool, error)
+		HasModulePermission(ctx context.Context, familyID int, profileID int, role models.ProfileRole, moduleID models.ModuleID, permission models.Permission) (bool, error)
+		IsEmailVerified(ctx context.Context, familyID int) (bool, error)
 	}
 	profileService interface {
-		GetProfileByID(id int) (*models.Profile, error)
+		GetProfileByID(ctx context.Context, id int) (*models.Profile, error)
+	}
+	tokenService interface {
+		IsTokenRevoked(ctx context.Context, jti string, sessionID string, familyID int, issuedAt time.Time) (bool, error)
 	}
 }
 
@@ -27,11 +34,15 @@ func NewAuthMiddleware(
 	jwtSecret string,
 	db *sql.DB,
 	familyService interface {
-		IsModuleEnabled(familyID int, moduleID models.ModuleID) (bool, error)
-		HasModulePermission(familyID int, role models.ProfileRole, moduleID models.ModuleID, permission models.Permission) (bool, error)
+		IsModuleEnabled(ctx context.Context, familyID int, moduleID models.ModuleID) (bool, error)
+		HasModulePermission(ctx context.Context, familyID int, profileID int, role models.ProfileRole, moduleID models.ModuleID, permission models.Permission) (bool, error)
+		IsEmailVerified(ctx context.Context, familyID int) (bool, error)
 	},
 	profileService interface {
-		GetProfileByID(id int) (*models.Profile, error)
+		GetProfileByID(ctx context.Context, id int) (*models.Profile, error)
+	},
+	tokenService interface {
+		IsTokenRevoked(ctx context.Context, jti string, sessionID string, familyID int, issuedAt time.Time) (bool, error)
 	},
 ) *AuthMiddleware {
 	return &AuthMiddleware{
@@ -39,17 +50,44 @@ func NewAuthMiddleware(
 		db:             db,
 		familyService:  familyService,
 		profileService: profileService,
+		tokenService:   tokenService,
 	}
 }
 
+// checkRevoked rejects tokens without an ID, tokens revoked on logout, tokens
+// of revoked device sessions and tokens issued before the family signed out of
+// all devices.
+func (m *AuthMiddleware) checkRevoked(ctx context.Context, claims jwt.MapClaims, familyID int) error {
+	jti, ok := claims["jti"].(string)
+	if !ok || jti == "" {
+		return apperror.Unauthorized("invalid token")
+	}
+
+	issuedAt, _ := claims["iat"].(float64)
+	sessionID, _ := claims["sid"].(string)
+
+	revoked, err := m.tokenService.IsTokenRevoked(ctx, jti, sessionID, familyID, time.Unix(int64(issuedAt), 0).UTC())
+	if err != nil {
+		return fmt.Errorf("error checking token revocation: %w", err)
+	}
+	if revoked {
+		return apperror.Unauthorized("token has been revoked")
+	}
+
+	return nil
+}
+
 func (m *AuthMiddleware) buildFamilyContext(claims jwt.MapClaims) (*models.FamilyContext, error) {
 	familyID, ok := claims["familyId"].(float64)
 	if !ok {
 		return nil, fmt.Errorf("invalid token: missing family ID")
 	}
 
+	sessionID, _ := claims["sid"].(string)
+
 	return &models.FamilyContext{
-		FamilyID: int(familyID),
+		FamilyID:  int(familyID),
+		SessionID: sessionID,
 	}, nil
 }
 
@@ -70,26 +108,31 @@ func (m *AuthMiddleware) buildProfileContext(claims jwt.MapClaims) (*models.Prof
 	}
 
 	isOwner, _ := claims["isOwner"].(bool)
+	sessionID, _ := claims["sid"].(string)
 
 	return &models.ProfileContext{
 		FamilyID:  int(familyID),
 		ProfileID: int(profileID),
 		Role:      models.ProfileRole(roleString),
 		IsOwner:   isOwner,
+		SessionID: sessionID,
 	}, nil
 }
 
+// FamilyAuthHandler accepts both family tokens and profile tokens. Profile
+// tokens carry the family ID, so an adult signed in with their own login can
+// use family-level routes such as switching to a child's profile.
 func (m *AuthMiddleware) FamilyAuthHandler(next http.HandlerFunc) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		authHeader := r.Header.Get("Authorization")
 		if authHeader == "" {
-			http.Error(w, "Authorization header required", http.StatusUnauthorized)
+			respond.Error(w, r, apperror.Unauthorized("authorization header required"))
 			return
 		}
 
 		bearerToken := strings.Split(authHeader, " ")
 		if len(bearerToken) != 2 || bearerToken[0] != "Bearer" {
-			http.Error(w, "Invalid authorization format", http.StatusUnauthorized)
+			respond.Error(w, r, apperror.Unauthorized("invalid authorization format"))
 			return
 		}
 
@@ -103,17 +146,23 @@ func (m *AuthMiddleware) FamilyAuthHandler(next http.HandlerFunc) http.HandlerFu
 		})
 
 		if err != nil || !token.Valid {
-			http.Error(w, "Invalid token", http.StatusUnauthorized)
+			respond.Error(w, r, apperror.Unauthorized("invalid token"))
 			return
 		}
 
 		familyCtx, err := m.buildFamilyContext(claims)
 		if err != nil {
-			http.Error(w, "Invalid family token", http.StatusUnauthorized)
+			respond.Error(w, r, apperror.Unauthorized("invalid family token"))
+			return
+		}
+
+		if err := m.checkRevoked(r.Context(), claims, familyCtx.FamilyID); err != nil {
+			respond.Error(w, r, err)
 			return
 		}
 
-		ctx := context.WithValue(r.Context(), "family", familyCtx)
+		ctx := withAuthLogging(r.Context(), familyCtx.FamilyID, 0)
+		ctx = withFamily(ctx, familyCtx)
 		next(w, r.WithContext(ctx))
 	}
 }
@@ -122,13 +171,13 @@ func (m *AuthMiddleware) ProfileAuthHandler(next http.HandlerFunc) http.HandlerF
 	return func(w http.ResponseWriter, r *http.Request) {
 		authHeader := r.Header.Get("Authorization")
 		if authHeader == "" {
-			http.Error(w, "Authorization header required", http.StatusUnauthorized)
+			respond.Error(w, r, apperror.Unauthorized("authorization header required"))
 			return
 		}
 
 		bearerToken := strings.Split(authHeader, " ")
 		if len(bearerToken) != 2 || bearerToken[0] != "Bearer" {
-			http.Error(w, "Invalid authorization format", http.StatusUnauthorized)
+			respond.Error(w, r, apperror.Unauthorized("invalid authorization format"))
 			return
 		}
 
@@ -142,17 +191,23 @@ func (m *AuthMiddleware) ProfileAuthHandler(next http.HandlerFunc) http.HandlerF
 		})
 
 		if err != nil || !token.Valid {
-			http.Error(w, "Invalid token", http.StatusUnauthorized)
+			respond.Error(w, r, apperror.Unauthorized("invalid token"))
 			return
 		}
 
 		profileCtx, err := m.buildProfileContext(claims)
 		if err != nil {
-			http.Error(w, "Invalid profile token", http.StatusUnauthorized)
+			respond.Error(w, r, apperror.Unauthorized("invalid profile token"))
 			return
 		}
 
-		ctx := context.WithValue(r.Context(), "profile", profileCtx)
+		if err := m.checkRevoked(r.Context(), claims, profileCtx.FamilyID); err != nil {
+			respond.Error(w, r, err)
+			return
+		}
+
+		ctx := withAuthLogging(r.Context(), profileCtx.FamilyID, profileCtx.ProfileID)
+		ctx = withProfile(ctx, profileCtx)
 		next(w, r.WithContext(ctx))
 	}
 }
@@ -160,20 +215,120 @@ func (m *AuthMiddleware) ProfileAuthHandler(next http.HandlerFunc) http.HandlerF
 func (m *AuthMiddleware) ModuleMiddleware(moduleID models.ModuleID, permission models.Permission) func(http.HandlerFunc) http.HandlerFunc {
 	return func(next http.HandlerFunc) http.HandlerFunc {
 		return m.ProfileAuthHandler(func(w http.ResponseWriter, r *http.Request) {
-			profileCtx := r.Context().Value("profile").(*models.ProfileContext)
+			if err := m.CheckModulePermission(r.Context(), moduleID, permission); err != nil {
+				respond.Error(w, r, err)
+				return
+			}
+
+			// Unverified families can still browse, but not change module data.
+			if permission != models.PermissionRead && r.Method != http.MethodGet {
+				profileCtx, _ := ProfileFrom(r.Context())
+				if err := m.checkVerifiedEmail(r.Context(), profileCtx.FamilyID); err != nil {
+					respond.Error(w, r, err)
+					return
+				}
+			}
+
+			next(w, r)
+		})
+	}
+}
+
+// CheckModulePermission reports whether the authenticated profile holds
+// permission on the module. Handlers use it when the permission needed
+// depends on the request body, after a ModuleMiddleware has checked the
+// baseline.
+func (m *AuthMiddleware) CheckModulePermission(ctx context.Context, moduleID models.ModuleID, permission models.Permission) error {
+	profileCtx, ok := ProfileFrom(ctx)
+	if !ok {
+		return apperror.Unauthorized("profile authentication required")
+	}
+
+	hasPermission, err := m.familyService.HasModulePermission(ctx, profileCtx.FamilyID, profileCtx.ProfileID, profileCtx.Role, moduleID, permission)
+	if err != nil {
+		return fmt.Errorf("error checking permissions: %w", err)
+	}
+
+	if !hasPermission {
+		return apperror.Forbidden("access denied: insufficient permissions")
+	}
+
+	return nil
+}
+
+// RequireVerifiedEmail restricts a route to families that have verified their
+// email. It must be wrapped by FamilyAuthHandler or ProfileAuthHandler.
+// ModuleMiddleware applies the same check to module writes.
+func (m *AuthMiddleware) RequireVerifiedEmail(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var familyID int
+		if profileCtx, ok := ProfileFrom(r.Context()); ok {
+			familyID = profileCtx.FamilyID
+		} else if familyCtx, ok := FamilyFrom(r.Context()); ok {
+			familyID = familyCtx.FamilyID
+		} else {
+			respond.Error(w, r, apperror.Unauthorized("authentication required"))
+			return
+		}
+
+		if err := m.checkVerifiedEmail(r.Context(), familyID); err != nil {
+			respond.Error(w, r, err)
+			return
+		}
+
+		next(w, r)
+	}
+}
+
+func (m *AuthMiddleware) checkVerifiedEmail(ctx context.Context, familyID int) error {
+	verified, err := m.familyService.IsEmailVerified(ctx, familyID)
+	if err != nil {
+		return fmt.Errorf("error checking email verification: %w", err)
+	}
+
+	if !verified {
+		return apperror.Forbidden("email address must be verified")
+	}
 
-			hasPermission, err := m.familyService.HasModulePermission(profileCtx.FamilyID, profileCtx.Role, moduleID, permission)
-			if err != nil {
-				http.Error(w, "Error checking permissions", http.StatusInternalServerError)
+	return nil
+}
+
+// RequireRole restricts a route to profiles with the given role. It must be
+// wrapped by ProfileAuthHandler.
+func (m *AuthMiddleware) RequireRole(role models.ProfileRole) func(http.HandlerFunc) http.HandlerFunc {
+	return func(next http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			profileCtx, ok := ProfileFrom(r.Context())
+			if !ok {
+				respond.Error(w, r, apperror.Unauthorized("profile authentication required"))
 				return
 			}
 
-			if !hasPermission {
-				http.Error(w, "Access denied: Insufficient permissions", http.StatusForbidden)
+			if profileCtx.Role != role {
+				respond.Error(w, r, apperror.Forbidden(fmt.Sprintf("access denied: %s profile required", strings.ToLower(string(role)))))
 				return
 			}
 
 			next(w, r)
-		})
+		}
+	}
+}
+
+// RequireOwner restricts a route to the family owner's profile. It must be
+// wrapped by ProfileAuthHandler.
+func (m *AuthMiddleware) RequireOwner(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		profileCtx, ok := ProfileFrom(r.Context())
+		if !ok {
+			respond.Error(w, r, apperror.Unauthorized("profile authentication required"))
+			return
+		}
+
+		if !profileCtx.IsOwner {
+			respond.Error(w, r, apperror.Forbidden("access denied: family owner required"))
+			return
+		}
+
+		next(w, r)
 	}
-}
\ No newline at end of file
+}
@@ -0,0 +1,33 @@
+package middleware
+
+import (
+	"context"
+
+	"github.com/chrisabs/cadence/internal/models"
+)
+
+type profileContextKey struct{}
+
+type familyContextKey struct{}
+
+// ProfileFrom returns the profile set by ProfileAuthHandler. ok is false when
+// the route was not wrapped by it.
+func ProfileFrom(ctx context.Context) (*models.ProfileContext, bool) {
+	profileCtx, ok := ctx.Value(profileContextKey{}).(*models.ProfileContext)
+	return profileCtx, ok && profileCtx != nil
+}
+
+// FamilyFrom returns the family set by FamilyAuthHandler. ok is false when the
+// route was not wrapped by it.
+func FamilyFrom(ctx context.Context) (*models.FamilyContext, bool) {
+	familyCtx, ok := ctx.Value(familyContextKey{}).(*models.FamilyContext)
+	return familyCtx, ok && familyCtx != nil
+}
+
+func withProfile(ctx context.Context, profileCtx *models.ProfileContext) context.Context {
+	return context.WithValue(ctx, profileContextKey{}, profileCtx)
+}
+
+func withFamily(ctx context.Context, familyCtx *models.FamilyContext) context.Context {
+	return context.WithValue(ctx, familyContextKey{}, familyCtx)
+}
@@ -0,0 +1,194 @@
+package middleware
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"runtime/debug"
+	"time"
+
+	"github.com/chrisabs/cadence/internal/platform/logging"
+	"github.com/chrisabs/cadence/internal/platform/respond"
+	"github.com/gorilla/mux"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const RequestIDHeader = "X-Request-ID"
+
+type requestInfoKey struct{}
+
+// requestInfo is shared by pointer so handlers further down the chain, which
+// run on a derived context, can report the matched route, the trace and who
+// made the request back to the access log and metrics around the router.
+type requestInfo struct {
+	route     string
+	traceID   string
+	familyID  int
+	profileID int
+}
+
+// withRequestInfo returns the request's shared info, attaching a new one if
+// no outer middleware has yet.
+func withRequestInfo(r *http.Request) (*requestInfo, *http.Request) {
+	if info, ok := r.Context().Value(requestInfoKey{}).(*requestInfo); ok {
+		return info, r
+	}
+	info := &requestInfo{}
+	return info, r.WithContext(context.WithValue(r.Context(), requestInfoKey{}, info))
+}
+
+type statusRecorder struct {
+	http.ResponseWriter
+	status      int
+	bytes       int
+	wroteHeader bool
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	if !r.wroteHeader {
+		r.status = status
+		r.wroteHeader = true
+	}
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *statusRecorder) Write(b []byte) (int, error) {
+	if !r.wroteHeader {
+		r.WriteHeader(http.StatusOK)
+	}
+	n, err := r.ResponseWriter.Write(b)
+	r.bytes += n
+	return n, err
+}
+
+func RequestID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestID := r.Header.Get(RequestIDHeader)
+		if requestID == "" || len(requestID) > 128 {
+			requestID = newRequestID()
+		}
+
+		w.Header().Set(RequestIDHeader, requestID)
+
+		ctx := logging.WithRequestID(r.Context(), requestID)
+		ctx = logging.WithLogger(ctx, logging.FromContext(ctx).With("request_id", requestID))
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// MatchedRoute runs inside the router, after tracing has started, and records
+// the route template and trace ID for the middleware wrapped around the
+// router, which only sees the request before it is matched.
+func MatchedRoute(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		info, r := withRequestInfo(r)
+
+		if current := mux.CurrentRoute(r); current != nil {
+			if template, err := current.GetPathTemplate(); err == nil {
+				info.route = template
+			}
+		}
+
+		if spanCtx := trace.SpanContextFromContext(r.Context()); spanCtx.IsValid() {
+			info.traceID = spanCtx.TraceID().String()
+			ctx := logging.WithLogger(r.Context(), logging.FromContext(r.Context()).With("trace_id", info.traceID))
+			r = r.WithContext(ctx)
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+func RequestLogger(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		info, r := withRequestInfo(r)
+		recorder := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+
+		next.ServeHTTP(recorder, r)
+
+		attrs := []any{
+			"method", r.Method,
+			"path", r.URL.Path,
+			"status", recorder.status,
+			"bytes", recorder.bytes,
+			"latency_ms", time.Since(start).Milliseconds(),
+			"remote_addr", r.RemoteAddr,
+		}
+		if info.route != "" {
+			attrs = append(attrs, "route", info.route)
+		}
+		if info.traceID != "" {
+			attrs = append(attrs, "trace_id", info.traceID)
+		}
+		if info.familyID != 0 {
+			attrs = append(attrs, "family_id", info.familyID)
+		}
+		if info.profileID != 0 {
+			attrs = append(attrs, "profile_id", info.profileID)
+		}
+
+		logger := logging.FromContext(r.Context())
+		switch {
+		case recorder.status >= http.StatusInternalServerError:
+			logger.Error("request completed", attrs...)
+		case recorder.status >= http.StatusBadRequest:
+			logger.Warn("request completed", attrs...)
+		default:
+			logger.Info("request completed", attrs...)
+		}
+	})
+}
+
+func Recoverer(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		recorder, ok := w.(*statusRecorder)
+		if !ok {
+			recorder = &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		}
+
+		defer func() {
+			if rec := recover(); rec != nil {
+				if rec == http.ErrAbortHandler {
+					panic(rec)
+				}
+
+				logging.FromContext(r.Context()).Error("panic recovered",
+					"panic", rec,
+					"stack", string(debug.Stack()),
+				)
+
+				if !recorder.wroteHeader {
+					respond.Error(recorder, r, fmt.Errorf("panic: %v", rec))
+				}
+			}
+		}()
+
+		next.ServeHTTP(recorder, r)
+	})
+}
+
+// withAuthLogging records the authenticated family and profile for the request
+// log and attaches them to the contextual logger used by services.
+func withAuthLogging(ctx context.Context, familyID int, profileID int) context.Context {
+	if info, ok := ctx.Value(requestInfoKey{}).(*requestInfo); ok {
+		info.familyID = familyID
+		info.profileID = profileID
+	}
+
+	logger := logging.FromContext(ctx).With("family_id", familyID)
+	if profileID != 0 {
+		logger = logger.With("profile_id", profileID)
+	}
+	return logging.WithLogger(ctx, logger)
+}
+
+func newRequestID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return time.Now().UTC().Format("20060102150405.000000000")
+	}
+	return hex.EncodeToString(b)
+}
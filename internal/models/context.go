@@ -1,7 +1,8 @@
 package models
 
 type FamilyContext struct {
-	FamilyID int `json:"familyId"`
+	FamilyID  int    `json:"familyId"`
+	SessionID string `json:"sessionId"`
 }
 
 type ProfileContext struct {
@@ -9,4 +10,5 @@ type ProfileContext struct {
 	ProfileID int         `json:"profileId"`
 	Role      ProfileRole `json:"role"`
 	IsOwner   bool        `json:"isOwner"`
+	SessionID string      `json:"sessionId"`
 }
\ No newline at end of file
@@ -16,6 +16,10 @@ type Profile struct {
 	Role      ProfileRole        `json:"role"`
 	Pin       string             `json:"-"` 
 	HasPin    bool               `json:"hasPin"`
+	PinFailedAttempts int        `json:"-"`
+	PinLockedUntil    *time.Time `json:"pinLockedUntil,omitempty"`
+	Email     string             `json:"email,omitempty"`
+	Password  string             `json:"-"`
 	ImageURL  string             `json:"imageUrl"`
 	IsOwner   bool               `json:"isOwner"`
 	CreatedAt time.Time          `json:"createdAt"`
@@ -0,0 +1,76 @@
+package notification
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/chrisabs/cadence/internal/apperror"
+	"github.com/chrisabs/cadence/internal/middleware"
+	"github.com/chrisabs/cadence/internal/platform/respond"
+	"github.com/gorilla/mux"
+)
+
+type Handler struct {
+	service        *Service
+	authMiddleware *middleware.AuthMiddleware
+}
+
+func NewHandler(service *Service, authMiddleware *middleware.AuthMiddleware) *Handler {
+	return &Handler{
+		service:        service,
+		authMiddleware: authMiddleware,
+	}
+}
+
+func (h *Handler) RegisterRoutes(router *mux.Router) {
+	router.HandleFunc("/notifications", h.authMiddleware.ProfileAuthHandler(h.handleGetNotifications)).Methods("GET")
+	router.HandleFunc("/notifications/{id}/read", h.authMiddleware.ProfileAuthHandler(h.handleMarkRead)).Methods("PUT")
+}
+
+func (h *Handler) handleGetNotifications(w http.ResponseWriter, r *http.Request) {
+	profileCtx, ok := middleware.ProfileFrom(r.Context())
+	if !ok {
+		respond.Error(w, r, apperror.Unauthorized("profile authentication required"))
+		return
+	}
+
+	unreadOnly := r.URL.Query().Get("unread") == "true"
+
+	notifications, err := h.service.GetNotifications(r.Context(), profileCtx.ProfileID, profileCtx.FamilyID, unreadOnly)
+	if err != nil {
+		respond.Error(w, r, err)
+		return
+	}
+
+	respond.JSON(w, http.StatusOK, notifications)
+}
+
+func (h *Handler) handleMarkRead(w http.ResponseWriter, r *http.Request) {
+	profileCtx, ok := middleware.ProfileFrom(r.Context())
+	if !ok {
+		respond.Error(w, r, apperror.Unauthorized("profile authentication required"))
+		return
+	}
+
+	id, err := getIDFromRequest(r)
+	if err != nil {
+		respond.Error(w, r, err)
+		return
+	}
+
+	if err := h.service.MarkRead(r.Context(), id, profileCtx.ProfileID, profileCtx.FamilyID); err != nil {
+		respond.Error(w, r, err)
+		return
+	}
+
+	respond.JSON(w, http.StatusOK, map[string]int{"read": id})
+}
+
+func getIDFromRequest(r *http.Request) (int, error) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		return 0, apperror.BadRequest("invalid id")
+	}
+	return id, nil
+}
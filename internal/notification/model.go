@@ -0,0 +1,22 @@
+package notification
+
+import "time"
+
+type Type string
+
+const (
+	TypePinLocked Type = "PIN_LOCKED"
+	TypeLowStock  Type = "LOW_STOCK"
+)
+
+type Notification struct {
+	ID        int       `json:"id"`
+	ProfileID int       `json:"profileId"`
+	FamilyID  int       `json:"familyId"`
+	Title     string    `json:"title"`
+	Message   string    `json:"message"`
+	Type      Type      `json:"type"`
+	SourceID  *int      `json:"sourceId,omitempty"`
+	IsRead    bool      `json:"isRead"`
+	CreatedAt time.Time `json:"createdAt"`
+}
@@ -0,0 +1,111 @@
+package notification
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/chrisabs/cadence/internal/apperror"
+)
+
+type Repository struct {
+	db *sql.DB
+}
+
+func NewRepository(db *sql.DB) *Repository {
+	return &Repository{db: db}
+}
+
+func (r *Repository) Create(ctx context.Context, notification *Notification) error {
+	query := `
+		INSERT INTO notification (
+			profile_id, family_id, title, message, type, source_id, created_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING id, created_at`
+
+	err := r.db.QueryRowContext(ctx,
+		query,
+		notification.ProfileID,
+		notification.FamilyID,
+		notification.Title,
+		notification.Message,
+		notification.Type,
+		notification.SourceID,
+		time.Now().UTC(),
+	).Scan(&notification.ID, &notification.CreatedAt)
+
+	if err != nil {
+		return fmt.Errorf("error creating notification: %w", err)
+	}
+
+	return nil
+}
+
+func (r *Repository) GetByProfileID(ctx context.Context, profileID int, familyID int, unreadOnly bool) ([]*Notification, error) {
+	query := `
+		SELECT id, profile_id, family_id, title, message, type, source_id, is_read, created_at
+		FROM notification
+		WHERE profile_id = $1 AND family_id = $2 AND is_deleted = false
+		  AND ($3 = false OR is_read = false)
+		ORDER BY created_at DESC
+		LIMIT 100`
+
+	rows, err := r.db.QueryContext(ctx, query, profileID, familyID, unreadOnly)
+	if err != nil {
+		return nil, fmt.Errorf("error querying notifications: %w", err)
+	}
+	defer rows.Close()
+
+	notifications := make([]*Notification, 0)
+	for rows.Next() {
+		notification := new(Notification)
+		var sourceID sql.NullInt64
+		err := rows.Scan(
+			&notification.ID,
+			&notification.ProfileID,
+			&notification.FamilyID,
+			&notification.Title,
+			&notification.Message,
+			&notification.Type,
+			&sourceID,
+			&notification.IsRead,
+			&notification.CreatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning notification: %w", err)
+		}
+
+		if sourceID.Valid {
+			id := int(sourceID.Int64)
+			notification.SourceID = &id
+		}
+
+		notifications = append(notifications, notification)
+	}
+
+	return notifications, rows.Err()
+}
+
+func (r *Repository) MarkRead(ctx context.Context, id int, profileID int, familyID int) error {
+	query := `
+		UPDATE notification
+		SET is_read = true
+		WHERE id = $1 AND profile_id = $2 AND family_id = $3 AND is_deleted = false`
+
+	result, err := r.db.ExecContext(ctx, query, id, profileID, familyID)
+	if err != nil {
+		return fmt.Errorf("error marking notification as read: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("error checking update result: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return apperror.NotFound("notification not found")
+	}
+
+	return nil
+}
@@ -0,0 +1,40 @@
+package notification
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/chrisabs/cadence/internal/platform/tracing"
+)
+
+type Service struct {
+	repo *Repository
+}
+
+func NewService(repo *Repository) *Service {
+	return &Service{repo: repo}
+}
+
+func (s *Service) Notify(ctx context.Context, notification *Notification) error {
+	ctx, span := tracing.Start(ctx, "notification.Service.Notify")
+	defer span.End()
+
+	if err := s.repo.Create(ctx, notification); err != nil {
+		return fmt.Errorf("failed to create notification: %w", err)
+	}
+	return nil
+}
+
+func (s *Service) GetNotifications(ctx context.Context, profileID int, familyID int, unreadOnly bool) ([]*Notification, error) {
+	ctx, span := tracing.Start(ctx, "notification.Service.GetNotifications")
+	defer span.End()
+
+	return s.repo.GetByProfileID(ctx, profileID, familyID, unreadOnly)
+}
+
+func (s *Service) MarkRead(ctx context.Context, id int, profileID int, familyID int) error {
+	ctx, span := tracing.Start(ctx, "notification.Service.MarkRead")
+	defer span.End()
+
+	return s.repo.MarkRead(ctx, id, profileID, familyID)
+}
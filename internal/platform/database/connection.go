@@ -5,7 +5,9 @@ import (
 	"fmt"
 	"os"
 
+	"github.com/XSAM/otelsql"
 	_ "github.com/lib/pq"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
 
 	"github.com/chrisabs/cadence/internal/platform/database/migrations"
 )
@@ -28,7 +30,10 @@ func NewPostgresDB() (*PostgresDB, error) {
         username, password,
     )
     
-    db, err := sql.Open("postgres", connStr)
+    db, err := otelsql.Open("postgres", connStr,
+        otelsql.WithAttributes(semconv.DBSystemPostgreSQL),
+        otelsql.WithSpanOptions(otelsql.SpanOptions{OmitConnResetSession: true, OmitRows: true}),
+    )
     if err != nil {
         return nil, fmt.Errorf("error connecting to database: %v", err)
     }
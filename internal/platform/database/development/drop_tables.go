@@ -3,6 +3,7 @@ package development
 import (
 	"database/sql"
 	"fmt"
+	"log/slog"
 )
 
 func DropAllTables(db *sql.DB) error {
@@ -11,10 +12,7 @@ func DropAllTables(db *sql.DB) error {
         return err
     }
 
-    fmt.Println("Current tables before dropping:")
-    for _, table := range existingTables {
-        fmt.Printf("- %s\n", table)
-    }
+    slog.Info("current tables before dropping", "tables", existingTables)
 
     dropServiceModuleTables := `
         DROP TABLE IF EXISTS service_payment CASCADE;
@@ -35,15 +33,23 @@ func DropAllTables(db *sql.DB) error {
     `
 
     dropStorageModuleTables := `
+        DROP TABLE IF EXISTS item_event CASCADE;
         DROP TABLE IF EXISTS item_tag CASCADE;
         DROP TABLE IF EXISTS item_image CASCADE;
         DROP TABLE IF EXISTS item CASCADE;
         DROP TABLE IF EXISTS tag CASCADE;
+        DROP TABLE IF EXISTS container_sequence CASCADE;
         DROP TABLE IF EXISTS container CASCADE;
         DROP TABLE IF EXISTS workspace CASCADE;
+        DROP SEQUENCE IF EXISTS deletion_batch_seq;
     `
 
     dropCoreTables := `
+        DROP TABLE IF EXISTS family_export CASCADE;
+        DROP TABLE IF EXISTS family_account_token CASCADE;
+        DROP TABLE IF EXISTS device_session CASCADE;
+        DROP TABLE IF EXISTS revoked_token CASCADE;
+        DROP TABLE IF EXISTS refresh_token CASCADE;
         DROP TABLE IF EXISTS notification CASCADE;
         DROP TABLE IF EXISTS calendar_event CASCADE;
         DROP TABLE IF EXISTS family_invite CASCADE;
@@ -52,27 +58,27 @@ func DropAllTables(db *sql.DB) error {
         DROP TABLE IF EXISTS profiles CASCADE;
     `
 
-    fmt.Println("Dropping service module tables...")
+    slog.Info("dropping service module tables")
     if _, err := db.Exec(dropServiceModuleTables); err != nil {
         return fmt.Errorf("error dropping service module tables: %v", err)
     }
 
-    fmt.Println("Dropping meals module tables...")
+    slog.Info("dropping meals module tables")
     if _, err := db.Exec(dropMealsModuleTables); err != nil {
         return fmt.Errorf("error dropping meals module tables: %v", err)
     }
 
-    fmt.Println("Dropping chores module tables...")
+    slog.Info("dropping chores module tables")
     if _, err := db.Exec(dropChoresModuleTables); err != nil {
         return fmt.Errorf("error dropping chores module tables: %v", err)
     }
 
-    fmt.Println("Dropping storage module tables...")
+    slog.Info("dropping storage module tables")
     if _, err := db.Exec(dropStorageModuleTables); err != nil {
         return fmt.Errorf("error dropping storage module tables: %v", err)
     }
 
-    fmt.Println("Dropping core tables...")
+    slog.Info("dropping core tables")
     if _, err := db.Exec(dropCoreTables); err != nil {
         return fmt.Errorf("error dropping core tables: %v", err)
     }
@@ -83,9 +89,8 @@ func DropAllTables(db *sql.DB) error {
     }
 
     if len(remainingTables) > 0 {
-        fmt.Println("Dropping remaining tables:")
+        slog.Info("dropping remaining tables", "tables", remainingTables)
         for _, table := range remainingTables {
-            fmt.Printf("- %s\n", table)
             if _, err := db.Exec(fmt.Sprintf("DROP TABLE IF EXISTS %s CASCADE;", table)); err != nil {
                 return fmt.Errorf("error dropping table %s: %v", table, err)
             }
@@ -104,7 +109,7 @@ func DropAllTables(db *sql.DB) error {
     END $$;
     `
     
-    fmt.Println("Dropping enum types...")
+    slog.Info("dropping enum types")
     if _, err := db.Exec(dropEnums); err != nil {
         return fmt.Errorf("error dropping enum types: %v", err)
     }
@@ -114,10 +119,7 @@ func DropAllTables(db *sql.DB) error {
         return err
     }
 
-    fmt.Println("Remaining tables after dropping:")
-    for _, table := range finalRemainingTables {
-        fmt.Printf("- %s\n", table)
-    }
+    slog.Info("remaining tables after dropping", "tables", finalRemainingTables)
 
     return nil
 }
@@ -2,6 +2,7 @@ package database
 
 import (
 	"fmt"
+	"log/slog"
 	"os"
 
 	"github.com/chrisabs/cadence/internal/platform/database/development"
@@ -9,42 +10,63 @@ import (
 )
 
 func (db *PostgresDB) Init() error {
-	fmt.Println("Starting database initialization...")
-
-	// db.migrationsManager = migrations.NewManager(db.DB)
-	// db.migrationsManager.EnableMigration("007_soft_delete")
-
-	// fmt.Println("Running soft delete migration...")
-	// if err := db.migrationsManager.Run(); err != nil {
-   	// 	 return fmt.Errorf("migrations failed: %v", err)
-	// }
+	slog.Info("starting database initialization")
 
 	if os.Getenv("DROP_TABLES") == "true" {
-		fmt.Println("DROP_TABLES environment variable is set to true. Dropping all tables...")
+		slog.Warn("DROP_TABLES is set to true, dropping all tables")
 		if err := development.DropAllTables(db.DB); err != nil {
 			return fmt.Errorf("failed to drop tables: %v", err)
 		}
-		fmt.Println("Tables dropped successfully.")
+		slog.Info("tables dropped successfully")
 	}
 
 	if err := db.createEnums(); err != nil {
 		return fmt.Errorf("enum initialization failed: %v", err)
 	}
 
+	existing, err := db.hasSchema()
+	if err != nil {
+		return err
+	}
+
+	// An existing database is migrated first, since the schema files create
+	// indexes on columns that only the migrations add to older tables.
+	if existing {
+		slog.Info("running migrations")
+		if err := db.migrationsManager.Run(); err != nil {
+			return fmt.Errorf("migrations failed: %v", err)
+		}
+	}
+
 	if err := db.initializeSchema(); err != nil {
 		return fmt.Errorf("schema initialization failed: %v", err)
 	}
 
+	if !existing {
+		if err := db.migrationsManager.MarkApplied(); err != nil {
+			return fmt.Errorf("failed to record migrations: %v", err)
+		}
+	}
+
 	return nil
 }
 
+// hasSchema reports whether the core tables were created by an earlier run.
+func (db *PostgresDB) hasSchema() (bool, error) {
+	var exists bool
+	if err := db.QueryRow(`SELECT to_regclass('public.profile') IS NOT NULL`).Scan(&exists); err != nil {
+		return false, fmt.Errorf("failed to check for existing schema: %v", err)
+	}
+	return exists, nil
+}
+
 func (db *PostgresDB) initializeSchema() error {
-	fmt.Println("Initializing core schema...")
+	slog.Info("initializing core schema")
 	if err := schema.InitCoreSchema(db.DB); err != nil {
 		return fmt.Errorf("core schema initialization failed: %v", err)
 	}
 
-	fmt.Println("Initializing module schemas...")
+	slog.Info("initializing module schemas")
 	
 	if err := schema.InitStorageSchema(db.DB); err != nil {
 		return fmt.Errorf("storage module schema initialization failed: %v", err)
@@ -0,0 +1,55 @@
+package migrations
+
+import (
+	"database/sql"
+	"fmt"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// MigrateHashedPins widens profile.pin for bcrypt hashes, adds the lockout
+// columns and hashes any PIN still stored as plaintext. Rows that already hold
+// a hash are skipped, so the migration is safe to run repeatedly.
+func MigrateHashedPins(tx *sql.Tx) error {
+    queries := []string{
+        `ALTER TABLE profile ALTER COLUMN pin TYPE TEXT;`,
+        `ALTER TABLE profile 
+         ADD COLUMN IF NOT EXISTS pin_failed_attempts INTEGER NOT NULL DEFAULT 0,
+         ADD COLUMN IF NOT EXISTS pin_locked_until TIMESTAMP WITH TIME ZONE;`,
+    }
+
+    for _, query := range queries {
+        if _, err := tx.Exec(query); err != nil {
+            return fmt.Errorf("failed to execute hashed pins migration query: %v", err)
+        }
+    }
+
+    rows, err := tx.Query(`SELECT id, pin FROM profile WHERE pin ~ '^[0-9]{6}$'`)
+    if err != nil {
+        return fmt.Errorf("failed to query plaintext pins: %v", err)
+    }
+
+    plaintext := make(map[int]string)
+    for rows.Next() {
+        var id int
+        var pin string
+        if err := rows.Scan(&id, &pin); err != nil {
+            rows.Close()
+            return fmt.Errorf("failed to scan pin: %v", err)
+        }
+        plaintext[id] = pin
+    }
+    rows.Close()
+
+    for id, pin := range plaintext {
+        hash, err := bcrypt.GenerateFromPassword([]byte(pin), bcrypt.DefaultCost)
+        if err != nil {
+            return fmt.Errorf("failed to hash pin for profile %d: %v", id, err)
+        }
+        if _, err := tx.Exec(`UPDATE profile SET pin = $1 WHERE id = $2`, string(hash), id); err != nil {
+            return fmt.Errorf("failed to update pin for profile %d: %v", id, err)
+        }
+    }
+
+    return nil
+}
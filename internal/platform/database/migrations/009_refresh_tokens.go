@@ -0,0 +1,43 @@
+package migrations
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+// MigrateRefreshTokens adds server-side refresh tokens, the access token
+// revocation list and the family-wide sign out cutoff.
+func MigrateRefreshTokens(tx *sql.Tx) error {
+    queries := []string{
+        `ALTER TABLE family_account 
+         ADD COLUMN IF NOT EXISTS tokens_revoked_at TIMESTAMP WITH TIME ZONE;`,
+        `CREATE TABLE IF NOT EXISTS refresh_token (
+            id SERIAL PRIMARY KEY,
+            family_id INTEGER NOT NULL REFERENCES family_account(id) ON DELETE CASCADE,
+            profile_id INTEGER REFERENCES profile(id) ON DELETE CASCADE,
+            chain_id TEXT NOT NULL,
+            token_hash TEXT NOT NULL UNIQUE,
+            expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+            revoked_at TIMESTAMP WITH TIME ZONE,
+            replaced_by INTEGER REFERENCES refresh_token(id),
+            created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+        );`,
+        `CREATE INDEX IF NOT EXISTS idx_refresh_token_family ON refresh_token(family_id);`,
+        `CREATE INDEX IF NOT EXISTS idx_refresh_token_chain ON refresh_token(chain_id);`,
+        `CREATE TABLE IF NOT EXISTS revoked_token (
+            jti TEXT PRIMARY KEY,
+            family_id INTEGER NOT NULL REFERENCES family_account(id) ON DELETE CASCADE,
+            expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+            revoked_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+        );`,
+        `CREATE INDEX IF NOT EXISTS idx_revoked_token_expires ON revoked_token(expires_at);`,
+    }
+
+    for _, query := range queries {
+        if _, err := tx.Exec(query); err != nil {
+            return fmt.Errorf("failed to execute refresh tokens migration query: %v", err)
+        }
+    }
+
+    return nil
+}
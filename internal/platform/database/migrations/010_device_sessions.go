@@ -0,0 +1,37 @@
+package migrations
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+// MigrateDeviceSessions adds the per-device session table. Refresh token
+// chains started before this migration have no session, so they are revoked
+// and those devices sign in again.
+func MigrateDeviceSessions(tx *sql.Tx) error {
+    queries := []string{
+        `CREATE TABLE IF NOT EXISTS device_session (
+            id SERIAL PRIMARY KEY,
+            family_id INTEGER NOT NULL REFERENCES family_account(id) ON DELETE CASCADE,
+            chain_id TEXT NOT NULL UNIQUE,
+            device_name VARCHAR(100) NOT NULL,
+            user_agent TEXT NOT NULL DEFAULT '',
+            ip_address VARCHAR(64) NOT NULL DEFAULT '',
+            created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+            last_seen_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+            revoked_at TIMESTAMP WITH TIME ZONE
+        );`,
+        `CREATE INDEX IF NOT EXISTS idx_device_session_family ON device_session(family_id);`,
+        `UPDATE refresh_token SET revoked_at = NOW()
+         WHERE revoked_at IS NULL
+           AND chain_id NOT IN (SELECT chain_id FROM device_session);`,
+    }
+
+    for _, query := range queries {
+        if _, err := tx.Exec(query); err != nil {
+            return fmt.Errorf("failed to execute device sessions migration query: %v", err)
+        }
+    }
+
+    return nil
+}
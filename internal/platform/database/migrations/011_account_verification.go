@@ -0,0 +1,55 @@
+package migrations
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+// MigrateAccountVerification adds email verification and the single-use
+// account token table. Accounts that existed before verification was
+// introduced are treated as verified; the backfill only runs when the column
+// is first added.
+func MigrateAccountVerification(tx *sql.Tx) error {
+    var hasColumn bool
+    err := tx.QueryRow(`
+        SELECT EXISTS (
+            SELECT 1 FROM information_schema.columns
+            WHERE table_name = 'family_account' AND column_name = 'email_verified_at'
+        )`).Scan(&hasColumn)
+    if err != nil {
+        return fmt.Errorf("failed to check email_verified_at column: %v", err)
+    }
+
+    if !hasColumn {
+        queries := []string{
+            `ALTER TABLE family_account ADD COLUMN email_verified_at TIMESTAMP WITH TIME ZONE;`,
+            `UPDATE family_account SET email_verified_at = created_at;`,
+        }
+        for _, query := range queries {
+            if _, err := tx.Exec(query); err != nil {
+                return fmt.Errorf("failed to execute account verification migration query: %v", err)
+            }
+        }
+    }
+
+    queries := []string{
+        `CREATE TABLE IF NOT EXISTS family_account_token (
+            id SERIAL PRIMARY KEY,
+            family_id INTEGER NOT NULL REFERENCES family_account(id) ON DELETE CASCADE,
+            purpose VARCHAR(32) NOT NULL,
+            token_hash TEXT NOT NULL UNIQUE,
+            expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+            used_at TIMESTAMP WITH TIME ZONE,
+            created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+        );`,
+        `CREATE INDEX IF NOT EXISTS idx_family_account_token_family ON family_account_token(family_id, purpose);`,
+    }
+
+    for _, query := range queries {
+        if _, err := tx.Exec(query); err != nil {
+            return fmt.Errorf("failed to execute account verification migration query: %v", err)
+        }
+    }
+
+    return nil
+}
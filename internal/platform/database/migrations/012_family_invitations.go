@@ -0,0 +1,51 @@
+package migrations
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+// MigrateFamilyInvitations brings family_invite (first created in 006 but
+// never used) up to the hashed token shape, and adds login credentials to
+// profiles so invited adults can sign in on their own. The old plaintext
+// token column is dropped; no invitations were ever issued with it.
+func MigrateFamilyInvitations(tx *sql.Tx) error {
+    queries := []string{
+        `ALTER TABLE profile 
+         ADD COLUMN IF NOT EXISTS email VARCHAR(255),
+         ADD COLUMN IF NOT EXISTS password TEXT;`,
+        `CREATE UNIQUE INDEX IF NOT EXISTS idx_profile_email ON profile(lower(email)) WHERE email IS NOT NULL AND is_deleted = false;`,
+        `CREATE TABLE IF NOT EXISTS family_invite (
+            id SERIAL PRIMARY KEY,
+            family_id INTEGER NOT NULL REFERENCES family_account(id) ON DELETE CASCADE,
+            email VARCHAR(255) NOT NULL,
+            role profile_role NOT NULL,
+            token_hash TEXT NOT NULL UNIQUE,
+            invited_by INTEGER NOT NULL REFERENCES profile(id),
+            expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+            accepted_at TIMESTAMP WITH TIME ZONE,
+            accepted_profile_id INTEGER REFERENCES profile(id),
+            revoked_at TIMESTAMP WITH TIME ZONE,
+            created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+            updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+        );`,
+        `DROP INDEX IF EXISTS idx_family_invite_token;`,
+        `ALTER TABLE family_invite DROP COLUMN IF EXISTS token;`,
+        `ALTER TABLE family_invite 
+         ADD COLUMN IF NOT EXISTS token_hash TEXT UNIQUE,
+         ADD COLUMN IF NOT EXISTS invited_by INTEGER REFERENCES profile(id),
+         ADD COLUMN IF NOT EXISTS accepted_at TIMESTAMP WITH TIME ZONE,
+         ADD COLUMN IF NOT EXISTS accepted_profile_id INTEGER REFERENCES profile(id),
+         ADD COLUMN IF NOT EXISTS revoked_at TIMESTAMP WITH TIME ZONE;`,
+        `CREATE INDEX IF NOT EXISTS idx_family_invite_family ON family_invite(family_id);`,
+        `CREATE INDEX IF NOT EXISTS idx_family_invite_email ON family_invite(email);`,
+    }
+
+    for _, query := range queries {
+        if _, err := tx.Exec(query); err != nil {
+            return fmt.Errorf("failed to execute family invitations migration query: %v", err)
+        }
+    }
+
+    return nil
+}
@@ -0,0 +1,21 @@
+package migrations
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+func MigrateProfileLogins(tx *sql.Tx) error {
+    queries := []string{
+        `ALTER TABLE device_session 
+         ADD COLUMN IF NOT EXISTS profile_id INTEGER REFERENCES profile(id) ON DELETE CASCADE;`,
+    }
+
+    for _, query := range queries {
+        if _, err := tx.Exec(query); err != nil {
+            return fmt.Errorf("failed to execute profile logins migration query: %v", err)
+        }
+    }
+
+    return nil
+}
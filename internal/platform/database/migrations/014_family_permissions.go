@@ -0,0 +1,23 @@
+package migrations
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+// MigrateFamilyPermissions adds the configurable permission matrix. Families
+// without one keep the built-in defaults.
+func MigrateFamilyPermissions(tx *sql.Tx) error {
+    queries := []string{
+        `ALTER TABLE family_settings 
+         ADD COLUMN IF NOT EXISTS permissions JSONB;`,
+    }
+
+    for _, query := range queries {
+        if _, err := tx.Exec(query); err != nil {
+            return fmt.Errorf("failed to execute family permissions migration query: %v", err)
+        }
+    }
+
+    return nil
+}
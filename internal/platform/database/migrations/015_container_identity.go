@@ -0,0 +1,79 @@
+package migrations
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/chrisabs/cadence/pkg/utils"
+)
+
+// MigrateContainerIdentity moves container IDs back to the serial sequence
+// after they were picked at random by the API, renumbers each family's
+// containers 1..n in creation order and seeds the per-family counter used for
+// new containers. QR codes still in the old STQRAGE-CONTAINER-<id>-<unix>
+// format are replaced with random ones, so existing labels stop being
+// guessable; those containers need their labels reprinted.
+func MigrateContainerIdentity(tx *sql.Tx) error {
+    queries := []string{
+        `SELECT setval(
+            pg_get_serial_sequence('container', 'id'),
+            GREATEST(COALESCE((SELECT MAX(id) FROM container), 0), 1),
+            (SELECT MAX(id) FROM container) IS NOT NULL
+        );`,
+        `CREATE TABLE IF NOT EXISTS container_sequence (
+            family_id INTEGER PRIMARY KEY REFERENCES family_account(id) ON DELETE CASCADE,
+            last_number INTEGER NOT NULL DEFAULT 0
+        );`,
+        `DO $$
+        BEGIN
+            IF NOT EXISTS (SELECT 1 FROM pg_indexes WHERE indexname = 'idx_container_family_number') THEN
+                UPDATE container c
+                SET number = numbered.rn
+                FROM (
+                    SELECT id, ROW_NUMBER() OVER (PARTITION BY family_id ORDER BY created_at, id) AS rn
+                    FROM container
+                ) numbered
+                WHERE c.id = numbered.id;
+            END IF;
+        END $$;`,
+        `CREATE UNIQUE INDEX IF NOT EXISTS idx_container_family_number ON container(family_id, number);`,
+        `INSERT INTO container_sequence (family_id, last_number)
+         SELECT family_id, MAX(number) FROM container GROUP BY family_id
+         ON CONFLICT (family_id) DO UPDATE
+         SET last_number = GREATEST(container_sequence.last_number, EXCLUDED.last_number);`,
+    }
+
+    for _, query := range queries {
+        if _, err := tx.Exec(query); err != nil {
+            return fmt.Errorf("failed to execute container identity migration query: %v", err)
+        }
+    }
+
+    rows, err := tx.Query(`SELECT id FROM container WHERE qr_code LIKE 'STQRAGE-CONTAINER-%'`)
+    if err != nil {
+        return fmt.Errorf("failed to query legacy QR codes: %v", err)
+    }
+
+    var legacy []int
+    for rows.Next() {
+        var id int
+        if err := rows.Scan(&id); err != nil {
+            rows.Close()
+            return fmt.Errorf("failed to scan container id: %v", err)
+        }
+        legacy = append(legacy, id)
+    }
+    rows.Close()
+
+    for _, id := range legacy {
+        qrCode, qrCodeImage, err := utils.GenerateQRCode()
+        if err != nil {
+            return fmt.Errorf("failed to generate QR code for container %d: %v", id, err)
+        }
+        if _, err := tx.Exec(`UPDATE container SET qr_code = $1, qr_code_image = $2 WHERE id = $3`, qrCode, qrCodeImage, id); err != nil {
+            return fmt.Errorf("failed to update QR code for container %d: %v", id, err)
+        }
+    }
+
+    return nil
+}
@@ -0,0 +1,22 @@
+package migrations
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+// MigrateNestedContainers lets a container sit inside another one.
+func MigrateNestedContainers(tx *sql.Tx) error {
+    queries := []string{
+        `ALTER TABLE container ADD COLUMN IF NOT EXISTS parent_container_id INTEGER REFERENCES container(id);`,
+        `CREATE INDEX IF NOT EXISTS idx_container_parent ON container(parent_container_id);`,
+    }
+
+    for _, query := range queries {
+        if _, err := tx.Exec(query); err != nil {
+            return fmt.Errorf("failed to execute nested containers migration query: %v", err)
+        }
+    }
+
+    return nil
+}
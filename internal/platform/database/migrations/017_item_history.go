@@ -0,0 +1,37 @@
+package migrations
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+// MigrateItemHistory adds the log of item moves and quantity changes.
+func MigrateItemHistory(tx *sql.Tx) error {
+    queries := []string{
+        `CREATE TABLE IF NOT EXISTS item_event (
+            id SERIAL PRIMARY KEY,
+            item_id INTEGER NOT NULL REFERENCES item(id) ON DELETE CASCADE,
+            family_id INTEGER NOT NULL REFERENCES family_account(id) ON DELETE CASCADE,
+            profile_id INTEGER REFERENCES profile(id) ON DELETE SET NULL,
+            event_type VARCHAR(30) NOT NULL,
+            container_id INTEGER REFERENCES container(id) ON DELETE SET NULL,
+            from_container_id INTEGER REFERENCES container(id) ON DELETE SET NULL,
+            to_container_id INTEGER REFERENCES container(id) ON DELETE SET NULL,
+            old_quantity INTEGER,
+            new_quantity INTEGER,
+            created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+        );`,
+        `CREATE INDEX IF NOT EXISTS idx_item_event_item ON item_event(item_id, created_at DESC);`,
+        `CREATE INDEX IF NOT EXISTS idx_item_event_container ON item_event(container_id);`,
+        `CREATE INDEX IF NOT EXISTS idx_item_event_from_container ON item_event(from_container_id);`,
+        `CREATE INDEX IF NOT EXISTS idx_item_event_to_container ON item_event(to_container_id);`,
+    }
+
+    for _, query := range queries {
+        if _, err := tx.Exec(query); err != nil {
+            return fmt.Errorf("failed to execute item history migration query: %v", err)
+        }
+    }
+
+    return nil
+}
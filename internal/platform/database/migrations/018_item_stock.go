@@ -0,0 +1,24 @@
+package migrations
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+// MigrateItemStock adds units, minimum stock levels and expiry dates to items.
+func MigrateItemStock(tx *sql.Tx) error {
+    queries := []string{
+        `ALTER TABLE item ADD COLUMN IF NOT EXISTS unit VARCHAR(20) NOT NULL DEFAULT '';`,
+        `ALTER TABLE item ADD COLUMN IF NOT EXISTS min_quantity INTEGER;`,
+        `ALTER TABLE item ADD COLUMN IF NOT EXISTS expiry_date DATE;`,
+        `CREATE INDEX IF NOT EXISTS idx_item_expiry ON item(family_id, expiry_date) WHERE expiry_date IS NOT NULL;`,
+    }
+
+    for _, query := range queries {
+        if _, err := tx.Exec(query); err != nil {
+            return fmt.Errorf("failed to execute item stock migration query: %v", err)
+        }
+    }
+
+    return nil
+}
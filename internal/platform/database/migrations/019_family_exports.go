@@ -0,0 +1,37 @@
+package migrations
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+// MigrateFamilyExports adds the queue of full family data exports.
+func MigrateFamilyExports(tx *sql.Tx) error {
+    queries := []string{
+        `CREATE TABLE IF NOT EXISTS family_export (
+            id SERIAL PRIMARY KEY,
+            family_id INTEGER NOT NULL REFERENCES family_account(id) ON DELETE CASCADE,
+            requested_by INTEGER REFERENCES profile(id) ON DELETE SET NULL,
+            status VARCHAR(20) NOT NULL DEFAULT 'PENDING',
+            attempts INTEGER NOT NULL DEFAULT 0,
+            object_key TEXT,
+            size_bytes BIGINT,
+            token_hash TEXT UNIQUE,
+            error TEXT,
+            created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+            started_at TIMESTAMP WITH TIME ZONE,
+            completed_at TIMESTAMP WITH TIME ZONE,
+            expires_at TIMESTAMP WITH TIME ZONE
+        );`,
+        `CREATE INDEX IF NOT EXISTS idx_family_export_family ON family_export(family_id, created_at DESC);`,
+        `CREATE INDEX IF NOT EXISTS idx_family_export_status ON family_export(status);`,
+    }
+
+    for _, query := range queries {
+        if _, err := tx.Exec(query); err != nil {
+            return fmt.Errorf("failed to execute family exports migration query: %v", err)
+        }
+    }
+
+    return nil
+}
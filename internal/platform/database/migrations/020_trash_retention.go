@@ -0,0 +1,29 @@
+package migrations
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+// MigrateTrashRetention adds the per-family trash retention window, marks
+// purged profiles and indexes soft-deleted rows by when they were deleted.
+func MigrateTrashRetention(tx *sql.Tx) error {
+    queries := []string{
+        `ALTER TABLE family_settings ADD COLUMN IF NOT EXISTS trash_retention_days INTEGER NOT NULL DEFAULT 30;`,
+        `ALTER TABLE profile ADD COLUMN IF NOT EXISTS purged_at TIMESTAMP WITH TIME ZONE;`,
+        `CREATE INDEX IF NOT EXISTS idx_workspace_trash ON workspace(family_id, deleted_at) WHERE is_deleted = true;`,
+        `CREATE INDEX IF NOT EXISTS idx_container_trash ON container(family_id, deleted_at) WHERE is_deleted = true;`,
+        `CREATE INDEX IF NOT EXISTS idx_item_trash ON item(family_id, deleted_at) WHERE is_deleted = true;`,
+        `CREATE INDEX IF NOT EXISTS idx_tag_trash ON tag(family_id, deleted_at) WHERE is_deleted = true;`,
+        `CREATE INDEX IF NOT EXISTS idx_chore_trash ON chore(family_id, deleted_at) WHERE is_deleted = true;`,
+        `CREATE INDEX IF NOT EXISTS idx_chore_instance_trash ON chore_instance(family_id, deleted_at) WHERE is_deleted = true;`,
+    }
+
+    for _, query := range queries {
+        if _, err := tx.Exec(query); err != nil {
+            return fmt.Errorf("failed to execute trash retention migration query: %v", err)
+        }
+    }
+
+    return nil
+}
@@ -0,0 +1,34 @@
+package migrations
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+// MigrateDeletionBatches tags every storage row soft deleted by one request
+// with the same batch number so a restore can bring back exactly that set.
+func MigrateDeletionBatches(tx *sql.Tx) error {
+    queries := []string{
+        `CREATE SEQUENCE IF NOT EXISTS deletion_batch_seq;`,
+        `ALTER TABLE workspace ADD COLUMN IF NOT EXISTS deletion_batch BIGINT;`,
+        `ALTER TABLE container ADD COLUMN IF NOT EXISTS deletion_batch BIGINT;`,
+        `ALTER TABLE tag ADD COLUMN IF NOT EXISTS deletion_batch BIGINT;`,
+        `ALTER TABLE item ADD COLUMN IF NOT EXISTS deletion_batch BIGINT;`,
+        `ALTER TABLE item_image ADD COLUMN IF NOT EXISTS deletion_batch BIGINT;`,
+        `ALTER TABLE item_tag ADD COLUMN IF NOT EXISTS deletion_batch BIGINT;`,
+        `CREATE INDEX IF NOT EXISTS idx_workspace_deletion_batch ON workspace(deletion_batch) WHERE deletion_batch IS NOT NULL;`,
+        `CREATE INDEX IF NOT EXISTS idx_container_deletion_batch ON container(deletion_batch) WHERE deletion_batch IS NOT NULL;`,
+        `CREATE INDEX IF NOT EXISTS idx_tag_deletion_batch ON tag(deletion_batch) WHERE deletion_batch IS NOT NULL;`,
+        `CREATE INDEX IF NOT EXISTS idx_item_deletion_batch ON item(deletion_batch) WHERE deletion_batch IS NOT NULL;`,
+        `CREATE INDEX IF NOT EXISTS idx_item_image_deletion_batch ON item_image(deletion_batch) WHERE deletion_batch IS NOT NULL;`,
+        `CREATE INDEX IF NOT EXISTS idx_item_tag_deletion_batch ON item_tag(deletion_batch) WHERE deletion_batch IS NOT NULL;`,
+    }
+
+    for _, query := range queries {
+        if _, err := tx.Exec(query); err != nil {
+            return fmt.Errorf("failed to execute deletion batches migration query: %v", err)
+        }
+    }
+
+    return nil
+}
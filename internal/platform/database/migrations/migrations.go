@@ -3,6 +3,7 @@ package migrations
 import (
 	"database/sql"
 	"fmt"
+	"log/slog"
 )
 
 type Migration struct {
@@ -19,6 +20,9 @@ type Manager struct {
 func NewManager(db *sql.DB) *Manager {
     return &Manager{
         db: db,
+        // 001-007 predate the migration log and were applied by hand. The
+        // schema files already include their changes, and some refer to
+        // tables that no longer exist, so they stay disabled.
         migrations: []Migration{
             {
                 ID:      "001_item_images",
@@ -27,7 +31,7 @@ func NewManager(db *sql.DB) *Manager {
             },
             {
                 ID:      "002_search_indexes",
-                Enabled: true,
+                Enabled: false,
                 Run:     MigrateSearchIndexes,
             },
             {
@@ -37,24 +41,94 @@ func NewManager(db *sql.DB) *Manager {
             },
             {
                 ID:      "004_container_description",
-                Enabled: true,  
+                Enabled: false,
                 Run:     MigrateContainerDescription,
             },
             {
                 ID:      "005_tag_description",
-                Enabled: true,
+                Enabled: false,
                 Run:     MigrateTagDescription,
             },
             {
                 ID:      "006_family_support",
-                Enabled: true,
+                Enabled: false,
                 Run:     MigrateFamilySupport,
             },
             {
                 ID:      "007_soft_delete",
-                Enabled: true,
+                Enabled: false,
                 Run:     MigrateSoftDelete,
             },
+            {
+                ID:      "008_hashed_pins",
+                Enabled: true,
+                Run:     MigrateHashedPins,
+            },
+            {
+                ID:      "009_refresh_tokens",
+                Enabled: true,
+                Run:     MigrateRefreshTokens,
+            },
+            {
+                ID:      "010_device_sessions",
+                Enabled: true,
+                Run:     MigrateDeviceSessions,
+            },
+            {
+                ID:      "011_account_verification",
+                Enabled: true,
+                Run:     MigrateAccountVerification,
+            },
+            {
+                ID:      "012_family_invitations",
+                Enabled: true,
+                Run:     MigrateFamilyInvitations,
+            },
+            {
+                ID:      "013_profile_logins",
+                Enabled: true,
+                Run:     MigrateProfileLogins,
+            },
+            {
+                ID:      "014_family_permissions",
+                Enabled: true,
+                Run:     MigrateFamilyPermissions,
+            },
+            {
+                ID:      "015_container_identity",
+                Enabled: true,
+                Run:     MigrateContainerIdentity,
+            },
+            {
+                ID:      "016_nested_containers",
+                Enabled: true,
+                Run:     MigrateNestedContainers,
+            },
+            {
+                ID:      "017_item_history",
+                Enabled: true,
+                Run:     MigrateItemHistory,
+            },
+            {
+                ID:      "018_item_stock",
+                Enabled: true,
+                Run:     MigrateItemStock,
+            },
+            {
+                ID:      "019_family_exports",
+                Enabled: true,
+                Run:     MigrateFamilyExports,
+            },
+            {
+                ID:      "020_trash_retention",
+                Enabled: true,
+                Run:     MigrateTrashRetention,
+            },
+            {
+                ID:      "021_deletion_batches",
+                Enabled: true,
+                Run:     MigrateDeletionBatches,
+            },
         },
     }
 }
@@ -68,6 +142,9 @@ func (m *Manager) EnableMigration(id string) {
     }
 }
 
+// Run applies every enabled migration that is not yet recorded in
+// schema_migration, in order and in a single transaction, so an existing
+// database is brought up to date before the schema files run against it.
 func (m *Manager) Run() error {
     tx, err := m.db.Begin()
     if err != nil {
@@ -75,13 +152,83 @@ func (m *Manager) Run() error {
     }
     defer tx.Rollback()
 
+    applied, err := appliedMigrations(tx)
+    if err != nil {
+        return err
+    }
+
     for _, migration := range m.migrations {
-        if migration.Enabled {
-            if err := migration.Run(tx); err != nil {
-                return fmt.Errorf("migration %s failed: %v", migration.ID, err)
-            }
+        if !migration.Enabled || applied[migration.ID] {
+            continue
+        }
+
+        slog.Info("running migration", "id", migration.ID)
+        if err := migration.Run(tx); err != nil {
+            return fmt.Errorf("migration %s failed: %v", migration.ID, err)
+        }
+        if err := recordMigration(tx, migration.ID); err != nil {
+            return err
         }
     }
 
     return tx.Commit()
-}
\ No newline at end of file
+}
+
+// MarkApplied records every enabled migration as applied without running it.
+// A database created from the current schema files already has their changes.
+func (m *Manager) MarkApplied() error {
+    tx, err := m.db.Begin()
+    if err != nil {
+        return fmt.Errorf("failed to start transaction: %v", err)
+    }
+    defer tx.Rollback()
+
+    if _, err := appliedMigrations(tx); err != nil {
+        return err
+    }
+
+    for _, migration := range m.migrations {
+        if !migration.Enabled {
+            continue
+        }
+        if err := recordMigration(tx, migration.ID); err != nil {
+            return err
+        }
+    }
+
+    return tx.Commit()
+}
+
+func appliedMigrations(tx *sql.Tx) (map[string]bool, error) {
+    if _, err := tx.Exec(`
+        CREATE TABLE IF NOT EXISTS schema_migration (
+            id TEXT PRIMARY KEY,
+            applied_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+        );`); err != nil {
+        return nil, fmt.Errorf("failed to create migration log: %v", err)
+    }
+
+    rows, err := tx.Query(`SELECT id FROM schema_migration`)
+    if err != nil {
+        return nil, fmt.Errorf("failed to query migration log: %v", err)
+    }
+    defer rows.Close()
+
+    applied := make(map[string]bool)
+    for rows.Next() {
+        var id string
+        if err := rows.Scan(&id); err != nil {
+            return nil, fmt.Errorf("failed to scan migration id: %v", err)
+        }
+        applied[id] = true
+    }
+
+    return applied, rows.Err()
+}
+
+func recordMigration(tx *sql.Tx, id string) error {
+    if _, err := tx.Exec(`INSERT INTO schema_migration (id) VALUES ($1) ON CONFLICT (id) DO NOTHING`, id); err != nil {
+        return fmt.Errorf("failed to record migration %s: %v", id, err)
+    }
+    return nil
+}
@@ -41,6 +41,7 @@ func createChoreTable(db *sql.DB) error {
     CREATE INDEX IF NOT EXISTS idx_chore_creator ON chore(creator_id);
     CREATE INDEX IF NOT EXISTS idx_chore_is_deleted ON chore(is_deleted);
     CREATE INDEX IF NOT EXISTS idx_chore_family_deleted ON chore(family_id, is_deleted);
+    CREATE INDEX IF NOT EXISTS idx_chore_trash ON chore(family_id, deleted_at) WHERE is_deleted = true;
     `
     
     _, err := db.Exec(query)
@@ -72,6 +73,7 @@ func createChoreInstanceTable(db *sql.DB) error {
     CREATE INDEX IF NOT EXISTS idx_chore_instance_status ON chore_instance(status);
     CREATE INDEX IF NOT EXISTS idx_chore_instance_is_deleted ON chore_instance(is_deleted);
     CREATE INDEX IF NOT EXISTS idx_chore_instance_family_deleted ON chore_instance(family_id, is_deleted);
+    CREATE INDEX IF NOT EXISTS idx_chore_instance_trash ON chore_instance(family_id, deleted_at) WHERE is_deleted = true;
     `
     
     _, err := db.Exec(query)
@@ -26,6 +26,18 @@ func InitCoreSchema(db *sql.DB) error {
         return fmt.Errorf("failed to create notification table: %v", err)
     }
 
+    if err := createAuthTables(db); err != nil {
+        return fmt.Errorf("failed to create auth tables: %v", err)
+    }
+
+    if err := createFamilyInviteTable(db); err != nil {
+        return fmt.Errorf("failed to create family invite table: %v", err)
+    }
+
+    if err := createFamilyExportTable(db); err != nil {
+        return fmt.Errorf("failed to create family export table: %v", err)
+    }
+
     return nil
 }
 
@@ -36,6 +48,8 @@ func createFamilyAccountTable(db *sql.DB) error {
         email VARCHAR(255) UNIQUE NOT NULL,
         password TEXT NOT NULL,
         family_name VARCHAR(100) NOT NULL,
+        email_verified_at TIMESTAMP WITH TIME ZONE,
+        tokens_revoked_at TIMESTAMP WITH TIME ZONE,
         created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
         updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
         is_deleted BOOLEAN NOT NULL DEFAULT false,
@@ -56,9 +70,14 @@ func createProfileTable(db *sql.DB) error {
         family_id INTEGER REFERENCES family_account(id) ON DELETE CASCADE,
         name VARCHAR(100) NOT NULL,
         role profile_role NOT NULL,
-        pin VARCHAR(6),
+        pin TEXT,
+        pin_failed_attempts INTEGER NOT NULL DEFAULT 0,
+        pin_locked_until TIMESTAMP WITH TIME ZONE,
+        email VARCHAR(255),
+        password TEXT,
         image_url TEXT,
         is_owner BOOLEAN NOT NULL DEFAULT false,
+        purged_at TIMESTAMP WITH TIME ZONE,
         created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
         updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
         is_deleted BOOLEAN NOT NULL DEFAULT false,
@@ -68,6 +87,7 @@ func createProfileTable(db *sql.DB) error {
     
     CREATE INDEX IF NOT EXISTS idx_profile_family ON profile(family_id);
     CREATE INDEX IF NOT EXISTS idx_profile_owner ON profile(family_id, is_owner);
+    CREATE UNIQUE INDEX IF NOT EXISTS idx_profile_email ON profile(lower(email)) WHERE email IS NOT NULL AND is_deleted = false;
     `
     _, err := db.Exec(query)
     return err
@@ -91,7 +111,9 @@ func createFamilySettingsTable(db *sql.DB) error {
                 "isEnabled": false
             }
         }'::jsonb,
+        permissions JSONB,
         status VARCHAR(50) NOT NULL DEFAULT 'ACTIVE',
+        trash_retention_days INTEGER NOT NULL DEFAULT 30,
         created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
         updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
         is_deleted BOOLEAN NOT NULL DEFAULT false,
@@ -156,3 +178,109 @@ func createNotificationTable(db *sql.DB) error {
     _, err := db.Exec(query)
     return err
 }
+
+func createAuthTables(db *sql.DB) error {
+    query := `
+    CREATE TABLE IF NOT EXISTS refresh_token (
+        id SERIAL PRIMARY KEY,
+        family_id INTEGER NOT NULL REFERENCES family_account(id) ON DELETE CASCADE,
+        profile_id INTEGER REFERENCES profile(id) ON DELETE CASCADE,
+        chain_id TEXT NOT NULL,
+        token_hash TEXT NOT NULL UNIQUE,
+        expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+        revoked_at TIMESTAMP WITH TIME ZONE,
+        replaced_by INTEGER REFERENCES refresh_token(id),
+        created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+    );
+
+    CREATE INDEX IF NOT EXISTS idx_refresh_token_family ON refresh_token(family_id);
+    CREATE INDEX IF NOT EXISTS idx_refresh_token_chain ON refresh_token(chain_id);
+
+    CREATE TABLE IF NOT EXISTS revoked_token (
+        jti TEXT PRIMARY KEY,
+        family_id INTEGER NOT NULL REFERENCES family_account(id) ON DELETE CASCADE,
+        expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+        revoked_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+    );
+
+    CREATE INDEX IF NOT EXISTS idx_revoked_token_expires ON revoked_token(expires_at);
+
+    CREATE TABLE IF NOT EXISTS device_session (
+        id SERIAL PRIMARY KEY,
+        family_id INTEGER NOT NULL REFERENCES family_account(id) ON DELETE CASCADE,
+        profile_id INTEGER REFERENCES profile(id) ON DELETE CASCADE,
+        chain_id TEXT NOT NULL UNIQUE,
+        device_name VARCHAR(100) NOT NULL,
+        user_agent TEXT NOT NULL DEFAULT '',
+        ip_address VARCHAR(64) NOT NULL DEFAULT '',
+        created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+        last_seen_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+        revoked_at TIMESTAMP WITH TIME ZONE
+    );
+
+    CREATE INDEX IF NOT EXISTS idx_device_session_family ON device_session(family_id);
+
+    CREATE TABLE IF NOT EXISTS family_account_token (
+        id SERIAL PRIMARY KEY,
+        family_id INTEGER NOT NULL REFERENCES family_account(id) ON DELETE CASCADE,
+        purpose VARCHAR(32) NOT NULL,
+        token_hash TEXT NOT NULL UNIQUE,
+        expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+        used_at TIMESTAMP WITH TIME ZONE,
+        created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+    );
+
+    CREATE INDEX IF NOT EXISTS idx_family_account_token_family ON family_account_token(family_id, purpose);
+    `
+    _, err := db.Exec(query)
+    return err
+}
+
+func createFamilyInviteTable(db *sql.DB) error {
+    query := `
+    CREATE TABLE IF NOT EXISTS family_invite (
+        id SERIAL PRIMARY KEY,
+        family_id INTEGER NOT NULL REFERENCES family_account(id) ON DELETE CASCADE,
+        email VARCHAR(255) NOT NULL,
+        role profile_role NOT NULL,
+        token_hash TEXT NOT NULL UNIQUE,
+        invited_by INTEGER NOT NULL REFERENCES profile(id),
+        expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+        accepted_at TIMESTAMP WITH TIME ZONE,
+        accepted_profile_id INTEGER REFERENCES profile(id),
+        revoked_at TIMESTAMP WITH TIME ZONE,
+        created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+        updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+    );
+
+    CREATE INDEX IF NOT EXISTS idx_family_invite_family ON family_invite(family_id);
+    CREATE INDEX IF NOT EXISTS idx_family_invite_email ON family_invite(email);
+    `
+    _, err := db.Exec(query)
+    return err
+}
+
+func createFamilyExportTable(db *sql.DB) error {
+    query := `
+    CREATE TABLE IF NOT EXISTS family_export (
+        id SERIAL PRIMARY KEY,
+        family_id INTEGER NOT NULL REFERENCES family_account(id) ON DELETE CASCADE,
+        requested_by INTEGER REFERENCES profile(id) ON DELETE SET NULL,
+        status VARCHAR(20) NOT NULL DEFAULT 'PENDING',
+        attempts INTEGER NOT NULL DEFAULT 0,
+        object_key TEXT,
+        size_bytes BIGINT,
+        token_hash TEXT UNIQUE,
+        error TEXT,
+        created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+        started_at TIMESTAMP WITH TIME ZONE,
+        completed_at TIMESTAMP WITH TIME ZONE,
+        expires_at TIMESTAMP WITH TIME ZONE
+    );
+
+    CREATE INDEX IF NOT EXISTS idx_family_export_family ON family_export(family_id, created_at DESC);
+    CREATE INDEX IF NOT EXISTS idx_family_export_status ON family_export(status);
+    `
+    _, err := db.Exec(query)
+    return err
+}
@@ -23,6 +23,8 @@ func InitStorageSchema(db *sql.DB) error {
 
 func createWorkspaceTable(db *sql.DB) error {
     query := `
+    CREATE SEQUENCE IF NOT EXISTS deletion_batch_seq;
+
     CREATE TABLE IF NOT EXISTS workspace (
         id SERIAL PRIMARY KEY,
         name VARCHAR(100) NOT NULL,
@@ -33,7 +35,8 @@ func createWorkspaceTable(db *sql.DB) error {
         updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
         is_deleted BOOLEAN NOT NULL DEFAULT false,
         deleted_at TIMESTAMP WITH TIME ZONE,
-        deleted_by INTEGER REFERENCES profile(id)
+        deleted_by INTEGER REFERENCES profile(id),
+        deletion_batch BIGINT
     );
 
     CREATE INDEX IF NOT EXISTS idx_workspace_profile ON workspace(profile_id);
@@ -43,6 +46,8 @@ func createWorkspaceTable(db *sql.DB) error {
     ON workspace USING gin (to_tsvector('english', name || ' ' || COALESCE(description, '')));
     CREATE INDEX IF NOT EXISTS idx_workspace_is_deleted ON workspace(is_deleted);
     CREATE INDEX IF NOT EXISTS idx_workspace_family_deleted ON workspace(family_id, is_deleted);
+    CREATE INDEX IF NOT EXISTS idx_workspace_trash ON workspace(family_id, deleted_at) WHERE is_deleted = true;
+    CREATE INDEX IF NOT EXISTS idx_workspace_deletion_batch ON workspace(deletion_batch) WHERE deletion_batch IS NOT NULL;
     `
     _, err := db.Exec(query)
     return err
@@ -61,15 +66,24 @@ func createContainerTable(db *sql.DB) error {
         profile_id INTEGER REFERENCES profile(id) NOT NULL,
         family_id INTEGER REFERENCES family_account(id) NOT NULL,
         workspace_id INTEGER REFERENCES workspace(id),
+        parent_container_id INTEGER REFERENCES container(id),
         created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
         updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
         is_deleted BOOLEAN NOT NULL DEFAULT false,
         deleted_at TIMESTAMP WITH TIME ZONE,
-        deleted_by INTEGER REFERENCES profile(id)
+        deleted_by INTEGER REFERENCES profile(id),
+        deletion_batch BIGINT
     );
 
     CREATE INDEX IF NOT EXISTS idx_container_qr_code ON container(qr_code);
+    CREATE INDEX IF NOT EXISTS idx_container_parent ON container(parent_container_id);
     CREATE INDEX IF NOT EXISTS idx_container_family ON container(family_id);
+    CREATE UNIQUE INDEX IF NOT EXISTS idx_container_family_number ON container(family_id, number);
+
+    CREATE TABLE IF NOT EXISTS container_sequence (
+        family_id INTEGER PRIMARY KEY REFERENCES family_account(id) ON DELETE CASCADE,
+        last_number INTEGER NOT NULL DEFAULT 0
+    );
     CREATE INDEX IF NOT EXISTS idx_container_workspace_id ON container(workspace_id);
     CREATE INDEX IF NOT EXISTS idx_container_workspace_profile ON container(workspace_id, profile_id);
     CREATE INDEX IF NOT EXISTS idx_container_name_pattern ON container USING gin (name gin_trgm_ops);
@@ -78,6 +92,8 @@ func createContainerTable(db *sql.DB) error {
         name || ' ' || COALESCE(description, '') || ' ' || COALESCE(location, '')));
     CREATE INDEX IF NOT EXISTS idx_container_is_deleted ON container(is_deleted);
     CREATE INDEX IF NOT EXISTS idx_container_family_deleted ON container(family_id, is_deleted);
+    CREATE INDEX IF NOT EXISTS idx_container_trash ON container(family_id, deleted_at) WHERE is_deleted = true;
+    CREATE INDEX IF NOT EXISTS idx_container_deletion_batch ON container(deletion_batch) WHERE deletion_batch IS NOT NULL;
     `
     _, err := db.Exec(query)
     return err
@@ -96,7 +112,8 @@ func createItemTables(db *sql.DB) error {
         updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
         is_deleted BOOLEAN NOT NULL DEFAULT false,
         deleted_at TIMESTAMP WITH TIME ZONE,
-        deleted_by INTEGER REFERENCES profile(id)
+        deleted_by INTEGER REFERENCES profile(id),
+        deletion_batch BIGINT
     );
 
     CREATE INDEX IF NOT EXISTS idx_tag_family ON tag(family_id);
@@ -106,12 +123,17 @@ func createItemTables(db *sql.DB) error {
     ON tag USING gin (to_tsvector('english', name || ' ' || COALESCE(description, '')));
     CREATE INDEX IF NOT EXISTS idx_tag_is_deleted ON tag(is_deleted);
     CREATE INDEX IF NOT EXISTS idx_tag_family_deleted ON tag(family_id, is_deleted);
+    CREATE INDEX IF NOT EXISTS idx_tag_trash ON tag(family_id, deleted_at) WHERE is_deleted = true;
+    CREATE INDEX IF NOT EXISTS idx_tag_deletion_batch ON tag(deletion_batch) WHERE deletion_batch IS NOT NULL;
 
     CREATE TABLE IF NOT EXISTS item (
         id SERIAL PRIMARY KEY,
         name VARCHAR(100),
         description TEXT,
         quantity INTEGER,
+        unit VARCHAR(20) NOT NULL DEFAULT '',
+        min_quantity INTEGER,
+        expiry_date DATE,
         container_id INTEGER REFERENCES container(id) ON DELETE CASCADE NULL,
         family_id INTEGER REFERENCES family_account(id) NOT NULL,
         profile_id INTEGER REFERENCES profile(id) NOT NULL,
@@ -119,7 +141,8 @@ func createItemTables(db *sql.DB) error {
         updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
         is_deleted BOOLEAN NOT NULL DEFAULT false,
         deleted_at TIMESTAMP WITH TIME ZONE,
-        deleted_by INTEGER REFERENCES profile(id)
+        deleted_by INTEGER REFERENCES profile(id),
+        deletion_batch BIGINT
     );
 
     CREATE TABLE IF NOT EXISTS item_image (
@@ -131,7 +154,8 @@ func createItemTables(db *sql.DB) error {
         updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
         is_deleted BOOLEAN NOT NULL DEFAULT false,
         deleted_at TIMESTAMP WITH TIME ZONE,
-        deleted_by INTEGER REFERENCES profile(id)
+        deleted_by INTEGER REFERENCES profile(id),
+        deletion_batch BIGINT
     );
 
     CREATE TABLE IF NOT EXISTS item_tag (
@@ -140,7 +164,22 @@ func createItemTables(db *sql.DB) error {
         PRIMARY KEY (item_id, tag_id),
         is_deleted BOOLEAN NOT NULL DEFAULT false,
         deleted_at TIMESTAMP WITH TIME ZONE,
-        deleted_by INTEGER REFERENCES profile(id)
+        deleted_by INTEGER REFERENCES profile(id),
+        deletion_batch BIGINT
+    );
+
+    CREATE TABLE IF NOT EXISTS item_event (
+        id SERIAL PRIMARY KEY,
+        item_id INTEGER NOT NULL REFERENCES item(id) ON DELETE CASCADE,
+        family_id INTEGER NOT NULL REFERENCES family_account(id) ON DELETE CASCADE,
+        profile_id INTEGER REFERENCES profile(id) ON DELETE SET NULL,
+        event_type VARCHAR(30) NOT NULL,
+        container_id INTEGER REFERENCES container(id) ON DELETE SET NULL,
+        from_container_id INTEGER REFERENCES container(id) ON DELETE SET NULL,
+        to_container_id INTEGER REFERENCES container(id) ON DELETE SET NULL,
+        old_quantity INTEGER,
+        new_quantity INTEGER,
+        created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
     );
 
     CREATE INDEX IF NOT EXISTS idx_item_container ON item(container_id);
@@ -155,8 +194,17 @@ func createItemTables(db *sql.DB) error {
     CREATE INDEX IF NOT EXISTS idx_item_image_display_order ON item_image(item_id, display_order);
     CREATE INDEX IF NOT EXISTS idx_item_is_deleted ON item(is_deleted);
     CREATE INDEX IF NOT EXISTS idx_item_family_deleted ON item(family_id, is_deleted);
+    CREATE INDEX IF NOT EXISTS idx_item_trash ON item(family_id, deleted_at) WHERE is_deleted = true;
+    CREATE INDEX IF NOT EXISTS idx_item_expiry ON item(family_id, expiry_date) WHERE expiry_date IS NOT NULL;
     CREATE INDEX IF NOT EXISTS idx_item_image_is_deleted ON item_image(is_deleted);
     CREATE INDEX IF NOT EXISTS idx_item_tag_is_deleted ON item_tag(is_deleted);
+    CREATE INDEX IF NOT EXISTS idx_item_deletion_batch ON item(deletion_batch) WHERE deletion_batch IS NOT NULL;
+    CREATE INDEX IF NOT EXISTS idx_item_image_deletion_batch ON item_image(deletion_batch) WHERE deletion_batch IS NOT NULL;
+    CREATE INDEX IF NOT EXISTS idx_item_tag_deletion_batch ON item_tag(deletion_batch) WHERE deletion_batch IS NOT NULL;
+    CREATE INDEX IF NOT EXISTS idx_item_event_item ON item_event(item_id, created_at DESC);
+    CREATE INDEX IF NOT EXISTS idx_item_event_container ON item_event(container_id);
+    CREATE INDEX IF NOT EXISTS idx_item_event_from_container ON item_event(from_container_id);
+    CREATE INDEX IF NOT EXISTS idx_item_event_to_container ON item_event(to_container_id);
     `
     _, err := db.Exec(query)
     return err
@@ -0,0 +1,94 @@
+package metrics
+
+import (
+	"database/sql"
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "cadence"
+
+var registry = prometheus.NewRegistry()
+
+var (
+	HTTPRequestsTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "http",
+			Name:      "requests_total",
+			Help:      "Total HTTP requests by method, route template and status code.",
+		},
+		[]string{"method", "route", "status"},
+	)
+
+	HTTPRequestDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "http",
+			Name:      "request_duration_seconds",
+			Help:      "HTTP request latency by method and route template.",
+			Buckets:   prometheus.DefBuckets,
+		},
+		[]string{"method", "route"},
+	)
+
+	ChoreInstancesGenerated = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "chores",
+			Name:      "instances_generated_total",
+			Help:      "Total chore instances generated.",
+		},
+	)
+
+	S3Uploads = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "s3",
+			Name:      "uploads_total",
+			Help:      "Total S3 uploads by top-level folder and result.",
+		},
+		[]string{"folder", "result"},
+	)
+
+	EmailsSent = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "email",
+			Name:      "sent_total",
+			Help:      "Total emails sent by type and result.",
+		},
+		[]string{"type", "result"},
+	)
+)
+
+func init() {
+	registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		HTTPRequestsTotal,
+		HTTPRequestDuration,
+		ChoreInstancesGenerated,
+		S3Uploads,
+		EmailsSent,
+	)
+}
+
+// RegisterDBStats exposes the sql.DBStats connection pool gauges for db.
+func RegisterDBStats(dbName string, db *sql.DB) error {
+	return registry.Register(collectors.NewDBStatsCollector(db, dbName))
+}
+
+func Handler() http.Handler {
+	return promhttp.HandlerFor(registry, promhttp.HandlerOpts{})
+}
+
+func Result(err error) string {
+	if err != nil {
+		return "failure"
+	}
+	return "success"
+}
@@ -0,0 +1,46 @@
+package respond
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/chrisabs/cadence/internal/apperror"
+	"github.com/chrisabs/cadence/internal/platform/logging"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+type errorBody struct {
+	Code      apperror.Code     `json:"code"`
+	Message   string            `json:"message"`
+	Details   map[string]string `json:"details,omitempty"`
+	RequestID string            `json:"requestId,omitempty"`
+}
+
+func JSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+// Error writes err as the standard error envelope. Errors that are not an
+// *apperror.Error are treated as internal and their cause is only logged.
+func Error(w http.ResponseWriter, r *http.Request, err error) {
+	ctx := r.Context()
+	appErr := apperror.As(err)
+	status := appErr.Status()
+
+	if status >= http.StatusInternalServerError {
+		logging.FromContext(ctx).Error("request failed", "error", err)
+		span := trace.SpanFromContext(ctx)
+		span.RecordError(err)
+		span.SetStatus(codes.Error, appErr.Message)
+	}
+
+	JSON(w, status, errorBody{
+		Code:      appErr.Code,
+		Message:   appErr.Message,
+		Details:   appErr.Details,
+		RequestID: logging.RequestIDFrom(ctx),
+	})
+}
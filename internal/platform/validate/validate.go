@@ -0,0 +1,207 @@
+package validate
+
+import (
+	"fmt"
+	"net/mail"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/chrisabs/cadence/internal/apperror"
+)
+
+// Errors collects field-level messages keyed by the JSON field name.
+type Errors map[string]string
+
+// Add records message for field unless the field already has an error, so the
+// first failing rule wins.
+func (e Errors) Add(field, message string) {
+	if _, exists := e[field]; !exists {
+		e[field] = message
+	}
+}
+
+// Err returns a validation error carrying the collected messages, or nil.
+func (e Errors) Err() error {
+	if len(e) == 0 {
+		return nil
+	}
+	return apperror.Validation("validation failed", e)
+}
+
+// Checker is implemented by request types with rules that span several
+// fields. Check runs after the tag rules.
+type Checker interface {
+	Check(errs Errors)
+}
+
+var (
+	hexColourPattern = regexp.MustCompile(`^#([0-9a-fA-F]{3}|[0-9a-fA-F]{6})$`)
+	digitsPattern    = regexp.MustCompile(`^[0-9]+$`)
+)
+
+// Struct validates v against its `validate` struct tags, e.g.
+//
+//	Name string `json:"name" validate:"required,max=100"`
+//
+// Supported rules are required, omitempty, min, max, len, oneof, email,
+// hexcolour, digits, date (YYYY-MM-DD) and dive, which applies the rules that
+// follow it to each element of a slice.
+func Struct(v interface{}) error {
+	errs := Errors{}
+	Collect(v, errs)
+	return errs.Err()
+}
+
+// Collect validates v into errs, letting callers add their own checks (such
+// as ownership lookups) before returning errs.Err().
+func Collect(v interface{}, errs Errors) {
+	collectStruct(reflect.ValueOf(v), "", errs)
+	if checker, ok := v.(Checker); ok {
+		checker.Check(errs)
+	}
+}
+
+func collectStruct(value reflect.Value, prefix string, errs Errors) {
+	for value.Kind() == reflect.Ptr {
+		if value.IsNil() {
+			return
+		}
+		value = value.Elem()
+	}
+	if value.Kind() != reflect.Struct {
+		return
+	}
+
+	valueType := value.Type()
+	for i := 0; i < valueType.NumField(); i++ {
+		field := valueType.Field(i)
+		tag := field.Tag.Get("validate")
+		if tag == "" || !field.IsExported() {
+			continue
+		}
+		applyRules(value.Field(i), prefix+fieldName(field), strings.Split(tag, ","), errs)
+	}
+}
+
+func applyRules(value reflect.Value, name string, rules []string, errs Errors) {
+	for i, rule := range rules {
+		if rule == "dive" {
+			if value.Kind() != reflect.Slice {
+				return
+			}
+			for j := 0; j < value.Len(); j++ {
+				elemName := fmt.Sprintf("%s[%d]", name, j)
+				elem := value.Index(j)
+				if len(rules[i+1:]) > 0 {
+					applyRules(elem, elemName, rules[i+1:], errs)
+				}
+				collectStruct(elem, elemName+".", errs)
+			}
+			return
+		}
+
+		key, param, _ := strings.Cut(rule, "=")
+		switch key {
+		case "required":
+			if isZero(value) {
+				errs.Add(name, "is required")
+				return
+			}
+		case "omitempty":
+			if isZero(value) || (value.Kind() == reflect.Ptr && isZero(value.Elem())) {
+				return
+			}
+		default:
+			if value.Kind() == reflect.Ptr {
+				if value.IsNil() {
+					return
+				}
+				value = value.Elem()
+			}
+			if message := check(value, key, param); message != "" {
+				errs.Add(name, message)
+				return
+			}
+		}
+	}
+}
+
+func check(value reflect.Value, rule, param string) string {
+	switch rule {
+	case "min", "max", "len":
+		limit, _ := strconv.Atoi(param)
+		var size int
+		unit := ""
+		switch value.Kind() {
+		case reflect.String:
+			size = utf8.RuneCountInString(value.String())
+			unit = " characters"
+		case reflect.Slice:
+			size = value.Len()
+			unit = " entries"
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			size = int(value.Int())
+		default:
+			return ""
+		}
+		switch {
+		case rule == "min" && size < limit:
+			return fmt.Sprintf("must be at least %d%s", limit, unit)
+		case rule == "max" && size > limit:
+			return fmt.Sprintf("must be at most %d%s", limit, unit)
+		case rule == "len" && size != limit:
+			return fmt.Sprintf("must be exactly %d%s", limit, unit)
+		}
+	case "oneof":
+		options := strings.Fields(param)
+		for _, option := range options {
+			if fmt.Sprint(value.Interface()) == option {
+				return ""
+			}
+		}
+		return "must be one of: " + strings.Join(options, ", ")
+	case "email":
+		address, err := mail.ParseAddress(value.String())
+		if err != nil || address.Address != value.String() {
+			return "must be a valid email address"
+		}
+	case "hexcolour":
+		if !hexColourPattern.MatchString(value.String()) {
+			return "must be a hex colour such as #1a2b3c"
+		}
+	case "digits":
+		if !digitsPattern.MatchString(value.String()) {
+			return "must contain only digits"
+		}
+	case "date":
+		if _, err := time.Parse("2006-01-02", value.String()); err != nil {
+			return "must be a date in YYYY-MM-DD format"
+		}
+	}
+	return ""
+}
+
+func isZero(value reflect.Value) bool {
+	switch value.Kind() {
+	case reflect.String:
+		return strings.TrimSpace(value.String()) == ""
+	case reflect.Slice, reflect.Map:
+		return value.Len() == 0
+	case reflect.Ptr, reflect.Interface:
+		return value.IsNil()
+	default:
+		return value.IsZero()
+	}
+}
+
+func fieldName(field reflect.StructField) string {
+	name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+	if name == "" || name == "-" {
+		return field.Name
+	}
+	return name
+}
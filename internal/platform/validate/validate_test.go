@@ -0,0 +1,286 @@
+package validate
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/chrisabs/cadence/internal/apperror"
+)
+
+func details(t *testing.T, err error) map[string]string {
+	t.Helper()
+	if err == nil {
+		return map[string]string{}
+	}
+	var appErr *apperror.Error
+	if !errors.As(err, &appErr) || appErr.Code != apperror.CodeValidation {
+		t.Fatalf("expected a validation error, got %v", err)
+	}
+	return appErr.Details
+}
+
+func intPtr(v int) *int       { return &v }
+func strPtr(v string) *string { return &v }
+
+func TestRules(t *testing.T) {
+	type required struct {
+		Name  string   `json:"name" validate:"required"`
+		Count int      `json:"count" validate:"required"`
+		Tags  []string `json:"tags" validate:"required"`
+		Ref   *int     `json:"ref" validate:"required"`
+	}
+	type bounds struct {
+		Name  string   `json:"name" validate:"min=2,max=5"`
+		Age   int      `json:"age" validate:"min=1,max=10"`
+		Items []string `json:"items" validate:"max=2"`
+		Code  string   `json:"code" validate:"len=4"`
+	}
+	type formats struct {
+		Role   string `json:"role" validate:"oneof=PARENT CHILD"`
+		Level  int    `json:"level" validate:"oneof=1 2 3"`
+		Email  string `json:"email" validate:"email"`
+		Colour string `json:"colour" validate:"hexcolour"`
+		Pin    string `json:"pin" validate:"digits"`
+		Date   string `json:"date" validate:"date"`
+	}
+
+	tests := []struct {
+		name  string
+		input interface{}
+		want  map[string]string
+	}{
+		{
+			name:  "required fields missing",
+			input: &required{Name: "   "},
+			want: map[string]string{
+				"name":  "is required",
+				"count": "is required",
+				"tags":  "is required",
+				"ref":   "is required",
+			},
+		},
+		{
+			name:  "required fields present",
+			input: &required{Name: "box", Count: 1, Tags: []string{"a"}, Ref: intPtr(0)},
+			want:  map[string]string{},
+		},
+		{
+			name:  "below minimums",
+			input: bounds{Name: "a", Age: 0, Code: "123"},
+			want: map[string]string{
+				"name": "must be at least 2 characters",
+				"age":  "must be at least 1",
+				"code": "must be exactly 4 characters",
+			},
+		},
+		{
+			name:  "above maximums",
+			input: bounds{Name: "abcdef", Age: 11, Items: []string{"a", "b", "c"}, Code: "1234"},
+			want: map[string]string{
+				"name":  "must be at most 5 characters",
+				"age":   "must be at most 10",
+				"items": "must be at most 2 entries",
+			},
+		},
+		{
+			name:  "lengths count runes not bytes",
+			input: bounds{Name: "ééééé", Age: 5, Code: "ñañá"},
+			want:  map[string]string{},
+		},
+		{
+			name:  "invalid formats",
+			input: formats{Role: "ADMIN", Level: 4, Email: "Someone <a@b.com>", Colour: "red", Pin: "12a4", Date: "2024-13-01"},
+			want: map[string]string{
+				"role":   "must be one of: PARENT, CHILD",
+				"level":  "must be one of: 1, 2, 3",
+				"email":  "must be a valid email address",
+				"colour": "must be a hex colour such as #1a2b3c",
+				"pin":    "must contain only digits",
+				"date":   "must be a date in YYYY-MM-DD format",
+			},
+		},
+		{
+			name:  "valid formats",
+			input: formats{Role: "CHILD", Level: 2, Email: "a@b.com", Colour: "#1A2b3c", Pin: "0042", Date: "2024-02-29"},
+			want:  map[string]string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := details(t, Struct(tt.input))
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFirstFailingRuleWins(t *testing.T) {
+	type request struct {
+		Name string `json:"name" validate:"required,min=3,max=1"`
+	}
+
+	got := details(t, Struct(request{Name: "ab"}))
+	if got["name"] != "must be at least 3 characters" {
+		t.Errorf("got %q, want the min message", got["name"])
+	}
+}
+
+func TestPointersAndOmitempty(t *testing.T) {
+	type request struct {
+		Quantity *int    `json:"quantity" validate:"omitempty,min=0"`
+		Colour   *string `json:"colour" validate:"omitempty,hexcolour"`
+		Note     string  `json:"note" validate:"omitempty,min=3"`
+		Limit    *int    `json:"limit" validate:"min=1"`
+	}
+
+	tests := []struct {
+		name  string
+		input request
+		want  map[string]string
+	}{
+		{
+			name:  "nil pointers and empty values are skipped",
+			input: request{},
+			want:  map[string]string{},
+		},
+		{
+			name:  "pointer to empty string is skipped by omitempty",
+			input: request{Colour: strPtr("")},
+			want:  map[string]string{},
+		},
+		{
+			name:  "pointer to zero is skipped by omitempty",
+			input: request{Quantity: intPtr(0)},
+			want:  map[string]string{},
+		},
+		{
+			name:  "set pointers are dereferenced",
+			input: request{Quantity: intPtr(-1), Colour: strPtr("blue"), Note: "ab", Limit: intPtr(0)},
+			want: map[string]string{
+				"quantity": "must be at least 0",
+				"colour":   "must be a hex colour such as #1a2b3c",
+				"note":     "must be at least 3 characters",
+				"limit":    "must be at least 1",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := details(t, Struct(&tt.input))
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNestedFieldPaths(t *testing.T) {
+	type line struct {
+		Name     string `json:"name" validate:"required"`
+		Quantity int    `json:"quantity" validate:"min=1"`
+	}
+	type request struct {
+		Lines  []line   `json:"lines" validate:"required,dive"`
+		Emails []string `json:"emails" validate:"dive,email"`
+	}
+
+	input := request{
+		Lines:  []line{{Name: "ok", Quantity: 1}, {Quantity: 0}},
+		Emails: []string{"a@b.com", "nope"},
+	}
+
+	got := details(t, Struct(input))
+	want := map[string]string{
+		"lines[1].name":     "is required",
+		"lines[1].quantity": "must be at least 1",
+		"emails[1]":         "must be a valid email address",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestFieldNames(t *testing.T) {
+	type request struct {
+		Tagged   string `json:"tagged,omitempty" validate:"required"`
+		Untagged string `validate:"required"`
+		Skipped  string `json:"-" validate:"required"`
+		private  string `validate:"required"`
+		Ignored  string
+	}
+
+	got := details(t, Struct(request{}))
+	want := map[string]string{
+		"tagged":   "is required",
+		"Untagged": "is required",
+		"Skipped":  "is required",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestUnknownRulesAreIgnored(t *testing.T) {
+	type request struct {
+		Name  string `json:"name" validate:"uuid,max=3"`
+		Count int    `json:"count" validate:"bogus=7"`
+	}
+
+	if err := Struct(request{Name: "abc"}); err != nil {
+		t.Fatalf("unknown rules should not fail, got %v", err)
+	}
+
+	got := details(t, Struct(request{Name: "abcd"}))
+	if got["name"] != "must be at most 3 characters" {
+		t.Errorf("rules after an unknown one should still run, got %v", got)
+	}
+}
+
+type checked struct {
+	Start int `json:"start" validate:"min=0"`
+	End   int `json:"end"`
+}
+
+func (c checked) Check(errs Errors) {
+	if c.End < c.Start {
+		errs.Add("end", "must not be before start")
+	}
+	errs.Add("start", "overwritten")
+}
+
+func TestChecker(t *testing.T) {
+	got := details(t, Struct(checked{Start: -1, End: -2}))
+	want := map[string]string{
+		"start": "must be at least 0",
+		"end":   "must not be before start",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestCollectAndNil(t *testing.T) {
+	type request struct {
+		Name string `json:"name" validate:"required"`
+	}
+
+	errs := Errors{}
+	errs.Add("owner", "not found")
+	Collect(&request{}, errs)
+	if len(errs) != 2 {
+		t.Errorf("expected caller and tag errors, got %v", errs)
+	}
+
+	var missing *request
+	if err := Struct(missing); err != nil {
+		t.Errorf("nil pointer should validate cleanly, got %v", err)
+	}
+
+	if err := (Errors{}).Err(); err != nil {
+		t.Errorf("empty errors should be nil, got %v", err)
+	}
+}
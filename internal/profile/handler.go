@@ -2,14 +2,19 @@ package profile
 
 import (
 	"encoding/json"
+	"fmt"
 	"mime/multipart"
 	"net/http"
 	"strconv"
 	"strings"
 
+	"github.com/chrisabs/cadence/internal/apperror"
+	"github.com/chrisabs/cadence/internal/auth"
 	"github.com/chrisabs/cadence/internal/cloud"
 	"github.com/chrisabs/cadence/internal/middleware"
 	"github.com/chrisabs/cadence/internal/models"
+	"github.com/chrisabs/cadence/internal/platform/respond"
+	"github.com/chrisabs/cadence/internal/platform/validate"
 	"github.com/gorilla/mux"
 )
 
@@ -27,47 +32,57 @@ func NewHandler(service *Service, authMiddleware *middleware.AuthMiddleware) *Ha
 
 func (h *Handler) RegisterRoutes(router *mux.Router) {
 	router.HandleFunc("/profiles", h.authMiddleware.FamilyAuthHandler(h.handleGetProfiles)).Methods("GET")
-	router.HandleFunc("/profiles", h.authMiddleware.FamilyAuthHandler(h.handleCreateProfile)).Methods("POST")
+	router.HandleFunc("/profiles", h.authMiddleware.ProfileAuthHandler(h.authMiddleware.RequireRole(models.RoleParent)(h.authMiddleware.RequireVerifiedEmail(h.handleCreateProfile)))).Methods("POST")
 	router.HandleFunc("/profiles/select", h.authMiddleware.FamilyAuthHandler(h.handleSelectProfile)).Methods("POST")
 	router.HandleFunc("/profiles/verify", h.authMiddleware.FamilyAuthHandler(h.handleVerifyPin)).Methods("POST")
+	router.HandleFunc("/profiles/login", h.handleLogin).Methods("POST")
 
 	router.HandleFunc("/profiles/{id}", h.authMiddleware.ProfileAuthHandler(h.handleGetProfile)).Methods("GET")
-	router.HandleFunc("/profiles/{id}", h.authMiddleware.ProfileAuthHandler(h.handleUpdateProfile)).Methods("PUT")
-	router.HandleFunc("/profiles/{id}", h.authMiddleware.ProfileAuthHandler(h.handleDeleteProfile)).Methods("DELETE")
-	router.HandleFunc("/profiles/{id}/restore", h.authMiddleware.ProfileAuthHandler(h.handleRestoreProfile)).Methods("PUT")
+	router.HandleFunc("/profiles/{id}", h.authMiddleware.ProfileAuthHandler(h.authMiddleware.RequireRole(models.RoleParent)(h.handleUpdateProfile))).Methods("PUT")
+	router.HandleFunc("/profiles/{id}", h.authMiddleware.ProfileAuthHandler(h.authMiddleware.RequireRole(models.RoleParent)(h.handleDeleteProfile))).Methods("DELETE")
+	router.HandleFunc("/profiles/{id}/restore", h.authMiddleware.ProfileAuthHandler(h.authMiddleware.RequireRole(models.RoleParent)(h.handleRestoreProfile))).Methods("PUT")
+	router.HandleFunc("/profiles/{id}/credentials", h.authMiddleware.ProfileAuthHandler(h.authMiddleware.RequireRole(models.RoleParent)(h.handleUpdateCredentials))).Methods("PUT")
 }
 
 func (h *Handler) handleGetProfiles(w http.ResponseWriter, r *http.Request) {
-	familyCtx := r.Context().Value("family").(*models.FamilyContext)
+	familyCtx, ok := middleware.FamilyFrom(r.Context())
+	if !ok {
+		respond.Error(w, r, apperror.Unauthorized("family authentication required"))
+		return
+	}
 	
-	profiles, err := h.service.GetProfilesByFamilyID(familyCtx.FamilyID)
+	profiles, err := h.service.GetProfilesByFamilyID(r.Context(), familyCtx.FamilyID)
 	if err != nil {
-		writeError(w, http.StatusInternalServerError, err.Error())
+		respond.Error(w, r, err)
 		return
 	}
 	
-	writeJSON(w, http.StatusOK, &ProfilesList{Profiles: profiles})
+	respond.JSON(w, http.StatusOK, &ProfilesList{Profiles: profiles})
 }
 
 func (h *Handler) handleCreateProfile(w http.ResponseWriter, r *http.Request) {
-    familyCtx := r.Context().Value("family").(*models.FamilyContext)
+    profileCtx, ok := middleware.ProfileFrom(r.Context())
+    if !ok {
+        respond.Error(w, r, apperror.Unauthorized("profile authentication required"))
+        return
+    }
     
     contentType := r.Header.Get("Content-Type")
     if strings.HasPrefix(contentType, "multipart/form-data") {
         if err := r.ParseMultipartForm(10 << 20); err != nil {
-            writeError(w, http.StatusBadRequest, "failed to parse multipart form")
+            respond.Error(w, r, apperror.BadRequest("failed to parse multipart form"))
             return
         }
         
         profileDataStr := r.FormValue("profileData")
         if profileDataStr == "" {
-            writeError(w, http.StatusBadRequest, "missing profileData field")
+            respond.Error(w, r, apperror.BadRequest("missing profileData field"))
             return
         }
         
         var req CreateProfileRequest
         if err := json.Unmarshal([]byte(profileDataStr), &req); err != nil {
-            writeError(w, http.StatusBadRequest, "invalid profile data format")
+            respond.Error(w, r, apperror.BadRequest("invalid profile data format"))
             return
         }
         
@@ -76,81 +91,84 @@ func (h *Handler) handleCreateProfile(w http.ResponseWriter, r *http.Request) {
             
             s3Handler, err := cloud.NewS3Handler()
             if err != nil {
-                writeError(w, http.StatusInternalServerError, "failed to initialize storage")
+                respond.Error(w, r, fmt.Errorf("failed to initialize storage: %w", err))
                 return
             }
             
-            imageURL, err := s3Handler.UploadFile(header, "profiles")
+            imageURL, err := s3Handler.UploadFile(r.Context(), header, "profiles")
             if err != nil {
-                writeError(w, http.StatusInternalServerError, "failed to upload image")
+                respond.Error(w, r, fmt.Errorf("failed to upload image: %w", err))
                 return
             }
             
             req.ImageURL = imageURL
         }
         
-        profile, err := h.service.CreateProfile(familyCtx.FamilyID, &req)
+        profile, err := h.service.CreateProfile(r.Context(), profileCtx.FamilyID, &req)
         if err != nil {
-            writeError(w, http.StatusInternalServerError, err.Error())
+            respond.Error(w, r, err)
             return
         }
         
-        writeJSON(w, http.StatusCreated, profile)
+        respond.JSON(w, http.StatusCreated, profile)
         return
     }
     
     var req CreateProfileRequest
     if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-        writeError(w, http.StatusBadRequest, "invalid request body")
+        respond.Error(w, r, apperror.BadRequest("invalid request body"))
         return
     }
     
-    profile, err := h.service.CreateProfile(familyCtx.FamilyID, &req)
+    profile, err := h.service.CreateProfile(r.Context(), profileCtx.FamilyID, &req)
     if err != nil {
-        writeError(w, http.StatusInternalServerError, err.Error())
+        respond.Error(w, r, err)
         return
     }
     
-    writeJSON(w, http.StatusCreated, profile)
+    respond.JSON(w, http.StatusCreated, profile)
 }
 
 func (h *Handler) handleGetProfile(w http.ResponseWriter, r *http.Request) {
-	profileCtx := r.Context().Value("profile").(*models.ProfileContext)
+	profileCtx, ok := middleware.ProfileFrom(r.Context())
+	if !ok {
+		respond.Error(w, r, apperror.Unauthorized("profile authentication required"))
+		return
+	}
 	
 	id, err := getIDFromRequest(r)
 	if err != nil {
-		writeError(w, http.StatusBadRequest, err.Error())
+		respond.Error(w, r, err)
 		return
 	}
 	
-	profile, err := h.service.GetProfileByID(id)
+	profile, err := h.service.GetProfileByID(r.Context(), id)
 	if err != nil {
-		writeError(w, http.StatusNotFound, err.Error())
+		respond.Error(w, r, err)
 		return
 	}
 	
 	if profile.FamilyID != profileCtx.FamilyID {
-		writeError(w, http.StatusForbidden, "access denied")
+		respond.Error(w, r, apperror.Forbidden("access denied"))
 		return
 	}
 	
-	writeJSON(w, http.StatusOK, profile)
+	respond.JSON(w, http.StatusOK, profile)
 }
 
 func (h *Handler) handleUpdateProfile(w http.ResponseWriter, r *http.Request) {
-	profileCtx := r.Context().Value("profile").(*models.ProfileContext)
+	profileCtx, ok := middleware.ProfileFrom(r.Context())
+	if !ok {
+		respond.Error(w, r, apperror.Unauthorized("profile authentication required"))
+		return
+	}
 	
 	id, err := getIDFromRequest(r)
 	if err != nil {
-		writeError(w, http.StatusBadRequest, err.Error())
+		respond.Error(w, r, err)
 		return
 	}
 
-	if !profileCtx.IsOwner && profileCtx.Role != models.RoleParent {
-		writeError(w, http.StatusForbidden, "only parents can update profiles")
-		return
-	}
-	
 	var imageFile *multipart.FileHeader
 	if err := r.ParseMultipartForm(10 << 20); err == nil {
 		if file, header, err := r.FormFile("image"); err == nil {
@@ -163,121 +181,165 @@ func (h *Handler) handleUpdateProfile(w http.ResponseWriter, r *http.Request) {
 	profileData := r.FormValue("profileData")
 	if profileData != "" {
 		if err := json.Unmarshal([]byte(profileData), &req); err != nil {
-			writeError(w, http.StatusBadRequest, "invalid profile data")
+			respond.Error(w, r, apperror.BadRequest("invalid profile data"))
 			return
 		}
 	} else {
 		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-			writeError(w, http.StatusBadRequest, "invalid request body")
+			respond.Error(w, r, apperror.BadRequest("invalid request body"))
 			return
 		}
 	}
 	
-	profile, err := h.service.UpdateProfile(id, profileCtx.FamilyID, &req, imageFile)
+	profile, err := h.service.UpdateProfile(r.Context(), id, profileCtx.FamilyID, profileCtx.ProfileID, profileCtx.IsOwner, &req, imageFile)
 	if err != nil {
-		writeError(w, http.StatusInternalServerError, err.Error())
+		respond.Error(w, r, err)
 		return
 	}
 	
-	writeJSON(w, http.StatusOK, profile)
+	respond.JSON(w, http.StatusOK, profile)
 }
 
 func (h *Handler) handleDeleteProfile(w http.ResponseWriter, r *http.Request) {
-	profileCtx := r.Context().Value("profile").(*models.ProfileContext)
-	
-	id, err := getIDFromRequest(r)
-	if err != nil {
-		writeError(w, http.StatusBadRequest, err.Error())
+	profileCtx, ok := middleware.ProfileFrom(r.Context())
+	if !ok {
+		respond.Error(w, r, apperror.Unauthorized("profile authentication required"))
 		return
 	}
 	
-	if !profileCtx.IsOwner && profileCtx.Role != models.RoleParent {
-		writeError(w, http.StatusForbidden, "only parents can delete profiles")
+	id, err := getIDFromRequest(r)
+	if err != nil {
+		respond.Error(w, r, err)
 		return
 	}
 	
-	if err := h.service.DeleteProfile(id, profileCtx.FamilyID, profileCtx.ProfileID); err != nil {
-		writeError(w, http.StatusInternalServerError, err.Error())
+	if err := h.service.DeleteProfile(r.Context(), id, profileCtx.FamilyID, profileCtx.ProfileID); err != nil {
+		respond.Error(w, r, err)
 		return
 	}
 	
-	writeJSON(w, http.StatusOK, map[string]string{"message": "profile deleted successfully"})
+	respond.JSON(w, http.StatusOK, map[string]string{"message": "profile deleted successfully"})
 }
 
 func (h *Handler) handleRestoreProfile(w http.ResponseWriter, r *http.Request) {
-	profileCtx := r.Context().Value("profile").(*models.ProfileContext)
-	
-	id, err := getIDFromRequest(r)
-	if err != nil {
-		writeError(w, http.StatusBadRequest, err.Error())
+	profileCtx, ok := middleware.ProfileFrom(r.Context())
+	if !ok {
+		respond.Error(w, r, apperror.Unauthorized("profile authentication required"))
 		return
 	}
 	
-	if !profileCtx.IsOwner && profileCtx.Role != models.RoleParent {
-		writeError(w, http.StatusForbidden, "only parents can restore profiles")
+	id, err := getIDFromRequest(r)
+	if err != nil {
+		respond.Error(w, r, err)
 		return
 	}
 	
-	if err := h.service.RestoreProfile(id, profileCtx.FamilyID); err != nil {
-		writeError(w, http.StatusInternalServerError, err.Error())
+	if err := h.service.RestoreProfile(r.Context(), id, profileCtx.FamilyID); err != nil {
+		respond.Error(w, r, err)
 		return
 	}
 	
-	writeJSON(w, http.StatusOK, map[string]string{"message": "profile restored successfully"})
+	respond.JSON(w, http.StatusOK, map[string]string{"message": "profile restored successfully"})
 }
 
 func (h *Handler) handleSelectProfile(w http.ResponseWriter, r *http.Request) {
-	familyCtx := r.Context().Value("family").(*models.FamilyContext)
+	familyCtx, ok := middleware.FamilyFrom(r.Context())
+	if !ok {
+		respond.Error(w, r, apperror.Unauthorized("family authentication required"))
+		return
+	}
 	
 	var req SelectProfileRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		writeError(w, http.StatusBadRequest, "invalid request body")
+		respond.Error(w, r, apperror.BadRequest("invalid request body"))
 		return
 	}
 	
-	profileResponse, err := h.service.SelectProfile(familyCtx.FamilyID, &req)
+	profileResponse, err := h.service.SelectProfile(r.Context(), familyCtx.FamilyID, familyCtx.SessionID, &req)
 	if err != nil {
-		writeError(w, http.StatusUnauthorized, err.Error())
+		respond.Error(w, r, err)
 		return
 	}
 	
-	writeJSON(w, http.StatusOK, profileResponse)
+	respond.JSON(w, http.StatusOK, profileResponse)
 }
 
 func (h *Handler) handleVerifyPin(w http.ResponseWriter, r *http.Request) {
-    familyCtx := r.Context().Value("family").(*models.FamilyContext)
+    familyCtx, ok := middleware.FamilyFrom(r.Context())
+    if !ok {
+        respond.Error(w, r, apperror.Unauthorized("family authentication required"))
+        return
+    }
     
     var req VerifyPinRequest
     if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-        writeError(w, http.StatusBadRequest, "invalid request body")
+        respond.Error(w, r, apperror.BadRequest("invalid request body"))
+        return
+    }
+
+    if err := validate.Struct(&req); err != nil {
+        respond.Error(w, r, err)
         return
     }
     
-    profileResponse, err := h.service.VerifyPin(familyCtx.FamilyID, req.ProfileID, req.Pin)
+    profileResponse, err := h.service.VerifyPin(r.Context(), familyCtx.FamilyID, familyCtx.SessionID, req.ProfileID, req.Pin)
     if err != nil {
-        if strings.Contains(err.Error(), "invalid PIN") {
-            writeError(w, http.StatusForbidden, "Invalid PIN")
-            return
-        }
-        
-        writeError(w, http.StatusUnauthorized, err.Error())
+        respond.Error(w, r, err)
         return
     }
     
-    writeJSON(w, http.StatusOK, profileResponse)
+    respond.JSON(w, http.StatusOK, profileResponse)
 }
 
-func getIDFromRequest(r *http.Request) (int, error) {
-	vars := mux.Vars(r)
-	return strconv.Atoi(vars["id"])
+func (h *Handler) handleLogin(w http.ResponseWriter, r *http.Request) {
+	var req LoginRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respond.Error(w, r, apperror.BadRequest("invalid request body"))
+		return
+	}
+
+	profileResponse, err := h.service.Login(r.Context(), &req, auth.DeviceFromRequest(r, req.DeviceName))
+	if err != nil {
+		respond.Error(w, r, err)
+		return
+	}
+
+	respond.JSON(w, http.StatusOK, profileResponse)
 }
 
-func writeJSON(w http.ResponseWriter, status int, v interface{}) {
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(status)
-	json.NewEncoder(w).Encode(v)
+func (h *Handler) handleUpdateCredentials(w http.ResponseWriter, r *http.Request) {
+	profileCtx, ok := middleware.ProfileFrom(r.Context())
+	if !ok {
+		respond.Error(w, r, apperror.Unauthorized("profile authentication required"))
+		return
+	}
+
+	id, err := getIDFromRequest(r)
+	if err != nil {
+		respond.Error(w, r, err)
+		return
+	}
+
+	var req UpdateCredentialsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respond.Error(w, r, apperror.BadRequest("invalid request body"))
+		return
+	}
+
+	profile, err := h.service.UpdateCredentials(r.Context(), id, profileCtx.FamilyID, profileCtx.ProfileID, &req)
+	if err != nil {
+		respond.Error(w, r, err)
+		return
+	}
+
+	respond.JSON(w, http.StatusOK, profile)
 }
 
-func writeError(w http.ResponseWriter, status int, message string) {
-	writeJSON(w, status, map[string]string{"error": message})
-}
\ No newline at end of file
+func getIDFromRequest(r *http.Request) (int, error) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		return 0, apperror.BadRequest("invalid id")
+	}
+	return id, nil
+}
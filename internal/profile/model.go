@@ -1,37 +1,50 @@
 package profile
 
 import (
+	"github.com/chrisabs/cadence/internal/auth"
 	"github.com/chrisabs/cadence/internal/models"
 )
 
 type CreateProfileRequest struct {
-	Name     string             `json:"name"`
-	Role     models.ProfileRole `json:"role"`
-	Pin      string             `json:"pin,omitempty"`
-	ImageURL string             `json:"imageUrl,omitempty"`
+	Name     string             `json:"name" validate:"required,max=100"`
+	Role     models.ProfileRole `json:"role" validate:"required,oneof=PARENT CHILD"`
+	Pin      string             `json:"pin,omitempty" validate:"omitempty,len=6,digits"`
+	ImageURL string             `json:"imageUrl,omitempty" validate:"max=2048"`
 }
 
 type UpdateProfileRequest struct {
     ID         int                `json:"id"`
-    Name       string             `json:"name,omitempty"`
-    Role       models.ProfileRole `json:"role,omitempty"`
-    Pin        *string            `json:"pin,omitempty"` 
-    CurrentPin string             `json:"currentPin,omitempty"`
-	ImageURL   string             `json:"imageUrl,omitempty"`
+    Name       string             `json:"name,omitempty" validate:"max=100"`
+    Role       models.ProfileRole `json:"role,omitempty" validate:"omitempty,oneof=PARENT CHILD"`
+    Pin        *string            `json:"pin,omitempty" validate:"omitempty,len=6,digits"` 
+    CurrentPin string             `json:"currentPin,omitempty" validate:"omitempty,len=6,digits"`
+	ImageURL   string             `json:"imageUrl,omitempty" validate:"max=2048"`
 }
 
 type SelectProfileRequest struct {
-	ProfileID int    `json:"profileId"`
-	Pin       string `json:"pin,omitempty"`
+	ProfileID int    `json:"profileId" validate:"required"`
+	Pin       string `json:"pin,omitempty" validate:"max=6"`
 }
 
 type VerifyPinRequest struct {
-	ProfileID int    `json:"profileId"`
-	Pin       string `json:"pin,omitempty"`
+	ProfileID int    `json:"profileId" validate:"required"`
+	Pin       string `json:"pin,omitempty" validate:"max=6"`
+}
+
+type LoginRequest struct {
+	Email      string `json:"email" validate:"required"`
+	Password   string `json:"password" validate:"required"`
+	DeviceName string `json:"deviceName,omitempty" validate:"max=100"`
+}
+
+type UpdateCredentialsRequest struct {
+	Email           string `json:"email" validate:"required,email,max=255"`
+	Password        string `json:"password,omitempty" validate:"omitempty,min=8,max=72"`
+	CurrentPassword string `json:"currentPassword,omitempty"`
 }
 
 type ProfileResponse struct {
-	Token   string         `json:"token"`
+	auth.TokenPair
 	Profile models.Profile `json:"profile"`
 }
 
@@ -0,0 +1,115 @@
+package profile
+
+import (
+	"context"
+	"crypto/subtle"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/chrisabs/cadence/internal/apperror"
+	"github.com/chrisabs/cadence/internal/models"
+	"github.com/chrisabs/cadence/internal/notification"
+	"github.com/chrisabs/cadence/internal/platform/logging"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	maxPinAttempts = 5
+	pinLockBase    = time.Minute
+	pinLockMax     = 24 * time.Hour
+)
+
+func hashPin(pin string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(pin), bcrypt.DefaultCost)
+	if err != nil {
+		return "", fmt.Errorf("error hashing pin: %w", err)
+	}
+	return string(hash), nil
+}
+
+// comparePin reports whether pin matches the stored value. PINs saved before
+// hashing was introduced are still plaintext; those are compared in constant
+// time and reported as legacy so the caller can rehash them.
+func comparePin(stored, pin string) (match bool, legacy bool) {
+	if !strings.HasPrefix(stored, "$2") {
+		return subtle.ConstantTimeCompare([]byte(stored), []byte(pin)) == 1, true
+	}
+	return bcrypt.CompareHashAndPassword([]byte(stored), []byte(pin)) == nil, false
+}
+
+// checkPin verifies pin against the profile, enforcing the lockout after
+// repeated failures. The attempt is counted in the database before the
+// comparison so concurrent guesses share one budget. The family owner is
+// notified when a profile gets locked.
+func (s *Service) checkPin(ctx context.Context, profile *models.Profile, pin string) error {
+	attempt, err := s.repo.ClaimPinAttempt(ctx, profile.ID, maxPinAttempts, pinLockBase, pinLockMax)
+	if err != nil {
+		return err
+	}
+	if attempt.Locked {
+		return pinLockedError(*attempt.LockedUntil)
+	}
+
+	match, legacy := comparePin(attempt.Pin, pin)
+	if !match || pin == "" {
+		if attempt.LockedUntil == nil {
+			return apperror.Unauthorized("invalid PIN")
+		}
+
+		s.notifyPinLocked(ctx, profile, *attempt.LockedUntil)
+		return pinLockedError(*attempt.LockedUntil)
+	}
+
+	if err := s.repo.ResetPinAttempts(ctx, profile.ID); err != nil {
+		return err
+	}
+	profile.PinFailedAttempts = 0
+	profile.PinLockedUntil = nil
+
+	if legacy {
+		hash, err := hashPin(pin)
+		if err != nil {
+			return err
+		}
+		if err := s.repo.UpdatePin(ctx, profile.ID, hash); err != nil {
+			return err
+		}
+		profile.Pin = hash
+	} else {
+		profile.Pin = attempt.Pin
+	}
+
+	return nil
+}
+
+func (s *Service) notifyPinLocked(ctx context.Context, profile *models.Profile, lockedUntil time.Time) {
+	if s.notificationService == nil {
+		return
+	}
+
+	owner, err := s.repo.GetOwnerProfile(ctx, profile.FamilyID)
+	if err != nil {
+		logging.FromContext(ctx).Error("failed to load owner for pin lock notification", "error", err)
+		return
+	}
+
+	sourceID := profile.ID
+	err = s.notificationService.Notify(ctx, &notification.Notification{
+		ProfileID: owner.ID,
+		FamilyID:  profile.FamilyID,
+		Title:     "Profile locked",
+		Message:   fmt.Sprintf("%s's profile was locked after too many incorrect PIN attempts. It unlocks at %s.", profile.Name, lockedUntil.Format(time.RFC3339)),
+		Type:      notification.TypePinLocked,
+		SourceID:  &sourceID,
+	})
+	if err != nil {
+		logging.FromContext(ctx).Error("failed to send pin lock notification", "error", err)
+	}
+}
+
+func pinLockedError(lockedUntil time.Time) error {
+	return apperror.RateLimited("too many failed PIN attempts", map[string]string{
+		"lockedUntil": lockedUntil.UTC().Format(time.RFC3339),
+	})
+}
@@ -1,10 +1,12 @@
 package profile
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"time"
 
+	"github.com/chrisabs/cadence/internal/apperror"
 	"github.com/chrisabs/cadence/internal/models"
 )
 
@@ -16,19 +18,47 @@ func NewRepository(db *sql.DB) *Repository {
 	return &Repository{db: db}
 }
 
-func (r *Repository) Create(profile *models.Profile) error {
+func (r *Repository) Create(ctx context.Context, profile *models.Profile) error {
+	return insertProfile(ctx, r.db, profile)
+}
+
+// CreateLinked inserts the profile and runs link in the same transaction, so
+// the profile is only kept if whatever refers to it is saved too.
+func (r *Repository) CreateLinked(ctx context.Context, profile *models.Profile, link func(ctx context.Context, tx *sql.Tx, profileID int) error) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("error starting transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := insertProfile(ctx, tx, profile); err != nil {
+		return err
+	}
+
+	if err := link(ctx, tx, profile.ID); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func insertProfile(ctx context.Context, q interface {
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}, profile *models.Profile) error {
 	query := `
 		INSERT INTO profile (
-			family_id, name, role, pin, image_url, is_owner, created_at, updated_at
-		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+			family_id, name, role, pin, email, password, image_url, is_owner, created_at, updated_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
 		RETURNING id, created_at, updated_at`
 
-	err := r.db.QueryRow(
+	err := q.QueryRowContext(ctx,
 		query,
 		profile.FamilyID,
 		profile.Name,
 		profile.Role,
 		profile.Pin,
+		nullString(profile.Email),
+		nullString(profile.Password),
 		profile.ImageURL,
 		profile.IsOwner,
 		time.Now().UTC(),
@@ -36,25 +66,29 @@ func (r *Repository) Create(profile *models.Profile) error {
 	).Scan(&profile.ID, &profile.CreatedAt, &profile.UpdatedAt)
 
 	if err != nil {
-		return fmt.Errorf("error creating profile: %v", err)
+		return fmt.Errorf("error creating profile: %w", err)
 	}
 
 	return nil
 }
 
-func (r *Repository) GetByID(id int) (*models.Profile, error) {
+func (r *Repository) GetByID(ctx context.Context, id int) (*models.Profile, error) {
 	query := `
-		SELECT id, family_id, name, role, pin, image_url, is_owner, created_at, updated_at
+		SELECT id, family_id, name, role, pin, pin_failed_attempts, pin_locked_until, COALESCE(email, ''), COALESCE(password, ''), image_url, is_owner, created_at, updated_at
 		FROM profile
 		WHERE id = $1 AND is_deleted = false`
 
 	profile := new(models.Profile)
-	err := r.db.QueryRow(query, id).Scan(
+	err := r.db.QueryRowContext(ctx, query, id).Scan(
 		&profile.ID,
 		&profile.FamilyID,
 		&profile.Name,
 		&profile.Role,
 		&profile.Pin,
+		&profile.PinFailedAttempts,
+		&profile.PinLockedUntil,
+		&profile.Email,
+		&profile.Password,
 		&profile.ImageURL,
 		&profile.IsOwner,
 		&profile.CreatedAt,
@@ -62,10 +96,10 @@ func (r *Repository) GetByID(id int) (*models.Profile, error) {
 	)
 
 	if err == sql.ErrNoRows {
-		return nil, fmt.Errorf("profile not found")
+		return nil, apperror.NotFound("profile not found")
 	}
 	if err != nil {
-		return nil, fmt.Errorf("error getting profile: %v", err)
+		return nil, fmt.Errorf("error getting profile: %w", err)
 	}
 
 	profile.HasPin = profile.Pin != ""
@@ -74,16 +108,16 @@ func (r *Repository) GetByID(id int) (*models.Profile, error) {
 }
 
 
-func (r *Repository) GetByFamilyID(familyID int) ([]*models.Profile, error) {
+func (r *Repository) GetByFamilyID(ctx context.Context, familyID int) ([]*models.Profile, error) {
 	query := `
-		SELECT id, family_id, name, role, pin, image_url, is_owner, created_at, updated_at
+		SELECT id, family_id, name, role, pin, pin_failed_attempts, pin_locked_until, COALESCE(email, ''), COALESCE(password, ''), image_url, is_owner, created_at, updated_at
 		FROM profile
 		WHERE family_id = $1 AND is_deleted = false
 		ORDER BY created_at DESC`
 
-	rows, err := r.db.Query(query, familyID)
+	rows, err := r.db.QueryContext(ctx, query, familyID)
 	if err != nil {
-		return nil, fmt.Errorf("error querying profiles: %v", err)
+		return nil, fmt.Errorf("error querying profiles: %w", err)
 	}
 	defer rows.Close()
 
@@ -96,13 +130,17 @@ func (r *Repository) GetByFamilyID(familyID int) ([]*models.Profile, error) {
 			&profile.Name,
 			&profile.Role,
 			&profile.Pin,
+			&profile.PinFailedAttempts,
+			&profile.PinLockedUntil,
+			&profile.Email,
+			&profile.Password,
 			&profile.ImageURL,
 			&profile.IsOwner,
 			&profile.CreatedAt,
 			&profile.UpdatedAt,
 		)
 		if err != nil {
-			return nil, fmt.Errorf("error scanning profile: %v", err)
+			return nil, fmt.Errorf("error scanning profile: %w", err)
 		}
 		
 		profile.HasPin = profile.Pin != ""
@@ -113,7 +151,7 @@ func (r *Repository) GetByFamilyID(familyID int) ([]*models.Profile, error) {
 	return profiles, nil
 }
 
-func (r *Repository) Update(profile *models.Profile) error {
+func (r *Repository) Update(ctx context.Context, profile *models.Profile) error {
 	query := `
 		UPDATE profile
 		SET name = $2, 
@@ -124,7 +162,7 @@ func (r *Repository) Update(profile *models.Profile) error {
 			updated_at = $7
 		WHERE id = $1 AND family_id = $8 AND is_deleted = false`
 
-	result, err := r.db.Exec(
+	result, err := r.db.ExecContext(ctx,
 		query,
 		profile.ID,
 		profile.Name,
@@ -137,81 +175,85 @@ func (r *Repository) Update(profile *models.Profile) error {
 	)
 
 	if err != nil {
-		return fmt.Errorf("error updating profile: %v", err)
+		return fmt.Errorf("error updating profile: %w", err)
 	}
 
 	rowsAffected, err := result.RowsAffected()
 	if err != nil {
-		return fmt.Errorf("error checking update result: %v", err)
+		return fmt.Errorf("error checking update result: %w", err)
 	}
 
 	if rowsAffected == 0 {
-		return fmt.Errorf("profile not found or access denied")
+		return apperror.NotFound("profile not found or access denied")
 	}
 
 	return nil
 }
 
-func (r *Repository) Delete(id int, familyID int, deletedBy int) error {
+func (r *Repository) Delete(ctx context.Context, id int, familyID int, deletedBy int) error {
 	query := `
 		UPDATE profile 
 		SET is_deleted = true, deleted_at = $3, deleted_by = $4, updated_at = $3
 		WHERE id = $1 AND family_id = $2 AND is_deleted = false`
 	
-	result, err := r.db.Exec(query, id, familyID, time.Now().UTC(), deletedBy)
+	result, err := r.db.ExecContext(ctx, query, id, familyID, time.Now().UTC(), deletedBy)
 	if err != nil {
-		return fmt.Errorf("error soft deleting profile: %v", err)
+		return fmt.Errorf("error soft deleting profile: %w", err)
 	}
 
 	rowsAffected, err := result.RowsAffected()
 	if err != nil {
-		return fmt.Errorf("error checking delete result: %v", err)
+		return fmt.Errorf("error checking delete result: %w", err)
 	}
 
 	if rowsAffected == 0 {
-		return fmt.Errorf("profile not found or access denied")
+		return apperror.NotFound("profile not found or access denied")
 	}
 
 	return nil
 }
 
-func (r *Repository) Restore(id int, familyID int) error {
+func (r *Repository) Restore(ctx context.Context, id int, familyID int) error {
 	query := `
 		UPDATE profile
 		SET is_deleted = false, deleted_at = NULL, deleted_by = NULL, updated_at = $3
-		WHERE id = $1 AND family_id = $2 AND is_deleted = true`
+		WHERE id = $1 AND family_id = $2 AND is_deleted = true AND purged_at IS NULL`
 	
-	result, err := r.db.Exec(query, id, familyID, time.Now().UTC())
+	result, err := r.db.ExecContext(ctx, query, id, familyID, time.Now().UTC())
 	if err != nil {
-		return fmt.Errorf("error restoring profile: %v", err)
+		return fmt.Errorf("error restoring profile: %w", err)
 	}
 
 	rowsAffected, err := result.RowsAffected()
 	if err != nil {
-		return fmt.Errorf("error checking restore result: %v", err)
+		return fmt.Errorf("error checking restore result: %w", err)
 	}
 
 	if rowsAffected == 0 {
-		return fmt.Errorf("profile not found or not deleted")
+		return apperror.NotFound("profile not found or not deleted")
 	}
 
 	return nil
 }
 
-func (r *Repository) GetOwnerProfile(familyID int) (*models.Profile, error) {
+func (r *Repository) GetOwnerProfile(ctx context.Context, familyID int) (*models.Profile, error) {
 	query := `
-		SELECT id, family_id, name, role, pin, image_url, is_owner, created_at, updated_at
+		SELECT id, family_id, name, role, pin, pin_failed_attempts, pin_locked_until, COALESCE(email, ''), COALESCE(password, ''), image_url, is_owner, created_at, updated_at
 		FROM profile
 		WHERE family_id = $1 AND is_owner = true AND is_deleted = false
 		LIMIT 1`
 
 	profile := new(models.Profile)
-	err := r.db.QueryRow(query, familyID).Scan(
+	err := r.db.QueryRowContext(ctx, query, familyID).Scan(
 		&profile.ID,
 		&profile.FamilyID,
 		&profile.Name,
 		&profile.Role,
 		&profile.Pin,
+		&profile.PinFailedAttempts,
+		&profile.PinLockedUntil,
+		&profile.Email,
+		&profile.Password,
 		&profile.ImageURL,
 		&profile.IsOwner,
 		&profile.CreatedAt,
@@ -219,13 +261,150 @@ func (r *Repository) GetOwnerProfile(familyID int) (*models.Profile, error) {
 	)
 
 	if err == sql.ErrNoRows {
-		return nil, fmt.Errorf("owner profile not found")
+		return nil, apperror.NotFound("owner profile not found")
 	}
 	if err != nil {
-		return nil, fmt.Errorf("error getting owner profile: %v", err)
+		return nil, fmt.Errorf("error getting owner profile: %w", err)
 	}
 
 	profile.HasPin = profile.Pin != ""
 
 	return profile, nil
-}
\ No newline at end of file
+}
+// PinAttempt is a PIN guess that has already been counted against the
+// profile. LockedUntil is set when counting it reached the lockout threshold,
+// so the lock applies if the guess turns out to be wrong. Locked means the
+// profile was already locked and the guess must not be checked at all.
+type PinAttempt struct {
+	Pin         string
+	LockedUntil *time.Time
+	Locked      bool
+}
+
+// ClaimPinAttempt counts a PIN guess before it is checked, in one statement
+// that skips locked profiles, so concurrent guesses cannot all get past the
+// lock before any failure is recorded. Once the counter reaches maxAttempts
+// the PIN is locked for baseLock doubled for every attempt past the
+// threshold, capped at maxLock. A correct guess clears the counter and lock
+// through ResetPinAttempts.
+func (r *Repository) ClaimPinAttempt(ctx context.Context, id int, maxAttempts int, baseLock time.Duration, maxLock time.Duration) (*PinAttempt, error) {
+	query := `
+		UPDATE profile
+		SET pin_failed_attempts = pin_failed_attempts + 1,
+			pin_locked_until = CASE
+				WHEN pin_failed_attempts + 1 >= $2 THEN
+					$5::timestamptz + make_interval(secs => LEAST($3 * power(2, pin_failed_attempts + 1 - $2), $4))
+				ELSE NULL
+			END
+		WHERE id = $1 AND is_deleted = false
+		AND (pin_locked_until IS NULL OR pin_locked_until <= $5)
+		RETURNING pin, pin_locked_until`
+
+	now := time.Now().UTC()
+	attempt := new(PinAttempt)
+	err := r.db.QueryRowContext(ctx,
+		query,
+		id,
+		maxAttempts,
+		baseLock.Seconds(),
+		maxLock.Seconds(),
+		now,
+	).Scan(&attempt.Pin, &attempt.LockedUntil)
+
+	if err == sql.ErrNoRows {
+		err = r.db.QueryRowContext(ctx, `
+			SELECT pin_locked_until FROM profile
+			WHERE id = $1 AND is_deleted = false`, id).Scan(&attempt.LockedUntil)
+		if err == sql.ErrNoRows {
+			return nil, apperror.NotFound("profile not found")
+		}
+		if err != nil {
+			return nil, fmt.Errorf("error checking pin lock: %w", err)
+		}
+		attempt.Locked = attempt.LockedUntil != nil && attempt.LockedUntil.After(now)
+		if !attempt.Locked {
+			// The lock expired between the two statements; count the guess
+			// again rather than letting it through uncounted.
+			return r.ClaimPinAttempt(ctx, id, maxAttempts, baseLock, maxLock)
+		}
+		return attempt, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error recording pin attempt: %w", err)
+	}
+
+	return attempt, nil
+}
+
+func (r *Repository) ResetPinAttempts(ctx context.Context, id int) error {
+	query := `
+		UPDATE profile
+		SET pin_failed_attempts = 0, pin_locked_until = NULL
+		WHERE id = $1`
+
+	if _, err := r.db.ExecContext(ctx, query, id); err != nil {
+		return fmt.Errorf("error resetting pin attempts: %w", err)
+	}
+	return nil
+}
+
+func (r *Repository) UpdatePin(ctx context.Context, id int, pinHash string) error {
+	query := `
+		UPDATE profile
+		SET pin = $2, updated_at = $3
+		WHERE id = $1 AND is_deleted = false`
+
+	if _, err := r.db.ExecContext(ctx, query, id, pinHash, time.Now().UTC()); err != nil {
+		return fmt.Errorf("error updating pin: %w", err)
+	}
+	return nil
+}
+
+func (r *Repository) IsEmailInUse(ctx context.Context, email string) (bool, error) {
+	query := `
+		SELECT EXISTS (
+			SELECT 1 FROM profile
+			WHERE lower(email) = lower($1) AND is_deleted = false
+		)`
+
+	var inUse bool
+	if err := r.db.QueryRowContext(ctx, query, email).Scan(&inUse); err != nil {
+		return false, fmt.Errorf("error checking profile email: %w", err)
+	}
+
+	return inUse, nil
+}
+
+func (r *Repository) GetByEmail(ctx context.Context, email string) (*models.Profile, error) {
+	query := `
+		SELECT id
+		FROM profile
+		WHERE lower(email) = lower($1) AND is_deleted = false`
+
+	var id int
+	err := r.db.QueryRowContext(ctx, query, email).Scan(&id)
+	if err == sql.ErrNoRows {
+		return nil, apperror.NotFound("profile not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error getting profile by email: %w", err)
+	}
+
+	return r.GetByID(ctx, id)
+}
+
+func (r *Repository) UpdateCredentials(ctx context.Context, id int, email string, passwordHash string) error {
+	query := `
+		UPDATE profile
+		SET email = $2, password = $3, updated_at = $4
+		WHERE id = $1 AND is_deleted = false`
+
+	if _, err := r.db.ExecContext(ctx, query, id, nullString(email), nullString(passwordHash), time.Now().UTC()); err != nil {
+		return fmt.Errorf("error updating profile credentials: %w", err)
+	}
+	return nil
+}
+
+func nullString(s string) sql.NullString {
+	return sql.NullString{String: s, Valid: s != ""}
+}
@@ -1,107 +1,204 @@
 package profile
 
 import (
+	"context"
+	"database/sql"
 	"fmt"
 	"mime/multipart"
-	"regexp"
+	"strings"
 	"time"
 
+	"github.com/chrisabs/cadence/internal/apperror"
+	"github.com/chrisabs/cadence/internal/auth"
 	"github.com/chrisabs/cadence/internal/cloud"
 	"github.com/chrisabs/cadence/internal/models"
-	"github.com/golang-jwt/jwt"
+	"github.com/chrisabs/cadence/internal/notification"
+	"github.com/chrisabs/cadence/internal/platform/tracing"
+	"github.com/chrisabs/cadence/internal/platform/validate"
+	"golang.org/x/crypto/bcrypt"
 )
 
+// TokenService issues the access and refresh tokens returned when a profile
+// is selected or an adult signs in with their own login.
+type TokenService interface {
+	IssueProfileTokens(ctx context.Context, sessionID string, profile *models.Profile) (*auth.TokenPair, error)
+	StartProfileSession(ctx context.Context, profile *models.Profile, device *auth.DeviceInfo) (*auth.TokenPair, error)
+}
+
 type Service struct {
-	repo      *Repository
-	jwtSecret string
+	repo                *Repository
+	tokenService        TokenService
+	notificationService interface {
+		Notify(ctx context.Context, n *notification.Notification) error
+	}
 }
 
-func NewService(repo *Repository, jwtSecret string) *Service {
+func NewService(repo *Repository, tokenService TokenService) *Service {
 	return &Service{
-		repo:      repo,
-		jwtSecret: jwtSecret,
+		repo:         repo,
+		tokenService: tokenService,
 	}
 }
 
-func (s *Service) GenerateProfileJWT(familyID, profileID int, role models.ProfileRole, isOwner bool) (string, error) {
-	token := jwt.New(jwt.SigningMethodHS256)
-	claims := token.Claims.(jwt.MapClaims)
-	claims["familyId"] = familyID
-	claims["profileId"] = profileID
-	claims["role"] = string(role)
-	claims["isOwner"] = isOwner
-	claims["exp"] = time.Now().Add(time.Hour * 24).Unix()
-
-	return token.SignedString([]byte(s.jwtSecret))
+func (s *Service) SetNotificationService(notificationService interface {
+	Notify(ctx context.Context, n *notification.Notification) error
+}) {
+	s.notificationService = notificationService
 }
 
+func (s *Service) CreateProfile(ctx context.Context, familyID int, req *CreateProfileRequest) (*models.Profile, error) {
+    ctx, span := tracing.Start(ctx, "profile.Service.CreateProfile")
+    defer span.End()
+
+    if err := validate.Struct(req); err != nil {
+        return nil, err
+    }
 
-func (s *Service) CreateProfile(familyID int, req *CreateProfileRequest) (*models.Profile, error) {
-    existingProfiles, err := s.repo.GetByFamilyID(familyID)
+    existingProfiles, err := s.repo.GetByFamilyID(ctx, familyID)
     if err != nil {
-        return nil, fmt.Errorf("error checking existing profiles: %v", err)
+        return nil, fmt.Errorf("error checking existing profiles: %w", err)
     }
 
     isOwner := len(existingProfiles) == 0
 
     if isOwner && req.Role != models.RoleParent {
-        return nil, fmt.Errorf("owner profile must be a parent")
+        return nil, apperror.Validation("owner profile must be a parent", map[string]string{"role": "must be parent"})
+    }
+
+    pin := ""
+    if req.Pin != "" {
+        if pin, err = hashPin(req.Pin); err != nil {
+            return nil, err
+        }
     }
 
     profile := &models.Profile{
         FamilyID:  familyID,
         Name:      req.Name,
         Role:      req.Role,
-        Pin:       req.Pin,
+        Pin:       pin,
         ImageURL:  req.ImageURL,
         IsOwner:   isOwner, 
         CreatedAt: time.Now().UTC(),
         UpdatedAt: time.Now().UTC(),
     }
 
-    if err := s.repo.Create(profile); err != nil {
-        return nil, fmt.Errorf("failed to create profile: %v", err)
+    if err := s.repo.Create(ctx, profile); err != nil {
+        return nil, fmt.Errorf("failed to create profile: %w", err)
     }
 
-    return s.repo.GetByID(profile.ID)
+    return s.repo.GetByID(ctx, profile.ID)
+}
+
+// CreateInvitedProfile creates the profile for an accepted invitation. The
+// profile gets its own login with the invited email and the chosen password.
+// accept runs in the same transaction, so the profile is only created if the
+// invitation is marked as accepted by it.
+func (s *Service) CreateInvitedProfile(ctx context.Context, familyID int, req *CreateProfileRequest, email string, password string, accept func(ctx context.Context, tx *sql.Tx, profileID int) error) (*models.Profile, error) {
+	ctx, span := tracing.Start(ctx, "profile.Service.CreateInvitedProfile")
+	defer span.End()
+
+	if err := validate.Struct(req); err != nil {
+		return nil, err
+	}
+
+	inUse, err := s.repo.IsEmailInUse(ctx, email)
+	if err != nil {
+		return nil, err
+	}
+	if inUse {
+		return nil, apperror.Conflict("email already in use")
+	}
+
+	passwordHash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return nil, fmt.Errorf("error hashing password: %w", err)
+	}
+
+	pin := ""
+	if req.Pin != "" {
+		if pin, err = hashPin(req.Pin); err != nil {
+			return nil, err
+		}
+	}
+
+	profile := &models.Profile{
+		FamilyID:  familyID,
+		Name:      req.Name,
+		Role:      req.Role,
+		Pin:       pin,
+		Email:     strings.ToLower(email),
+		Password:  string(passwordHash),
+		ImageURL:  req.ImageURL,
+		IsOwner:   false,
+		CreatedAt: time.Now().UTC(),
+		UpdatedAt: time.Now().UTC(),
+	}
+
+	if err := s.repo.CreateLinked(ctx, profile, accept); err != nil {
+		return nil, fmt.Errorf("failed to create profile: %w", err)
+	}
+
+	return s.repo.GetByID(ctx, profile.ID)
 }
 
-func (s *Service) GetProfileByID(id int) (*models.Profile, error) {
-	return s.repo.GetByID(id)
+func (s *Service) GetProfileByID(ctx context.Context, id int) (*models.Profile, error) {
+	ctx, span := tracing.Start(ctx, "profile.Service.GetProfileByID")
+	defer span.End()
+
+	return s.repo.GetByID(ctx, id)
 }
 
-func (s *Service) GetProfilesByFamilyID(familyID int) ([]*models.Profile, error) {
-	return s.repo.GetByFamilyID(familyID)
+func (s *Service) GetProfilesByFamilyID(ctx context.Context, familyID int) ([]*models.Profile, error) {
+	ctx, span := tracing.Start(ctx, "profile.Service.GetProfilesByFamilyID")
+	defer span.End()
+
+	return s.repo.GetByFamilyID(ctx, familyID)
 }
 
-func (s *Service) UpdateProfile(id int, familyID int, req *UpdateProfileRequest, imageFile *multipart.FileHeader) (*models.Profile, error) {
-	profile, err := s.repo.GetByID(id)
+// UpdateProfile applies req to the profile. Changing a PIN that is already set
+// needs the current PIN, unless a parent is resetting a child's PIN or the
+// owner is resetting another profile's PIN.
+func (s *Service) UpdateProfile(ctx context.Context, id int, familyID int, updatedBy int, updatedByOwner bool, req *UpdateProfileRequest, imageFile *multipart.FileHeader) (*models.Profile, error) {
+	ctx, span := tracing.Start(ctx, "profile.Service.UpdateProfile")
+	defer span.End()
+
+	if err := validate.Struct(req); err != nil {
+		return nil, err
+	}
+
+	profile, err := s.repo.GetByID(ctx, id)
 	if err != nil {
-		return nil, fmt.Errorf("profile not found: %v", err)
+		return nil, fmt.Errorf("profile not found: %w", err)
 	}
 
 	if profile.FamilyID != familyID {
-		return nil, fmt.Errorf("profile does not belong to this family")
+		return nil, apperror.Forbidden("profile does not belong to this family")
 	}
 
+	canResetPin := updatedBy != profile.ID && (profile.Role == models.RoleChild || updatedByOwner)
+
 	if req.Name != "" {
 		profile.Name = req.Name
 	}
 
 	if req.Role != "" {
 		if profile.IsOwner && req.Role != models.RoleParent {
-			return nil, fmt.Errorf("cannot change role of owner profile")
+			return nil, apperror.Validation("cannot change role of owner profile", map[string]string{"role": "cannot be changed for the owner"})
 		}
 		profile.Role = req.Role
 	}
 
 	if req.Pin != nil {
-		if profile.HasPin && profile.Pin != "" {
+		if profile.HasPin && profile.Pin != "" && !canResetPin {
 			if req.CurrentPin == "" {
-				return nil, fmt.Errorf("current PIN required to change PIN")
+				return nil, apperror.Validation("current PIN required to change PIN", map[string]string{"currentPin": "is required"})
 			}
-			if req.CurrentPin != profile.Pin {
-				return nil, fmt.Errorf("invalid current PIN")
+			if err := s.checkPin(ctx, profile, req.CurrentPin); err != nil {
+				if apperror.As(err).Code == apperror.CodeUnauthorized {
+					return nil, apperror.Forbidden("invalid current PIN")
+				}
+				return nil, err
 			}
 		}
 	
@@ -109,10 +206,11 @@ func (s *Service) UpdateProfile(id int, familyID int, req *UpdateProfileRequest,
 			profile.Pin = ""
 			profile.HasPin = false
 		} else {
-			if len(*req.Pin) != 6 || !regexp.MustCompile(`^\d{6}$`).MatchString(*req.Pin) {
-				return nil, fmt.Errorf("PIN must be exactly 6 digits")
+			hash, err := hashPin(*req.Pin)
+			if err != nil {
+				return nil, err
 			}
-			profile.Pin = *req.Pin
+			profile.Pin = hash
 			profile.HasPin = true
 		}
 	}
@@ -120,12 +218,12 @@ func (s *Service) UpdateProfile(id int, familyID int, req *UpdateProfileRequest,
 	if imageFile != nil {
 		s3Handler, err := cloud.NewS3Handler()
 		if err != nil {
-			return nil, fmt.Errorf("failed to initialize storage: %v", err)
+			return nil, fmt.Errorf("failed to initialize storage: %w", err)
 		}
 
-		imageURL, err := s3Handler.UploadFile(imageFile, fmt.Sprintf("profiles/%d", id))
+		imageURL, err := s3Handler.UploadFile(ctx, imageFile, fmt.Sprintf("profiles/%d", id))
 		if err != nil {
-			return nil, fmt.Errorf("failed to upload image: %v", err)
+			return nil, fmt.Errorf("failed to upload image: %w", err)
 		}
 		profile.ImageURL = imageURL
 	} else if req.ImageURL != "" {
@@ -134,63 +232,188 @@ func (s *Service) UpdateProfile(id int, familyID int, req *UpdateProfileRequest,
 
 	profile.UpdatedAt = time.Now().UTC()
 
-	if err := s.repo.Update(profile); err != nil {
-		return nil, fmt.Errorf("failed to update profile: %v", err)
+	if err := s.repo.Update(ctx, profile); err != nil {
+		return nil, fmt.Errorf("failed to update profile: %w", err)
 	}
 
-	return s.repo.GetByID(profile.ID)
+	// A new PIN also lifts any lockout left by the old one.
+	if req.Pin != nil {
+		if err := s.repo.ResetPinAttempts(ctx, profile.ID); err != nil {
+			return nil, err
+		}
+	}
+
+	return s.repo.GetByID(ctx, profile.ID)
 }
 
-func (s *Service) DeleteProfile(id int, familyID int, deletedBy int) error {
-	profile, err := s.repo.GetByID(id)
+func (s *Service) DeleteProfile(ctx context.Context, id int, familyID int, deletedBy int) error {
+	ctx, span := tracing.Start(ctx, "profile.Service.DeleteProfile")
+	defer span.End()
+
+	profile, err := s.repo.GetByID(ctx, id)
 	if err != nil {
-		return fmt.Errorf("profile not found: %v", err)
+		return fmt.Errorf("profile not found: %w", err)
 	}
 
 	if profile.FamilyID != familyID {
-		return fmt.Errorf("profile does not belong to this family")
+		return apperror.Forbidden("profile does not belong to this family")
 	}
 
 	if profile.IsOwner {
-		return fmt.Errorf("cannot delete the owner profile")
+		return apperror.Conflict("cannot delete the owner profile")
 	}
 
-	return s.repo.Delete(id, familyID, deletedBy)
+	return s.repo.Delete(ctx, id, familyID, deletedBy)
 }
 
-func (s *Service) RestoreProfile(id int, familyID int) error {
-	return s.repo.Restore(id, familyID)
+func (s *Service) RestoreProfile(ctx context.Context, id int, familyID int) error {
+	ctx, span := tracing.Start(ctx, "profile.Service.RestoreProfile")
+	defer span.End()
+
+	return s.repo.Restore(ctx, id, familyID)
 }
 
-func (s *Service) VerifyPin(familyID int, profileID int, pin string) (*ProfileResponse, error) {
-	profile, err := s.repo.GetByID(profileID)
+func (s *Service) VerifyPin(ctx context.Context, familyID int, sessionID string, profileID int, pin string) (*ProfileResponse, error) {
+	ctx, span := tracing.Start(ctx, "profile.Service.VerifyPin")
+	defer span.End()
+
+	profile, err := s.repo.GetByID(ctx, profileID)
 	if err != nil {
-		return nil, fmt.Errorf("profile not found")
+		return nil, apperror.NotFound("profile not found")
 	}
 
 	if profile.FamilyID != familyID {
-		return nil, fmt.Errorf("profile does not belong to this family")
+		return nil, apperror.Forbidden("profile does not belong to this family")
 	}
 
-	if profile.HasPin && (pin == "" || profile.Pin != pin) {
-		return nil, fmt.Errorf("invalid PIN")
+	if profile.HasPin {
+		if err := s.checkPin(ctx, profile, pin); err != nil {
+			return nil, err
+		}
 	}
 
-	token, err := s.GenerateProfileJWT(familyID, profileID, profile.Role, profile.IsOwner)
+	tokens, err := s.tokenService.IssueProfileTokens(ctx, sessionID, profile)
 	if err != nil {
-		return nil, fmt.Errorf("error generating token")
+		return nil, fmt.Errorf("error generating token: %w", err)
 	}
 
 	return &ProfileResponse{
-		Token:   token,
-		Profile: *profile,
+		TokenPair: *tokens,
+		Profile:   *profile,
 	}, nil
 }
 
-func (s *Service) SelectProfile(familyID int, req *SelectProfileRequest) (*ProfileResponse, error) {
-	return s.VerifyPin(familyID, req.ProfileID, req.Pin)
+func (s *Service) SelectProfile(ctx context.Context, familyID int, sessionID string, req *SelectProfileRequest) (*ProfileResponse, error) {
+	ctx, span := tracing.Start(ctx, "profile.Service.SelectProfile")
+	defer span.End()
+
+	if err := validate.Struct(req); err != nil {
+		return nil, err
+	}
+
+	return s.VerifyPin(ctx, familyID, sessionID, req.ProfileID, req.Pin)
 }
 
-func (s *Service) GetOwnerProfile(familyID int) (*models.Profile, error) {
-	return s.repo.GetOwnerProfile(familyID)
+// Login signs an adult in with their own profile email and password. Children
+// have no login of their own and are only reachable through the family login.
+func (s *Service) Login(ctx context.Context, req *LoginRequest, device *auth.DeviceInfo) (*ProfileResponse, error) {
+	ctx, span := tracing.Start(ctx, "profile.Service.Login")
+	defer span.End()
+
+	if err := validate.Struct(req); err != nil {
+		return nil, err
+	}
+
+	profile, err := s.repo.GetByEmail(ctx, req.Email)
+	if err != nil {
+		return nil, apperror.Unauthorized("invalid email or password")
+	}
+
+	if profile.Password == "" || profile.Role != models.RoleParent {
+		return nil, apperror.Unauthorized("invalid email or password")
+	}
+
+	if err := bcrypt.CompareHashAndPassword([]byte(profile.Password), []byte(req.Password)); err != nil {
+		return nil, apperror.Unauthorized("invalid email or password")
+	}
+
+	tokens, err := s.tokenService.StartProfileSession(ctx, profile, device)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate token: %w", err)
+	}
+
+	return &ProfileResponse{
+		TokenPair: *tokens,
+		Profile:   *profile,
+	}, nil
+}
+
+// UpdateCredentials sets the email and password a parent uses to sign in to
+// their own profile. Only the profile itself can change them, and the current
+// password is required once one has been set.
+func (s *Service) UpdateCredentials(ctx context.Context, id int, familyID int, actorID int, req *UpdateCredentialsRequest) (*models.Profile, error) {
+	ctx, span := tracing.Start(ctx, "profile.Service.UpdateCredentials")
+	defer span.End()
+
+	if err := validate.Struct(req); err != nil {
+		return nil, err
+	}
+
+	if id != actorID {
+		return nil, apperror.Forbidden("you can only change your own login")
+	}
+
+	profile, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if profile.FamilyID != familyID {
+		return nil, apperror.Forbidden("profile does not belong to this family")
+	}
+
+	if profile.Role != models.RoleParent {
+		return nil, apperror.Forbidden("only parents can have their own login")
+	}
+
+	if profile.Password != "" {
+		if err := bcrypt.CompareHashAndPassword([]byte(profile.Password), []byte(req.CurrentPassword)); err != nil {
+			return nil, apperror.Forbidden("current password is incorrect")
+		}
+	} else if req.Password == "" {
+		return nil, apperror.Validation("password required", map[string]string{"password": "is required"})
+	}
+
+	email := strings.ToLower(req.Email)
+	if email != profile.Email {
+		inUse, err := s.repo.IsEmailInUse(ctx, email)
+		if err != nil {
+			return nil, err
+		}
+		if inUse {
+			return nil, apperror.Conflict("email already in use")
+		}
+	}
+
+	passwordHash := profile.Password
+	if req.Password != "" {
+		hash, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
+		if err != nil {
+			return nil, fmt.Errorf("error hashing password: %w", err)
+		}
+		passwordHash = string(hash)
+	}
+
+	if err := s.repo.UpdateCredentials(ctx, id, email, passwordHash); err != nil {
+		return nil, err
+	}
+
+	return s.repo.GetByID(ctx, id)
+}
+
+func (s *Service) GetOwnerProfile(ctx context.Context, familyID int) (*models.Profile, error) {
+	ctx, span := tracing.Start(ctx, "profile.Service.GetOwnerProfile")
+	defer span.End()
+
+	return s.repo.GetOwnerProfile(ctx, familyID)
 }
\ No newline at end of file
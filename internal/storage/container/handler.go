@@ -6,8 +6,10 @@ import (
 	"strconv"
 	"strings"
 
+	"github.com/chrisabs/cadence/internal/apperror"
 	"github.com/chrisabs/cadence/internal/middleware"
 	"github.com/chrisabs/cadence/internal/models"
+	"github.com/chrisabs/cadence/internal/platform/respond"
 	"github.com/gorilla/mux"
 )
 
@@ -24,149 +26,296 @@ func NewHandler(service *Service, authMiddleware *middleware.AuthMiddleware) *Ha
 }
 
 func (h *Handler) RegisterRoutes(router *mux.Router) {
-    router.HandleFunc("/containers", h.authMiddleware.ProfileAuthHandler(h.handleGetContainers)).Methods("GET")
-    router.HandleFunc("/containers", h.authMiddleware.ProfileAuthHandler(h.handleCreateContainer)).Methods("POST")
+    router.HandleFunc("/containers", h.authMiddleware.ModuleMiddleware(models.ModuleStorage, models.PermissionRead)(h.handleGetContainers)).Methods("GET")
+    router.HandleFunc("/containers", h.authMiddleware.ModuleMiddleware(models.ModuleStorage, models.PermissionWrite)(h.handleCreateContainer)).Methods("POST")
 
-    router.HandleFunc("/containers/{id}", h.authMiddleware.ProfileAuthHandler(h.handleGetContainerByID)).Methods("GET")
-    router.HandleFunc("/containers/{id}", h.authMiddleware.ProfileAuthHandler(h.handleDeleteContainer)).Methods("DELETE")
-    router.HandleFunc("/containers/{id}", h.authMiddleware.ProfileAuthHandler(h.handleUpdateContainer)).Methods("PUT")
+    router.HandleFunc("/containers/labels", h.authMiddleware.ModuleMiddleware(models.ModuleStorage, models.PermissionRead)(h.handleGetLabels)).Methods("POST")
+
+    router.HandleFunc("/containers/{id}", h.authMiddleware.ModuleMiddleware(models.ModuleStorage, models.PermissionRead)(h.handleGetContainerByID)).Methods("GET")
+    router.HandleFunc("/containers/{id}", h.authMiddleware.ModuleMiddleware(models.ModuleStorage, models.PermissionManage)(h.handleDeleteContainer)).Methods("DELETE")
+    router.HandleFunc("/containers/{id}", h.authMiddleware.ModuleMiddleware(models.ModuleStorage, models.PermissionWrite)(h.handleUpdateContainer)).Methods("PUT")
     
-    router.HandleFunc("/containers/{id}/restore", h.authMiddleware.ProfileAuthHandler(h.handleRestoreContainer)).Methods("PUT")
+    router.HandleFunc("/containers/{id}/items", h.authMiddleware.ModuleMiddleware(models.ModuleStorage, models.PermissionRead)(h.handleGetContainerItems)).Methods("GET")
+
+    router.HandleFunc("/containers/{id}/activity", h.authMiddleware.ModuleMiddleware(models.ModuleStorage, models.PermissionRead)(h.handleGetContainerActivity)).Methods("GET")
+    router.HandleFunc("/containers/{id}/restore", h.authMiddleware.ModuleMiddleware(models.ModuleStorage, models.PermissionManage)(h.handleRestoreContainer)).Methods("PUT")
+
+    router.HandleFunc("/containers/{id}/qr", h.authMiddleware.ModuleMiddleware(models.ModuleStorage, models.PermissionManage)(h.handleRegenerateQRCode)).Methods("POST")
 
-    router.HandleFunc("/containers/qr/{qrcode}", h.authMiddleware.ProfileAuthHandler(h.handleGetContainerByQR)).Methods("GET")
+    router.HandleFunc("/containers/qr/{qrcode}", h.authMiddleware.ModuleMiddleware(models.ModuleStorage, models.PermissionRead)(h.handleGetContainerByQR)).Methods("GET")
 }
 
 func (h *Handler) handleGetContainers(w http.ResponseWriter, r *http.Request) {
-    profileCtx := r.Context().Value("profile").(*models.ProfileContext)
+    profileCtx, ok := middleware.ProfileFrom(r.Context())
+    if !ok {
+        respond.Error(w, r, apperror.Unauthorized("profile authentication required"))
+        return
+    }
 
-    containers, err := h.service.GetContainersByFamilyID(profileCtx.FamilyID)
+    containers, err := h.service.GetContainersByFamilyID(r.Context(), profileCtx.FamilyID)
     if err != nil {
-        writeError(w, http.StatusInternalServerError, err.Error())
+        respond.Error(w, r, err)
         return
     }
-    writeJSON(w, http.StatusOK, containers)
+    respond.JSON(w, http.StatusOK, containers)
 }
 
 func (h *Handler) handleCreateContainer(w http.ResponseWriter, r *http.Request) {
-    profileCtx := r.Context().Value("profile").(*models.ProfileContext)
+    profileCtx, ok := middleware.ProfileFrom(r.Context())
+    if !ok {
+        respond.Error(w, r, apperror.Unauthorized("profile authentication required"))
+        return
+    }
 
     var req CreateContainerRequest
     if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-        writeError(w, http.StatusBadRequest, "invalid request body")
+        respond.Error(w, r, apperror.BadRequest("invalid request body"))
         return
     }
 
-    container, err := h.service.CreateContainer(profileCtx.ProfileID, profileCtx.FamilyID, &req)
+    container, err := h.service.CreateContainer(r.Context(), profileCtx.ProfileID, profileCtx.FamilyID, &req)
     if err != nil {
-        writeError(w, http.StatusInternalServerError, err.Error())
+        respond.Error(w, r, err)
         return
     }
-    writeJSON(w, http.StatusCreated, container)
+    respond.JSON(w, http.StatusCreated, container)
 }
 
 func (h *Handler) handleGetContainerByID(w http.ResponseWriter, r *http.Request) {
-    profileCtx := r.Context().Value("profile").(*models.ProfileContext)
+    profileCtx, ok := middleware.ProfileFrom(r.Context())
+    if !ok {
+        respond.Error(w, r, apperror.Unauthorized("profile authentication required"))
+        return
+    }
 
     containerID, err := getIDFromRequest(r)
     if err != nil {
-        writeError(w, http.StatusBadRequest, err.Error())
+        respond.Error(w, r, err)
         return
     }
 
-    container, err := h.service.GetContainerByID(containerID, profileCtx.FamilyID)
+    container, err := h.service.GetContainerByID(r.Context(), containerID, profileCtx.FamilyID)
     if err != nil {
-        writeError(w, http.StatusNotFound, err.Error())
+        respond.Error(w, r, err)
         return
     }
 
-    writeJSON(w, http.StatusOK, container)
+    respond.JSON(w, http.StatusOK, container)
+}
+
+// handleGetContainerItems lists a container's items. Items in nested
+// containers are included unless recursive=false is given.
+func (h *Handler) handleGetContainerItems(w http.ResponseWriter, r *http.Request) {
+    profileCtx, ok := middleware.ProfileFrom(r.Context())
+    if !ok {
+        respond.Error(w, r, apperror.Unauthorized("profile authentication required"))
+        return
+    }
+
+    containerID, err := getIDFromRequest(r)
+    if err != nil {
+        respond.Error(w, r, err)
+        return
+    }
+
+    recursive := true
+    if value := r.URL.Query().Get("recursive"); value != "" {
+        recursive, err = strconv.ParseBool(value)
+        if err != nil {
+            respond.Error(w, r, apperror.BadRequest("recursive must be true or false"))
+            return
+        }
+    }
+
+    items, err := h.service.GetContainerItems(r.Context(), containerID, profileCtx.FamilyID, recursive)
+    if err != nil {
+        respond.Error(w, r, err)
+        return
+    }
+
+    respond.JSON(w, http.StatusOK, items)
+}
+
+// handleGetContainerActivity pages through the container's in/out feed with
+// the optional limit and before query parameters.
+func (h *Handler) handleGetContainerActivity(w http.ResponseWriter, r *http.Request) {
+    profileCtx, ok := middleware.ProfileFrom(r.Context())
+    if !ok {
+        respond.Error(w, r, apperror.Unauthorized("profile authentication required"))
+        return
+    }
+
+    containerID, err := getIDFromRequest(r)
+    if err != nil {
+        respond.Error(w, r, err)
+        return
+    }
+
+    var limit, beforeID int
+    if value := r.URL.Query().Get("limit"); value != "" {
+        if limit, err = strconv.Atoi(value); err != nil {
+            respond.Error(w, r, apperror.BadRequest("invalid limit"))
+            return
+        }
+    }
+    if value := r.URL.Query().Get("before"); value != "" {
+        if beforeID, err = strconv.Atoi(value); err != nil {
+            respond.Error(w, r, apperror.BadRequest("invalid before"))
+            return
+        }
+    }
+
+    events, err := h.service.GetContainerActivity(r.Context(), containerID, profileCtx.FamilyID, limit, beforeID)
+    if err != nil {
+        respond.Error(w, r, err)
+        return
+    }
+
+    respond.JSON(w, http.StatusOK, events)
 }
 
 func (h *Handler) handleUpdateContainer(w http.ResponseWriter, r *http.Request) {
-    profileCtx := r.Context().Value("profile").(*models.ProfileContext)
+    profileCtx, ok := middleware.ProfileFrom(r.Context())
+    if !ok {
+        respond.Error(w, r, apperror.Unauthorized("profile authentication required"))
+        return
+    }
 
     containerID, err := getIDFromRequest(r)
     if err != nil {
-        writeError(w, http.StatusBadRequest, err.Error())
+        respond.Error(w, r, err)
         return
     }
 
     var req UpdateContainerRequest
     if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-        writeError(w, http.StatusBadRequest, "invalid request body")
+        respond.Error(w, r, apperror.BadRequest("invalid request body"))
         return
     }
 
-    container, err := h.service.UpdateContainer(containerID, profileCtx.FamilyID, &req)
+    container, err := h.service.UpdateContainer(r.Context(), containerID, profileCtx.FamilyID, &req)
     if err != nil {
-        writeError(w, http.StatusInternalServerError, err.Error())
+        respond.Error(w, r, err)
         return
     }
-    writeJSON(w, http.StatusOK, container)
+    respond.JSON(w, http.StatusOK, container)
 }
 
 func (h *Handler) handleGetContainerByQR(w http.ResponseWriter, r *http.Request) {
-    profileCtx := r.Context().Value("profile").(*models.ProfileContext)
+    profileCtx, ok := middleware.ProfileFrom(r.Context())
+    if !ok {
+        respond.Error(w, r, apperror.Unauthorized("profile authentication required"))
+        return
+    }
 
     vars := mux.Vars(r)
     qrCode := strings.TrimSpace(vars["qrcode"])
     if qrCode == "" {
-        writeError(w, http.StatusBadRequest, "QR code is required")
+        respond.Error(w, r, apperror.BadRequest("QR code is required"))
+        return
+    }
+
+    container, err := h.service.GetContainerByQR(r.Context(), qrCode, profileCtx.FamilyID)
+    if err != nil {
+        respond.Error(w, r, err)
+        return
+    }
+
+    respond.JSON(w, http.StatusOK, container)
+}
+
+func (h *Handler) handleRegenerateQRCode(w http.ResponseWriter, r *http.Request) {
+    profileCtx, ok := middleware.ProfileFrom(r.Context())
+    if !ok {
+        respond.Error(w, r, apperror.Unauthorized("profile authentication required"))
+        return
+    }
+
+    containerID, err := getIDFromRequest(r)
+    if err != nil {
+        respond.Error(w, r, err)
+        return
+    }
+
+    container, err := h.service.RegenerateQRCode(r.Context(), containerID, profileCtx.FamilyID)
+    if err != nil {
+        respond.Error(w, r, err)
+        return
+    }
+
+    respond.JSON(w, http.StatusOK, container)
+}
+
+func (h *Handler) handleGetLabels(w http.ResponseWriter, r *http.Request) {
+    profileCtx, ok := middleware.ProfileFrom(r.Context())
+    if !ok {
+        respond.Error(w, r, apperror.Unauthorized("profile authentication required"))
+        return
+    }
+
+    var req LabelsRequest
+    if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+        respond.Error(w, r, apperror.BadRequest("invalid request body"))
         return
     }
 
-    container, err := h.service.GetContainerByQR(qrCode, profileCtx.FamilyID)
+    document, err := h.service.GenerateLabels(r.Context(), profileCtx.FamilyID, &req)
     if err != nil {
-        writeError(w, http.StatusNotFound, err.Error())
+        respond.Error(w, r, err)
         return
     }
 
-    writeJSON(w, http.StatusOK, container)
+    w.Header().Set("Content-Type", "application/pdf")
+    w.Header().Set("Content-Disposition", `attachment; filename="container-labels.pdf"`)
+    w.WriteHeader(http.StatusOK)
+    w.Write(document)
 }
 
 func (h *Handler) handleDeleteContainer(w http.ResponseWriter, r *http.Request) {
-    profileCtx := r.Context().Value("profile").(*models.ProfileContext)
+    profileCtx, ok := middleware.ProfileFrom(r.Context())
+    if !ok {
+        respond.Error(w, r, apperror.Unauthorized("profile authentication required"))
+        return
+    }
 
     containerID, err := getIDFromRequest(r)
     if err != nil {
-        writeError(w, http.StatusBadRequest, err.Error())
+        respond.Error(w, r, err)
         return
     }
 
-    if err := h.service.DeleteContainer(containerID, profileCtx.FamilyID, profileCtx.ProfileID); err != nil {
-        writeError(w, http.StatusInternalServerError, err.Error())
+    if err := h.service.DeleteContainer(r.Context(), containerID, profileCtx.FamilyID, profileCtx.ProfileID); err != nil {
+        respond.Error(w, r, err)
         return
     }
-    writeJSON(w, http.StatusOK, map[string]int{"deleted": containerID})
+    respond.JSON(w, http.StatusOK, map[string]int{"deleted": containerID})
 }
 
 func (h *Handler) handleRestoreContainer(w http.ResponseWriter, r *http.Request) {
-    profileCtx := r.Context().Value("profile").(*models.ProfileContext)
+    profileCtx, ok := middleware.ProfileFrom(r.Context())
+    if !ok {
+        respond.Error(w, r, apperror.Unauthorized("profile authentication required"))
+        return
+    }
 
     containerID, err := getIDFromRequest(r)
     if err != nil {
-        writeError(w, http.StatusBadRequest, err.Error())
+        respond.Error(w, r, err)
         return
     }
 
-    if err := h.service.RestoreContainer(containerID, profileCtx.FamilyID); err != nil {
-        writeError(w, http.StatusInternalServerError, err.Error())
+    if err := h.service.RestoreContainer(r.Context(), containerID, profileCtx.FamilyID); err != nil {
+        respond.Error(w, r, err)
         return
     }
-    writeJSON(w, http.StatusOK, map[string]int{"restored": containerID})
+    respond.JSON(w, http.StatusOK, map[string]int{"restored": containerID})
 }
 
 func getIDFromRequest(r *http.Request) (int, error) {
 	vars := mux.Vars(r)
-	return strconv.Atoi(vars["id"])
-}
-
-func writeJSON(w http.ResponseWriter, status int, v interface{}) {
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(status)
-	json.NewEncoder(w).Encode(v)
-}
-
-func writeError(w http.ResponseWriter, status int, message string) {
-	writeJSON(w, status, map[string]string{"error": message})
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		return 0, apperror.BadRequest("invalid id")
+	}
+	return id, nil
 }
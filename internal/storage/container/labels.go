@@ -0,0 +1,213 @@
+package container
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/chrisabs/cadence/internal/storage/entities"
+	"github.com/chrisabs/cadence/pkg/pdf"
+	"github.com/chrisabs/cadence/pkg/utils"
+)
+
+type LabelLayout string
+
+const (
+	LabelLayoutL7160  LabelLayout = "L7160"
+	LabelLayout5160   LabelLayout = "5160"
+	LabelLayoutSingle LabelLayout = "single"
+)
+
+// labelSheet describes a label stock. Offsets are from the top left corner of
+// the page, in points.
+type labelSheet struct {
+	pageWidth   float64
+	pageHeight  float64
+	columns     int
+	rows        int
+	labelWidth  float64
+	labelHeight float64
+	marginLeft  float64
+	marginTop   float64
+	pitchX      float64
+	pitchY      float64
+	padding     float64
+}
+
+var labelSheets = map[LabelLayout]labelSheet{
+	// Avery L7160: A4, 21 labels of 63.5 x 38.1mm.
+	LabelLayoutL7160: {
+		pageWidth:   210 * pdf.MM,
+		pageHeight:  297 * pdf.MM,
+		columns:     3,
+		rows:        7,
+		labelWidth:  63.5 * pdf.MM,
+		labelHeight: 38.1 * pdf.MM,
+		marginLeft:  7.2 * pdf.MM,
+		marginTop:   15.15 * pdf.MM,
+		pitchX:      66 * pdf.MM,
+		pitchY:      38.1 * pdf.MM,
+		padding:     2.5 * pdf.MM,
+	},
+	// Avery 5160: US Letter, 30 labels of 2.625 x 1in.
+	LabelLayout5160: {
+		pageWidth:   8.5 * pdf.Inch,
+		pageHeight:  11 * pdf.Inch,
+		columns:     3,
+		rows:        10,
+		labelWidth:  2.625 * pdf.Inch,
+		labelHeight: 1 * pdf.Inch,
+		marginLeft:  0.1875 * pdf.Inch,
+		marginTop:   0.5 * pdf.Inch,
+		pitchX:      2.75 * pdf.Inch,
+		pitchY:      1 * pdf.Inch,
+		padding:     0.06 * pdf.Inch,
+	},
+	// One large label per A4 page.
+	LabelLayoutSingle: {
+		pageWidth:   210 * pdf.MM,
+		pageHeight:  297 * pdf.MM,
+		columns:     1,
+		rows:        1,
+		labelWidth:  170 * pdf.MM,
+		labelHeight: 230 * pdf.MM,
+		marginLeft:  20 * pdf.MM,
+		marginTop:   33.5 * pdf.MM,
+		pitchX:      170 * pdf.MM,
+		pitchY:      230 * pdf.MM,
+		padding:     10 * pdf.MM,
+	},
+}
+
+type labelLine struct {
+	text string
+	size float64
+	bold bool
+}
+
+// renderLabels lays the containers out on the sheet, leaving the first skip
+// positions empty so a partly used sheet can be fed through again.
+func renderLabels(containers []*entities.Container, layout LabelLayout, skip int) ([]byte, error) {
+	sheet, ok := labelSheets[layout]
+	if !ok {
+		return nil, fmt.Errorf("unknown label layout %q", layout)
+	}
+
+	doc := pdf.New(sheet.pageWidth, sheet.pageHeight)
+	perPage := sheet.columns * sheet.rows
+
+	var page *pdf.Page
+	for i, container := range containers {
+		position := (skip + i) % perPage
+		if page == nil || position == 0 {
+			page = doc.AddPage()
+		}
+
+		col := position % sheet.columns
+		row := position / sheet.columns
+		x := sheet.marginLeft + float64(col)*sheet.pitchX
+		y := sheet.pageHeight - sheet.marginTop - float64(row)*sheet.pitchY - sheet.labelHeight
+
+		if err := drawLabel(doc, page, sheet, container, x, y); err != nil {
+			return nil, err
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := doc.Write(&buf); err != nil {
+		return nil, fmt.Errorf("error writing label PDF: %w", err)
+	}
+
+	return buf.Bytes(), nil
+}
+
+// drawLabel draws one label with its bottom left corner at x, y. Wide labels
+// put the QR code on the left with the text beside it; tall ones put the text
+// underneath.
+func drawLabel(doc *pdf.Document, page *pdf.Page, sheet labelSheet, container *entities.Container, x, y float64) error {
+	w, h, pad := sheet.labelWidth, sheet.labelHeight, sheet.padding
+	wide := w >= h*1.3
+
+	var qrSize, textX, textTop, textWidth float64
+	if wide {
+		qrSize = h - 2*pad
+		textX = x + qrSize + 2*pad
+		textTop = y + h - pad
+		textWidth = w - qrSize - 3*pad
+	} else {
+		qrSize = w - 2*pad
+		if qrSize > h*0.7 {
+			qrSize = h * 0.7
+		}
+		textX = x + pad
+		textTop = y + h - 2*pad - qrSize
+		textWidth = w - 2*pad
+	}
+
+	if container.QRCode != "" {
+		img, err := qrImage(doc, container.QRCode)
+		if err != nil {
+			return err
+		}
+
+		qrX := x + pad
+		if !wide {
+			qrX = x + (w-qrSize)/2
+		}
+		page.Image(img, qrX, y+h-pad-qrSize, qrSize, qrSize)
+	}
+
+	nameSize := h * 0.14
+	if nameSize > 12 && wide {
+		nameSize = 12
+	}
+	if !wide {
+		nameSize = 28
+	}
+	detailSize := nameSize * 0.8
+
+	lines := []labelLine{
+		{container.Name, nameSize, true},
+		{fmt.Sprintf("#%d", container.Number), detailSize, false},
+	}
+	if container.Location != "" {
+		lines = append(lines, labelLine{container.Location, detailSize, false})
+	}
+
+	lineY := textTop
+	for _, line := range lines {
+		lineY -= line.size * 1.2
+		if lineY < y+pad/2 {
+			break
+		}
+
+		text := pdf.Truncate(line.text, line.size, line.bold, textWidth)
+		lineX := textX
+		if !wide {
+			lineX = x + (w-pdf.TextWidth(text, line.size, line.bold))/2
+		}
+		page.Text(lineX, lineY, line.size, line.bold, text)
+	}
+
+	return nil
+}
+
+func qrImage(doc *pdf.Document, payload string) (*pdf.Image, error) {
+	bitmap, err := utils.QRCodeBitmap(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	size := len(bitmap)
+	pixels := make([]byte, 0, size*size)
+	for _, row := range bitmap {
+		for _, black := range row {
+			if black {
+				pixels = append(pixels, 0)
+			} else {
+				pixels = append(pixels, 255)
+			}
+		}
+	}
+
+	return doc.AddGrayImage(size, size, pixels)
+}
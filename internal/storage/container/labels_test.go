@@ -0,0 +1,221 @@
+package container
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"testing"
+
+	"github.com/chrisabs/cadence/internal/storage/entities"
+)
+
+// parsedPDF holds the objects of a rendered document, keyed by object number,
+// after checking that the xref table points at each of them.
+type parsedPDF struct {
+	objects map[int][]byte
+}
+
+var (
+	startXrefPattern = regexp.MustCompile(`startxref\n(\d+)\n%%EOF\n$`)
+	xrefEntryPattern = regexp.MustCompile(`(\d{10}) 00000 n `)
+	contentsPattern  = regexp.MustCompile(`/Contents (\d+) 0 R`)
+	kidsPattern      = regexp.MustCompile(`/Kids \[([^\]]*)\] /Count (\d+)`)
+	lengthPattern    = regexp.MustCompile(`/Length (\d+) >>\nstream\n`)
+	imagePattern     = regexp.MustCompile(`q [\d.]+ 0 0 [\d.]+ ([\d.]+) ([\d.]+) cm /Im\d+ Do Q`)
+)
+
+func parsePDF(t *testing.T, data []byte) *parsedPDF {
+	t.Helper()
+
+	if !bytes.HasPrefix(data, []byte("%PDF-1.4\n")) {
+		t.Fatalf("missing PDF header")
+	}
+
+	match := startXrefPattern.FindSubmatch(data)
+	if match == nil {
+		t.Fatalf("missing startxref trailer")
+	}
+	xrefOffset, _ := strconv.Atoi(string(match[1]))
+	if xrefOffset >= len(data) || !bytes.HasPrefix(data[xrefOffset:], []byte("xref\n")) {
+		t.Fatalf("startxref %d does not point at the xref table", xrefOffset)
+	}
+
+	entries := xrefEntryPattern.FindAllSubmatch(data[xrefOffset:], -1)
+	if len(entries) == 0 {
+		t.Fatalf("empty xref table")
+	}
+
+	doc := &parsedPDF{objects: make(map[int][]byte)}
+	for i, entry := range entries {
+		id := i + 1
+		offset, _ := strconv.Atoi(string(entry[1]))
+		header := []byte(fmt.Sprintf("%d 0 obj\n", id))
+		if !bytes.HasPrefix(data[offset:], header) {
+			t.Fatalf("xref offset %d for object %d does not point at its header", offset, id)
+		}
+
+		end := bytes.Index(data[offset:], []byte("endobj\n"))
+		if end < 0 {
+			t.Fatalf("object %d is not terminated", id)
+		}
+		doc.objects[id] = data[offset+len(header) : offset+end]
+	}
+
+	return doc
+}
+
+// pages returns the decompressed content stream of each page, in order.
+func (d *parsedPDF) pages(t *testing.T) [][]byte {
+	t.Helper()
+
+	match := kidsPattern.FindSubmatch(d.objects[2])
+	if match == nil {
+		t.Fatalf("page tree has no kids")
+	}
+	count, _ := strconv.Atoi(string(match[2]))
+
+	var contents [][]byte
+	for _, ref := range regexp.MustCompile(`(\d+) 0 R`).FindAllSubmatch(match[1], -1) {
+		pageID, _ := strconv.Atoi(string(ref[1]))
+		contentsMatch := contentsPattern.FindSubmatch(d.objects[pageID])
+		if contentsMatch == nil {
+			t.Fatalf("page object %d has no contents", pageID)
+		}
+		contentID, _ := strconv.Atoi(string(contentsMatch[1]))
+		contents = append(contents, d.stream(t, contentID))
+	}
+
+	if len(contents) != count {
+		t.Fatalf("page tree lists %d kids but a count of %d", len(contents), count)
+	}
+	return contents
+}
+
+func (d *parsedPDF) stream(t *testing.T, id int) []byte {
+	t.Helper()
+
+	object := d.objects[id]
+	loc := lengthPattern.FindSubmatchIndex(object)
+	if loc == nil {
+		t.Fatalf("object %d is not a stream", id)
+	}
+	length, _ := strconv.Atoi(string(object[loc[2]:loc[3]]))
+	compressed := object[loc[1] : loc[1]+length]
+
+	zr, err := zlib.NewReader(bytes.NewReader(compressed))
+	if err != nil {
+		t.Fatalf("object %d: %v", id, err)
+	}
+	content, err := io.ReadAll(zr)
+	if err != nil {
+		t.Fatalf("object %d: %v", id, err)
+	}
+	return content
+}
+
+// imagePositions returns the x, y placement of every QR code on a page.
+func imagePositions(content []byte) []string {
+	var positions []string
+	for _, match := range imagePattern.FindAllSubmatch(content, -1) {
+		positions = append(positions, string(match[1])+" "+string(match[2]))
+	}
+	return positions
+}
+
+func testContainers(n int) []*entities.Container {
+	containers := make([]*entities.Container, n)
+	for i := range containers {
+		containers[i] = &entities.Container{
+			Name:     fmt.Sprintf("Box %d", i+1),
+			Number:   i + 1,
+			Location: "Garage (top shelf)",
+			QRCode:   fmt.Sprintf("STQRAGE-test%d", i+1),
+		}
+	}
+	return containers
+}
+
+func TestRenderLabelsPages(t *testing.T) {
+	for layout, sheet := range labelSheets {
+		perPage := sheet.columns * sheet.rows
+
+		tests := []struct {
+			name       string
+			containers int
+			skip       int
+			pages      int
+		}{
+			{"one label", 1, 0, 1},
+			{"full sheet", perPage, 0, 1},
+			{"one past a full sheet", perPage + 1, 0, 2},
+			{"skip pushes the last label over", perPage, 1, 2},
+		}
+
+		for _, tt := range tests {
+			t.Run(string(layout)+" "+tt.name, func(t *testing.T) {
+				skip := tt.skip
+				pages := tt.pages
+				if perPage == 1 {
+					// A single label sheet has no positions to skip.
+					pages = tt.containers
+				}
+
+				data, err := renderLabels(testContainers(tt.containers), layout, skip)
+				if err != nil {
+					t.Fatalf("renderLabels: %v", err)
+				}
+
+				contents := parsePDF(t, data).pages(t)
+				if len(contents) != pages {
+					t.Fatalf("expected %d pages, got %d", pages, len(contents))
+				}
+
+				var labels int
+				for _, content := range contents {
+					labels += len(imagePositions(content))
+				}
+				if labels != tt.containers {
+					t.Errorf("expected %d labels, got %d", tt.containers, labels)
+				}
+			})
+		}
+	}
+}
+
+func TestRenderLabelsSkip(t *testing.T) {
+	for layout, sheet := range labelSheets {
+		perPage := sheet.columns * sheet.rows
+		if perPage == 1 {
+			continue
+		}
+
+		for _, skip := range []int{1, sheet.columns, perPage - 1} {
+			t.Run(fmt.Sprintf("%s skip %d", layout, skip), func(t *testing.T) {
+				full, err := renderLabels(testContainers(perPage), layout, 0)
+				if err != nil {
+					t.Fatalf("renderLabels: %v", err)
+				}
+				want := imagePositions(parsePDF(t, full).pages(t)[0])
+
+				skipped, err := renderLabels(testContainers(1), layout, skip)
+				if err != nil {
+					t.Fatalf("renderLabels: %v", err)
+				}
+				got := imagePositions(parsePDF(t, skipped).pages(t)[0])
+
+				if len(got) != 1 || got[0] != want[skip] {
+					t.Errorf("expected the label at position %d (%s), got %v", skip, want[skip], got)
+				}
+			})
+		}
+	}
+}
+
+func TestRenderLabelsUnknownLayout(t *testing.T) {
+	if _, err := renderLabels(testContainers(1), LabelLayout("A0"), 0); err == nil {
+		t.Error("expected an error for an unknown layout")
+	}
+}
@@ -1,25 +1,34 @@
 package container
 
 type CreateItemRequest struct {
-	Name        string `json:"name"`
-	Description string `json:"description"`
-	ImageURL    string `json:"imageUrl"`
-	Quantity    int    `json:"quantity"`
+	Name        string `json:"name" validate:"required,max=100"`
+	Description string `json:"description" validate:"max=1000"`
+	ImageURL    string `json:"imageUrl" validate:"max=2048"`
+	Quantity    int    `json:"quantity" validate:"min=0"`
 	TagIDs      []int  `json:"tagIds"`
 }
 
 type CreateContainerRequest struct {
-    Name        string              `json:"name"`
-	Description string              `json:"description"`
-    Location    string              `json:"location"`
-    WorkspaceID *int                `json:"workspaceId,omitempty"`
-    Items       []CreateItemRequest `json:"items"`
+    Name              string              `json:"name" validate:"required,max=50"`
+	Description       string              `json:"description" validate:"max=1000"`
+    Location          string              `json:"location" validate:"max=50"`
+    WorkspaceID       *int                `json:"workspaceId,omitempty"`
+    ParentContainerID *int                `json:"parentContainerId,omitempty"`
+    Items             []CreateItemRequest `json:"items" validate:"dive"`
 }
 
 type UpdateContainerRequest struct {
-    Name        string `json:"name"`
-	Description string `json:"description"`
-    Location    string `json:"location"`
-    WorkspaceID *int   `json:"workspaceId,omitempty"`
-    ItemIDs     []int  `json:"itemIds,omitempty"`
+    Name              string `json:"name" validate:"required,max=50"`
+	Description       string `json:"description" validate:"max=1000"`
+    Location          string `json:"location" validate:"max=50"`
+    WorkspaceID       *int   `json:"workspaceId,omitempty"`
+    ParentContainerID *int   `json:"parentContainerId,omitempty"`
+    ItemIDs           []int  `json:"itemIds,omitempty"`
+}
+
+type LabelsRequest struct {
+	ContainerIDs []int       `json:"containerIds" validate:"max=500"`
+	WorkspaceID  *int        `json:"workspaceId,omitempty"`
+	Layout       LabelLayout `json:"layout" validate:"required,oneof=L7160 5160 single"`
+	Skip         int         `json:"skip" validate:"min=0,max=29"`
 }
@@ -1,12 +1,17 @@
 package container
 
 import (
+	"context"
 	"database/sql"
 	"encoding/json"
 	"fmt"
 	"time"
 
+	"github.com/chrisabs/cadence/internal/apperror"
+	"github.com/chrisabs/cadence/internal/storage/deletion"
 	"github.com/chrisabs/cadence/internal/storage/entities"
+	"github.com/chrisabs/cadence/internal/storage/history"
+	"github.com/lib/pq"
 )
 
 type Repository struct {
@@ -17,24 +22,43 @@ func NewRepository(db *sql.DB) *Repository {
     return &Repository{db: db}
 }
 
-func (r *Repository) Create(container *entities.Container, itemRequests []CreateItemRequest) error {
-    tx, err := r.db.Begin()
+func (r *Repository) Create(ctx context.Context, container *entities.Container, itemRequests []CreateItemRequest) error {
+    tx, err := r.db.BeginTx(ctx, nil)
     if err != nil {
-        return fmt.Errorf("error starting transaction: %v", err)
+        return fmt.Errorf("error starting transaction: %w", err)
     }
     defer tx.Rollback()
 
+    if err := r.CreateTx(ctx, tx, container, itemRequests); err != nil {
+        return err
+    }
+
+    return tx.Commit()
+}
+
+// CreateTx creates the container and its items inside the caller's
+// transaction, for imports that must succeed or fail as a whole.
+func (r *Repository) CreateTx(ctx context.Context, tx *sql.Tx, container *entities.Container, itemRequests []CreateItemRequest) error {
+    numberQuery := `
+        INSERT INTO container_sequence (family_id, last_number)
+        VALUES ($1, 1)
+        ON CONFLICT (family_id) DO UPDATE
+        SET last_number = container_sequence.last_number + 1
+        RETURNING last_number`
+
+    if err := tx.QueryRowContext(ctx, numberQuery, container.FamilyID).Scan(&container.Number); err != nil {
+        return fmt.Errorf("error allocating container number: %w", err)
+    }
+
     containerQuery := `
         INSERT INTO container (
-            id, name, description, qr_code, qr_code_image, number, 
-            location, profile_id, family_id, workspace_id, created_at, updated_at
+            name, description, qr_code, qr_code_image, number, 
+            location, profile_id, family_id, workspace_id, parent_container_id, created_at, updated_at
         ) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
         RETURNING id`
 
-    var containerID int
-    err = tx.QueryRow(
+    err := tx.QueryRowContext(ctx,
         containerQuery,
-        container.ID,
         container.Name,
         container.Description,
         container.QRCode,
@@ -44,12 +68,13 @@ func (r *Repository) Create(container *entities.Container, itemRequests []Create
         container.ProfileID, 
         container.FamilyID,
         container.WorkspaceID,
+        container.ParentContainerID,
         container.CreatedAt,
         container.UpdatedAt,
-    ).Scan(&containerID)
+    ).Scan(&container.ID)
 
     if err != nil {
-        return fmt.Errorf("error creating container: %v", err)
+        return fmt.Errorf("error creating container: %w", err)
     }
 
     if len(itemRequests) > 0 {
@@ -62,30 +87,30 @@ func (r *Repository) Create(container *entities.Container, itemRequests []Create
 
         for _, itemReq := range itemRequests {
             var itemID int
-            err = tx.QueryRow(
+            err = tx.QueryRowContext(ctx,
                 itemQuery,
                 itemReq.Name,
                 itemReq.Description,
                 itemReq.Quantity,
-                containerID,
+                container.ID,
                 container.FamilyID,
                 time.Now().UTC(),
                 time.Now().UTC(),
             ).Scan(&itemID)
 
             if err != nil {
-                return fmt.Errorf("error creating item: %v", err)
+                return fmt.Errorf("error creating item: %w", err)
             }
         }
     }
 
-    return tx.Commit()
+    return nil
 }
 
-func (r *Repository) GetByID(id int, familyID int) (*entities.Container, error) {
+func (r *Repository) GetByID(ctx context.Context, id int, familyID int) (*entities.Container, error) {
     containerQuery := `
         SELECT c.id, c.name, c.description, c.qr_code, c.qr_code_image, c.number, 
-            c.location, c.profile_id, c.family_id, c.workspace_id, c.created_at, c.updated_at,
+            c.location, c.profile_id, c.family_id, c.workspace_id, c.parent_container_id, c.created_at, c.updated_at,
             w.id, w.name, w.description, w.profile_id, w.family_id, w.created_at, w.updated_at
         FROM container c
         LEFT JOIN workspace w ON c.workspace_id = w.id AND w.family_id = c.family_id AND w.is_deleted = false
@@ -93,6 +118,7 @@ func (r *Repository) GetByID(id int, familyID int) (*entities.Container, error)
 
     container := new(entities.Container)
     var workspaceID sql.NullInt64
+    var parentID sql.NullInt64
     var wsFields struct {
         ID          sql.NullInt64
         Name        sql.NullString
@@ -103,23 +129,28 @@ func (r *Repository) GetByID(id int, familyID int) (*entities.Container, error)
         UpdatedAt   sql.NullTime
     }
 
-    err := r.db.QueryRow(containerQuery, id, familyID).Scan(
+    err := r.db.QueryRowContext(ctx, containerQuery, id, familyID).Scan(
         &container.ID, &container.Name, &container.Description, &container.QRCode,
         &container.QRCodeImage, &container.Number, &container.Location,
         &container.ProfileID,
-        &container.FamilyID, &workspaceID, 
+        &container.FamilyID, &workspaceID, &parentID,
         &container.CreatedAt, &container.UpdatedAt,
         &wsFields.ID, &wsFields.Name, &wsFields.Description,
         &wsFields.profileId, &wsFields.FamilyID, &wsFields.CreatedAt, &wsFields.UpdatedAt,
     )
 
     if err == sql.ErrNoRows {
-        return nil, fmt.Errorf("container not found")
+        return nil, apperror.NotFound("container not found")
     }
     if err != nil {
         return nil, err
     }
 
+    if parentID.Valid {
+        pid := int(parentID.Int64)
+        container.ParentContainerID = &pid
+    }
+
     if workspaceID.Valid && wsFields.ID.Valid {
         wsID := int(workspaceID.Int64)
         container.WorkspaceID = &wsID
@@ -165,14 +196,14 @@ func (r *Repository) GetByID(id int, familyID int) (*entities.Container, error)
                ) as tags
         FROM item i
         LEFT JOIN item_images img ON i.id = img.item_id
-        LEFT JOIN item_tag it ON i.id = it.item_id
-        LEFT JOIN tag t ON it.tag_id = t.id AND t.family_id = i.family_id
-        WHERE i.container_id = $1 AND i.family_id = $2
+        LEFT JOIN item_tag it ON i.id = it.item_id AND it.is_deleted = false
+        LEFT JOIN tag t ON it.tag_id = t.id AND t.family_id = i.family_id AND t.is_deleted = false
+        WHERE i.container_id = $1 AND i.family_id = $2 AND i.is_deleted = false
         GROUP BY i.id, i.name, i.description, i.quantity, 
                  i.container_id, i.family_id, i.created_at, i.updated_at,
                  img.images`
 
-    rows, err := r.db.Query(itemsQuery, id, familyID)
+    rows, err := r.db.QueryContext(ctx, itemsQuery, id, familyID)
     if err != nil {
         return nil, err
     }
@@ -194,32 +225,40 @@ func (r *Repository) GetByID(id int, familyID int) (*entities.Container, error)
         }
 
         if err := json.Unmarshal(imagesJSON, &item.Images); err != nil {
-            return nil, fmt.Errorf("error parsing images: %v", err)
+            return nil, fmt.Errorf("error parsing images: %w", err)
         }
 
         if err := json.Unmarshal(tagsJSON, &item.Tags); err != nil {
-            return nil, fmt.Errorf("error parsing tags: %v", err)
+            return nil, fmt.Errorf("error parsing tags: %w", err)
         }
 
         container.Items = append(container.Items, item)
     }
 
+    if container.Path, err = r.GetPath(ctx, container.ID, familyID); err != nil {
+        return nil, err
+    }
+
+    if container.ChildContainers, err = r.GetChildren(ctx, container.ID, familyID); err != nil {
+        return nil, err
+    }
+
     return container, nil
 }
 
-func (r *Repository) GetByFamilyID(familyID int) ([]*entities.Container, error) {
+func (r *Repository) GetByFamilyID(ctx context.Context, familyID int) ([]*entities.Container, error) {
     query := `
         SELECT c.id, c.name, c.description, c.qr_code, c.qr_code_image, c.number, 
-            c.location, c.profile_id, c.family_id, c.workspace_id, c.created_at, c.updated_at,
+            c.location, c.profile_id, c.family_id, c.workspace_id, c.parent_container_id, c.created_at, c.updated_at,
             w.id, w.name, w.description, w.profile_id, w.family_id, w.created_at, w.updated_at
         FROM container c
         LEFT JOIN workspace w ON c.workspace_id = w.id AND w.family_id = c.family_id AND w.is_deleted = false
         WHERE c.family_id = $1 AND c.is_deleted = false
         ORDER BY c.created_at DESC`
 
-    rows, err := r.db.Query(query, familyID)
+    rows, err := r.db.QueryContext(ctx, query, familyID)
     if err != nil {
-        return nil, fmt.Errorf("error querying containers: %v", err)
+        return nil, fmt.Errorf("error querying containers: %w", err)
     }
     defer rows.Close()
 
@@ -227,6 +266,7 @@ func (r *Repository) GetByFamilyID(familyID int) ([]*entities.Container, error)
     for rows.Next() {
         container := new(entities.Container)
         var workspaceID sql.NullInt64
+        var parentID sql.NullInt64
         var wsFields struct {
             ID          sql.NullInt64
             Name        sql.NullString
@@ -241,13 +281,18 @@ func (r *Repository) GetByFamilyID(familyID int) ([]*entities.Container, error)
             &container.ID, &container.Name, &container.Description, &container.QRCode,
             &container.QRCodeImage, &container.Number, &container.Location,
             &container.ProfileID, 
-            &container.FamilyID, &workspaceID, 
+            &container.FamilyID, &workspaceID, &parentID,
             &container.CreatedAt, &container.UpdatedAt,
             &wsFields.ID, &wsFields.Name, &wsFields.Description,
             &wsFields.profileId, &wsFields.FamilyID, &wsFields.CreatedAt, &wsFields.UpdatedAt,
         )
         if err != nil {
-            return nil, fmt.Errorf("error scanning container: %v", err)
+            return nil, fmt.Errorf("error scanning container: %w", err)
+        }
+
+        if parentID.Valid {
+            pid := int(parentID.Int64)
+            container.ParentContainerID = &pid
         }
 
         if workspaceID.Valid && wsFields.ID.Valid {
@@ -295,16 +340,16 @@ func (r *Repository) GetByFamilyID(familyID int) ([]*entities.Container, error)
                    ) as tags
             FROM item i
             LEFT JOIN item_images img ON i.id = img.item_id
-            LEFT JOIN item_tag it ON i.id = it.item_id
-            LEFT JOIN tag t ON it.tag_id = t.id AND t.family_id = i.family_id
-            WHERE i.container_id = $1 AND i.family_id = $2
+            LEFT JOIN item_tag it ON i.id = it.item_id AND it.is_deleted = false
+            LEFT JOIN tag t ON it.tag_id = t.id AND t.family_id = i.family_id AND t.is_deleted = false
+            WHERE i.container_id = $1 AND i.family_id = $2 AND i.is_deleted = false
             GROUP BY i.id, i.name, i.description, i.quantity, 
                      i.container_id, i.family_id, i.created_at, i.updated_at,
                      img.images`
 
-        itemRows, err := r.db.Query(itemsQuery, container.ID, familyID)
+        itemRows, err := r.db.QueryContext(ctx, itemsQuery, container.ID, familyID)
         if err != nil {
-            return nil, fmt.Errorf("error querying items: %v", err)
+            return nil, fmt.Errorf("error querying items: %w", err)
         }
 
         container.Items = make([]entities.Item, 0)
@@ -342,11 +387,11 @@ func (r *Repository) GetByFamilyID(familyID int) ([]*entities.Container, error)
     return containers, nil
 }
 
-func (r *Repository) GetByQR(qrCode string, familyID int) (*entities.Container, error) {
+func (r *Repository) GetByQR(ctx context.Context, qrCode string, familyID int) (*entities.Container, error) {
     query := `
     SELECT 
         c.id, c.name, c.description, c.qr_code, c.qr_code_image, c.number, 
-        c.location, c.profile_id, c.family_id, c.workspace_id, c.created_at, c.updated_at,
+        c.location, c.profile_id, c.family_id, c.workspace_id, c.parent_container_id, c.created_at, c.updated_at,
         w.id, w.name, w.description, w.profile_id, w.family_id, w.created_at, w.updated_at
     FROM container c
     LEFT JOIN workspace w ON c.workspace_id = w.id AND w.family_id = c.family_id AND w.is_deleted = false
@@ -354,6 +399,7 @@ func (r *Repository) GetByQR(qrCode string, familyID int) (*entities.Container,
 
     container := new(entities.Container)
     var workspaceID sql.NullInt64
+    var parentID sql.NullInt64
     var wsFields struct {
         ID          sql.NullInt64
         Name        sql.NullString
@@ -364,23 +410,28 @@ func (r *Repository) GetByQR(qrCode string, familyID int) (*entities.Container,
         UpdatedAt   sql.NullTime
     }
 
-    err := r.db.QueryRow(query, qrCode, familyID).Scan(
+    err := r.db.QueryRowContext(ctx, query, qrCode, familyID).Scan(
         &container.ID, &container.Name, &container.Description, &container.QRCode,
         &container.QRCodeImage, &container.Number, &container.Location,
         &container.ProfileID, 
-        &container.FamilyID, &workspaceID, 
+        &container.FamilyID, &workspaceID, &parentID,
         &container.CreatedAt, &container.UpdatedAt,
         &wsFields.ID, &wsFields.Name, &wsFields.Description,
         &wsFields.profileId, &wsFields.FamilyID, &wsFields.CreatedAt, &wsFields.UpdatedAt,
     )
 
     if err == sql.ErrNoRows {
-        return nil, fmt.Errorf("container not found")
+        return nil, apperror.NotFound("container not found")
     }
     if err != nil {
         return nil, err
     }
 
+    if parentID.Valid {
+        pid := int(parentID.Int64)
+        container.ParentContainerID = &pid
+    }
+
     if workspaceID.Valid && wsFields.ID.Valid {
         wsID := int(workspaceID.Int64)
         container.WorkspaceID = &wsID
@@ -426,14 +477,14 @@ func (r *Repository) GetByQR(qrCode string, familyID int) (*entities.Container,
                ) as tags
         FROM item i
         LEFT JOIN item_images img ON i.id = img.item_id
-        LEFT JOIN item_tag it ON i.id = it.item_id
-        LEFT JOIN tag t ON it.tag_id = t.id AND t.family_id = i.family_id
-        WHERE i.container_id = $1 AND i.family_id = $2
+        LEFT JOIN item_tag it ON i.id = it.item_id AND it.is_deleted = false
+        LEFT JOIN tag t ON it.tag_id = t.id AND t.family_id = i.family_id AND t.is_deleted = false
+        WHERE i.container_id = $1 AND i.family_id = $2 AND i.is_deleted = false
         GROUP BY i.id, i.name, i.description, i.quantity, 
                  i.container_id, i.family_id, i.created_at, i.updated_at,
                  img.images`
 
-    rows, err := r.db.Query(itemsQuery, container.ID, familyID)
+    rows, err := r.db.QueryContext(ctx, itemsQuery, container.ID, familyID)
     if err != nil {
         return nil, err
     }
@@ -455,115 +506,426 @@ func (r *Repository) GetByQR(qrCode string, familyID int) (*entities.Container,
         }
 
         if err := json.Unmarshal(imagesJSON, &item.Images); err != nil {
-            return nil, fmt.Errorf("error parsing images: %v", err)
+            return nil, fmt.Errorf("error parsing images: %w", err)
         }
 
         if err := json.Unmarshal(tagsJSON, &item.Tags); err != nil {
-            return nil, fmt.Errorf("error parsing tags: %v", err)
+            return nil, fmt.Errorf("error parsing tags: %w", err)
         }
 
         container.Items = append(container.Items, item)
     }
 
+    if container.Path, err = r.GetPath(ctx, container.ID, familyID); err != nil {
+        return nil, err
+    }
+
+    if container.ChildContainers, err = r.GetChildren(ctx, container.ID, familyID); err != nil {
+        return nil, err
+    }
+
     return container, nil
 }
 
-func (r *Repository) Update(container *entities.Container) error {
+// Update saves the container and moves its nested containers into the same
+// workspace. Tree changes are serialised per family so two concurrent moves
+// cannot form a cycle between them.
+func (r *Repository) Update(ctx context.Context, container *entities.Container) error {
+    tx, err := r.db.BeginTx(ctx, nil)
+    if err != nil {
+        return fmt.Errorf("error starting transaction: %w", err)
+    }
+    defer tx.Rollback()
+
+    if _, err := tx.ExecContext(ctx, `SELECT pg_advisory_xact_lock(hashtext('container_tree'), $1)`, container.FamilyID); err != nil {
+        return fmt.Errorf("error locking container tree: %w", err)
+    }
+
+    if container.ParentContainerID != nil {
+        cycleQuery := `
+            WITH RECURSIVE subtree AS (
+                SELECT id FROM container WHERE id = $1 AND family_id = $2
+                UNION
+                SELECT c.id FROM container c
+                JOIN subtree s ON c.parent_container_id = s.id
+                WHERE c.family_id = $2
+            )
+            SELECT EXISTS (SELECT 1 FROM subtree WHERE id = $3)`
+
+        var cycle bool
+        if err := tx.QueryRowContext(ctx, cycleQuery, container.ID, container.FamilyID, *container.ParentContainerID).Scan(&cycle); err != nil {
+            return fmt.Errorf("error checking container tree: %w", err)
+        }
+        if cycle {
+            return apperror.Validation("invalid parent container", map[string]string{
+                "parentContainerId": "cannot be the container itself or one nested inside it",
+            })
+        }
+    }
+
     query := `
         UPDATE container
-        SET name = $2, description = $3, location = $4, workspace_id = $5, updated_at = $6
-        WHERE id = $1 AND family_id = $7 AND is_deleted = false`
+        SET name = $2, description = $3, location = $4, workspace_id = $5, parent_container_id = $6, updated_at = $7
+        WHERE id = $1 AND family_id = $8 AND is_deleted = false`
 
     var workspaceID sql.NullInt64
     if container.WorkspaceID != nil {
         workspaceID = sql.NullInt64{Int64: int64(*container.WorkspaceID), Valid: true}
     }
 
-    result, err := r.db.Exec(
+    now := time.Now().UTC()
+
+    result, err := tx.ExecContext(ctx,
         query,
         container.ID,
         container.Name,
         container.Description,
         container.Location,
         workspaceID,
-        time.Now().UTC(),
+        container.ParentContainerID,
+        now,
         container.FamilyID,
     )
 
     if err != nil {
-        return fmt.Errorf("error updating container: %v", err)
+        return fmt.Errorf("error updating container: %w", err)
     }
 
     rowsAffected, err := result.RowsAffected()
     if err != nil {
-        return fmt.Errorf("error checking update result: %v", err)
+        return fmt.Errorf("error checking update result: %w", err)
     }
 
     if rowsAffected == 0 {
-        return fmt.Errorf("container not found")
+        return apperror.NotFound("container not found")
     }
 
-    return nil
+    subtreeQuery := `
+        WITH RECURSIVE subtree AS (
+            SELECT id FROM container WHERE parent_container_id = $1 AND family_id = $2
+            UNION
+            SELECT c.id FROM container c
+            JOIN subtree s ON c.parent_container_id = s.id
+            WHERE c.family_id = $2
+        )
+        UPDATE container
+        SET workspace_id = $3, updated_at = $4
+        WHERE id IN (SELECT id FROM subtree) AND workspace_id IS DISTINCT FROM $3`
+
+    if _, err := tx.ExecContext(ctx, subtreeQuery, container.ID, container.FamilyID, workspaceID, now); err != nil {
+        return fmt.Errorf("error moving nested containers: %w", err)
+    }
+
+    return tx.Commit()
+}
+
+// GetPath returns the breadcrumb of containers holding the given one, from
+// the outermost down to its direct parent.
+func (r *Repository) GetPath(ctx context.Context, id int, familyID int) ([]entities.ContainerRef, error) {
+    query := `
+        WITH RECURSIVE ancestors AS (
+            SELECT p.id, p.name, p.number, p.location, p.parent_container_id, 1 AS depth
+            FROM container c
+            JOIN container p ON p.id = c.parent_container_id AND p.family_id = c.family_id
+            WHERE c.id = $1 AND c.family_id = $2
+            UNION ALL
+            SELECT p.id, p.name, p.number, p.location, p.parent_container_id, a.depth + 1
+            FROM container p
+            JOIN ancestors a ON p.id = a.parent_container_id
+            WHERE p.family_id = $2 AND a.depth < 64
+        )
+        SELECT id, COALESCE(name, ''), COALESCE(number, 0), COALESCE(location, '')
+        FROM ancestors
+        ORDER BY depth DESC`
+
+    return r.queryContainerRefs(ctx, query, id, familyID)
+}
+
+// GetChildren returns the containers placed directly inside the given one.
+func (r *Repository) GetChildren(ctx context.Context, id int, familyID int) ([]entities.ContainerRef, error) {
+    query := `
+        SELECT id, COALESCE(name, ''), COALESCE(number, 0), COALESCE(location, '')
+        FROM container
+        WHERE parent_container_id = $1 AND family_id = $2 AND is_deleted = false
+        ORDER BY number, id`
+
+    return r.queryContainerRefs(ctx, query, id, familyID)
 }
 
-func (r *Repository) Delete(id int, familyID int, deletedBy int) error {
-    tx, err := r.db.Begin()
+func (r *Repository) queryContainerRefs(ctx context.Context, query string, args ...interface{}) ([]entities.ContainerRef, error) {
+    rows, err := r.db.QueryContext(ctx, query, args...)
     if err != nil {
-        return fmt.Errorf("error starting transaction: %v", err)
+        return nil, fmt.Errorf("error querying containers: %w", err)
+    }
+    defer rows.Close()
+
+    refs := make([]entities.ContainerRef, 0)
+    for rows.Next() {
+        var ref entities.ContainerRef
+        if err := rows.Scan(&ref.ID, &ref.Name, &ref.Number, &ref.Location); err != nil {
+            return nil, fmt.Errorf("error scanning container: %w", err)
+        }
+        refs = append(refs, ref)
+    }
+
+    return refs, rows.Err()
+}
+
+// GetTreeItems returns the items in a container and, when recursive is set,
+// in every container nested inside it.
+func (r *Repository) GetTreeItems(ctx context.Context, id int, familyID int, recursive bool) ([]entities.Item, error) {
+    query := `
+        WITH RECURSIVE subtree AS (
+            SELECT id FROM container WHERE id = $1 AND family_id = $2 AND is_deleted = false
+            UNION
+            SELECT c.id FROM container c
+            JOIN subtree s ON c.parent_container_id = s.id
+            WHERE $3 AND c.family_id = $2 AND c.is_deleted = false
+        ),
+        item_images AS (
+            SELECT item_id,
+                   jsonb_agg(
+                       jsonb_build_object(
+                           'url', url,
+                           'displayOrder', display_order,
+                           'createdAt', created_at,
+                           'updatedAt', updated_at
+                       ) ORDER BY display_order
+                   ) as images
+            FROM item_image
+            WHERE is_deleted = false 
+            GROUP BY item_id
+        )
+        SELECT i.id, i.name, i.description, i.quantity, 
+               i.container_id, i.family_id, i.created_at, i.updated_at,
+               COALESCE(img.images, '[]'::jsonb) as images,
+               COALESCE(
+                   jsonb_agg(
+                       DISTINCT jsonb_build_object(
+                           'id', t.id,
+                           'name', t.name,
+                           'colour', t.colour,
+                           'createdAt', t.created_at,
+                           'updatedAt', t.updated_at
+                       )
+                   ) FILTER (WHERE t.id IS NOT NULL),
+                   '[]'
+               ) as tags
+        FROM item i
+        LEFT JOIN item_images img ON i.id = img.item_id
+        LEFT JOIN item_tag it ON i.id = it.item_id AND it.is_deleted = false
+        LEFT JOIN tag t ON it.tag_id = t.id AND t.family_id = i.family_id AND t.is_deleted = false
+        WHERE i.container_id IN (SELECT id FROM subtree) AND i.family_id = $2 AND i.is_deleted = false
+        GROUP BY i.id, i.name, i.description, i.quantity, 
+                 i.container_id, i.family_id, i.created_at, i.updated_at,
+                 img.images
+        ORDER BY i.container_id, i.name`
+
+    rows, err := r.db.QueryContext(ctx, query, id, familyID, recursive)
+    if err != nil {
+        return nil, fmt.Errorf("error querying items: %w", err)
+    }
+    defer rows.Close()
+
+    items := make([]entities.Item, 0)
+    for rows.Next() {
+        var item entities.Item
+        var imagesJSON, tagsJSON []byte
+
+        err := rows.Scan(
+            &item.ID, &item.Name, &item.Description,
+            &item.Quantity, &item.ContainerID, &item.FamilyID,
+            &item.CreatedAt, &item.UpdatedAt,
+            &imagesJSON, &tagsJSON,
+        )
+        if err != nil {
+            return nil, fmt.Errorf("error scanning item: %w", err)
+        }
+
+        if err := json.Unmarshal(imagesJSON, &item.Images); err != nil {
+            return nil, fmt.Errorf("error parsing images: %w", err)
+        }
+
+        if err := json.Unmarshal(tagsJSON, &item.Tags); err != nil {
+            return nil, fmt.Errorf("error parsing tags: %w", err)
+        }
+
+        items = append(items, item)
     }
-    defer tx.Rollback()
 
-    itemQuery := `
-        UPDATE item 
-        SET container_id = NULL, updated_at = $3
-        WHERE container_id = $1 AND family_id = $2 AND is_deleted = false`
+    return items, rows.Err()
+}
 
-    _, err = tx.Exec(itemQuery, id, familyID, time.Now().UTC())
+// GetActivity returns the items that came into or went out of a container,
+// and quantity changes to items while they were in it, newest first. Only
+// events with an ID below beforeID are returned when it is set, so the feed can
+// be paged.
+func (r *Repository) GetActivity(ctx context.Context, id int, familyID int, limit int, beforeID int) ([]entities.ItemEvent, error) {
+    query := `
+        SELECT e.id, e.item_id, COALESCE(i.name, ''), e.event_type,
+               CASE
+                   WHEN e.event_type = $5 AND e.to_container_id = $1 THEN 'in'
+                   WHEN e.event_type = $5 AND e.from_container_id = $1 THEN 'out'
+                   ELSE ''
+               END,
+               e.container_id, e.from_container_id, COALESCE(fc.name, ''),
+               e.to_container_id, COALESCE(tc.name, ''),
+               e.old_quantity, e.new_quantity,
+               e.profile_id, COALESCE(p.name, ''), e.created_at
+        FROM item_event e
+        JOIN item i ON i.id = e.item_id
+        LEFT JOIN container fc ON fc.id = e.from_container_id
+        LEFT JOIN container tc ON tc.id = e.to_container_id
+        LEFT JOIN profile p ON p.id = e.profile_id
+        WHERE e.family_id = $2
+        AND (
+            e.from_container_id = $1 OR e.to_container_id = $1
+            OR (e.event_type = $6 AND e.container_id = $1)
+        )
+        AND ($4 = 0 OR e.id < $4)
+        ORDER BY e.id DESC
+        LIMIT $3`
+
+    rows, err := r.db.QueryContext(ctx, query, id, familyID, limit, beforeID,
+        entities.ItemEventMoved, entities.ItemEventQuantityChanged)
     if err != nil {
-        return fmt.Errorf("error updating items: %v", err)
+        return nil, fmt.Errorf("error querying container activity: %w", err)
     }
+    defer rows.Close()
 
-    containerQuery := `
-        UPDATE container 
-        SET is_deleted = true, deleted_at = $3, deleted_by = $4, updated_at = $3
+    return history.ScanEvents(rows)
+}
+
+// GetContainerWorkspace reports whether the container exists in the family
+// and returns its workspace.
+func (r *Repository) GetContainerWorkspace(ctx context.Context, id int, familyID int) (bool, *int, error) {
+    query := `
+        SELECT workspace_id FROM container
+        WHERE id = $1 AND family_id = $2 AND is_deleted = false`
+
+    var workspaceID sql.NullInt64
+    err := r.db.QueryRowContext(ctx, query, id, familyID).Scan(&workspaceID)
+    if err == sql.ErrNoRows {
+        return false, nil, nil
+    }
+    if err != nil {
+        return false, nil, fmt.Errorf("error checking container: %w", err)
+    }
+
+    if !workspaceID.Valid {
+        return true, nil, nil
+    }
+    wsID := int(workspaceID.Int64)
+    return true, &wsID, nil
+}
+
+func (r *Repository) UpdateQRCode(ctx context.Context, id int, familyID int, qrCode string, qrCodeImage string) error {
+    query := `
+        UPDATE container
+        SET qr_code = $3, qr_code_image = $4, updated_at = $5
         WHERE id = $1 AND family_id = $2 AND is_deleted = false`
-        
-    result, err := tx.Exec(containerQuery, id, familyID, time.Now().UTC(), deletedBy)
+
+    result, err := r.db.ExecContext(ctx, query, id, familyID, qrCode, qrCodeImage, time.Now().UTC())
     if err != nil {
-        return fmt.Errorf("error deleting container: %v", err)
+        return fmt.Errorf("error updating container QR code: %w", err)
     }
 
     rowsAffected, err := result.RowsAffected()
     if err != nil {
-        return fmt.Errorf("error checking delete result: %v", err)
+        return fmt.Errorf("error checking update result: %w", err)
     }
 
     if rowsAffected == 0 {
-        return fmt.Errorf("container not found")
+        return apperror.NotFound("container not found")
+    }
+
+    return nil
+}
+
+// Delete soft deletes the container together with the containers nested in
+// it, their items and the items' images and tag links.
+func (r *Repository) Delete(ctx context.Context, id int, familyID int, deletedBy int) error {
+    tx, err := r.db.BeginTx(ctx, nil)
+    if err != nil {
+        return fmt.Errorf("error starting transaction: %w", err)
+    }
+    defer tx.Rollback()
+
+    batch, err := deletion.Begin(ctx, tx, familyID, deletedBy)
+    if err != nil {
+        return err
+    }
+
+    deleted, err := batch.Container(ctx, id)
+    if err != nil {
+        return err
+    }
+    if !deleted {
+        return apperror.NotFound("container not found")
     }
 
     return tx.Commit()
 }
 
-func (r *Repository) RestoreDeleted(id int, familyID int) error {
-    query := `
-        UPDATE container
-        SET is_deleted = false, deleted_at = NULL, deleted_by = NULL, updated_at = $3
-        WHERE id = $1 AND family_id = $2 AND is_deleted = true`
-    
-    result, err := r.db.Exec(query, id, familyID, time.Now().UTC())
+// RestoreDeleted brings back the container and everything deleted with it,
+// which may be its whole workspace if that is how it was deleted.
+func (r *Repository) RestoreDeleted(ctx context.Context, id int, familyID int) error {
+    tx, err := r.db.BeginTx(ctx, nil)
     if err != nil {
-        return fmt.Errorf("error restoring container: %v", err)
+        return fmt.Errorf("error starting transaction: %w", err)
     }
+    defer tx.Rollback()
 
-    rowsAffected, err := result.RowsAffected()
+    restored, err := deletion.Restore(ctx, tx, deletion.Container, id, familyID)
     if err != nil {
-        return fmt.Errorf("error checking restore result: %v", err)
+        return err
+    }
+    if !restored {
+        return apperror.NotFound("container not found or not deleted")
     }
 
-    if rowsAffected == 0 {
-        return fmt.Errorf("container not found or not deleted")
+    return tx.Commit()
+}
+
+// GetForLabels returns the fields printed on labels for the given containers,
+// or for every container in the workspace when ids is empty.
+func (r *Repository) GetForLabels(ctx context.Context, familyID int, ids []int, workspaceID *int) ([]*entities.Container, error) {
+    query := `
+        SELECT id, name, COALESCE(qr_code, ''), COALESCE(number, 0), COALESCE(location, '')
+        FROM container
+        WHERE family_id = $1 AND is_deleted = false
+          AND (cardinality($2::int[]) = 0 OR id = ANY($2))
+          AND ($3::int IS NULL OR workspace_id = $3)
+        ORDER BY number, id`
+
+    rows, err := r.db.QueryContext(ctx, query, familyID, pq.Array(ids), workspaceID)
+    if err != nil {
+        return nil, fmt.Errorf("error querying containers for labels: %w", err)
     }
+    defer rows.Close()
 
-    return nil
+    var containers []*entities.Container
+    for rows.Next() {
+        container := &entities.Container{FamilyID: familyID}
+        if err := rows.Scan(&container.ID, &container.Name, &container.QRCode, &container.Number, &container.Location); err != nil {
+            return nil, fmt.Errorf("error scanning container: %w", err)
+        }
+        containers = append(containers, container)
+    }
+
+    return containers, rows.Err()
+}
+
+func (r *Repository) IsFamilyWorkspace(ctx context.Context, id int, familyID int) (bool, error) {
+    query := `
+        SELECT EXISTS (
+            SELECT 1 FROM workspace
+            WHERE id = $1 AND family_id = $2 AND is_deleted = false
+        )`
+
+    var exists bool
+    if err := r.db.QueryRowContext(ctx, query, id, familyID).Scan(&exists); err != nil {
+        return false, fmt.Errorf("error checking workspace: %w", err)
+    }
+    return exists, nil
 }
@@ -1,14 +1,22 @@
 package container
 
 import (
+	"context"
 	"fmt"
-	"math/rand"
 	"time"
 
+	"github.com/chrisabs/cadence/internal/apperror"
+	"github.com/chrisabs/cadence/internal/platform/tracing"
+	"github.com/chrisabs/cadence/internal/platform/validate"
 	"github.com/chrisabs/cadence/internal/storage/entities"
 	"github.com/chrisabs/cadence/pkg/utils"
 )
 
+const (
+    defaultActivityLimit = 50
+    maxActivityLimit     = 200
+)
+
 type Service struct {
     repo *Repository
 }
@@ -17,81 +25,245 @@ func NewService(repo *Repository) *Service {
     return &Service{repo: repo}
 }
 
-func (s *Service) CreateContainer(profileId int, familyID int, req *CreateContainerRequest) (*entities.Container, error) {
-    containerID := rand.Intn(10000)
-    qrString, qrImage, err := utils.GenerateQRCode(containerID)
+func (s *Service) CreateContainer(ctx context.Context, profileId int, familyID int, req *CreateContainerRequest) (*entities.Container, error) {
+    ctx, span := tracing.Start(ctx, "container.Service.CreateContainer")
+    defer span.End()
+
+    if err := s.validateRequest(ctx, familyID, req.WorkspaceID, req); err != nil {
+        return nil, err
+    }
+
+    workspaceID, err := s.resolveWorkspace(ctx, familyID, req.ParentContainerID, req.WorkspaceID)
     if err != nil {
-        qrString = fmt.Sprintf("STORAGE-CONTAINER-%d", containerID)
-        qrImage = ""
+        return nil, err
+    }
+
+    qrString, qrImage, err := utils.GenerateQRCode()
+    if err != nil {
+        return nil, err
     }
 
     container := &entities.Container{
-        ID:          containerID,
-        Name:        req.Name,
-        Description: req.Description,
-        QRCode:      qrString,
-        QRCodeImage: qrImage,
-        Number:      rand.Intn(1000),
-        Location:    req.Location,
-        ProfileID:   profileId,
-        FamilyID:    familyID,        
-        WorkspaceID: req.WorkspaceID,
-        CreatedAt:   time.Now().UTC(),
-        UpdatedAt:   time.Now().UTC(),
+        Name:              req.Name,
+        Description:       req.Description,
+        QRCode:            qrString,
+        QRCodeImage:       qrImage,
+        Location:          req.Location,
+        ProfileID:         profileId,
+        FamilyID:          familyID,
+        WorkspaceID:       workspaceID,
+        ParentContainerID: req.ParentContainerID,
+        CreatedAt:         time.Now().UTC(),
+        UpdatedAt:         time.Now().UTC(),
     }
 
-    if err := s.repo.Create(container, req.Items); err != nil {
-        return nil, fmt.Errorf("failed to create container with items: %v", err)
+    if err := s.repo.Create(ctx, container, req.Items); err != nil {
+        return nil, fmt.Errorf("failed to create container with items: %w", err)
     }
 
-    return s.repo.GetByID(container.ID, familyID)
+    return s.repo.GetByID(ctx, container.ID, familyID)
 }
 
-func (s *Service) GetContainerByID(id int, familyID int) (*entities.Container, error) {
-    container, err := s.repo.GetByID(id, familyID)
+func (s *Service) GetContainerByID(ctx context.Context, id int, familyID int) (*entities.Container, error) {
+    ctx, span := tracing.Start(ctx, "container.Service.GetContainerByID")
+    defer span.End()
+
+    container, err := s.repo.GetByID(ctx, id, familyID)
     if err != nil {
-        return nil, fmt.Errorf("error getting container: %v", err)
+        return nil, fmt.Errorf("error getting container: %w", err)
     }
     return container, nil
 }
 
-func (s *Service) GetContainersByFamilyID(familyID int) ([]*entities.Container, error) {
-    return s.repo.GetByFamilyID(familyID)
+func (s *Service) GetContainersByFamilyID(ctx context.Context, familyID int) ([]*entities.Container, error) {
+    ctx, span := tracing.Start(ctx, "container.Service.GetContainersByFamilyID")
+    defer span.End()
+
+    return s.repo.GetByFamilyID(ctx, familyID)
 }
 
-func (s *Service) UpdateContainer(id int, familyID int, req *UpdateContainerRequest) (*entities.Container, error) {
-    container, err := s.repo.GetByID(id, familyID)
+func (s *Service) UpdateContainer(ctx context.Context, id int, familyID int, req *UpdateContainerRequest) (*entities.Container, error) {
+    ctx, span := tracing.Start(ctx, "container.Service.UpdateContainer")
+    defer span.End()
+
+    if err := s.validateRequest(ctx, familyID, req.WorkspaceID, req); err != nil {
+        return nil, err
+    }
+
+    workspaceID, err := s.resolveWorkspace(ctx, familyID, req.ParentContainerID, req.WorkspaceID)
     if err != nil {
-        return nil, fmt.Errorf("container not found: %v", err)
+        return nil, err
+    }
+
+    container, err := s.repo.GetByID(ctx, id, familyID)
+    if err != nil {
+        return nil, fmt.Errorf("container not found: %w", err)
     }
 
     container.Name = req.Name
     container.Description = req.Description
     container.Location = req.Location
-    container.WorkspaceID = req.WorkspaceID
+    container.WorkspaceID = workspaceID
+    container.ParentContainerID = req.ParentContainerID
     container.UpdatedAt = time.Now().UTC()
 
-    if err := s.repo.Update(container); err != nil {
-        return nil, fmt.Errorf("failed to update container: %v", err)
+    if err := s.repo.Update(ctx, container); err != nil {
+        return nil, fmt.Errorf("failed to update container: %w", err)
     }
 
-    return container, nil
+    return s.repo.GetByID(ctx, id, familyID)
 }
 
-func (s *Service) GetContainerByQR(qrCode string, familyID int) (*entities.Container, error) {
-    return s.repo.GetByQR(qrCode, familyID)
+// GetContainerItems lists the items in a container, including those in
+// containers nested inside it when recursive is set.
+func (s *Service) GetContainerItems(ctx context.Context, id int, familyID int, recursive bool) ([]entities.Item, error) {
+    ctx, span := tracing.Start(ctx, "container.Service.GetContainerItems")
+    defer span.End()
+
+    exists, _, err := s.repo.GetContainerWorkspace(ctx, id, familyID)
+    if err != nil {
+        return nil, err
+    }
+    if !exists {
+        return nil, apperror.NotFound("container not found")
+    }
+
+    return s.repo.GetTreeItems(ctx, id, familyID, recursive)
+}
+
+// GetContainerActivity returns a page of the container's in/out feed.
+func (s *Service) GetContainerActivity(ctx context.Context, id int, familyID int, limit int, beforeID int) ([]entities.ItemEvent, error) {
+    ctx, span := tracing.Start(ctx, "container.Service.GetContainerActivity")
+    defer span.End()
+
+    if limit <= 0 {
+        limit = defaultActivityLimit
+    }
+    if limit > maxActivityLimit {
+        limit = maxActivityLimit
+    }
+
+    exists, _, err := s.repo.GetContainerWorkspace(ctx, id, familyID)
+    if err != nil {
+        return nil, err
+    }
+    if !exists {
+        return nil, apperror.NotFound("container not found")
+    }
+
+    return s.repo.GetActivity(ctx, id, familyID, limit, beforeID)
 }
 
-func (s *Service) DeleteContainer(id int, familyID int, deletedBy int) error {
-    if err := s.repo.Delete(id, familyID, deletedBy); err != nil {
-        return fmt.Errorf("failed to delete container: %v", err)
+func (s *Service) GetContainerByQR(ctx context.Context, qrCode string, familyID int) (*entities.Container, error) {
+    ctx, span := tracing.Start(ctx, "container.Service.GetContainerByQR")
+    defer span.End()
+
+    return s.repo.GetByQR(ctx, qrCode, familyID)
+}
+
+// RegenerateQRCode gives the container a new QR code. The old code stops
+// resolving straight away, so labels printed with it need replacing.
+func (s *Service) RegenerateQRCode(ctx context.Context, id int, familyID int) (*entities.Container, error) {
+    ctx, span := tracing.Start(ctx, "container.Service.RegenerateQRCode")
+    defer span.End()
+
+    qrString, qrImage, err := utils.GenerateQRCode()
+    if err != nil {
+        return nil, err
+    }
+
+    if err := s.repo.UpdateQRCode(ctx, id, familyID, qrString, qrImage); err != nil {
+        return nil, err
+    }
+
+    return s.repo.GetByID(ctx, id, familyID)
+}
+
+// GenerateLabels renders a PDF of QR labels for the requested containers, or
+// for every container in a workspace.
+func (s *Service) GenerateLabels(ctx context.Context, familyID int, req *LabelsRequest) ([]byte, error) {
+    ctx, span := tracing.Start(ctx, "container.Service.GenerateLabels")
+    defer span.End()
+
+    if err := validate.Struct(req); err != nil {
+        return nil, err
+    }
+
+    if len(req.ContainerIDs) == 0 && req.WorkspaceID == nil {
+        return nil, apperror.Validation("containers required", map[string]string{"containerIds": "provide container IDs or a workspace"})
+    }
+
+    if perPage := labelSheets[req.Layout].columns * labelSheets[req.Layout].rows; req.Skip >= perPage {
+        return nil, apperror.Validation("invalid skip", map[string]string{"skip": fmt.Sprintf("must be less than %d for this layout", perPage)})
+    }
+
+    containers, err := s.repo.GetForLabels(ctx, familyID, req.ContainerIDs, req.WorkspaceID)
+    if err != nil {
+        return nil, err
+    }
+
+    if len(containers) == 0 {
+        return nil, apperror.NotFound("no containers found")
+    }
+
+    return renderLabels(containers, req.Layout, req.Skip)
+}
+
+func (s *Service) DeleteContainer(ctx context.Context, id int, familyID int, deletedBy int) error {
+    ctx, span := tracing.Start(ctx, "container.Service.DeleteContainer")
+    defer span.End()
+
+    if err := s.repo.Delete(ctx, id, familyID, deletedBy); err != nil {
+        return fmt.Errorf("failed to delete container: %w", err)
     }
     return nil
 }
 
-func (s *Service) RestoreContainer(id int, familyID int) error {
-    if err := s.repo.RestoreDeleted(id, familyID); err != nil {
-        return fmt.Errorf("failed to restore container: %v", err)
+func (s *Service) RestoreContainer(ctx context.Context, id int, familyID int) error {
+    ctx, span := tracing.Start(ctx, "container.Service.RestoreContainer")
+    defer span.End()
+
+    if err := s.repo.RestoreDeleted(ctx, id, familyID); err != nil {
+        return fmt.Errorf("failed to restore container: %w", err)
     }
     return nil
-}
\ No newline at end of file
+}
+
+// validateRequest runs the request's field rules and checks that the target
+// workspace, if any, belongs to the family.
+func (s *Service) validateRequest(ctx context.Context, familyID int, workspaceID *int, req interface{}) error {
+    errs := validate.Errors{}
+    validate.Collect(req, errs)
+
+    if workspaceID != nil {
+        owned, err := s.repo.IsFamilyWorkspace(ctx, *workspaceID, familyID)
+        if err != nil {
+            return err
+        }
+        if !owned {
+            errs.Add("workspaceId", "must be a workspace in this family")
+        }
+    }
+
+    return errs.Err()
+}
+
+// resolveWorkspace works out which workspace a container belongs in. A nested
+// container always lives in its parent's workspace, whatever the request says.
+func (s *Service) resolveWorkspace(ctx context.Context, familyID int, parentID *int, workspaceID *int) (*int, error) {
+    if parentID == nil {
+        return workspaceID, nil
+    }
+
+    exists, parentWorkspaceID, err := s.repo.GetContainerWorkspace(ctx, *parentID, familyID)
+    if err != nil {
+        return nil, err
+    }
+    if !exists {
+        return nil, apperror.Validation("invalid parent container", map[string]string{
+            "parentContainerId": "must be a container in this family",
+        })
+    }
+
+    return parentWorkspaceID, nil
+}
@@ -0,0 +1,286 @@
+// Package deletion soft deletes storage entities together with everything
+// under them. Every row deleted along with an entity shares that deletion's
+// batch number, so a restore brings back exactly what was deleted together
+// and nothing that was deleted on its own before or after.
+package deletion
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// Entity is a storage table that can be deleted and restored directly.
+type Entity string
+
+const (
+    Workspace Entity = "workspace"
+    Container Entity = "container"
+    Item      Entity = "item"
+    Tag       Entity = "tag"
+)
+
+// Batch soft deletes rows inside the caller's transaction under one batch
+// number.
+type Batch struct {
+    tx        *sql.Tx
+    id        int64
+    familyID  int
+    deletedBy int
+    now       time.Time
+}
+
+func Begin(ctx context.Context, tx *sql.Tx, familyID int, deletedBy int) (*Batch, error) {
+    b := &Batch{tx: tx, familyID: familyID, deletedBy: deletedBy, now: time.Now().UTC()}
+    if err := tx.QueryRowContext(ctx, "SELECT nextval('deletion_batch_seq')").Scan(&b.id); err != nil {
+        return nil, fmt.Errorf("error starting deletion batch: %w", err)
+    }
+    return b, nil
+}
+
+// Workspace deletes a workspace, every container in it and everything in
+// those containers. It reports false if the workspace is missing or already
+// deleted.
+func (b *Batch) Workspace(ctx context.Context, id int) (bool, error) {
+    result, err := b.tx.ExecContext(ctx, `
+        UPDATE workspace
+        SET is_deleted = true, deleted_at = $3, deleted_by = $4, updated_at = $3, deletion_batch = $5
+        WHERE id = $1 AND family_id = $2 AND is_deleted = false`,
+        id, b.familyID, b.now, b.deletedBy, b.id,
+    )
+    if err != nil {
+        return false, fmt.Errorf("error deleting workspace: %w", err)
+    }
+    if deleted, err := affected(result); err != nil || !deleted {
+        return false, err
+    }
+
+    _, err = b.containers(ctx, "workspace_id = $1", id)
+    return true, err
+}
+
+// Container deletes a container, the containers nested in it and their
+// items. It reports false if the container is missing or already deleted.
+func (b *Batch) Container(ctx context.Context, id int) (bool, error) {
+    return b.containers(ctx, "id = $1", id)
+}
+
+// Items deletes the live items among ids with their images and tag links and
+// returns how many it deleted.
+func (b *Batch) Items(ctx context.Context, ids []int) (int64, error) {
+    result, err := b.tx.ExecContext(ctx, `
+        UPDATE item
+        SET is_deleted = true, deleted_at = $3, deleted_by = $4, updated_at = $3, deletion_batch = $5
+        WHERE id = ANY($1) AND family_id = $2 AND is_deleted = false`,
+        pq.Array(ids), b.familyID, b.now, b.deletedBy, b.id,
+    )
+    if err != nil {
+        return 0, fmt.Errorf("error deleting items: %w", err)
+    }
+
+    count, err := result.RowsAffected()
+    if err != nil {
+        return 0, fmt.Errorf("error checking delete result: %w", err)
+    }
+
+    return count, b.itemChildren(ctx)
+}
+
+// Tag deletes a tag and its links to items. It reports false if the tag is
+// missing or already deleted.
+func (b *Batch) Tag(ctx context.Context, id int) (bool, error) {
+    result, err := b.tx.ExecContext(ctx, `
+        UPDATE tag
+        SET is_deleted = true, deleted_at = $3, deleted_by = $4, updated_at = $3, deletion_batch = $5
+        WHERE id = $1 AND family_id = $2 AND is_deleted = false`,
+        id, b.familyID, b.now, b.deletedBy, b.id,
+    )
+    if err != nil {
+        return false, fmt.Errorf("error deleting tag: %w", err)
+    }
+    if deleted, err := affected(result); err != nil || !deleted {
+        return false, err
+    }
+
+    _, err = b.tx.ExecContext(ctx, `
+        UPDATE item_tag
+        SET is_deleted = true, deleted_at = $2, deleted_by = $3, deletion_batch = $1
+        WHERE is_deleted = false AND tag_id IN (SELECT id FROM tag WHERE deletion_batch = $1)`,
+        b.id, b.now, b.deletedBy,
+    )
+    if err != nil {
+        return false, fmt.Errorf("error deleting item-tag associations: %w", err)
+    }
+
+    return true, nil
+}
+
+// containers deletes the live containers matching where, every live
+// container nested under them and the items in all of them.
+func (b *Batch) containers(ctx context.Context, where string, arg any) (bool, error) {
+    result, err := b.tx.ExecContext(ctx, `
+        WITH RECURSIVE subtree AS (
+            SELECT id FROM container
+            WHERE `+where+` AND family_id = $2 AND is_deleted = false
+            UNION
+            SELECT c.id FROM container c
+            JOIN subtree s ON c.parent_container_id = s.id
+            WHERE c.family_id = $2 AND c.is_deleted = false
+        )
+        UPDATE container
+        SET is_deleted = true, deleted_at = $3, deleted_by = $4, updated_at = $3, deletion_batch = $5
+        WHERE id IN (SELECT id FROM subtree)`,
+        arg, b.familyID, b.now, b.deletedBy, b.id,
+    )
+    if err != nil {
+        return false, fmt.Errorf("error deleting containers: %w", err)
+    }
+    deleted, err := affected(result)
+    if err != nil || !deleted {
+        return false, err
+    }
+
+    _, err = b.tx.ExecContext(ctx, `
+        UPDATE item
+        SET is_deleted = true, deleted_at = $2, deleted_by = $3, updated_at = $2, deletion_batch = $4
+        WHERE family_id = $1 AND is_deleted = false
+        AND container_id IN (SELECT id FROM container WHERE deletion_batch = $4)`,
+        b.familyID, b.now, b.deletedBy, b.id,
+    )
+    if err != nil {
+        return false, fmt.Errorf("error deleting items: %w", err)
+    }
+
+    return true, b.itemChildren(ctx)
+}
+
+// itemChildren deletes the images and tag links of the items in the batch.
+func (b *Batch) itemChildren(ctx context.Context) error {
+    _, err := b.tx.ExecContext(ctx, `
+        UPDATE item_image
+        SET is_deleted = true, deleted_at = $2, deleted_by = $3, updated_at = $2, deletion_batch = $1
+        WHERE is_deleted = false AND item_id IN (SELECT id FROM item WHERE deletion_batch = $1)`,
+        b.id, b.now, b.deletedBy,
+    )
+    if err != nil {
+        return fmt.Errorf("error deleting item images: %w", err)
+    }
+
+    _, err = b.tx.ExecContext(ctx, `
+        UPDATE item_tag
+        SET is_deleted = true, deleted_at = $2, deleted_by = $3, deletion_batch = $1
+        WHERE is_deleted = false AND item_id IN (SELECT id FROM item WHERE deletion_batch = $1)`,
+        b.id, b.now, b.deletedBy,
+    )
+    if err != nil {
+        return fmt.Errorf("error deleting item-tag associations: %w", err)
+    }
+
+    return nil
+}
+
+// Restore brings back the deleted entity and everything deleted in the same
+// batch. Restored rows whose parent is still deleted are detached from it,
+// as though the parent had been deleted on its own. It reports false if the
+// entity is missing or not deleted.
+func Restore(ctx context.Context, tx *sql.Tx, entity Entity, id int, familyID int) (bool, error) {
+    var batch sql.NullInt64
+    err := tx.QueryRowContext(ctx, fmt.Sprintf(`
+        SELECT deletion_batch FROM %s
+        WHERE id = $1 AND family_id = $2 AND is_deleted = true
+        FOR UPDATE`, entity),
+        id, familyID,
+    ).Scan(&batch)
+    if err == sql.ErrNoRows {
+        return false, nil
+    }
+    if err != nil {
+        return false, fmt.Errorf("error finding deleted %s: %w", entity, err)
+    }
+
+    now := time.Now().UTC()
+
+    // Rows deleted before batches existed can only come back on their own.
+    if !batch.Valid {
+        _, err := tx.ExecContext(ctx, fmt.Sprintf(`
+            UPDATE %s
+            SET is_deleted = false, deleted_at = NULL, deleted_by = NULL, updated_at = $3
+            WHERE id = $1 AND family_id = $2`, entity),
+            id, familyID, now,
+        )
+        if err != nil {
+            return false, fmt.Errorf("error restoring %s: %w", entity, err)
+        }
+        return true, nil
+    }
+
+    return true, restoreBatch(ctx, tx, batch.Int64, familyID, now)
+}
+
+func restoreBatch(ctx context.Context, tx *sql.Tx, batch int64, familyID int, now time.Time) error {
+    detach := []string{`
+        UPDATE container c SET workspace_id = NULL
+        FROM workspace w
+        WHERE c.deletion_batch = $1 AND c.family_id = $2 AND w.id = c.workspace_id
+        AND w.is_deleted = true AND w.deletion_batch IS DISTINCT FROM $1`, `
+        UPDATE container c SET parent_container_id = NULL
+        FROM container p
+        WHERE c.deletion_batch = $1 AND c.family_id = $2 AND p.id = c.parent_container_id
+        AND p.is_deleted = true AND p.deletion_batch IS DISTINCT FROM $1`, `
+        UPDATE item i SET container_id = NULL
+        FROM container c
+        WHERE i.deletion_batch = $1 AND i.family_id = $2 AND c.id = i.container_id
+        AND c.is_deleted = true AND c.deletion_batch IS DISTINCT FROM $1`,
+    }
+
+    for _, query := range detach {
+        if _, err := tx.ExecContext(ctx, query, batch, familyID); err != nil {
+            return fmt.Errorf("error detaching restored rows: %w", err)
+        }
+    }
+
+    for _, entity := range []Entity{Workspace, Container, Tag, Item} {
+        _, err := tx.ExecContext(ctx, fmt.Sprintf(`
+            UPDATE %s
+            SET is_deleted = false, deleted_at = NULL, deleted_by = NULL, deletion_batch = NULL, updated_at = $3
+            WHERE deletion_batch = $1 AND family_id = $2`, entity),
+            batch, familyID, now,
+        )
+        if err != nil {
+            return fmt.Errorf("error restoring %ss: %w", entity, err)
+        }
+    }
+
+    _, err := tx.ExecContext(ctx, `
+        UPDATE item_image
+        SET is_deleted = false, deleted_at = NULL, deleted_by = NULL, deletion_batch = NULL, updated_at = $3
+        WHERE deletion_batch = $1 AND item_id IN (SELECT id FROM item WHERE family_id = $2)`,
+        batch, familyID, now,
+    )
+    if err != nil {
+        return fmt.Errorf("error restoring item images: %w", err)
+    }
+
+    _, err = tx.ExecContext(ctx, `
+        UPDATE item_tag
+        SET is_deleted = false, deleted_at = NULL, deleted_by = NULL, deletion_batch = NULL
+        WHERE deletion_batch = $1 AND item_id IN (SELECT id FROM item WHERE family_id = $2)`,
+        batch, familyID,
+    )
+    if err != nil {
+        return fmt.Errorf("error restoring item-tag associations: %w", err)
+    }
+
+    return nil
+}
+
+func affected(result sql.Result) (bool, error) {
+    rows, err := result.RowsAffected()
+    if err != nil {
+        return false, fmt.Errorf("error checking delete result: %w", err)
+    }
+    return rows > 0, nil
+}
@@ -3,18 +3,30 @@ package entities
 import "time"
 
 type Container struct {
-    ID           int        `json:"id"`
-    Name         string     `json:"name"`
-    Description  string     `json:"description"`
-    QRCode       string     `json:"qrCode"`
-    QRCodeImage  string     `json:"qrCodeImage"`
-    Number       int        `json:"number"`
-    Location     string     `json:"location"`
-    ProfileID    int        `json:"profileId"`
-    FamilyID     int        `json:"familyId"`
-    WorkspaceID  *int       `json:"workspaceId,omitempty"`
-    Workspace    *Workspace `json:"workspace,omitempty"`
-    Items        []Item     `json:"items"`
-    CreatedAt    time.Time  `json:"createdAt"`
-    UpdatedAt    time.Time  `json:"updatedAt"`
+    ID                int            `json:"id"`
+    Name              string         `json:"name"`
+    Description       string         `json:"description"`
+    QRCode            string         `json:"qrCode"`
+    QRCodeImage       string         `json:"qrCodeImage"`
+    Number            int            `json:"number"`
+    Location          string         `json:"location"`
+    ProfileID         int            `json:"profileId"`
+    FamilyID          int            `json:"familyId"`
+    WorkspaceID       *int           `json:"workspaceId,omitempty"`
+    Workspace         *Workspace     `json:"workspace,omitempty"`
+    ParentContainerID *int           `json:"parentContainerId,omitempty"`
+    Path              []ContainerRef `json:"path,omitempty"`
+    ChildContainers   []ContainerRef `json:"childContainers,omitempty"`
+    Items             []Item         `json:"items"`
+    CreatedAt         time.Time      `json:"createdAt"`
+    UpdatedAt         time.Time      `json:"updatedAt"`
+}
+
+// ContainerRef is the short form of a container used for breadcrumbs and
+// nested container listings.
+type ContainerRef struct {
+    ID       int    `json:"id"`
+    Name     string `json:"name"`
+    Number   int    `json:"number"`
+    Location string `json:"location,omitempty"`
 }
@@ -16,6 +16,9 @@ type Item struct {
     Description string      `json:"description"`
     Images      []ItemImage `json:"images"`
     Quantity    int         `json:"quantity"`
+    Unit        string      `json:"unit,omitempty"`
+    MinQuantity *int        `json:"minQuantity,omitempty"`
+    ExpiryDate  *string     `json:"expiryDate,omitempty"`
     ContainerID *int        `json:"containerId,omitempty"`
     Container   *Container  `json:"container,omitempty"`
     ProfileID   int         `json:"profileId"`
@@ -24,3 +27,41 @@ type Item struct {
     CreatedAt   time.Time   `json:"createdAt"`
     UpdatedAt   time.Time   `json:"updatedAt"`
 }
+
+// SameContainer reports whether two optional container IDs refer to the same
+// container, treating two nils as the same place.
+func SameContainer(a, b *int) bool {
+    if a == nil || b == nil {
+        return a == nil && b == nil
+    }
+    return *a == *b
+}
+
+type ItemEventType string
+
+const (
+    ItemEventMoved           ItemEventType = "moved"
+    ItemEventQuantityChanged ItemEventType = "quantity_changed"
+)
+
+// ItemEvent records an item changing container or quantity. Moves fill in the
+// from and to containers; quantity changes record the container the item was
+// in. Direction is only set in a container's feed, where it says whether the
+// item came in or went out of that container.
+type ItemEvent struct {
+    ID                int           `json:"id"`
+    ItemID            int           `json:"itemId"`
+    ItemName          string        `json:"itemName"`
+    Type              ItemEventType `json:"type"`
+    Direction         string        `json:"direction,omitempty"`
+    ContainerID       *int          `json:"containerId,omitempty"`
+    FromContainerID   *int          `json:"fromContainerId,omitempty"`
+    FromContainerName string        `json:"fromContainerName,omitempty"`
+    ToContainerID     *int          `json:"toContainerId,omitempty"`
+    ToContainerName   string        `json:"toContainerName,omitempty"`
+    OldQuantity       *int          `json:"oldQuantity,omitempty"`
+    NewQuantity       *int          `json:"newQuantity,omitempty"`
+    ProfileID         *int          `json:"profileId,omitempty"`
+    ProfileName       string        `json:"profileName,omitempty"`
+    CreatedAt         time.Time     `json:"createdAt"`
+}
@@ -0,0 +1,35 @@
+// Package history reads the item_event log behind an item's history and a
+// container's activity feed.
+package history
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/chrisabs/cadence/internal/storage/entities"
+)
+
+// ScanEvents reads item events from rows selecting, in order: id, item ID,
+// item name, event type, direction, container ID, from container ID and name,
+// to container ID and name, old and new quantity, profile ID and name, and
+// created at.
+func ScanEvents(rows *sql.Rows) ([]entities.ItemEvent, error) {
+    events := make([]entities.ItemEvent, 0)
+    for rows.Next() {
+        var event entities.ItemEvent
+        err := rows.Scan(
+            &event.ID, &event.ItemID, &event.ItemName, &event.Type, &event.Direction,
+            &event.ContainerID, &event.FromContainerID, &event.FromContainerName,
+            &event.ToContainerID, &event.ToContainerName,
+            &event.OldQuantity, &event.NewQuantity,
+            &event.ProfileID, &event.ProfileName, &event.CreatedAt,
+        )
+        if err != nil {
+            return nil, fmt.Errorf("error scanning item event: %w", err)
+        }
+
+        events = append(events, event)
+    }
+
+    return events, rows.Err()
+}
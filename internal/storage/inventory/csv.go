@@ -0,0 +1,173 @@
+package inventory
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/chrisabs/cadence/internal/apperror"
+)
+
+// csvColumns is the header written on export. Import matches headers without
+// regard to case, spaces or underscores, and ignores columns it does not know.
+var csvColumns = []string{
+    "workspace", "container", "container_number", "location",
+    "item", "description", "quantity", "unit", "min_quantity", "expiry_date",
+    "tags", "image_urls",
+}
+
+// listSeparator joins tags and image URLs within a single cell.
+const listSeparator = ";"
+
+func writeCSV(w io.Writer, rows []Row) error {
+    writer := csv.NewWriter(w)
+
+    if err := writer.Write(csvColumns); err != nil {
+        return err
+    }
+
+    for _, row := range rows {
+        var containerNumber, minQuantity string
+        if row.ContainerNumber > 0 {
+            containerNumber = strconv.Itoa(row.ContainerNumber)
+        }
+        if row.MinQuantity != nil {
+            minQuantity = strconv.Itoa(*row.MinQuantity)
+        }
+
+        var quantity string
+        if row.Item != "" {
+            quantity = strconv.Itoa(row.Quantity)
+        }
+
+        record := []string{
+            row.Workspace, row.Container, containerNumber, row.Location,
+            row.Item, row.Description, quantity, row.Unit, minQuantity, row.ExpiryDate,
+            strings.Join(row.Tags, listSeparator), strings.Join(row.ImageURLs, listSeparator),
+        }
+        if err := writer.Write(record); err != nil {
+            return err
+        }
+    }
+
+    writer.Flush()
+    return writer.Error()
+}
+
+// readCSV parses rows from a CSV file with a header line. Blank lines are
+// skipped but still counted, so row numbers match the spreadsheet. Cells that
+// cannot be parsed are reported against their row rather than failing the
+// file.
+func readCSV(r io.Reader) ([]parsedRow, error) {
+    reader := csv.NewReader(r)
+    reader.FieldsPerRecord = -1
+    reader.TrimLeadingSpace = true
+
+    header, err := reader.Read()
+    if err == io.EOF {
+        return nil, apperror.BadRequest("file is empty")
+    }
+    if err != nil {
+        return nil, apperror.BadRequest(fmt.Sprintf("invalid CSV: %v", err))
+    }
+
+    columns := make(map[string]int, len(header))
+    for i, name := range header {
+        columns[normaliseColumn(name)] = i
+    }
+
+    _, hasItem := columns["item"]
+    _, hasContainer := columns["container"]
+    _, hasWorkspace := columns["workspace"]
+    if !hasItem && !hasContainer && !hasWorkspace {
+        return nil, apperror.BadRequest("CSV needs a workspace, container or item column")
+    }
+
+    var rows []parsedRow
+    number := 0
+
+    for {
+        record, err := reader.Read()
+        if err == io.EOF {
+            break
+        }
+        if err != nil {
+            return nil, apperror.BadRequest(fmt.Sprintf("invalid CSV: %v", err))
+        }
+        number++
+
+        cell := func(name string) string {
+            i, ok := columns[name]
+            if !ok || i >= len(record) {
+                return ""
+            }
+            return strings.TrimSpace(record[i])
+        }
+
+        errs := map[string]string{}
+        integer := func(column, field string) int {
+            value := cell(column)
+            if value == "" {
+                return 0
+            }
+            n, err := strconv.Atoi(value)
+            if err != nil {
+                errs[field] = "must be a whole number"
+            }
+            return n
+        }
+
+        row := Row{
+            Workspace:       cell("workspace"),
+            Container:       cell("container"),
+            ContainerNumber: integer("containernumber", "containerNumber"),
+            Location:        cell("location"),
+            Item:            cell("item"),
+            Description:     cell("description"),
+            Quantity:        integer("quantity", "quantity"),
+            Unit:            cell("unit"),
+            ExpiryDate:      cell("expirydate"),
+            Tags:            splitList(cell("tags")),
+            ImageURLs:       splitList(cell("imageurls")),
+        }
+        if cell("minquantity") != "" {
+            minQuantity := integer("minquantity", "minQuantity")
+            row.MinQuantity = &minQuantity
+        }
+
+        if isBlank(row) {
+            continue
+        }
+
+        rows = append(rows, parsedRow{Row: row, number: number, errors: errs})
+    }
+
+    return rows, nil
+}
+
+func normaliseColumn(name string) string {
+    name = strings.TrimPrefix(name, "\ufeff")
+    name = strings.ToLower(strings.TrimSpace(name))
+    return strings.NewReplacer(" ", "", "_", "", "-", "").Replace(name)
+}
+
+func splitList(value string) []string {
+    if value == "" {
+        return nil
+    }
+
+    var values []string
+    for _, part := range strings.Split(value, listSeparator) {
+        if part = strings.TrimSpace(part); part != "" {
+            values = append(values, part)
+        }
+    }
+    return values
+}
+
+func isBlank(row Row) bool {
+    return row.Workspace == "" && row.Container == "" && row.Item == "" &&
+        row.Description == "" && len(row.Tags) == 0
+}
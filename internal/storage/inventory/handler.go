@@ -0,0 +1,110 @@
+package inventory
+
+import (
+	"fmt"
+	"mime"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/chrisabs/cadence/internal/apperror"
+	"github.com/chrisabs/cadence/internal/middleware"
+	"github.com/chrisabs/cadence/internal/models"
+	"github.com/chrisabs/cadence/internal/platform/respond"
+	"github.com/gorilla/mux"
+)
+
+// maxImportBytes caps the size of an uploaded spreadsheet.
+const maxImportBytes = 5 << 20
+
+type Handler struct {
+    service        *Service
+    authMiddleware *middleware.AuthMiddleware
+}
+
+func NewHandler(service *Service, authMiddleware *middleware.AuthMiddleware) *Handler {
+    return &Handler{
+        service:        service,
+        authMiddleware: authMiddleware,
+    }
+}
+
+func (h *Handler) RegisterRoutes(router *mux.Router) {
+    router.HandleFunc("/inventory/export", h.authMiddleware.ModuleMiddleware(models.ModuleStorage, models.PermissionRead)(h.handleExport)).Methods("GET")
+    router.HandleFunc("/inventory/import", h.authMiddleware.ModuleMiddleware(models.ModuleStorage, models.PermissionWrite)(h.handleImport)).Methods("POST")
+}
+
+// handleExport downloads the inventory as ?format=csv (the default) or json.
+func (h *Handler) handleExport(w http.ResponseWriter, r *http.Request) {
+    profileCtx, ok := middleware.ProfileFrom(r.Context())
+    if !ok {
+        respond.Error(w, r, apperror.Unauthorized("profile authentication required"))
+        return
+    }
+
+    format := Format(r.URL.Query().Get("format"))
+    if format == "" {
+        format = FormatCSV
+    }
+
+    data, err := h.service.Export(r.Context(), profileCtx.FamilyID, format)
+    if err != nil {
+        respond.Error(w, r, err)
+        return
+    }
+
+    contentType := "text/csv; charset=utf-8"
+    if format == FormatJSON {
+        contentType = "application/json"
+    }
+
+    filename := fmt.Sprintf("inventory-%s.%s", time.Now().UTC().Format("2006-01-02"), format)
+    w.Header().Set("Content-Type", contentType)
+    w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+    w.WriteHeader(http.StatusOK)
+    w.Write(data)
+}
+
+// handleImport takes the file as the request body. The format comes from
+// ?format= or, failing that, the Content-Type. With ?dryRun=true nothing is
+// saved and the response shows what would change. A file with invalid rows is
+// never applied and is answered with 422 and the per-row errors.
+func (h *Handler) handleImport(w http.ResponseWriter, r *http.Request) {
+    profileCtx, ok := middleware.ProfileFrom(r.Context())
+    if !ok {
+        respond.Error(w, r, apperror.Unauthorized("profile authentication required"))
+        return
+    }
+
+    format := Format(r.URL.Query().Get("format"))
+    if format == "" {
+        format = FormatCSV
+        if mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type")); err == nil && mediaType == "application/json" {
+            format = FormatJSON
+        }
+    }
+
+    dryRun := false
+    if value := r.URL.Query().Get("dryRun"); value != "" {
+        parsed, err := strconv.ParseBool(value)
+        if err != nil {
+            respond.Error(w, r, apperror.BadRequest("dryRun must be true or false"))
+            return
+        }
+        dryRun = parsed
+    }
+
+    body := http.MaxBytesReader(w, r.Body, maxImportBytes)
+    result, err := h.service.Import(r.Context(), profileCtx.FamilyID, profileCtx.ProfileID, format, body, dryRun)
+    if err != nil {
+        respond.Error(w, r, err)
+        return
+    }
+
+    status := http.StatusOK
+    if result.Summary.Errors > 0 {
+        status = http.StatusUnprocessableEntity
+    }
+
+    respond.JSON(w, status, result)
+}
@@ -0,0 +1,74 @@
+package inventory
+
+type Format string
+
+const (
+    FormatCSV  Format = "csv"
+    FormatJSON Format = "json"
+)
+
+// Row is one line of an inventory spreadsheet: an item with the container and
+// workspace it lives in. Rows without an item describe an empty container,
+// and rows with only a workspace an empty workspace. Image URLs are exported
+// for reference but ignored on import.
+type Row struct {
+    Workspace       string   `json:"workspace,omitempty" validate:"max=100"`
+    Container       string   `json:"container,omitempty" validate:"max=50"`
+    ContainerNumber int      `json:"containerNumber,omitempty" validate:"min=0"`
+    Location        string   `json:"location,omitempty" validate:"max=50"`
+    Item            string   `json:"item,omitempty" validate:"max=100"`
+    Description     string   `json:"description,omitempty" validate:"max=1000"`
+    Quantity        int      `json:"quantity" validate:"min=0"`
+    Unit            string   `json:"unit,omitempty" validate:"max=20"`
+    MinQuantity     *int     `json:"minQuantity,omitempty" validate:"omitempty,min=0"`
+    ExpiryDate      string   `json:"expiryDate,omitempty" validate:"omitempty,date"`
+    Tags            []string `json:"tags,omitempty" validate:"dive,required,max=50"`
+    ImageURLs       []string `json:"imageUrls,omitempty"`
+}
+
+// parsedRow is a row read from an upload, with its position in the file and
+// any cells that could not be parsed.
+type parsedRow struct {
+    Row
+    number int
+    errors map[string]string
+}
+
+type RowAction string
+
+const (
+    ActionCreate    RowAction = "create"
+    ActionUpdate    RowAction = "update"
+    ActionUnchanged RowAction = "unchanged"
+    ActionError     RowAction = "error"
+)
+
+// RowResult says what the import did, or would do, with one row. Row counts
+// data rows from 1, so in a CSV file it is the line number minus the header.
+type RowResult struct {
+    Row       int               `json:"row"`
+    Action    RowAction         `json:"action"`
+    Container string            `json:"container,omitempty"`
+    Item      string            `json:"item,omitempty"`
+    Changes   []string          `json:"changes,omitempty"`
+    Errors    map[string]string `json:"errors,omitempty"`
+}
+
+type ImportSummary struct {
+    WorkspacesCreated int `json:"workspacesCreated"`
+    ContainersCreated int `json:"containersCreated"`
+    TagsCreated       int `json:"tagsCreated"`
+    ItemsCreated      int `json:"itemsCreated"`
+    ItemsUpdated      int `json:"itemsUpdated"`
+    ItemsUnchanged    int `json:"itemsUnchanged"`
+    Errors            int `json:"errors"`
+}
+
+// ImportResult is the diff of an import. Nothing is written unless Applied is
+// set, which needs a request that is not a dry run and has no row errors.
+type ImportResult struct {
+    DryRun  bool          `json:"dryRun"`
+    Applied bool          `json:"applied"`
+    Summary ImportSummary `json:"summary"`
+    Rows    []RowResult   `json:"rows"`
+}
@@ -0,0 +1,253 @@
+package inventory
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/chrisabs/cadence/internal/storage/entities"
+	"github.com/lib/pq"
+)
+
+type Repository struct {
+    db *sql.DB
+}
+
+func NewRepository(db *sql.DB) *Repository {
+    return &Repository{db: db}
+}
+
+// GetExportRows returns one row per item, plus a row for each empty container
+// and each empty workspace, ordered the way a spreadsheet would group them.
+func (r *Repository) GetExportRows(ctx context.Context, familyID int) ([]Row, error) {
+    query := `
+        SELECT COALESCE(w.name, ''), COALESCE(c.name, ''), COALESCE(c.number, 0), COALESCE(c.location, ''),
+               COALESCE(i.name, ''), COALESCE(i.description, ''), COALESCE(i.quantity, 0), i.unit,
+               i.min_quantity, COALESCE(to_char(i.expiry_date, 'YYYY-MM-DD'), ''),
+               COALESCE((
+                   SELECT array_agg(t.name ORDER BY t.name)
+                   FROM item_tag it
+                   JOIN tag t ON t.id = it.tag_id AND t.is_deleted = false
+                   WHERE it.item_id = i.id AND it.is_deleted = false
+               ), '{}'),
+               COALESCE((
+                   SELECT array_agg(img.url ORDER BY img.display_order)
+                   FROM item_image img
+                   WHERE img.item_id = i.id AND img.is_deleted = false
+               ), '{}')
+        FROM item i
+        LEFT JOIN container c ON c.id = i.container_id AND c.family_id = i.family_id AND c.is_deleted = false
+        LEFT JOIN workspace w ON w.id = c.workspace_id AND w.family_id = i.family_id AND w.is_deleted = false
+        WHERE i.family_id = $1 AND i.is_deleted = false
+
+        UNION ALL
+
+        SELECT COALESCE(w.name, ''), COALESCE(c.name, ''), COALESCE(c.number, 0), COALESCE(c.location, ''),
+               '', '', 0, '', NULL, '', '{}', '{}'
+        FROM container c
+        LEFT JOIN workspace w ON w.id = c.workspace_id AND w.family_id = c.family_id AND w.is_deleted = false
+        WHERE c.family_id = $1 AND c.is_deleted = false
+        AND NOT EXISTS (
+            SELECT 1 FROM item i WHERE i.container_id = c.id AND i.is_deleted = false
+        )
+
+        UNION ALL
+
+        SELECT w.name, '', 0, '', '', '', 0, '', NULL, '', '{}', '{}'
+        FROM workspace w
+        WHERE w.family_id = $1 AND w.is_deleted = false
+        AND NOT EXISTS (
+            SELECT 1 FROM container c WHERE c.workspace_id = w.id AND c.is_deleted = false
+        )
+
+        ORDER BY 1, 3, 5`
+
+    rows, err := r.db.QueryContext(ctx, query, familyID)
+    if err != nil {
+        return nil, fmt.Errorf("error querying inventory: %w", err)
+    }
+    defer rows.Close()
+
+    result := make([]Row, 0)
+    for rows.Next() {
+        var row Row
+        var minQuantity sql.NullInt64
+
+        err := rows.Scan(
+            &row.Workspace, &row.Container, &row.ContainerNumber, &row.Location,
+            &row.Item, &row.Description, &row.Quantity, &row.Unit,
+            &minQuantity, &row.ExpiryDate,
+            pq.Array(&row.Tags), pq.Array(&row.ImageURLs),
+        )
+        if err != nil {
+            return nil, fmt.Errorf("error scanning inventory row: %w", err)
+        }
+
+        if minQuantity.Valid {
+            value := int(minQuantity.Int64)
+            row.MinQuantity = &value
+        }
+
+        result = append(result, row)
+    }
+
+    return result, rows.Err()
+}
+
+func (r *Repository) BeginTx(ctx context.Context) (*sql.Tx, error) {
+    return r.db.BeginTx(ctx, nil)
+}
+
+// LockFamily serialises imports for a family until the transaction ends, so
+// two uploads cannot both create the same missing container.
+func (r *Repository) LockFamily(ctx context.Context, tx *sql.Tx, familyID int) error {
+    if _, err := tx.ExecContext(ctx, `SELECT pg_advisory_xact_lock(hashtext('inventory_import'), $1)`, familyID); err != nil {
+        return fmt.Errorf("error locking family inventory: %w", err)
+    }
+    return nil
+}
+
+type namedRecord struct {
+    id   int
+    name string
+}
+
+type containerRecord struct {
+    id     int
+    name   string
+    number int
+}
+
+// familyState is the part of a family's inventory an import is matched
+// against.
+type familyState struct {
+    workspaces []namedRecord
+    containers []containerRecord
+    tags       []namedRecord
+    items      []*entities.Item
+}
+
+func (r *Repository) LoadState(ctx context.Context, tx *sql.Tx, familyID int) (*familyState, error) {
+    state := &familyState{}
+
+    workspaces, err := queryNamed(ctx, tx, `
+        SELECT id, name FROM workspace
+        WHERE family_id = $1 AND is_deleted = false
+        ORDER BY id`, familyID)
+    if err != nil {
+        return nil, fmt.Errorf("error loading workspaces: %w", err)
+    }
+    state.workspaces = workspaces
+
+    tags, err := queryNamed(ctx, tx, `
+        SELECT id, name FROM tag
+        WHERE family_id = $1 AND is_deleted = false
+        ORDER BY id`, familyID)
+    if err != nil {
+        return nil, fmt.Errorf("error loading tags: %w", err)
+    }
+    state.tags = tags
+
+    containerRows, err := tx.QueryContext(ctx, `
+        SELECT id, COALESCE(name, ''), COALESCE(number, 0) FROM container
+        WHERE family_id = $1 AND is_deleted = false
+        ORDER BY number, id`, familyID)
+    if err != nil {
+        return nil, fmt.Errorf("error loading containers: %w", err)
+    }
+    defer containerRows.Close()
+
+    for containerRows.Next() {
+        var record containerRecord
+        if err := containerRows.Scan(&record.id, &record.name, &record.number); err != nil {
+            return nil, fmt.Errorf("error scanning container: %w", err)
+        }
+        state.containers = append(state.containers, record)
+    }
+    if err := containerRows.Err(); err != nil {
+        return nil, fmt.Errorf("error loading containers: %w", err)
+    }
+
+    itemRows, err := tx.QueryContext(ctx, `
+        SELECT i.id, COALESCE(i.name, ''), COALESCE(i.description, ''), COALESCE(i.quantity, 0),
+               i.unit, i.min_quantity, to_char(i.expiry_date, 'YYYY-MM-DD'), i.container_id,
+               COALESCE(array_agg(it.tag_id ORDER BY it.tag_id) FILTER (WHERE it.tag_id IS NOT NULL), '{}')
+        FROM item i
+        LEFT JOIN item_tag it ON it.item_id = i.id AND it.is_deleted = false
+        WHERE i.family_id = $1 AND i.is_deleted = false
+        GROUP BY i.id
+        ORDER BY i.id`, familyID)
+    if err != nil {
+        return nil, fmt.Errorf("error loading items: %w", err)
+    }
+    defer itemRows.Close()
+
+    for itemRows.Next() {
+        item := &entities.Item{FamilyID: familyID}
+        var tagIDs []int64
+
+        err := itemRows.Scan(
+            &item.ID, &item.Name, &item.Description, &item.Quantity,
+            &item.Unit, &item.MinQuantity, &item.ExpiryDate, &item.ContainerID,
+            pq.Array(&tagIDs),
+        )
+        if err != nil {
+            return nil, fmt.Errorf("error scanning item: %w", err)
+        }
+
+        item.Tags = make([]entities.Tag, len(tagIDs))
+        for i, id := range tagIDs {
+            item.Tags[i] = entities.Tag{ID: int(id), FamilyID: familyID}
+        }
+
+        state.items = append(state.items, item)
+    }
+
+    return state, itemRows.Err()
+}
+
+func queryNamed(ctx context.Context, tx *sql.Tx, query string, familyID int) ([]namedRecord, error) {
+    rows, err := tx.QueryContext(ctx, query, familyID)
+    if err != nil {
+        return nil, err
+    }
+    defer rows.Close()
+
+    var records []namedRecord
+    for rows.Next() {
+        var record namedRecord
+        if err := rows.Scan(&record.id, &record.name); err != nil {
+            return nil, err
+        }
+        records = append(records, record)
+    }
+
+    return records, rows.Err()
+}
+
+func (r *Repository) CreateWorkspaceTx(ctx context.Context, tx *sql.Tx, familyID int, profileID int, name string) (int, error) {
+    query := `
+        INSERT INTO workspace (name, description, profile_id, family_id, created_at, updated_at)
+        VALUES ($1, '', $2, $3, $4, $4)
+        RETURNING id`
+
+    var id int
+    if err := tx.QueryRowContext(ctx, query, name, profileID, familyID, time.Now().UTC()).Scan(&id); err != nil {
+        return 0, fmt.Errorf("error creating workspace: %w", err)
+    }
+    return id, nil
+}
+
+func (r *Repository) CreateTagTx(ctx context.Context, tx *sql.Tx, familyID int, profileID int, name string) (int, error) {
+    query := `
+        INSERT INTO tag (name, profile_id, family_id, created_at, updated_at)
+        VALUES ($1, $2, $3, $4, $4)
+        RETURNING id`
+
+    var id int
+    if err := tx.QueryRowContext(ctx, query, name, profileID, familyID, time.Now().UTC()).Scan(&id); err != nil {
+        return 0, fmt.Errorf("error creating tag: %w", err)
+    }
+    return id, nil
+}
@@ -0,0 +1,479 @@
+package inventory
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/chrisabs/cadence/internal/apperror"
+	"github.com/chrisabs/cadence/internal/platform/tracing"
+	"github.com/chrisabs/cadence/internal/platform/validate"
+	"github.com/chrisabs/cadence/internal/storage/container"
+	"github.com/chrisabs/cadence/internal/storage/entities"
+	"github.com/chrisabs/cadence/internal/storage/item"
+	"github.com/chrisabs/cadence/pkg/utils"
+)
+
+// maxImportRows bounds a single upload so an import fits comfortably in one
+// transaction.
+const maxImportRows = 5000
+
+type Service struct {
+    repo          *Repository
+    containerRepo *container.Repository
+    itemRepo      *item.Repository
+}
+
+func NewService(repo *Repository, containerRepo *container.Repository, itemRepo *item.Repository) *Service {
+    return &Service{
+        repo:          repo,
+        containerRepo: containerRepo,
+        itemRepo:      itemRepo,
+    }
+}
+
+// Export writes the family's inventory in the given format.
+func (s *Service) Export(ctx context.Context, familyID int, format Format) ([]byte, error) {
+    ctx, span := tracing.Start(ctx, "inventory.Service.Export")
+    defer span.End()
+
+    rows, err := s.repo.GetExportRows(ctx, familyID)
+    if err != nil {
+        return nil, err
+    }
+
+    var buf bytes.Buffer
+    switch format {
+    case FormatCSV:
+        if err := writeCSV(&buf, rows); err != nil {
+            return nil, fmt.Errorf("error writing CSV: %w", err)
+        }
+    case FormatJSON:
+        if err := json.NewEncoder(&buf).Encode(rows); err != nil {
+            return nil, fmt.Errorf("error writing JSON: %w", err)
+        }
+    default:
+        return nil, apperror.BadRequest("format must be csv or json")
+    }
+
+    return buf.Bytes(), nil
+}
+
+// Import matches the uploaded rows against the family's inventory. Missing
+// workspaces, containers and tags are created by name and items are created
+// or updated. Everything runs in one transaction, which is only committed when
+// dryRun is false and no row has errors, so a dry run reports exactly what an
+// import would do.
+func (s *Service) Import(ctx context.Context, familyID int, profileID int, format Format, body io.Reader, dryRun bool) (*ImportResult, error) {
+    ctx, span := tracing.Start(ctx, "inventory.Service.Import")
+    defer span.End()
+
+    var rows []parsedRow
+    switch format {
+    case FormatCSV:
+        parsed, err := readCSV(body)
+        if err != nil {
+            return nil, err
+        }
+        rows = parsed
+    case FormatJSON:
+        var decoded []Row
+        if err := json.NewDecoder(body).Decode(&decoded); err != nil {
+            return nil, apperror.BadRequest("invalid JSON: expected an array of rows")
+        }
+        for i, row := range decoded {
+            rows = append(rows, parsedRow{Row: row, number: i + 1})
+        }
+    default:
+        return nil, apperror.BadRequest("format must be csv or json")
+    }
+
+    if len(rows) == 0 {
+        return nil, apperror.BadRequest("file has no rows")
+    }
+    if len(rows) > maxImportRows {
+        return nil, apperror.BadRequest(fmt.Sprintf("file has more than %d rows", maxImportRows))
+    }
+
+    tx, err := s.repo.BeginTx(ctx)
+    if err != nil {
+        return nil, fmt.Errorf("error starting transaction: %w", err)
+    }
+    defer tx.Rollback()
+
+    if err := s.repo.LockFamily(ctx, tx, familyID); err != nil {
+        return nil, err
+    }
+
+    state, err := s.repo.LoadState(ctx, tx, familyID)
+    if err != nil {
+        return nil, err
+    }
+
+    run := &importRun{
+        service:   s,
+        tx:        tx,
+        familyID:  familyID,
+        profileID: profileID,
+        state:     state,
+        result:    &ImportResult{DryRun: dryRun, Rows: make([]RowResult, 0, len(rows))},
+    }
+
+    for _, row := range rows {
+        if err := run.apply(ctx, row); err != nil {
+            return nil, err
+        }
+    }
+
+    if dryRun || run.result.Summary.Errors > 0 {
+        return run.result, nil
+    }
+
+    if err := tx.Commit(); err != nil {
+        return nil, fmt.Errorf("error committing import: %w", err)
+    }
+    run.result.Applied = true
+
+    return run.result, nil
+}
+
+// importRun carries one import's transaction and the family state as it
+// changes, so later rows see containers and tags created by earlier ones.
+type importRun struct {
+    service   *Service
+    tx        *sql.Tx
+    familyID  int
+    profileID int
+    state     *familyState
+    result    *ImportResult
+}
+
+func (run *importRun) apply(ctx context.Context, row parsedRow) error {
+    result := RowResult{Row: row.number, Container: row.Container, Item: row.Item}
+
+    errs := validate.Errors{}
+    for field, message := range row.errors {
+        errs.Add(field, message)
+    }
+    validate.Collect(&row.Row, errs)
+    if row.Workspace == "" && row.Container == "" && row.Item == "" {
+        errs.Add("item", "a row needs a workspace, container or item")
+    }
+
+    if len(errs) > 0 {
+        result.Action = ActionError
+        result.Errors = errs
+        run.result.Summary.Errors++
+        run.result.Rows = append(run.result.Rows, result)
+        return nil
+    }
+
+    created := false
+
+    var workspaceID *int
+    if row.Workspace != "" {
+        id, isNew, err := run.workspace(ctx, row.Workspace)
+        if err != nil {
+            return err
+        }
+        workspaceID = &id
+        if isNew {
+            created = true
+            result.Changes = append(result.Changes, fmt.Sprintf("new workspace %q", row.Workspace))
+        }
+    }
+
+    var containerID *int
+    if row.Container != "" {
+        id, isNew, err := run.container(ctx, row.Row, workspaceID)
+        if err != nil {
+            return err
+        }
+        containerID = &id
+        if isNew {
+            created = true
+            result.Changes = append(result.Changes, fmt.Sprintf("new container %q", row.Container))
+        }
+    }
+
+    if row.Item == "" {
+        result.Action = ActionUnchanged
+        if created {
+            result.Action = ActionCreate
+        }
+        run.result.Rows = append(run.result.Rows, result)
+        return nil
+    }
+
+    tagNames, tagIDs, err := run.tags(ctx, row.Tags, &result)
+    if err != nil {
+        return err
+    }
+
+    existing := run.findItem(containerID, row.Item)
+    if existing == nil {
+        if err := run.createItem(ctx, row.Row, containerID, tagNames); err != nil {
+            return err
+        }
+        result.Action = ActionCreate
+        run.result.Summary.ItemsCreated++
+        run.result.Rows = append(run.result.Rows, result)
+        return nil
+    }
+
+    changes := itemChanges(existing, row.Row, tagIDs)
+    if len(changes) == 0 {
+        result.Action = ActionUnchanged
+        run.result.Summary.ItemsUnchanged++
+        run.result.Rows = append(run.result.Rows, result)
+        return nil
+    }
+
+    if err := run.updateItem(ctx, existing, row.Row, tagIDs); err != nil {
+        return err
+    }
+
+    result.Action = ActionUpdate
+    result.Changes = append(result.Changes, changes...)
+    run.result.Summary.ItemsUpdated++
+    run.result.Rows = append(run.result.Rows, result)
+    return nil
+}
+
+func (run *importRun) workspace(ctx context.Context, name string) (int, bool, error) {
+    for _, workspace := range run.state.workspaces {
+        if strings.EqualFold(workspace.name, name) {
+            return workspace.id, false, nil
+        }
+    }
+
+    id, err := run.service.repo.CreateWorkspaceTx(ctx, run.tx, run.familyID, run.profileID, name)
+    if err != nil {
+        return 0, false, err
+    }
+
+    run.state.workspaces = append(run.state.workspaces, namedRecord{id: id, name: name})
+    run.result.Summary.WorkspacesCreated++
+    return id, true, nil
+}
+
+// container finds the row's container, preferring the one with the given
+// number when its name also matches, and creates it in the row's workspace
+// when there is none.
+func (run *importRun) container(ctx context.Context, row Row, workspaceID *int) (int, bool, error) {
+    var match *containerRecord
+    for i, candidate := range run.state.containers {
+        if !strings.EqualFold(candidate.name, row.Container) {
+            continue
+        }
+        if row.ContainerNumber > 0 && candidate.number == row.ContainerNumber {
+            match = &run.state.containers[i]
+            break
+        }
+        if match == nil {
+            match = &run.state.containers[i]
+        }
+    }
+    if match != nil {
+        return match.id, false, nil
+    }
+
+    qrCode, qrImage, err := utils.GenerateQRCode()
+    if err != nil {
+        return 0, false, err
+    }
+
+    now := time.Now().UTC()
+    created := &entities.Container{
+        Name:        row.Container,
+        QRCode:      qrCode,
+        QRCodeImage: qrImage,
+        Location:    row.Location,
+        ProfileID:   run.profileID,
+        FamilyID:    run.familyID,
+        WorkspaceID: workspaceID,
+        CreatedAt:   now,
+        UpdatedAt:   now,
+    }
+
+    if err := run.service.containerRepo.CreateTx(ctx, run.tx, created, nil); err != nil {
+        return 0, false, err
+    }
+
+    run.state.containers = append(run.state.containers, containerRecord{
+        id:     created.ID,
+        name:   created.Name,
+        number: created.Number,
+    })
+    run.result.Summary.ContainersCreated++
+    return created.ID, true, nil
+}
+
+// tags resolves tag names case-insensitively, creating the missing ones, and
+// returns them spelt as stored along with their IDs.
+func (run *importRun) tags(ctx context.Context, names []string, result *RowResult) ([]string, []int, error) {
+    resolvedNames := make([]string, 0, len(names))
+    ids := make([]int, 0, len(names))
+
+    for _, name := range names {
+        var found *namedRecord
+        for i, tag := range run.state.tags {
+            if strings.EqualFold(tag.name, name) {
+                found = &run.state.tags[i]
+                break
+            }
+        }
+
+        if found == nil {
+            id, err := run.service.repo.CreateTagTx(ctx, run.tx, run.familyID, run.profileID, name)
+            if err != nil {
+                return nil, nil, err
+            }
+            run.state.tags = append(run.state.tags, namedRecord{id: id, name: name})
+            found = &run.state.tags[len(run.state.tags)-1]
+            run.result.Summary.TagsCreated++
+            result.Changes = append(result.Changes, fmt.Sprintf("new tag %q", name))
+        }
+
+        if !containsInt(ids, found.id) {
+            resolvedNames = append(resolvedNames, found.name)
+            ids = append(ids, found.id)
+        }
+    }
+
+    return resolvedNames, ids, nil
+}
+
+func (run *importRun) findItem(containerID *int, name string) *entities.Item {
+    for _, existing := range run.state.items {
+        if !strings.EqualFold(existing.Name, name) {
+            continue
+        }
+        if entities.SameContainer(existing.ContainerID, containerID) {
+            return existing
+        }
+    }
+    return nil
+}
+
+func (run *importRun) createItem(ctx context.Context, row Row, containerID *int, tagNames []string) error {
+    now := time.Now().UTC()
+    created := &entities.Item{
+        Name:        row.Item,
+        Description: row.Description,
+        Quantity:    row.Quantity,
+        Unit:        row.Unit,
+        MinQuantity: row.MinQuantity,
+        ExpiryDate:  optionalString(row.ExpiryDate),
+        ContainerID: containerID,
+        ProfileID:   run.profileID,
+        FamilyID:    run.familyID,
+        CreatedAt:   now,
+        UpdatedAt:   now,
+    }
+
+    if err := run.service.itemRepo.CreateTx(ctx, run.tx, created, tagNames); err != nil {
+        return err
+    }
+
+    created.Tags = make([]entities.Tag, 0, len(tagNames))
+    run.state.items = append(run.state.items, created)
+    return nil
+}
+
+// updateItem overwrites the item's fields with the row's and adds the row's
+// tags. Tags the item already has are kept, so an import never removes them.
+func (run *importRun) updateItem(ctx context.Context, existing *entities.Item, row Row, tagIDs []int) error {
+    existing.Description = row.Description
+    existing.Quantity = row.Quantity
+    existing.Unit = row.Unit
+    existing.MinQuantity = row.MinQuantity
+    existing.ExpiryDate = optionalString(row.ExpiryDate)
+    existing.ProfileID = run.profileID
+
+    for _, id := range tagIDs {
+        if !hasTag(existing, id) {
+            existing.Tags = append(existing.Tags, entities.Tag{ID: id, FamilyID: run.familyID})
+        }
+    }
+
+    return run.service.itemRepo.UpdateTx(ctx, run.tx, existing)
+}
+
+// itemChanges describes how applying the row would change the item.
+func itemChanges(existing *entities.Item, row Row, tagIDs []int) []string {
+    var changes []string
+
+    if existing.Description != row.Description {
+        changes = append(changes, "description updated")
+    }
+    if existing.Quantity != row.Quantity {
+        changes = append(changes, fmt.Sprintf("quantity %d -> %d", existing.Quantity, row.Quantity))
+    }
+    if existing.Unit != row.Unit {
+        changes = append(changes, fmt.Sprintf("unit %q -> %q", existing.Unit, row.Unit))
+    }
+    if formatOptionalInt(existing.MinQuantity) != formatOptionalInt(row.MinQuantity) {
+        changes = append(changes, fmt.Sprintf("minimum %s -> %s", formatOptionalInt(existing.MinQuantity), formatOptionalInt(row.MinQuantity)))
+    }
+    if derefString(existing.ExpiryDate) != row.ExpiryDate {
+        changes = append(changes, fmt.Sprintf("expiry %q -> %q", derefString(existing.ExpiryDate), row.ExpiryDate))
+    }
+
+    added := 0
+    for _, id := range tagIDs {
+        if !hasTag(existing, id) {
+            added++
+        }
+    }
+    if added > 0 {
+        changes = append(changes, fmt.Sprintf("%d tag(s) added", added))
+    }
+
+    return changes
+}
+
+func hasTag(existing *entities.Item, id int) bool {
+    for _, tag := range existing.Tags {
+        if tag.ID == id {
+            return true
+        }
+    }
+    return false
+}
+
+func containsInt(values []int, value int) bool {
+    for _, v := range values {
+        if v == value {
+            return true
+        }
+    }
+    return false
+}
+
+func optionalString(value string) *string {
+    if value == "" {
+        return nil
+    }
+    return &value
+}
+
+func derefString(value *string) string {
+    if value == nil {
+        return ""
+    }
+    return *value
+}
+
+func formatOptionalInt(value *int) string {
+    if value == nil {
+        return "none"
+    }
+    return strconv.Itoa(*value)
+}
@@ -7,107 +7,196 @@ import (
 	"strconv"
 	"strings"
 
+	"github.com/chrisabs/cadence/internal/apperror"
 	"github.com/chrisabs/cadence/internal/cloud"
 	"github.com/chrisabs/cadence/internal/middleware"
 	"github.com/chrisabs/cadence/internal/models"
-	"github.com/chrisabs/cadence/internal/storage/entities"
+	"github.com/chrisabs/cadence/internal/platform/respond"
 
 	"github.com/gorilla/mux"
 )
 
-type ContainerService interface {
-    GetContainerByID(id int, familyID int) (*entities.Container, error)
-}
-
 type Handler struct {
-    service          *Service
-    containerService ContainerService
-    authMiddleware   *middleware.AuthMiddleware
+    service        *Service
+    authMiddleware *middleware.AuthMiddleware
 }
 
-func NewHandler(service *Service, containerService ContainerService, authMiddleware *middleware.AuthMiddleware) *Handler {
+func NewHandler(service *Service, authMiddleware *middleware.AuthMiddleware) *Handler {
     return &Handler{
-        service:          service,
-        containerService: containerService,
-        authMiddleware:   authMiddleware,
+        service:        service,
+        authMiddleware: authMiddleware,
     }
 }
 
 func (h *Handler) RegisterRoutes(router *mux.Router) {
-    router.HandleFunc("/items", h.authMiddleware.ProfileAuthHandler(h.handleGetItems)).Methods("GET")
-    router.HandleFunc("/items", h.authMiddleware.ProfileAuthHandler(h.handleCreateItem)).Methods("POST")
+    router.HandleFunc("/items", h.authMiddleware.ModuleMiddleware(models.ModuleStorage, models.PermissionRead)(h.handleGetItems)).Methods("GET")
+    router.HandleFunc("/items", h.authMiddleware.ModuleMiddleware(models.ModuleStorage, models.PermissionWrite)(h.handleCreateItem)).Methods("POST")
+
+    router.HandleFunc("/items/alerts", h.authMiddleware.ModuleMiddleware(models.ModuleStorage, models.PermissionRead)(h.handleGetStockAlerts)).Methods("GET")
+    router.HandleFunc("/items/bulk", h.authMiddleware.ModuleMiddleware(models.ModuleStorage, models.PermissionWrite)(h.handleBulkUpdate)).Methods("POST")
 
-    router.HandleFunc("/items/{id}", h.authMiddleware.ProfileAuthHandler(h.handleGetItem)).Methods("GET")
-    router.HandleFunc("/items/{id}", h.authMiddleware.ProfileAuthHandler(h.handleUpdateItem)).Methods("PUT")
-    router.HandleFunc("/items/{id}", h.authMiddleware.ProfileAuthHandler(h.handleDeleteItem)).Methods("DELETE")
+    router.HandleFunc("/items/{id}", h.authMiddleware.ModuleMiddleware(models.ModuleStorage, models.PermissionRead)(h.handleGetItem)).Methods("GET")
+    router.HandleFunc("/items/{id}", h.authMiddleware.ModuleMiddleware(models.ModuleStorage, models.PermissionWrite)(h.handleUpdateItem)).Methods("PUT")
+    router.HandleFunc("/items/{id}", h.authMiddleware.ModuleMiddleware(models.ModuleStorage, models.PermissionManage)(h.handleDeleteItem)).Methods("DELETE")
 
-    router.HandleFunc("/items/{id}/restore", h.authMiddleware.ProfileAuthHandler(h.handleRestoreItem)).Methods("PUT")
+    router.HandleFunc("/items/{id}/history", h.authMiddleware.ModuleMiddleware(models.ModuleStorage, models.PermissionRead)(h.handleGetItemHistory)).Methods("GET")
+
+    router.HandleFunc("/items/{id}/restore", h.authMiddleware.ModuleMiddleware(models.ModuleStorage, models.PermissionManage)(h.handleRestoreItem)).Methods("PUT")
 }
 
 func (h *Handler) handleGetItems(w http.ResponseWriter, r *http.Request) {
-    profileCtx := r.Context().Value("profile").(*models.ProfileContext)
+    profileCtx, ok := middleware.ProfileFrom(r.Context())
+    if !ok {
+        respond.Error(w, r, apperror.Unauthorized("profile authentication required"))
+        return
+    }
     
-    items, err := h.service.GetItemsByFamilyID(profileCtx.FamilyID)
+    items, err := h.service.GetItemsByFamilyID(r.Context(), profileCtx.FamilyID)
     if err != nil {
-        writeError(w, http.StatusInternalServerError, err.Error())
+        respond.Error(w, r, err)
         return
     }
-    writeJSON(w, http.StatusOK, items)
+    respond.JSON(w, http.StatusOK, items)
 }
 
 func (h *Handler) handleCreateItem(w http.ResponseWriter, r *http.Request) {
-    profileCtx := r.Context().Value("profile").(*models.ProfileContext)
+    profileCtx, ok := middleware.ProfileFrom(r.Context())
+    if !ok {
+        respond.Error(w, r, apperror.Unauthorized("profile authentication required"))
+        return
+    }
 
     var req CreateItemRequest
     if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-        writeError(w, http.StatusBadRequest, "invalid request body")
+        respond.Error(w, r, apperror.BadRequest("invalid request body"))
         return
     }
 
-    if req.ContainerID != nil {
-        if _, err := h.containerService.GetContainerByID(*req.ContainerID, profileCtx.FamilyID); err != nil {
-            writeError(w, http.StatusNotFound, "container not found")
-            return
-        }
+    item, err := h.service.CreateItem(r.Context(), profileCtx.FamilyID, profileCtx.ProfileID, &req)
+    if err != nil {
+        respond.Error(w, r, err)
+        return
+    }
+    respond.JSON(w, http.StatusCreated, item)
+}
+
+func (h *Handler) handleGetItem(w http.ResponseWriter, r *http.Request) {
+    profileCtx, ok := middleware.ProfileFrom(r.Context())
+    if !ok {
+        respond.Error(w, r, apperror.Unauthorized("profile authentication required"))
+        return
     }
 
-    item, err := h.service.CreateItem(profileCtx.FamilyID, profileCtx.ProfileID, &req)
+    itemID, err := getIDFromRequest(r)
+    if err != nil {
+        respond.Error(w, r, err)
+        return
+    }
+
+    item, err := h.service.GetItemByID(r.Context(), itemID, profileCtx.FamilyID)
     if err != nil {
-        writeError(w, http.StatusInternalServerError, err.Error())
+        respond.Error(w, r, err)
         return
     }
-    writeJSON(w, http.StatusCreated, item)
+
+    respond.JSON(w, http.StatusOK, item)
 }
 
-func (h *Handler) handleGetItem(w http.ResponseWriter, r *http.Request) {
-    profileCtx := r.Context().Value("profile").(*models.ProfileContext)
+func (h *Handler) handleGetItemHistory(w http.ResponseWriter, r *http.Request) {
+    profileCtx, ok := middleware.ProfileFrom(r.Context())
+    if !ok {
+        respond.Error(w, r, apperror.Unauthorized("profile authentication required"))
+        return
+    }
 
     itemID, err := getIDFromRequest(r)
     if err != nil {
-        writeError(w, http.StatusBadRequest, err.Error())
+        respond.Error(w, r, err)
         return
     }
 
-    item, err := h.service.GetItemByID(itemID, profileCtx.FamilyID)
+    events, err := h.service.GetItemHistory(r.Context(), itemID, profileCtx.FamilyID)
     if err != nil {
-        writeError(w, http.StatusNotFound, err.Error())
+        respond.Error(w, r, err)
         return
     }
 
-    writeJSON(w, http.StatusOK, item)
+    respond.JSON(w, http.StatusOK, events)
+}
+
+// handleGetStockAlerts lists low stock and expiring items. The optional days
+// parameter sets how far ahead to look for expiry dates.
+func (h *Handler) handleGetStockAlerts(w http.ResponseWriter, r *http.Request) {
+    profileCtx, ok := middleware.ProfileFrom(r.Context())
+    if !ok {
+        respond.Error(w, r, apperror.Unauthorized("profile authentication required"))
+        return
+    }
+
+    var days int
+    if value := r.URL.Query().Get("days"); value != "" {
+        var err error
+        if days, err = strconv.Atoi(value); err != nil {
+            respond.Error(w, r, apperror.BadRequest("invalid days"))
+            return
+        }
+    }
+
+    alerts, err := h.service.GetStockAlerts(r.Context(), profileCtx.FamilyID, days)
+    if err != nil {
+        respond.Error(w, r, err)
+        return
+    }
+
+    respond.JSON(w, http.StatusOK, alerts)
+}
+
+// handleBulkUpdate needs write access, and manage access as well for the
+// delete and restore operations, matching the single-item routes.
+func (h *Handler) handleBulkUpdate(w http.ResponseWriter, r *http.Request) {
+    profileCtx, ok := middleware.ProfileFrom(r.Context())
+    if !ok {
+        respond.Error(w, r, apperror.Unauthorized("profile authentication required"))
+        return
+    }
+
+    var req BulkRequest
+    if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+        respond.Error(w, r, apperror.BadRequest("invalid request body"))
+        return
+    }
+
+    if req.Operation == BulkDelete || req.Operation == BulkRestore {
+        if err := h.authMiddleware.CheckModulePermission(r.Context(), models.ModuleStorage, models.PermissionManage); err != nil {
+            respond.Error(w, r, err)
+            return
+        }
+    }
+
+    response, err := h.service.BulkUpdate(r.Context(), profileCtx.FamilyID, profileCtx.ProfileID, &req)
+    if err != nil {
+        respond.Error(w, r, err)
+        return
+    }
+
+    respond.JSON(w, http.StatusOK, response)
 }
 
 func (h *Handler) handleUpdateItem(w http.ResponseWriter, r *http.Request) {
-    profileCtx := r.Context().Value("profile").(*models.ProfileContext)
+    profileCtx, ok := middleware.ProfileFrom(r.Context())
+    if !ok {
+        respond.Error(w, r, apperror.Unauthorized("profile authentication required"))
+        return
+    }
  
     itemID, err := getIDFromRequest(r)
     if err != nil {
-        writeError(w, http.StatusBadRequest, "invalid item ID")
+        respond.Error(w, r, apperror.BadRequest("invalid item ID"))
         return
     }
  
-	if _, err := h.service.GetItemByID(itemID, profileCtx.FamilyID); err != nil {
-        writeError(w, http.StatusNotFound, err.Error())
+	if _, err := h.service.GetItemByID(r.Context(), itemID, profileCtx.FamilyID); err != nil {
+        respond.Error(w, r, err)
         return
     }
 
@@ -115,109 +204,111 @@ func (h *Handler) handleUpdateItem(w http.ResponseWriter, r *http.Request) {
     contentType := r.Header.Get("Content-Type")
     if strings.Contains(contentType, "multipart/form-data") {
         if err := r.ParseMultipartForm(10 << 20); err != nil {
-            writeError(w, http.StatusBadRequest, fmt.Sprintf("failed to parse form: %v", err))
+            respond.Error(w, r, apperror.BadRequest(fmt.Sprintf("failed to parse form: %v", err)))
             return
         }
 
         itemDataStr := r.FormValue("itemData")
         if itemDataStr == "" {
-            writeError(w, http.StatusBadRequest, "missing itemData")
+            respond.Error(w, r, apperror.BadRequest("missing itemData"))
             return
         }
 
         if err := json.NewDecoder(strings.NewReader(itemDataStr)).Decode(&req); err != nil {
-            writeError(w, http.StatusBadRequest, fmt.Sprintf("invalid item data: %v", err))
+            respond.Error(w, r, apperror.BadRequest(fmt.Sprintf("invalid item data: %v", err)))
             return
         }
 
         if files := r.MultipartForm.File["images"]; len(files) > 0 {
             s3Handler, err := cloud.NewS3Handler()
             if err != nil {
-                writeError(w, http.StatusInternalServerError, err.Error())
+                respond.Error(w, r, err)
                 return
             }
 
             for _, fileHeader := range files {
-                url, err := s3Handler.UploadFile(fileHeader, fmt.Sprintf("items/%d", itemID))
+                url, err := s3Handler.UploadFile(r.Context(), fileHeader, fmt.Sprintf("items/%d", itemID))
                 if err != nil {
-                    writeError(w, http.StatusInternalServerError, err.Error())
+                    respond.Error(w, r, err)
                     return
                 }
 
-                if err := h.service.AddItemImage(itemID, profileCtx.FamilyID, url); err != nil {
-                    writeError(w, http.StatusInternalServerError, err.Error())
+                if err := h.service.AddItemImage(r.Context(), itemID, profileCtx.FamilyID, url); err != nil {
+                    respond.Error(w, r, err)
                     return
                 }
             }
         }
     } else {
         if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-            writeError(w, http.StatusBadRequest, "invalid request body")
+            respond.Error(w, r, apperror.BadRequest("invalid request body"))
             return
         }
     }
 
     if len(req.ImagesToDelete) > 0 {
         for _, url := range req.ImagesToDelete {
-            if err := h.service.DeleteItemImage(itemID, profileCtx.FamilyID, url); err != nil {
-                writeError(w, http.StatusInternalServerError, err.Error())
+            if err := h.service.DeleteItemImage(r.Context(), itemID, profileCtx.FamilyID, url); err != nil {
+                respond.Error(w, r, err)
                 return
             }
         }
     }
 
-    updatedItem, err := h.service.UpdateItem(itemID, profileCtx.FamilyID, profileCtx.ProfileID, &req)
+    updatedItem, err := h.service.UpdateItem(r.Context(), itemID, profileCtx.FamilyID, profileCtx.ProfileID, &req)
     if err != nil {
-        writeError(w, http.StatusInternalServerError, err.Error())
+        respond.Error(w, r, err)
         return
     }
  
-    writeJSON(w, http.StatusOK, updatedItem)
+    respond.JSON(w, http.StatusOK, updatedItem)
 }
 
 func (h *Handler) handleDeleteItem(w http.ResponseWriter, r *http.Request) {
-    profileCtx := r.Context().Value("profile").(*models.ProfileContext)
+    profileCtx, ok := middleware.ProfileFrom(r.Context())
+    if !ok {
+        respond.Error(w, r, apperror.Unauthorized("profile authentication required"))
+        return
+    }
 
     itemID, err := getIDFromRequest(r)
     if err != nil {
-        writeError(w, http.StatusBadRequest, err.Error())
+        respond.Error(w, r, err)
         return
     }
 
-    if err := h.service.DeleteItem(itemID, profileCtx.FamilyID, profileCtx.ProfileID); err != nil {
-        writeError(w, http.StatusInternalServerError, err.Error())
+    if err := h.service.DeleteItem(r.Context(), itemID, profileCtx.FamilyID, profileCtx.ProfileID); err != nil {
+        respond.Error(w, r, err)
         return
     }
-    writeJSON(w, http.StatusOK, map[string]int{"deleted": itemID})
+    respond.JSON(w, http.StatusOK, map[string]int{"deleted": itemID})
 }
 
 func (h *Handler) handleRestoreItem(w http.ResponseWriter, r *http.Request) {
-    profileCtx := r.Context().Value("profile").(*models.ProfileContext)
+    profileCtx, ok := middleware.ProfileFrom(r.Context())
+    if !ok {
+        respond.Error(w, r, apperror.Unauthorized("profile authentication required"))
+        return
+    }
 
     itemID, err := getIDFromRequest(r)
     if err != nil {
-        writeError(w, http.StatusBadRequest, err.Error())
+        respond.Error(w, r, err)
         return
     }
 
-    if err := h.service.RestoreItem(itemID, profileCtx.FamilyID); err != nil {
-        writeError(w, http.StatusInternalServerError, err.Error())
+    if err := h.service.RestoreItem(r.Context(), itemID, profileCtx.FamilyID); err != nil {
+        respond.Error(w, r, err)
         return
     }
-    writeJSON(w, http.StatusOK, map[string]int{"restored": itemID})
+    respond.JSON(w, http.StatusOK, map[string]int{"restored": itemID})
 }
 
 func getIDFromRequest(r *http.Request) (int, error) {
     vars := mux.Vars(r)
-    return strconv.Atoi(vars["id"])
-}
-
-func writeJSON(w http.ResponseWriter, status int, v interface{}) {
-    w.Header().Set("Content-Type", "application/json")
-    w.WriteHeader(status)
-    json.NewEncoder(w).Encode(v)
+    id, err := strconv.Atoi(vars["id"])
+    if err != nil {
+        return 0, apperror.BadRequest("invalid id")
+    }
+    return id, nil
 }
-
-func writeError(w http.ResponseWriter, status int, message string) {
-    writeJSON(w, status, map[string]string{"error": message})
-}
\ No newline at end of file
@@ -1,17 +1,25 @@
 package item
 
+import "github.com/chrisabs/cadence/internal/storage/entities"
+
 type CreateItemRequest struct {
-    Name        string   `json:"name"`
-    Description string   `json:"description"`
-    Quantity    int      `json:"quantity"`
+    Name        string   `json:"name" validate:"required,max=100"`
+    Description string   `json:"description" validate:"max=1000"`
+    Quantity    int      `json:"quantity" validate:"min=0"`
+    Unit        string   `json:"unit" validate:"max=20"`
+    MinQuantity *int     `json:"minQuantity,omitempty" validate:"omitempty,min=0"`
+    ExpiryDate  *string  `json:"expiryDate,omitempty" validate:"omitempty,date"`
     ContainerID *int     `json:"containerId,omitempty"`
-    TagNames    []string `json:"tagNames"`
+    TagNames    []string `json:"tagNames" validate:"dive,required,max=50"`
 }
 
 type UpdateItemRequest struct {
-    Name           string   `json:"name"`
-    Description    string   `json:"description"`
-    Quantity       int      `json:"quantity"`
+    Name           string   `json:"name" validate:"required,max=100"`
+    Description    string   `json:"description" validate:"max=1000"`
+    Quantity       int      `json:"quantity" validate:"min=0"`
+    Unit           string   `json:"unit" validate:"max=20"`
+    MinQuantity    *int     `json:"minQuantity,omitempty" validate:"omitempty,min=0"`
+    ExpiryDate     *string  `json:"expiryDate,omitempty" validate:"omitempty,date"`
     ContainerID    *int     `json:"containerId,omitempty"`
     Tags           []int    `json:"tags,omitempty"`
     ImagesToDelete []string `json:"imagesToDelete,omitempty"`
@@ -20,4 +28,56 @@ type UpdateItemRequest struct {
 type AddImageRequest struct {
     ItemID      int    `json:"itemId"`
     ImageURL    string `json:"imageUrl"`
-}
\ No newline at end of file
+}
+
+type BulkOperation string
+
+const (
+    BulkMove           BulkOperation = "move"
+    BulkAddTags        BulkOperation = "add_tags"
+    BulkRemoveTags     BulkOperation = "remove_tags"
+    BulkSetQuantity    BulkOperation = "set_quantity"
+    BulkAdjustQuantity BulkOperation = "adjust_quantity"
+    BulkDelete         BulkOperation = "delete"
+    BulkRestore        BulkOperation = "restore"
+)
+
+// BulkRequest applies one operation to many items. ContainerID is the move
+// target, with null taking the items out of their containers; Quantity is used
+// by set_quantity and Delta by adjust_quantity.
+type BulkRequest struct {
+    ItemIDs     []int         `json:"itemIds" validate:"required,min=1,max=500"`
+    Operation   BulkOperation `json:"operation" validate:"required,oneof=move add_tags remove_tags set_quantity adjust_quantity delete restore"`
+    ContainerID *int          `json:"containerId,omitempty"`
+    TagIDs      []int         `json:"tagIds,omitempty"`
+    Quantity    *int          `json:"quantity,omitempty" validate:"omitempty,min=0"`
+    Delta       *int          `json:"delta,omitempty"`
+}
+
+type BulkItemResult struct {
+    ItemID  int    `json:"itemId"`
+    Success bool   `json:"success"`
+    Error   string `json:"error,omitempty"`
+
+    droppedBelowMinimum bool
+}
+
+type BulkResponse struct {
+    Operation BulkOperation    `json:"operation"`
+    Succeeded int              `json:"succeeded"`
+    Failed    int              `json:"failed"`
+    Results   []BulkItemResult `json:"results"`
+}
+
+// StockAlerts lists the items that need attention: those below their minimum
+// quantity and those expiring within the requested window.
+type StockAlerts struct {
+    LowStock []*entities.Item `json:"lowStock"`
+    Expiring []*entities.Item `json:"expiring"`
+}
+
+// DroppedBelowMinimum reports whether a quantity change took an item from at
+// or above its minimum to below it. Items without a minimum never alert.
+func DroppedBelowMinimum(previous, current int, minimum *int) bool {
+    return minimum != nil && previous >= *minimum && current < *minimum
+}
@@ -1,14 +1,26 @@
 package item
 
 import (
+	"context"
 	"database/sql"
 	"encoding/json"
 	"fmt"
 	"time"
 
+	"github.com/chrisabs/cadence/internal/apperror"
+	"github.com/chrisabs/cadence/internal/models"
+	"github.com/chrisabs/cadence/internal/storage/deletion"
 	"github.com/chrisabs/cadence/internal/storage/entities"
+	"github.com/chrisabs/cadence/internal/storage/history"
+	"github.com/lib/pq"
 )
 
+// reviveTagLink lets linking an item to a tag bring back a link that was
+// soft deleted with the tag, rather than failing on the existing row.
+const reviveTagLink = `
+    ON CONFLICT (item_id, tag_id) DO UPDATE
+    SET is_deleted = false, deleted_at = NULL, deleted_by = NULL, deletion_batch = NULL`
+
 type Repository struct {
     db *sql.DB
 }
@@ -17,25 +29,42 @@ func NewRepository(db *sql.DB) *Repository {
     return &Repository{db: db}
 }
 
-func (r *Repository) Create(item *entities.Item, tagNames []string) (*entities.Item, error) {
-    tx, err := r.db.Begin()
+func (r *Repository) Create(ctx context.Context, item *entities.Item, tagNames []string) (*entities.Item, error) {
+    tx, err := r.db.BeginTx(ctx, nil)
     if err != nil {
-        return nil, fmt.Errorf("error starting transaction: %v", err)
+        return nil, fmt.Errorf("error starting transaction: %w", err)
     }
     defer tx.Rollback()
 
+    if err := r.CreateTx(ctx, tx, item, tagNames); err != nil {
+        return nil, err
+    }
+
+    if err = tx.Commit(); err != nil {
+        return nil, fmt.Errorf("error committing transaction: %w", err)
+    }
+
+    return r.GetByID(ctx, item.ID, item.FamilyID)
+}
+
+// CreateTx creates the item inside the caller's transaction, creating any
+// tags that do not exist yet.
+func (r *Repository) CreateTx(ctx context.Context, tx *sql.Tx, item *entities.Item, tagNames []string) error {
     itemQuery := `
         INSERT INTO item (
-            name, description, quantity, container_id, 
-            profile_id, family_id, created_at, updated_at
-        ) VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+            name, description, quantity, unit, min_quantity, expiry_date,
+            container_id, profile_id, family_id, created_at, updated_at
+        ) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
         RETURNING id, created_at, updated_at`
 
-    err = tx.QueryRow(
+    err := tx.QueryRowContext(ctx,
         itemQuery,
         item.Name,
         item.Description,
         item.Quantity,
+        item.Unit,
+        item.MinQuantity,
+        item.ExpiryDate,
         item.ContainerID,
         item.ProfileID,
         item.FamilyID,
@@ -44,19 +73,19 @@ func (r *Repository) Create(item *entities.Item, tagNames []string) (*entities.I
     ).Scan(&item.ID, &item.CreatedAt, &item.UpdatedAt)
 
     if err != nil {
-        return nil, fmt.Errorf("error creating item: %v", err)
+        return fmt.Errorf("error creating item: %w", err)
     }
 
     for _, tagName := range tagNames {
         var tagID int
-        err := tx.QueryRow(`
+        err := tx.QueryRowContext(ctx, `
             SELECT id FROM tag 
             WHERE name = $1 AND family_id = $2 AND is_deleted = false`,
             tagName, item.FamilyID,
         ).Scan(&tagID)
 
         if err == sql.ErrNoRows {
-            err = tx.QueryRow(`
+            err = tx.QueryRowContext(ctx, `
                 INSERT INTO tag (name, family_id, created_at, updated_at)
                 VALUES ($1, $2, $3, $4)
                 RETURNING id`,
@@ -67,29 +96,25 @@ func (r *Repository) Create(item *entities.Item, tagNames []string) (*entities.I
             ).Scan(&tagID)
 
             if err != nil {
-                return nil, fmt.Errorf("error creating tag: %v", err)
+                return fmt.Errorf("error creating tag: %w", err)
             }
         } else if err != nil {
-            return nil, fmt.Errorf("error checking existing tag: %v", err)
+            return fmt.Errorf("error checking existing tag: %w", err)
         }
 
-        _, err = tx.Exec(
-            "INSERT INTO item_tag (item_id, tag_id) VALUES ($1, $2)",
+        _, err = tx.ExecContext(ctx,
+            "INSERT INTO item_tag (item_id, tag_id) VALUES ($1, $2)"+reviveTagLink,
             item.ID, tagID,
         )
         if err != nil {
-            return nil, fmt.Errorf("error linking tag to item: %v", err)
+            return fmt.Errorf("error linking tag to item: %w", err)
         }
     }
 
-    if err = tx.Commit(); err != nil {
-        return nil, fmt.Errorf("error committing transaction: %v", err)
-    }
-
-    return r.GetByID(item.ID, item.FamilyID)
+    return nil
 }
 
-func (r *Repository) GetByID(id int, familyID int) (*entities.Item, error) {
+func (r *Repository) GetByID(ctx context.Context, id int, familyID int) (*entities.Item, error) {
     query := `
         WITH item_images AS (
             SELECT item_id,
@@ -106,6 +131,7 @@ func (r *Repository) GetByID(id int, familyID int) (*entities.Item, error) {
             GROUP BY item_id
         )
         SELECT i.id, i.name, i.description, i.quantity, 
+            i.unit, i.min_quantity, to_char(i.expiry_date, 'YYYY-MM-DD'),
             i.profile_id, i.container_id, i.family_id, i.created_at, i.updated_at,
                COALESCE(img.images, '[]'::jsonb) as images,
                COALESCE(
@@ -155,7 +181,7 @@ func (r *Repository) GetByID(id int, familyID int) (*entities.Item, error) {
         LEFT JOIN item_images img ON i.id = img.item_id
         LEFT JOIN container c ON i.container_id = c.id AND c.family_id = i.family_id AND c.is_deleted = false
         LEFT JOIN workspace w ON c.workspace_id = w.id AND w.family_id = c.family_id AND w.is_deleted = false
-        LEFT JOIN item_tag it ON i.id = it.item_id
+        LEFT JOIN item_tag it ON i.id = it.item_id AND it.is_deleted = false
         LEFT JOIN tag t ON it.tag_id = t.id AND t.family_id = i.family_id AND t.is_deleted = false
         WHERE i.id = $1 AND i.family_id = $2 AND i.is_deleted = false
         GROUP BY i.id, i.name, i.description, i.quantity, 
@@ -168,38 +194,64 @@ func (r *Repository) GetByID(id int, familyID int) (*entities.Item, error) {
     item := new(entities.Item)
     var imagesJSON, containerJSON, tagsJSON []byte
 
-    err := r.db.QueryRow(query, id, familyID).Scan(
+    err := r.db.QueryRowContext(ctx, query, id, familyID).Scan(
         &item.ID, &item.Name, &item.Description,
-        &item.Quantity, &item.ProfileID, &item.ContainerID, &item.FamilyID,
+        &item.Quantity, &item.Unit, &item.MinQuantity, &item.ExpiryDate,
+        &item.ProfileID, &item.ContainerID, &item.FamilyID,
         &item.CreatedAt, &item.UpdatedAt,
         &imagesJSON, &containerJSON, &tagsJSON,
     )
 
     if err == sql.ErrNoRows {
-        return nil, fmt.Errorf("item not found")
+        return nil, apperror.NotFound("item not found")
     }
     if err != nil {
         return nil, err
     }
 
     if err := json.Unmarshal(imagesJSON, &item.Images); err != nil {
-        return nil, fmt.Errorf("error parsing images: %v", err)
+        return nil, fmt.Errorf("error parsing images: %w", err)
     }
 
     if containerJSON != nil {
         if err := json.Unmarshal(containerJSON, &item.Container); err != nil {
-            return nil, fmt.Errorf("error parsing container: %v", err)
+            return nil, fmt.Errorf("error parsing container: %w", err)
         }
     }
 
     if err := json.Unmarshal(tagsJSON, &item.Tags); err != nil {
-        return nil, fmt.Errorf("error parsing tags: %v", err)
+        return nil, fmt.Errorf("error parsing tags: %w", err)
     }
 
     return item, nil
 }
 
-func (r *Repository) GetByFamilyID(familyID int) ([]*entities.Item, error) {
+func (r *Repository) GetByFamilyID(ctx context.Context, familyID int) ([]*entities.Item, error) {
+    return r.list(ctx, "", "i.created_at DESC", familyID)
+}
+
+// GetLowStock returns the items whose quantity is below their minimum.
+func (r *Repository) GetLowStock(ctx context.Context, familyID int) ([]*entities.Item, error) {
+    return r.list(ctx,
+        "AND i.min_quantity IS NOT NULL AND COALESCE(i.quantity, 0) < i.min_quantity",
+        "i.name",
+        familyID,
+    )
+}
+
+// GetExpiring returns the items that expire on or before the given date,
+// including those already past it.
+func (r *Repository) GetExpiring(ctx context.Context, familyID int, before time.Time) ([]*entities.Item, error) {
+    return r.list(ctx,
+        "AND i.expiry_date IS NOT NULL AND i.expiry_date <= $2",
+        "i.expiry_date, i.name",
+        familyID, before,
+    )
+}
+
+// list returns the family's items matching condition, which is appended to
+// the WHERE clause and may use $2 onwards for its own arguments.
+func (r *Repository) list(ctx context.Context, condition string, orderBy string, args ...interface{}) ([]*entities.Item, error) {
     query := `
         WITH item_images AS (
             SELECT item_id,
@@ -216,6 +268,7 @@ func (r *Repository) GetByFamilyID(familyID int) ([]*entities.Item, error) {
             GROUP BY item_id
         )
         SELECT i.id, i.name, i.description, i.quantity, 
+            i.unit, i.min_quantity, to_char(i.expiry_date, 'YYYY-MM-DD'),
             i.profile_id, i.container_id, i.family_id, i.created_at, i.updated_at,
                COALESCE(img.images, '[]'::jsonb) as images,
                COALESCE(
@@ -265,20 +318,20 @@ func (r *Repository) GetByFamilyID(familyID int) ([]*entities.Item, error) {
         LEFT JOIN item_images img ON i.id = img.item_id
         LEFT JOIN container c ON i.container_id = c.id AND c.family_id = i.family_id AND c.is_deleted = false
         LEFT JOIN workspace w ON c.workspace_id = w.id AND w.family_id = c.family_id AND w.is_deleted = false
-        LEFT JOIN item_tag it ON i.id = it.item_id
+        LEFT JOIN item_tag it ON i.id = it.item_id AND it.is_deleted = false
         LEFT JOIN tag t ON it.tag_id = t.id AND t.family_id = i.family_id AND t.is_deleted = false
-        WHERE i.family_id = $1 AND i.is_deleted = false
+        WHERE i.family_id = $1 AND i.is_deleted = false ` + condition + `
         GROUP BY i.id, i.name, i.description, i.quantity, 
                  i.container_id, i.family_id, i.created_at, i.updated_at,
                  img.images,
                  c.id, c.name, c.description, c.qr_code, c.qr_code_image, c.number, c.location,
                  c.profile_id, c.family_id, c.workspace_id, c.created_at, c.updated_at,
                  w.id, w.name, w.description, w.profile_id, w.family_id, w.created_at, w.updated_at
-        ORDER BY i.created_at DESC`
+        ORDER BY ` + orderBy
 
-    rows, err := r.db.Query(query, familyID)
+    rows, err := r.db.QueryContext(ctx, query, args...)
     if err != nil {
-        return nil, fmt.Errorf("error querying items: %v", err)
+        return nil, fmt.Errorf("error querying items: %w", err)
     }
     defer rows.Close()
 
@@ -289,26 +342,27 @@ func (r *Repository) GetByFamilyID(familyID int) ([]*entities.Item, error) {
 
         err := rows.Scan(
             &item.ID, &item.Name, &item.Description,
-            &item.Quantity, &item.ProfileID, &item.ContainerID, &item.FamilyID,
+            &item.Quantity, &item.Unit, &item.MinQuantity, &item.ExpiryDate,
+            &item.ProfileID, &item.ContainerID, &item.FamilyID,
             &item.CreatedAt, &item.UpdatedAt,
             &imagesJSON, &containerJSON, &tagsJSON,
         )
         if err != nil {
-            return nil, fmt.Errorf("error scanning item: %v", err)
+            return nil, fmt.Errorf("error scanning item: %w", err)
         }
 
         if err := json.Unmarshal(imagesJSON, &item.Images); err != nil {
-            return nil, fmt.Errorf("error parsing images: %v", err)
+            return nil, fmt.Errorf("error parsing images: %w", err)
         }
 
         if containerJSON != nil {
             if err := json.Unmarshal(containerJSON, &item.Container); err != nil {
-                return nil, fmt.Errorf("error parsing container: %v", err)
+                return nil, fmt.Errorf("error parsing container: %w", err)
             }
         }
 
         if err := json.Unmarshal(tagsJSON, &item.Tags); err != nil {
-            return nil, fmt.Errorf("error parsing tags: %v", err)
+            return nil, fmt.Errorf("error parsing tags: %w", err)
         }
 
         items = append(items, item)
@@ -317,20 +371,47 @@ func (r *Repository) GetByFamilyID(familyID int) ([]*entities.Item, error) {
     return items, nil
 }
 
-func (r *Repository) Update(item *entities.Item) error {
-    tx, err := r.db.Begin()
+func (r *Repository) Update(ctx context.Context, item *entities.Item) error {
+    tx, err := r.db.BeginTx(ctx, nil)
     if err != nil {
-        return fmt.Errorf("error starting transaction: %v", err)
+        return fmt.Errorf("error starting transaction: %w", err)
     }
     defer tx.Rollback()
 
+    if err := r.UpdateTx(ctx, tx, item); err != nil {
+        return err
+    }
+
+    return tx.Commit()
+}
+
+// UpdateTx saves the item inside the caller's transaction, recording moves
+// and quantity changes and replacing its tags with item.Tags.
+func (r *Repository) UpdateTx(ctx context.Context, tx *sql.Tx, item *entities.Item) error {
+    var previousContainerID sql.NullInt64
+    var previousQuantity int
+    err := tx.QueryRowContext(ctx, `
+        SELECT container_id, COALESCE(quantity, 0) FROM item
+        WHERE id = $1 AND family_id = $2 AND is_deleted = false
+        FOR UPDATE`,
+        item.ID, item.FamilyID,
+    ).Scan(&previousContainerID, &previousQuantity)
+
+    if err == sql.ErrNoRows {
+        return apperror.NotFound("item not found")
+    }
+    if err != nil {
+        return fmt.Errorf("error loading item: %w", err)
+    }
+
         query := `
         UPDATE item
         SET name = $2, description = $3,
-        quantity = $4, container_id = $5, profile_id = $6, updated_at = $7
+        quantity = $4, container_id = $5, profile_id = $6, updated_at = $7,
+        unit = $9, min_quantity = $10, expiry_date = $11
         WHERE id = $1 AND family_id = $8 AND is_deleted = false`
 
-        result, err := tx.Exec(
+        result, err := tx.ExecContext(ctx,
         query,
         item.ID,
         item.Name,
@@ -340,63 +421,149 @@ func (r *Repository) Update(item *entities.Item) error {
         item.ProfileID,
         time.Now().UTC(),
         item.FamilyID,
+        item.Unit,
+        item.MinQuantity,
+        item.ExpiryDate,
         )
     if err != nil {
-        return fmt.Errorf("error updating item: %v", err)
+        return fmt.Errorf("error updating item: %w", err)
     }
 
     rowsAffected, err := result.RowsAffected()
     if err != nil {
-        return fmt.Errorf("error checking update result: %v", err)
+        return fmt.Errorf("error checking update result: %w", err)
     }
 
     if rowsAffected == 0 {
-        return fmt.Errorf("item not found")
+        return apperror.NotFound("item not found")
+    }
+
+    var fromContainerID *int
+    if previousContainerID.Valid {
+        id := int(previousContainerID.Int64)
+        fromContainerID = &id
     }
 
-    _, err = tx.Exec("DELETE FROM item_tag WHERE item_id = $1", item.ID)
+    if err := r.recordEvents(ctx, tx, item, fromContainerID, previousQuantity); err != nil {
+        return err
+    }
+
+    _, err = tx.ExecContext(ctx, "DELETE FROM item_tag WHERE item_id = $1 AND is_deleted = false", item.ID)
     if err != nil {
-        return fmt.Errorf("error removing old tags: %v", err)
+        return fmt.Errorf("error removing old tags: %w", err)
     }
 
     if len(item.Tags) > 0 {
-        tagQuery := `INSERT INTO item_tag (item_id, tag_id) VALUES ($1, $2)`
+        tagQuery := `INSERT INTO item_tag (item_id, tag_id) VALUES ($1, $2)` + reviveTagLink
         for _, tag := range item.Tags {
-            _, err = tx.Exec(tagQuery, item.ID, tag.ID)
+            _, err = tx.ExecContext(ctx, tagQuery, item.ID, tag.ID)
             if err != nil {
-                return fmt.Errorf("error associating tag: %v", err)
+                return fmt.Errorf("error associating tag: %w", err)
             }
         }
     }
 
-    return tx.Commit()
+    return nil
 }
 
-func (r *Repository) AddItemImage(itemID int, familyID int, url string, displayOrder int) error {
+// recordEvents writes the history rows for an item that was in fromContainerID
+// with previousQuantity and now matches item. Nothing is written when neither
+// changed.
+func (r *Repository) recordEvents(ctx context.Context, tx *sql.Tx, item *entities.Item, fromContainerID *int, previousQuantity int) error {
+    query := `
+        INSERT INTO item_event (
+            item_id, family_id, profile_id, event_type, container_id,
+            from_container_id, to_container_id, old_quantity, new_quantity, created_at
+        ) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)`
+
+    now := time.Now().UTC()
+
+    if !entities.SameContainer(fromContainerID, item.ContainerID) {
+        _, err := tx.ExecContext(ctx, query,
+            item.ID, item.FamilyID, item.ProfileID, entities.ItemEventMoved, nil,
+            fromContainerID, item.ContainerID, nil, nil, now,
+        )
+        if err != nil {
+            return fmt.Errorf("error recording item move: %w", err)
+        }
+    }
+
+    if previousQuantity != item.Quantity {
+        _, err := tx.ExecContext(ctx, query,
+            item.ID, item.FamilyID, item.ProfileID, entities.ItemEventQuantityChanged, item.ContainerID,
+            nil, nil, previousQuantity, item.Quantity, now,
+        )
+        if err != nil {
+            return fmt.Errorf("error recording quantity change: %w", err)
+        }
+    }
+
+    return nil
+}
+
+// GetHistory returns the item's moves and quantity changes, newest first. It
+// also works for deleted items so their last location can be found.
+func (r *Repository) GetHistory(ctx context.Context, id int, familyID int) ([]entities.ItemEvent, error) {
+    var exists bool
+    err := r.db.QueryRowContext(ctx,
+        "SELECT EXISTS (SELECT 1 FROM item WHERE id = $1 AND family_id = $2)",
+        id, familyID,
+    ).Scan(&exists)
+    if err != nil {
+        return nil, fmt.Errorf("error checking item: %w", err)
+    }
+    if !exists {
+        return nil, apperror.NotFound("item not found")
+    }
+
+    query := `
+        SELECT e.id, e.item_id, COALESCE(i.name, ''), e.event_type, '',
+               e.container_id, e.from_container_id, COALESCE(fc.name, ''),
+               e.to_container_id, COALESCE(tc.name, ''),
+               e.old_quantity, e.new_quantity,
+               e.profile_id, COALESCE(p.name, ''), e.created_at
+        FROM item_event e
+        JOIN item i ON i.id = e.item_id
+        LEFT JOIN container fc ON fc.id = e.from_container_id
+        LEFT JOIN container tc ON tc.id = e.to_container_id
+        LEFT JOIN profile p ON p.id = e.profile_id
+        WHERE e.item_id = $1 AND e.family_id = $2
+        ORDER BY e.created_at DESC, e.id DESC`
+
+    rows, err := r.db.QueryContext(ctx, query, id, familyID)
+    if err != nil {
+        return nil, fmt.Errorf("error querying item history: %w", err)
+    }
+    defer rows.Close()
+
+    return history.ScanEvents(rows)
+}
+
+func (r *Repository) AddItemImage(ctx context.Context, itemID int, familyID int, url string, displayOrder int) error {
     query := `
         INSERT INTO item_image (item_id, url, display_order)
         SELECT $1, $2, $3
         FROM item
         WHERE id = $1 AND family_id = $4`
     
-    result, err := r.db.Exec(query, itemID, url, displayOrder, familyID)
+    result, err := r.db.ExecContext(ctx, query, itemID, url, displayOrder, familyID)
     if err != nil {
-        return fmt.Errorf("error adding item image: %v", err)
+        return fmt.Errorf("error adding item image: %w", err)
     }
 
     rows, err := result.RowsAffected()
     if err != nil {
-        return fmt.Errorf("error checking result: %v", err)
+        return fmt.Errorf("error checking result: %w", err)
     }
 
     if rows == 0 {
-        return fmt.Errorf("item not found or access denied")
+        return apperror.NotFound("item not found or access denied")
     }
     
     return nil
 }
 
-func (r *Repository) DeleteItemImage(itemID int, familyID int, url string) error {
+func (r *Repository) DeleteItemImage(ctx context.Context, itemID int, familyID int, url string) error {
     query := `
         DELETE FROM item_image
         WHERE item_id = $1 AND url = $2
@@ -405,84 +572,281 @@ func (r *Repository) DeleteItemImage(itemID int, familyID int, url string) error
             WHERE id = $1 AND family_id = $3
         )`
     
-    result, err := r.db.Exec(query, itemID, url, familyID)
+    result, err := r.db.ExecContext(ctx, query, itemID, url, familyID)
     if err != nil {
-        return fmt.Errorf("error deleting item image: %v", err)
+        return fmt.Errorf("error deleting item image: %w", err)
     }
     
     rowsAffected, err := result.RowsAffected()
     if err != nil {
-        return fmt.Errorf("error checking delete result: %v", err)
+        return fmt.Errorf("error checking delete result: %w", err)
     }
     
     if rowsAffected == 0 {
-        return fmt.Errorf("image not found or access denied")
+        return apperror.NotFound("image not found or access denied")
     }
     
     return nil
 }
 
-func (r *Repository) Delete(id int, familyID int, deletedBy int) error {
-    tx, err := r.db.Begin()
+// Delete soft deletes the item together with its images and tag links.
+func (r *Repository) Delete(ctx context.Context, id int, familyID int, deletedBy int) error {
+    tx, err := r.db.BeginTx(ctx, nil)
     if err != nil {
-        return fmt.Errorf("error starting transaction: %v", err)
+        return fmt.Errorf("error starting transaction: %w", err)
     }
     defer tx.Rollback()
 
-    itemTagQuery := `
-        DELETE FROM item_tag
-        WHERE item_id = $1
-        AND EXISTS (
-            SELECT 1 FROM item
-            WHERE id = $1 AND family_id = $2 AND is_deleted = false
-        )`
-    
-    _, err = tx.Exec(itemTagQuery, id, familyID)
+    batch, err := deletion.Begin(ctx, tx, familyID, deletedBy)
     if err != nil {
-        return fmt.Errorf("error removing item-tag associations: %v", err)
+        return err
     }
 
-    itemQuery := `
-        UPDATE item
-        SET is_deleted = true, deleted_at = $3, deleted_by = $4, updated_at = $3
-        WHERE id = $1 AND family_id = $2 AND is_deleted = false`
-        
-    result, err := tx.Exec(itemQuery, id, familyID, time.Now().UTC(), deletedBy)
+    deleted, err := batch.Items(ctx, []int{id})
     if err != nil {
-        return fmt.Errorf("error deleting item: %v", err)
+        return err
+    }
+    if deleted == 0 {
+        return apperror.NotFound("item not found or access denied")
     }
 
-    rowsAffected, err := result.RowsAffected()
+    return tx.Commit()
+}
+
+// RestoreDeleted brings back the item and everything deleted with it, which
+// is its whole container or workspace if that is how it was deleted.
+func (r *Repository) RestoreDeleted(ctx context.Context, id int, familyID int) error {
+    tx, err := r.db.BeginTx(ctx, nil)
     if err != nil {
-        return fmt.Errorf("error checking delete result: %v", err)
+        return fmt.Errorf("error starting transaction: %w", err)
     }
+    defer tx.Rollback()
 
-    if rowsAffected == 0 {
-        return fmt.Errorf("item not found or access denied")
+    restored, err := deletion.Restore(ctx, tx, deletion.Item, id, familyID)
+    if err != nil {
+        return err
+    }
+    if !restored {
+        return apperror.NotFound("item not found or not deleted")
     }
 
     return tx.Commit()
 }
 
-func (r *Repository) RestoreDeleted(id int, familyID int) error {
-    query := `
-        UPDATE item
-        SET is_deleted = false, deleted_at = NULL, deleted_by = NULL, updated_at = $3
-        WHERE id = $1 AND family_id = $2 AND is_deleted = true`
-    
-    result, err := r.db.Exec(query, id, familyID, time.Now().UTC())
+// Bulk applies req to each of its items in one transaction. Items that are
+// missing, in the wrong state or would end up with a negative quantity are
+// reported as failures and skipped; the rest are committed together.
+func (r *Repository) Bulk(ctx context.Context, familyID int, profileID int, req *BulkRequest) ([]BulkItemResult, error) {
+    tx, err := r.db.BeginTx(ctx, nil)
     if err != nil {
-        return fmt.Errorf("error restoring item: %v", err)
+        return nil, fmt.Errorf("error starting transaction: %w", err)
     }
+    defer tx.Rollback()
 
-    rowsAffected, err := result.RowsAffected()
+    type itemState struct {
+        containerID *int
+        quantity    int
+        minQuantity *int
+        deleted     bool
+    }
+
+    rows, err := tx.QueryContext(ctx, `
+        SELECT id, container_id, COALESCE(quantity, 0), min_quantity, is_deleted
+        FROM item
+        WHERE id = ANY($1) AND family_id = $2
+        ORDER BY id
+        FOR UPDATE`,
+        pq.Array(req.ItemIDs), familyID,
+    )
     if err != nil {
-        return fmt.Errorf("error checking restore result: %v", err)
+        return nil, fmt.Errorf("error loading items: %w", err)
     }
 
-    if rowsAffected == 0 {
-        return fmt.Errorf("item not found or not deleted")
+    states := make(map[int]itemState)
+    for rows.Next() {
+        var id int
+        var state itemState
+        if err := rows.Scan(&id, &state.containerID, &state.quantity, &state.minQuantity, &state.deleted); err != nil {
+            rows.Close()
+            return nil, fmt.Errorf("error scanning item: %w", err)
+        }
+        states[id] = state
+    }
+    rows.Close()
+    if err := rows.Err(); err != nil {
+        return nil, fmt.Errorf("error loading items: %w", err)
     }
 
-    return nil
-}
\ No newline at end of file
+    now := time.Now().UTC()
+    results := make([]BulkItemResult, 0, len(req.ItemIDs))
+
+    for _, id := range req.ItemIDs {
+        result := BulkItemResult{ItemID: id}
+
+        state, ok := states[id]
+        switch {
+        case !ok:
+            result.Error = "item not found"
+        case req.Operation == BulkRestore && !state.deleted:
+            result.Error = "item is not deleted"
+        case req.Operation != BulkRestore && state.deleted:
+            result.Error = "item is deleted"
+        }
+
+        if result.Error == "" {
+            var newQuantity int
+            newQuantity, result.Error, err = r.applyBulk(ctx, tx, familyID, profileID, id, state.containerID, state.quantity, req, now)
+            if err != nil {
+                return nil, err
+            }
+            result.droppedBelowMinimum = result.Error == "" && DroppedBelowMinimum(state.quantity, newQuantity, state.minQuantity)
+        }
+
+        result.Success = result.Error == ""
+        results = append(results, result)
+    }
+
+    if err := tx.Commit(); err != nil {
+        return nil, fmt.Errorf("error committing transaction: %w", err)
+    }
+
+    return results, nil
+}
+
+// applyBulk runs the operation on one item and returns its quantity
+// afterwards. A non-empty message is a failure for that item alone; an error
+// aborts the whole batch.
+func (r *Repository) applyBulk(ctx context.Context, tx *sql.Tx, familyID int, profileID int, id int, containerID *int, quantity int, req *BulkRequest, now time.Time) (int, string, error) {
+    item := &entities.Item{
+        ID:          id,
+        FamilyID:    familyID,
+        ProfileID:   profileID,
+        ContainerID: containerID,
+        Quantity:    quantity,
+    }
+
+    switch req.Operation {
+    case BulkMove:
+        if entities.SameContainer(containerID, req.ContainerID) {
+            return quantity, "", nil
+        }
+        if _, err := tx.ExecContext(ctx,
+            "UPDATE item SET container_id = $2, profile_id = $3, updated_at = $4 WHERE id = $1",
+            id, req.ContainerID, profileID, now,
+        ); err != nil {
+            return 0, "", fmt.Errorf("error moving item: %w", err)
+        }
+        item.ContainerID = req.ContainerID
+        return item.Quantity, "", r.recordEvents(ctx, tx, item, containerID, quantity)
+
+    case BulkSetQuantity, BulkAdjustQuantity:
+        if req.Operation == BulkSetQuantity {
+            item.Quantity = *req.Quantity
+        } else {
+            item.Quantity = quantity + *req.Delta
+        }
+        if item.Quantity < 0 {
+            return quantity, "quantity cannot go below zero", nil
+        }
+        if _, err := tx.ExecContext(ctx,
+            "UPDATE item SET quantity = $2, profile_id = $3, updated_at = $4 WHERE id = $1",
+            id, item.Quantity, profileID, now,
+        ); err != nil {
+            return 0, "", fmt.Errorf("error updating quantity: %w", err)
+        }
+        return item.Quantity, "", r.recordEvents(ctx, tx, item, containerID, quantity)
+
+    case BulkAddTags:
+        if _, err := tx.ExecContext(ctx, `
+            INSERT INTO item_tag (item_id, tag_id)
+            SELECT DISTINCT $1::int, unnest($2::int[])
+            `+reviveTagLink,
+            id, pq.Array(req.TagIDs),
+        ); err != nil {
+            return 0, "", fmt.Errorf("error adding tags: %w", err)
+        }
+
+    case BulkRemoveTags:
+        if _, err := tx.ExecContext(ctx,
+            "DELETE FROM item_tag WHERE item_id = $1 AND tag_id = ANY($2) AND is_deleted = false",
+            id, pq.Array(req.TagIDs),
+        ); err != nil {
+            return 0, "", fmt.Errorf("error removing tags: %w", err)
+        }
+
+    case BulkDelete:
+        // Each item is its own deletion so it can be restored on its own.
+        batch, err := deletion.Begin(ctx, tx, familyID, profileID)
+        if err != nil {
+            return 0, "", err
+        }
+        if _, err := batch.Items(ctx, []int{id}); err != nil {
+            return 0, "", err
+        }
+
+    case BulkRestore:
+        // An item already brought back with an earlier one in the request
+        // has nothing left to restore, which is still a success.
+        if _, err := deletion.Restore(ctx, tx, deletion.Item, id, familyID); err != nil {
+            return 0, "", err
+        }
+    }
+
+    return quantity, "", nil
+}
+
+// GetParentProfileIDs returns the family's parent profiles, who are told
+// when stock runs low.
+func (r *Repository) GetParentProfileIDs(ctx context.Context, familyID int) ([]int, error) {
+    rows, err := r.db.QueryContext(ctx, `
+        SELECT id FROM profile
+        WHERE family_id = $1 AND role = $2 AND is_deleted = false`,
+        familyID, models.RoleParent,
+    )
+    if err != nil {
+        return nil, fmt.Errorf("error querying parent profiles: %w", err)
+    }
+    defer rows.Close()
+
+    var ids []int
+    for rows.Next() {
+        var id int
+        if err := rows.Scan(&id); err != nil {
+            return nil, fmt.Errorf("error scanning profile: %w", err)
+        }
+        ids = append(ids, id)
+    }
+
+    return ids, rows.Err()
+}
+
+func (r *Repository) IsFamilyContainer(ctx context.Context, id int, familyID int) (bool, error) {
+    query := `
+        SELECT EXISTS (
+            SELECT 1 FROM container
+            WHERE id = $1 AND family_id = $2 AND is_deleted = false
+        )`
+
+    var exists bool
+    if err := r.db.QueryRowContext(ctx, query, id, familyID).Scan(&exists); err != nil {
+        return false, fmt.Errorf("error checking container: %w", err)
+    }
+    return exists, nil
+}
+
+func (r *Repository) AreFamilyTags(ctx context.Context, ids []int, familyID int) (bool, error) {
+    query := `
+        SELECT NOT EXISTS (
+            SELECT 1 FROM unnest($1::int[]) AS requested(id)
+            WHERE NOT EXISTS (
+                SELECT 1 FROM tag
+                WHERE tag.id = requested.id AND tag.family_id = $2 AND tag.is_deleted = false
+            )
+        )`
+
+    var owned bool
+    if err := r.db.QueryRowContext(ctx, query, pq.Array(ids), familyID).Scan(&owned); err != nil {
+        return false, fmt.Errorf("error checking tags: %w", err)
+    }
+    return owned, nil
+}
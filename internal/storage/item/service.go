@@ -1,29 +1,55 @@
 package item
 
 import (
+	"context"
 	"fmt"
+	"strconv"
 	"time"
 
+	"github.com/chrisabs/cadence/internal/notification"
+	"github.com/chrisabs/cadence/internal/platform/logging"
+	"github.com/chrisabs/cadence/internal/platform/tracing"
+	"github.com/chrisabs/cadence/internal/platform/validate"
 	"github.com/chrisabs/cadence/internal/storage/entities"
 )
 
+const (
+    defaultExpiryWindowDays = 14
+    maxExpiryWindowDays     = 365
+)
+
 type Service struct {
-    repo *Repository
+    repo                *Repository
+    notificationService interface {
+        Notify(ctx context.Context, n *notification.Notification) error
+    }
 }
 
 func NewService(repo *Repository) *Service {
     return &Service{repo: repo}
 }
 
-func (s *Service) CreateItem(familyID int, profileID int, req *CreateItemRequest) (*entities.Item, error) {
-    if req.Name == "" {
-        return nil, fmt.Errorf("item name is required")
+func (s *Service) SetNotificationService(notificationService interface {
+    Notify(ctx context.Context, n *notification.Notification) error
+}) {
+    s.notificationService = notificationService
+}
+
+func (s *Service) CreateItem(ctx context.Context, familyID int, profileID int, req *CreateItemRequest) (*entities.Item, error) {
+    ctx, span := tracing.Start(ctx, "item.Service.CreateItem")
+    defer span.End()
+
+    if err := s.validateRequest(ctx, familyID, req.ContainerID, nil, req); err != nil {
+        return nil, err
     }
 
     item := &entities.Item{
         Name:        req.Name,
         Description: req.Description,
         Quantity:    req.Quantity,
+        Unit:        req.Unit,
+        MinQuantity: req.MinQuantity,
+        ExpiryDate:  req.ExpiryDate,
         ContainerID: req.ContainerID,
         ProfileID:   profileID,  
         FamilyID:    familyID,
@@ -33,28 +59,49 @@ func (s *Service) CreateItem(familyID int, profileID int, req *CreateItemRequest
         UpdatedAt:   time.Now().UTC(),
     }
 
-    createdItem, err := s.repo.Create(item, req.TagNames)
+    createdItem, err := s.repo.Create(ctx, item, req.TagNames)
     if err != nil {
-        return nil, fmt.Errorf("failed to create item: %v", err)
+        return nil, fmt.Errorf("failed to create item: %w", err)
     }
 
     return createdItem, nil
 }
 
-func (s *Service) GetItemByID(id int, familyID int) (*entities.Item, error) {
-    return s.repo.GetByID(id, familyID)
+func (s *Service) GetItemByID(ctx context.Context, id int, familyID int) (*entities.Item, error) {
+    ctx, span := tracing.Start(ctx, "item.Service.GetItemByID")
+    defer span.End()
+
+    return s.repo.GetByID(ctx, id, familyID)
 }
 
-func (s *Service) GetItemsByFamilyID(familyID int) ([]*entities.Item, error) {
-    return s.repo.GetByFamilyID(familyID)
+func (s *Service) GetItemsByFamilyID(ctx context.Context, familyID int) ([]*entities.Item, error) {
+    ctx, span := tracing.Start(ctx, "item.Service.GetItemsByFamilyID")
+    defer span.End()
+
+    return s.repo.GetByFamilyID(ctx, familyID)
 }
 
-func (s *Service) UpdateItem(id int, familyID int, profileID int, req *UpdateItemRequest) (*entities.Item, error) {
+func (s *Service) UpdateItem(ctx context.Context, id int, familyID int, profileID int, req *UpdateItemRequest) (*entities.Item, error) {
+    ctx, span := tracing.Start(ctx, "item.Service.UpdateItem")
+    defer span.End()
+
+    if err := s.validateRequest(ctx, familyID, req.ContainerID, req.Tags, req); err != nil {
+        return nil, err
+    }
+
+    previous, err := s.repo.GetByID(ctx, id, familyID)
+    if err != nil {
+        return nil, err
+    }
+
     item := &entities.Item{
         ID:          id,
         Name:        req.Name,
         Description: req.Description,
         Quantity:    req.Quantity,
+        Unit:        req.Unit,
+        MinQuantity: req.MinQuantity,
+        ExpiryDate:  req.ExpiryDate,
         ContainerID: req.ContainerID,
         ProfileID:   profileID,  
         FamilyID:    familyID,
@@ -71,34 +118,232 @@ func (s *Service) UpdateItem(id int, familyID int, profileID int, req *UpdateIte
         }
     }
 
-    if err := s.repo.Update(item); err != nil {
-        return nil, fmt.Errorf("failed to update item: %v", err)
+    if err := s.repo.Update(ctx, item); err != nil {
+        return nil, fmt.Errorf("failed to update item: %w", err)
+    }
+
+    if DroppedBelowMinimum(previous.Quantity, item.Quantity, item.MinQuantity) {
+        s.notifyLowStock(ctx, item)
+    }
+
+    return s.repo.GetByID(ctx, id, familyID)
+}
+
+// GetStockAlerts lists items below their minimum quantity and items expiring
+// within the next days days.
+func (s *Service) GetStockAlerts(ctx context.Context, familyID int, days int) (*StockAlerts, error) {
+    ctx, span := tracing.Start(ctx, "item.Service.GetStockAlerts")
+    defer span.End()
+
+    if days <= 0 {
+        days = defaultExpiryWindowDays
+    }
+    if days > maxExpiryWindowDays {
+        days = maxExpiryWindowDays
+    }
+
+    lowStock, err := s.repo.GetLowStock(ctx, familyID)
+    if err != nil {
+        return nil, err
+    }
+
+    expiring, err := s.repo.GetExpiring(ctx, familyID, time.Now().UTC().AddDate(0, 0, days))
+    if err != nil {
+        return nil, err
+    }
+
+    alerts := &StockAlerts{
+        LowStock: make([]*entities.Item, 0, len(lowStock)),
+        Expiring: make([]*entities.Item, 0, len(expiring)),
+    }
+    alerts.LowStock = append(alerts.LowStock, lowStock...)
+    alerts.Expiring = append(alerts.Expiring, expiring...)
+
+    return alerts, nil
+}
+
+// notifyLowStock tells every parent in the family that the item has run low.
+// Failures are logged rather than returned so the update itself still succeeds.
+func (s *Service) notifyLowStock(ctx context.Context, item *entities.Item) {
+    if s.notificationService == nil {
+        return
+    }
+
+    parentIDs, err := s.repo.GetParentProfileIDs(ctx, item.FamilyID)
+    if err != nil {
+        logging.FromContext(ctx).Error("failed to load parents for low stock notification", "error", err)
+        return
+    }
+
+    quantity := strconv.Itoa(item.Quantity)
+    if item.Unit != "" {
+        quantity += " " + item.Unit
     }
 
-    return s.repo.GetByID(id, familyID)
+    sourceID := item.ID
+    for _, profileID := range parentIDs {
+        err := s.notificationService.Notify(ctx, &notification.Notification{
+            ProfileID: profileID,
+            FamilyID:  item.FamilyID,
+            Title:     "Running low",
+            Message:   fmt.Sprintf("%s is down to %s, below the minimum of %d.", item.Name, quantity, *item.MinQuantity),
+            Type:      notification.TypeLowStock,
+            SourceID:  &sourceID,
+        })
+        if err != nil {
+            logging.FromContext(ctx).Error("failed to send low stock notification", "error", err, "profileId", profileID)
+        }
+    }
+}
+
+func (s *Service) GetItemHistory(ctx context.Context, id int, familyID int) ([]entities.ItemEvent, error) {
+    ctx, span := tracing.Start(ctx, "item.Service.GetItemHistory")
+    defer span.End()
+
+    return s.repo.GetHistory(ctx, id, familyID)
 }
 
-func (s *Service) AddItemImage(itemID int, familyID int, url string) error {
+func (s *Service) AddItemImage(ctx context.Context, itemID int, familyID int, url string) error {
+    ctx, span := tracing.Start(ctx, "item.Service.AddItemImage")
+    defer span.End()
+
     displayOrder := 0
-    item, err := s.repo.GetByID(itemID, familyID)
+    item, err := s.repo.GetByID(ctx, itemID, familyID)
     if err == nil {
         displayOrder = len(item.Images)
     }
     
-    return s.repo.AddItemImage(itemID, familyID, url, displayOrder)
+    return s.repo.AddItemImage(ctx, itemID, familyID, url, displayOrder)
 }
 
-func (s *Service) DeleteItemImage(itemID int, familyID int, url string) error {
-    return s.repo.DeleteItemImage(itemID, familyID, url)
+func (s *Service) DeleteItemImage(ctx context.Context, itemID int, familyID int, url string) error {
+    ctx, span := tracing.Start(ctx, "item.Service.DeleteItemImage")
+    defer span.End()
+
+    return s.repo.DeleteItemImage(ctx, itemID, familyID, url)
 }
 
-func (s *Service) DeleteItem(id int, familyID int, deletedBy int) error {
-    return s.repo.Delete(id, familyID, deletedBy)
+func (s *Service) DeleteItem(ctx context.Context, id int, familyID int, deletedBy int) error {
+    ctx, span := tracing.Start(ctx, "item.Service.DeleteItem")
+    defer span.End()
+
+    return s.repo.Delete(ctx, id, familyID, deletedBy)
 }
 
-func (s *Service) RestoreItem(id int, familyID int) error {
-    if err := s.repo.RestoreDeleted(id, familyID); err != nil {
-        return fmt.Errorf("failed to restore item: %v", err)
+func (s *Service) RestoreItem(ctx context.Context, id int, familyID int) error {
+    ctx, span := tracing.Start(ctx, "item.Service.RestoreItem")
+    defer span.End()
+
+    if err := s.repo.RestoreDeleted(ctx, id, familyID); err != nil {
+        return fmt.Errorf("failed to restore item: %w", err)
     }
     return nil
-}
\ No newline at end of file
+}
+
+// BulkUpdate applies one operation to many items and reports the outcome for
+// each. Repeated IDs are only processed once.
+func (s *Service) BulkUpdate(ctx context.Context, familyID int, profileID int, req *BulkRequest) (*BulkResponse, error) {
+    ctx, span := tracing.Start(ctx, "item.Service.BulkUpdate")
+    defer span.End()
+
+    if err := s.validateBulkRequest(ctx, familyID, req); err != nil {
+        return nil, err
+    }
+
+    seen := make(map[int]bool, len(req.ItemIDs))
+    itemIDs := make([]int, 0, len(req.ItemIDs))
+    for _, id := range req.ItemIDs {
+        if !seen[id] {
+            seen[id] = true
+            itemIDs = append(itemIDs, id)
+        }
+    }
+    req.ItemIDs = itemIDs
+
+    results, err := s.repo.Bulk(ctx, familyID, profileID, req)
+    if err != nil {
+        return nil, fmt.Errorf("failed to apply bulk operation: %w", err)
+    }
+
+    response := &BulkResponse{Operation: req.Operation, Results: results}
+    for _, result := range results {
+        if result.Success {
+            response.Succeeded++
+        } else {
+            response.Failed++
+        }
+
+        if result.droppedBelowMinimum {
+            if item, err := s.repo.GetByID(ctx, result.ItemID, familyID); err == nil {
+                s.notifyLowStock(ctx, item)
+            }
+        }
+    }
+
+    return response, nil
+}
+
+func (s *Service) validateBulkRequest(ctx context.Context, familyID int, req *BulkRequest) error {
+    errs := validate.Errors{}
+    validate.Collect(req, errs)
+
+    switch req.Operation {
+    case BulkMove:
+        if req.ContainerID != nil {
+            owned, err := s.repo.IsFamilyContainer(ctx, *req.ContainerID, familyID)
+            if err != nil {
+                return err
+            }
+            if !owned {
+                errs.Add("containerId", "must be a container in this family")
+            }
+        }
+    case BulkAddTags, BulkRemoveTags:
+        if len(req.TagIDs) == 0 {
+            errs.Add("tagIds", "is required for this operation")
+        } else if owned, err := s.repo.AreFamilyTags(ctx, req.TagIDs, familyID); err != nil {
+            return err
+        } else if !owned {
+            errs.Add("tagIds", "must only contain tags in this family")
+        }
+    case BulkSetQuantity:
+        if req.Quantity == nil {
+            errs.Add("quantity", "is required for this operation")
+        }
+    case BulkAdjustQuantity:
+        if req.Delta == nil {
+            errs.Add("delta", "is required for this operation")
+        }
+    }
+
+    return errs.Err()
+}
+
+// validateRequest runs the request's field rules and checks that the target
+// container and any referenced tags belong to the family.
+func (s *Service) validateRequest(ctx context.Context, familyID int, containerID *int, tagIDs []int, req interface{}) error {
+    errs := validate.Errors{}
+    validate.Collect(req, errs)
+
+    if containerID != nil {
+        owned, err := s.repo.IsFamilyContainer(ctx, *containerID, familyID)
+        if err != nil {
+            return err
+        }
+        if !owned {
+            errs.Add("containerId", "must be a container in this family")
+        }
+    }
+
+    if len(tagIDs) > 0 {
+        owned, err := s.repo.AreFamilyTags(ctx, tagIDs, familyID)
+        if err != nil {
+            return err
+        }
+        if !owned {
+            errs.Add("tags", "must only contain tags in this family")
+        }
+    }
+
+    return errs.Err()
+}
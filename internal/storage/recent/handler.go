@@ -1,11 +1,12 @@
 package recent
 
 import (
-	"encoding/json"
 	"net/http"
 
+	"github.com/chrisabs/cadence/internal/apperror"
 	"github.com/chrisabs/cadence/internal/middleware"
 	"github.com/chrisabs/cadence/internal/models"
+	"github.com/chrisabs/cadence/internal/platform/respond"
 	"github.com/gorilla/mux"
 )
 
@@ -22,27 +23,21 @@ func NewHandler(service *Service, authMiddleware *middleware.AuthMiddleware) *Ha
 }
 
 func (h *Handler) RegisterRoutes(router *mux.Router) {
-    router.HandleFunc("/recent", h.authMiddleware.ProfileAuthHandler(h.handleGetRecent)).Methods("GET")
+    router.HandleFunc("/recent", h.authMiddleware.ModuleMiddleware(models.ModuleStorage, models.PermissionRead)(h.handleGetRecent)).Methods("GET")
 }
 
 func (h *Handler) handleGetRecent(w http.ResponseWriter, r *http.Request) {
-    profileCtx := r.Context().Value("profile").(*models.ProfileContext)
+    profileCtx, ok := middleware.ProfileFrom(r.Context())
+    if !ok {
+        respond.Error(w, r, apperror.Unauthorized("profile authentication required"))
+        return
+    }
     
-    response, err := h.service.GetRecentEntities(profileCtx.FamilyID)
+    response, err := h.service.GetRecentEntities(r.Context(), profileCtx.FamilyID)
     if err != nil {
-        writeError(w, http.StatusInternalServerError, err.Error())
+        respond.Error(w, r, err)
         return
     }
 
-    writeJSON(w, http.StatusOK, response)
+    respond.JSON(w, http.StatusOK, response)
 }
-
-func writeJSON(w http.ResponseWriter, status int, v interface{}) {
-    w.Header().Set("Content-Type", "application/json")
-    w.WriteHeader(status)
-    json.NewEncoder(w).Encode(v)
-}
-
-func writeError(w http.ResponseWriter, status int, message string) {
-    writeJSON(w, status, map[string]string{"error": message})
-}
\ No newline at end of file
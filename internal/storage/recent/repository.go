@@ -1,6 +1,7 @@
 package recent
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 )
@@ -13,10 +14,10 @@ func NewRepository(db *sql.DB) *Repository {
     return &Repository{db: db}
 }
 
-func (r *Repository) GetRecentEntities(familyID int, limit int) (*Response, error) {
-    tx, err := r.db.Begin()
+func (r *Repository) GetRecentEntities(ctx context.Context, familyID int, limit int) (*Response, error) {
+    tx, err := r.db.BeginTx(ctx, nil)
     if err != nil {
-        return nil, fmt.Errorf("failed to begin transaction: %v", err)
+        return nil, fmt.Errorf("failed to begin transaction: %w", err)
     }
     defer tx.Rollback()
 
@@ -32,8 +33,8 @@ func (r *Repository) GetRecentEntities(familyID int, limit int) (*Response, erro
         FROM container 
         WHERE family_id = $1 AND is_deleted = false
     `
-    if err := tx.QueryRow(containerCountQuery, familyID).Scan(&response.Containers.Total); err != nil {
-        return nil, fmt.Errorf("failed to get container count: %v", err)
+    if err := tx.QueryRowContext(ctx, containerCountQuery, familyID).Scan(&response.Containers.Total); err != nil {
+        return nil, fmt.Errorf("failed to get container count: %w", err)
     }
 
     containerQuery := `
@@ -43,16 +44,16 @@ func (r *Repository) GetRecentEntities(familyID int, limit int) (*Response, erro
         ORDER BY created_at DESC 
         LIMIT $2
     `
-    containerRows, err := tx.Query(containerQuery, familyID, limit)
+    containerRows, err := tx.QueryContext(ctx, containerQuery, familyID, limit)
     if err != nil {
-        return nil, fmt.Errorf("failed to fetch recent containers: %v", err)
+        return nil, fmt.Errorf("failed to fetch recent containers: %w", err)
     }
     defer containerRows.Close()
 
     for containerRows.Next() {
         var preview EntityPreview
         if err := containerRows.Scan(&preview.ID, &preview.Name, &preview.CreatedAt); err != nil {
-            return nil, fmt.Errorf("failed to scan container row: %v", err)
+            return nil, fmt.Errorf("failed to scan container row: %w", err)
         }
         response.Containers.Recent = append(response.Containers.Recent, preview)
     }
@@ -62,8 +63,8 @@ func (r *Repository) GetRecentEntities(familyID int, limit int) (*Response, erro
         FROM item i
         WHERE i.family_id = $1 AND i.is_deleted = false
     `
-    if err := tx.QueryRow(itemCountQuery, familyID).Scan(&response.Items.Total); err != nil {
-        return nil, fmt.Errorf("failed to get item count: %v", err)
+    if err := tx.QueryRowContext(ctx, itemCountQuery, familyID).Scan(&response.Items.Total); err != nil {
+        return nil, fmt.Errorf("failed to get item count: %w", err)
     }
 
     itemQuery := `
@@ -74,16 +75,16 @@ func (r *Repository) GetRecentEntities(familyID int, limit int) (*Response, erro
         LIMIT $2
     `
 
-    itemRows, err := tx.Query(itemQuery, familyID, limit)
+    itemRows, err := tx.QueryContext(ctx, itemQuery, familyID, limit)
     if err != nil {
-        return nil, fmt.Errorf("failed to fetch recent items: %v", err)
+        return nil, fmt.Errorf("failed to fetch recent items: %w", err)
     }
     defer itemRows.Close()
 
     for itemRows.Next() {
         var preview EntityPreview
         if err := itemRows.Scan(&preview.ID, &preview.Name, &preview.CreatedAt); err != nil {
-            return nil, fmt.Errorf("failed to scan item row: %v", err)
+            return nil, fmt.Errorf("failed to scan item row: %w", err)
         }
         response.Items.Recent = append(response.Items.Recent, preview)
     }
@@ -93,8 +94,8 @@ func (r *Repository) GetRecentEntities(familyID int, limit int) (*Response, erro
         FROM tag t
         WHERE t.family_id = $1 AND t.is_deleted = false
     `
-    if err := tx.QueryRow(tagCountQuery, familyID).Scan(&response.Tags.Total); err != nil {
-        return nil, fmt.Errorf("failed to get tag count: %v", err)
+    if err := tx.QueryRowContext(ctx, tagCountQuery, familyID).Scan(&response.Tags.Total); err != nil {
+        return nil, fmt.Errorf("failed to get tag count: %w", err)
     }
 
     tagQuery := `
@@ -104,16 +105,16 @@ func (r *Repository) GetRecentEntities(familyID int, limit int) (*Response, erro
         ORDER BY t.created_at DESC 
         LIMIT $2
     `
-    tagRows, err := tx.Query(tagQuery, familyID, limit)
+    tagRows, err := tx.QueryContext(ctx, tagQuery, familyID, limit)
     if err != nil {
-        return nil, fmt.Errorf("failed to fetch recent tags: %v", err)
+        return nil, fmt.Errorf("failed to fetch recent tags: %w", err)
     }
     defer tagRows.Close()
 
     for tagRows.Next() {
         var preview EntityPreview
         if err := tagRows.Scan(&preview.ID, &preview.Name, &preview.CreatedAt); err != nil {
-            return nil, fmt.Errorf("failed to scan tag row: %v", err)
+            return nil, fmt.Errorf("failed to scan tag row: %w", err)
         }
         response.Tags.Recent = append(response.Tags.Recent, preview)
     }
@@ -123,8 +124,8 @@ func (r *Repository) GetRecentEntities(familyID int, limit int) (*Response, erro
         FROM workspace 
         WHERE family_id = $1 AND is_deleted = false
     `
-    if err := tx.QueryRow(workspaceCountQuery, familyID).Scan(&response.Workspaces.Total); err != nil {
-        return nil, fmt.Errorf("failed to get workspace count: %v", err)
+    if err := tx.QueryRowContext(ctx, workspaceCountQuery, familyID).Scan(&response.Workspaces.Total); err != nil {
+        return nil, fmt.Errorf("failed to get workspace count: %w", err)
     }
 
     workspaceQuery := `
@@ -134,22 +135,22 @@ func (r *Repository) GetRecentEntities(familyID int, limit int) (*Response, erro
         ORDER BY created_at DESC 
         LIMIT $2
     `
-    workspaceRows, err := tx.Query(workspaceQuery, familyID, limit)
+    workspaceRows, err := tx.QueryContext(ctx, workspaceQuery, familyID, limit)
     if err != nil {
-        return nil, fmt.Errorf("failed to fetch recent workspaces: %v", err)
+        return nil, fmt.Errorf("failed to fetch recent workspaces: %w", err)
     }
     defer workspaceRows.Close()
 
     for workspaceRows.Next() {
         var preview EntityPreview
         if err := workspaceRows.Scan(&preview.ID, &preview.Name, &preview.CreatedAt); err != nil {
-            return nil, fmt.Errorf("failed to scan workspace row: %v", err)
+            return nil, fmt.Errorf("failed to scan workspace row: %w", err)
         }
         response.Workspaces.Recent = append(response.Workspaces.Recent, preview)
     }
 
     if err := tx.Commit(); err != nil {
-        return nil, fmt.Errorf("failed to commit transaction: %v", err)
+        return nil, fmt.Errorf("failed to commit transaction: %w", err)
     }
 
     return response, nil
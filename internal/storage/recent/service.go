@@ -1,5 +1,11 @@
 package recent
 
+import (
+	"context"
+
+	"github.com/chrisabs/cadence/internal/platform/tracing"
+)
+
 type Service struct {
     repo *Repository
 }
@@ -8,7 +14,10 @@ func NewService(repo *Repository) *Service {
     return &Service{repo: repo}
 }
 
-func (s *Service) GetRecentEntities(familyID int) (*Response, error) {
+func (s *Service) GetRecentEntities(ctx context.Context, familyID int) (*Response, error) {
+    ctx, span := tracing.Start(ctx, "recent.Service.GetRecentEntities")
+    defer span.End()
+
     const defaultLimit = 10
-    return s.repo.GetRecentEntities(familyID, defaultLimit)
+    return s.repo.GetRecentEntities(ctx, familyID, defaultLimit)
 }
\ No newline at end of file
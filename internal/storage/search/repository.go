@@ -1,10 +1,12 @@
 package search
 
 import (
+	"context"
 	"database/sql"
 	"encoding/json"
 	"fmt"
 
+	"github.com/chrisabs/cadence/internal/apperror"
 	"github.com/chrisabs/cadence/internal/storage/entities"
 )
 
@@ -16,7 +18,7 @@ func NewRepository(db *sql.DB) *Repository {
     return &Repository{db: db}
 }
 
-func (r *Repository) Search(query string, familyID int) (*SearchResponse, error) {
+func (r *Repository) Search(ctx context.Context, query string, familyID int) (*SearchResponse, error) {
     sqlQuery := `
     WITH workspace_matches AS (
         SELECT 
@@ -166,9 +168,9 @@ func (r *Repository) Search(query string, familyID int) (*SearchResponse, error)
     ) combined_results
     ORDER BY rank DESC;`
 
-    rows, err := r.db.Query(sqlQuery, query, familyID)
+    rows, err := r.db.QueryContext(ctx, sqlQuery, query, familyID)
     if err != nil {
-        return nil, fmt.Errorf("error executing search: %v", err)
+        return nil, fmt.Errorf("error executing search: %w", err)
     }
     defer rows.Close()
 
@@ -194,7 +196,7 @@ func (r *Repository) Search(query string, familyID int) (*SearchResponse, error)
             &colour,
         )
         if err != nil {
-            return nil, fmt.Errorf("error scanning search result: %v", err)
+            return nil, fmt.Errorf("error scanning search result: %w", err)
         }
     
         if containerName.Valid {
@@ -224,7 +226,7 @@ func (r *Repository) Search(query string, familyID int) (*SearchResponse, error)
     return response, nil
 }
 
-func (r *Repository) SearchWorkspaces(query string, familyID int) (WorkspaceSearchResults, error) {
+func (r *Repository) SearchWorkspaces(ctx context.Context, query string, familyID int) (WorkspaceSearchResults, error) {
     quickCheckQuery := `
         SELECT EXISTS (
             SELECT 1
@@ -242,9 +244,9 @@ func (r *Repository) SearchWorkspaces(query string, familyID int) (WorkspaceSear
         );`
 
     var hasResults bool
-    err := r.db.QueryRow(quickCheckQuery, query, familyID).Scan(&hasResults)
+    err := r.db.QueryRowContext(ctx, quickCheckQuery, query, familyID).Scan(&hasResults)
     if err != nil {
-        return nil, fmt.Errorf("error checking for results: %v", err)
+        return nil, fmt.Errorf("error checking for results: %w", err)
     }
 
     if !hasResults {
@@ -321,9 +323,9 @@ func (r *Repository) SearchWorkspaces(query string, familyID int) (WorkspaceSear
         ORDER BY rw.rank DESC
         LIMIT 50;`
 
-    rows, err := r.db.Query(sqlQuery, query, familyID)
+    rows, err := r.db.QueryContext(ctx, sqlQuery, query, familyID)
     if err != nil {
-        return nil, fmt.Errorf("error executing workspace search: %v", err)
+        return nil, fmt.Errorf("error executing workspace search: %w", err)
     }
     defer rows.Close()
 
@@ -343,11 +345,11 @@ func (r *Repository) SearchWorkspaces(query string, familyID int) (WorkspaceSear
             &containersJSON,
         )
         if err != nil {
-            return nil, fmt.Errorf("error scanning workspace search result: %v", err)
+            return nil, fmt.Errorf("error scanning workspace search result: %w", err)
         }
 
         if err := json.Unmarshal(containersJSON, &result.Containers); err != nil {
-            return nil, fmt.Errorf("error unmarshaling containers: %v", err)
+            return nil, fmt.Errorf("error unmarshaling containers: %w", err)
         }
 
         results = append(results, result)
@@ -356,7 +358,7 @@ func (r *Repository) SearchWorkspaces(query string, familyID int) (WorkspaceSear
     return results, nil
 }
 
-func (r *Repository) SearchContainers(query string, familyID int) (ContainerSearchResults, error) {
+func (r *Repository) SearchContainers(ctx context.Context, query string, familyID int) (ContainerSearchResults, error) {
     quickCheckQuery := `
         SELECT EXISTS (
             SELECT 1
@@ -375,9 +377,9 @@ func (r *Repository) SearchContainers(query string, familyID int) (ContainerSear
         );`
 
     var hasResults bool
-    err := r.db.QueryRow(quickCheckQuery, query, familyID).Scan(&hasResults)
+    err := r.db.QueryRowContext(ctx, quickCheckQuery, query, familyID).Scan(&hasResults)
     if err != nil {
-        return nil, fmt.Errorf("error checking for results: %v", err)
+        return nil, fmt.Errorf("error checking for results: %w", err)
     }
 
     if !hasResults {
@@ -459,9 +461,9 @@ func (r *Repository) SearchContainers(query string, familyID int) (ContainerSear
         ORDER BY rc.rank DESC
         LIMIT 50;`
 
-    rows, err := r.db.Query(sqlQuery, query, familyID)
+    rows, err := r.db.QueryContext(ctx, sqlQuery, query, familyID)
     if err != nil {
-        return nil, fmt.Errorf("error executing container search: %v", err)
+        return nil, fmt.Errorf("error executing container search: %w", err)
     }
     defer rows.Close()
 
@@ -486,12 +488,12 @@ func (r *Repository) SearchContainers(query string, familyID int) (ContainerSear
             &workspaceJSON,
         )
         if err != nil {
-            return nil, fmt.Errorf("error scanning container search result: %v", err)
+            return nil, fmt.Errorf("error scanning container search result: %w", err)
         }
 
         if len(workspaceJSON) > 0 {
             if err := json.Unmarshal(workspaceJSON, &result.Workspace); err != nil {
-                return nil, fmt.Errorf("error unmarshaling workspace: %v", err)
+                return nil, fmt.Errorf("error unmarshaling workspace: %w", err)
             }
         }
 
@@ -501,7 +503,7 @@ func (r *Repository) SearchContainers(query string, familyID int) (ContainerSear
     return results, nil
 }
 
-func (r *Repository) SearchItems(query string, familyID int) (ItemSearchResults, error) {
+func (r *Repository) SearchItems(ctx context.Context, query string, familyID int) (ItemSearchResults, error) {
     quickCheckQuery := `
         SELECT EXISTS (
             SELECT 1
@@ -523,9 +525,9 @@ func (r *Repository) SearchItems(query string, familyID int) (ItemSearchResults,
         );`
 
     var hasResults bool
-    err := r.db.QueryRow(quickCheckQuery, query, familyID).Scan(&hasResults)
+    err := r.db.QueryRowContext(ctx, quickCheckQuery, query, familyID).Scan(&hasResults)
     if err != nil {
-        return nil, fmt.Errorf("error checking for results: %v", err)
+        return nil, fmt.Errorf("error checking for results: %w", err)
     }
 
     if !hasResults {
@@ -634,9 +636,9 @@ func (r *Repository) SearchItems(query string, familyID int) (ItemSearchResults,
         ORDER BY i.rank DESC
         LIMIT 50;`
 
-    rows, err := r.db.Query(sqlQuery, query, familyID)
+    rows, err := r.db.QueryContext(ctx, sqlQuery, query, familyID)
     if err != nil {
-        return nil, fmt.Errorf("error executing item search: %v", err)
+        return nil, fmt.Errorf("error executing item search: %w", err)
     }
     defer rows.Close()
 
@@ -659,21 +661,21 @@ func (r *Repository) SearchItems(query string, familyID int) (ItemSearchResults,
             &imagesJSON,
         )
         if err != nil {
-            return nil, fmt.Errorf("error scanning item search result: %v", err)
+            return nil, fmt.Errorf("error scanning item search result: %w", err)
         }
 
         if len(containerJSON) > 0 {
             if err := json.Unmarshal(containerJSON, &result.Container); err != nil {
-                return nil, fmt.Errorf("error unmarshaling container: %v", err)
+                return nil, fmt.Errorf("error unmarshaling container: %w", err)
             }
         }
 
         if err := json.Unmarshal(tagsJSON, &result.Tags); err != nil {
-            return nil, fmt.Errorf("error unmarshaling tags: %v", err)
+            return nil, fmt.Errorf("error unmarshaling tags: %w", err)
         }
 
         if err := json.Unmarshal(imagesJSON, &result.Images); err != nil {
-            return nil, fmt.Errorf("error unmarshaling images: %v", err)
+            return nil, fmt.Errorf("error unmarshaling images: %w", err)
         }
 
         results = append(results, result)
@@ -682,7 +684,7 @@ func (r *Repository) SearchItems(query string, familyID int) (ItemSearchResults,
     return results, nil
 }
 
-func (r *Repository) SearchTags(query string, familyID int) (TagSearchResults, error) {
+func (r *Repository) SearchTags(ctx context.Context, query string, familyID int) (TagSearchResults, error) {
     quickCheckQuery := `
         SELECT EXISTS (
             SELECT 1
@@ -699,9 +701,9 @@ func (r *Repository) SearchTags(query string, familyID int) (TagSearchResults, e
         );`
 
     var hasResults bool
-    err := r.db.QueryRow(quickCheckQuery, query, familyID).Scan(&hasResults)
+    err := r.db.QueryRowContext(ctx, quickCheckQuery, query, familyID).Scan(&hasResults)
     if err != nil {
-        return nil, fmt.Errorf("error checking for results: %v", err)
+        return nil, fmt.Errorf("error checking for results: %w", err)
     }
 
     if !hasResults {
@@ -772,9 +774,9 @@ func (r *Repository) SearchTags(query string, familyID int) (TagSearchResults, e
         ORDER BY rt.rank DESC
         LIMIT 50;`
 
-    rows, err := r.db.Query(sqlQuery, query, familyID)
+    rows, err := r.db.QueryContext(ctx, sqlQuery, query, familyID)
     if err != nil {
-        return nil, fmt.Errorf("error executing tag search: %v", err)
+        return nil, fmt.Errorf("error executing tag search: %w", err)
     }
     defer rows.Close()
 
@@ -794,11 +796,11 @@ func (r *Repository) SearchTags(query string, familyID int) (TagSearchResults, e
             &itemsJSON,
         )
         if err != nil {
-            return nil, fmt.Errorf("error scanning tag search result: %v", err)
+            return nil, fmt.Errorf("error scanning tag search result: %w", err)
         }
 
         if err := json.Unmarshal(itemsJSON, &result.Items); err != nil {
-            return nil, fmt.Errorf("error unmarshaling items: %v", err)
+            return nil, fmt.Errorf("error unmarshaling items: %w", err)
         }
 
         results = append(results, result)
@@ -807,7 +809,7 @@ func (r *Repository) SearchTags(query string, familyID int) (TagSearchResults, e
     return results, nil
 }
 
-func (r *Repository) FindContainerByQR(qrCode string, familyID int) (*entities.Container, error) {
+func (r *Repository) FindContainerByQR(ctx context.Context, qrCode string, familyID int) (*entities.Container, error) {
     query := `
         SELECT 
             c.*,
@@ -827,7 +829,7 @@ func (r *Repository) FindContainerByQR(qrCode string, familyID int) (*entities.C
    container := new(entities.Container)
    var workspaceJSON []byte
    
-   err := r.db.QueryRow(query, qrCode, familyID).Scan(
+   err := r.db.QueryRowContext(ctx, query, qrCode, familyID).Scan(
        &container.ID,
        &container.Name,
        &container.QRCode,
@@ -843,15 +845,15 @@ func (r *Repository) FindContainerByQR(qrCode string, familyID int) (*entities.C
    )
 
    if err == sql.ErrNoRows {
-       return nil, fmt.Errorf("container not found")
+       return nil, apperror.NotFound("container not found")
    }
    if err != nil {
-       return nil, fmt.Errorf("error finding container: %v", err)
+       return nil, fmt.Errorf("error finding container: %w", err)
    }
 
    if len(workspaceJSON) > 0 {
        if err := json.Unmarshal(workspaceJSON, &container.Workspace); err != nil {
-           return nil, fmt.Errorf("error unmarshaling workspace: %v", err)
+           return nil, fmt.Errorf("error unmarshaling workspace: %w", err)
        }
    }
 
@@ -1,8 +1,11 @@
 package search
 
 import (
+	"context"
 	"fmt"
 
+	"github.com/chrisabs/cadence/internal/apperror"
+	"github.com/chrisabs/cadence/internal/platform/tracing"
 	"github.com/chrisabs/cadence/internal/storage/entities"
 )
 
@@ -16,79 +19,97 @@ func NewService(repo *Repository) *Service {
     }
 }
 
-func (s *Service) Search(query string, familyID int) (*SearchResponse, error) {
+func (s *Service) Search(ctx context.Context, query string, familyID int) (*SearchResponse, error) {
+    ctx, span := tracing.Start(ctx, "search.Service.Search")
+    defer span.End()
+
     if query == "" {
-        return nil, fmt.Errorf("search query cannot be empty")
+        return nil, apperror.Validation("search query cannot be empty", map[string]string{"q": "is required"})
     }
 
-    results, err := s.repo.Search(query, familyID)
+    results, err := s.repo.Search(ctx, query, familyID)
     if err != nil {
-        return nil, fmt.Errorf("failed to execute search: %v", err)
+        return nil, fmt.Errorf("failed to execute search: %w", err)
     }
 
     return results, nil
 }
 
-func (s *Service) SearchWorkspaces(query string, familyID int) (WorkspaceSearchResults, error) {
+func (s *Service) SearchWorkspaces(ctx context.Context, query string, familyID int) (WorkspaceSearchResults, error) {
+    ctx, span := tracing.Start(ctx, "search.Service.SearchWorkspaces")
+    defer span.End()
+
     if query == "" {
-        return nil, fmt.Errorf("search query cannot be empty")
+        return nil, apperror.Validation("search query cannot be empty", map[string]string{"q": "is required"})
     }
 
-    results, err := s.repo.SearchWorkspaces(query, familyID)
+    results, err := s.repo.SearchWorkspaces(ctx, query, familyID)
     if err != nil {
-        return nil, fmt.Errorf("failed to execute workspace search: %v", err)
+        return nil, fmt.Errorf("failed to execute workspace search: %w", err)
     }
 
     return results, nil
 }
 
-func (s *Service) SearchContainers(query string, familyID int) (ContainerSearchResults, error) {
+func (s *Service) SearchContainers(ctx context.Context, query string, familyID int) (ContainerSearchResults, error) {
+    ctx, span := tracing.Start(ctx, "search.Service.SearchContainers")
+    defer span.End()
+
     if query == "" {
-        return nil, fmt.Errorf("search query cannot be empty")
+        return nil, apperror.Validation("search query cannot be empty", map[string]string{"q": "is required"})
     }
 
-    results, err := s.repo.SearchContainers(query, familyID)
+    results, err := s.repo.SearchContainers(ctx, query, familyID)
     if err != nil {
-        return nil, fmt.Errorf("failed to execute container search: %v", err)
+        return nil, fmt.Errorf("failed to execute container search: %w", err)
     }
 
     return results, nil
 }
 
-func (s *Service) SearchItems(query string, familyID int) (ItemSearchResults, error) {
+func (s *Service) SearchItems(ctx context.Context, query string, familyID int) (ItemSearchResults, error) {
+    ctx, span := tracing.Start(ctx, "search.Service.SearchItems")
+    defer span.End()
+
     if query == "" {
-        return nil, fmt.Errorf("search query cannot be empty")
+        return nil, apperror.Validation("search query cannot be empty", map[string]string{"q": "is required"})
     }
 
-    results, err := s.repo.SearchItems(query, familyID)
+    results, err := s.repo.SearchItems(ctx, query, familyID)
     if err != nil {
-        return nil, fmt.Errorf("failed to execute item search: %v", err)
+        return nil, fmt.Errorf("failed to execute item search: %w", err)
     }
 
     return results, nil
 }
 
-func (s *Service) SearchTags(query string, familyID int) (TagSearchResults, error) {
+func (s *Service) SearchTags(ctx context.Context, query string, familyID int) (TagSearchResults, error) {
+    ctx, span := tracing.Start(ctx, "search.Service.SearchTags")
+    defer span.End()
+
     if query == "" {
-        return nil, fmt.Errorf("search query cannot be empty")
+        return nil, apperror.Validation("search query cannot be empty", map[string]string{"q": "is required"})
     }
 
-    results, err := s.repo.SearchTags(query, familyID)
+    results, err := s.repo.SearchTags(ctx, query, familyID)
     if err != nil {
-        return nil, fmt.Errorf("failed to execute tag search: %v", err)
+        return nil, fmt.Errorf("failed to execute tag search: %w", err)
     }
 
     return results, nil
 }
 
-func (s *Service) FindContainerByQR(qrCode string, familyID int) (*entities.Container, error) {
+func (s *Service) FindContainerByQR(ctx context.Context, qrCode string, familyID int) (*entities.Container, error) {
+    ctx, span := tracing.Start(ctx, "search.Service.FindContainerByQR")
+    defer span.End()
+
     if qrCode == "" {
-        return nil, fmt.Errorf("QR code cannot be empty")
+        return nil, apperror.Validation("QR code cannot be empty", map[string]string{"qrCode": "is required"})
     }
 
-    container, err := s.repo.FindContainerByQR(qrCode, familyID)
+    container, err := s.repo.FindContainerByQR(ctx, qrCode, familyID)
     if err != nil {
-        return nil, fmt.Errorf("failed to find container: %v", err)
+        return nil, fmt.Errorf("failed to find container: %w", err)
     }
 
     return container, nil
@@ -5,8 +5,10 @@ import (
 	"net/http"
 	"strconv"
 
+	"github.com/chrisabs/cadence/internal/apperror"
 	"github.com/chrisabs/cadence/internal/middleware"
 	"github.com/chrisabs/cadence/internal/models"
+	"github.com/chrisabs/cadence/internal/platform/respond"
 	"github.com/gorilla/mux"
 )
 
@@ -23,145 +25,167 @@ func NewHandler(service *Service, authMiddleware *middleware.AuthMiddleware) *Ha
 }
 
 func (h *Handler) RegisterRoutes(router *mux.Router) {
-    router.HandleFunc("/tags", h.authMiddleware.ProfileAuthHandler(h.handleGetTags)).Methods("GET")
-    router.HandleFunc("/tags", h.authMiddleware.ProfileAuthHandler(h.handleCreateTag)).Methods("POST")
+    router.HandleFunc("/tags", h.authMiddleware.ModuleMiddleware(models.ModuleStorage, models.PermissionRead)(h.handleGetTags)).Methods("GET")
+    router.HandleFunc("/tags", h.authMiddleware.ModuleMiddleware(models.ModuleStorage, models.PermissionWrite)(h.handleCreateTag)).Methods("POST")
     
-    router.HandleFunc("/tags/{id}", h.authMiddleware.ProfileAuthHandler(h.handleGetTag)).Methods("GET")
-    router.HandleFunc("/tags/{id}", h.authMiddleware.ProfileAuthHandler(h.handleUpdateTag)).Methods("PUT")
-    router.HandleFunc("/tags/{id}", h.authMiddleware.ProfileAuthHandler(h.handleDeleteTag)).Methods("DELETE")
+    router.HandleFunc("/tags/{id}", h.authMiddleware.ModuleMiddleware(models.ModuleStorage, models.PermissionRead)(h.handleGetTag)).Methods("GET")
+    router.HandleFunc("/tags/{id}", h.authMiddleware.ModuleMiddleware(models.ModuleStorage, models.PermissionWrite)(h.handleUpdateTag)).Methods("PUT")
+    router.HandleFunc("/tags/{id}", h.authMiddleware.ModuleMiddleware(models.ModuleStorage, models.PermissionManage)(h.handleDeleteTag)).Methods("DELETE")
 
-    router.HandleFunc("/tags/{id}/restore", h.authMiddleware.ProfileAuthHandler(h.handleRestoreTag)).Methods("PUT")
-    router.HandleFunc("/tags/assign", h.authMiddleware.ProfileAuthHandler(h.handleAssignTags)).Methods("POST")
+    router.HandleFunc("/tags/{id}/restore", h.authMiddleware.ModuleMiddleware(models.ModuleStorage, models.PermissionManage)(h.handleRestoreTag)).Methods("PUT")
+    router.HandleFunc("/tags/assign", h.authMiddleware.ModuleMiddleware(models.ModuleStorage, models.PermissionWrite)(h.handleAssignTags)).Methods("POST")
 }
 
 func (h *Handler) handleGetTags(w http.ResponseWriter, r *http.Request) {
-    profileCtx := r.Context().Value("profile").(*models.ProfileContext)
+    profileCtx, ok := middleware.ProfileFrom(r.Context())
+    if !ok {
+        respond.Error(w, r, apperror.Unauthorized("profile authentication required"))
+        return
+    }
 
-    tags, err := h.service.GetAllTags(profileCtx.FamilyID)
+    tags, err := h.service.GetAllTags(r.Context(), profileCtx.FamilyID)
     if err != nil {
-        writeError(w, http.StatusInternalServerError, err.Error())
+        respond.Error(w, r, err)
         return
     }
-    writeJSON(w, http.StatusOK, tags)
+    respond.JSON(w, http.StatusOK, tags)
 }
 
 func (h *Handler) handleCreateTag(w http.ResponseWriter, r *http.Request) {
-    profileCtx := r.Context().Value("profile").(*models.ProfileContext)
+    profileCtx, ok := middleware.ProfileFrom(r.Context())
+    if !ok {
+        respond.Error(w, r, apperror.Unauthorized("profile authentication required"))
+        return
+    }
 
     var req CreateTagRequest
     if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-        writeError(w, http.StatusBadRequest, "invalid request body")
+        respond.Error(w, r, apperror.BadRequest("invalid request body"))
         return
     }
 
-    tag, err := h.service.CreateTag(profileCtx.FamilyID, profileCtx.ProfileID, &req)
+    tag, err := h.service.CreateTag(r.Context(), profileCtx.FamilyID, profileCtx.ProfileID, &req)
     if err != nil {
-        writeError(w, http.StatusInternalServerError, err.Error())
+        respond.Error(w, r, err)
         return
     }
-    writeJSON(w, http.StatusCreated, tag)
+    respond.JSON(w, http.StatusCreated, tag)
 }
 
 func (h *Handler) handleGetTag(w http.ResponseWriter, r *http.Request) {
-    profileCtx := r.Context().Value("profile").(*models.ProfileContext)
+    profileCtx, ok := middleware.ProfileFrom(r.Context())
+    if !ok {
+        respond.Error(w, r, apperror.Unauthorized("profile authentication required"))
+        return
+    }
 
     id, err := getIDFromRequest(r)
     if err != nil {
-        writeError(w, http.StatusBadRequest, err.Error())
+        respond.Error(w, r, err)
         return
     }
 
-    tag, err := h.service.GetTagByID(id, profileCtx.FamilyID)
+    tag, err := h.service.GetTagByID(r.Context(), id, profileCtx.FamilyID)
     if err != nil {
-        writeError(w, http.StatusNotFound, err.Error())
+        respond.Error(w, r, err)
         return
     }
-    writeJSON(w, http.StatusOK, tag)
+    respond.JSON(w, http.StatusOK, tag)
 }
 
 func (h *Handler) handleUpdateTag(w http.ResponseWriter, r *http.Request) {
-    profileCtx := r.Context().Value("profile").(*models.ProfileContext)
+    profileCtx, ok := middleware.ProfileFrom(r.Context())
+    if !ok {
+        respond.Error(w, r, apperror.Unauthorized("profile authentication required"))
+        return
+    }
 
     id, err := getIDFromRequest(r)
     if err != nil {
-        writeError(w, http.StatusBadRequest, err.Error())
+        respond.Error(w, r, err)
         return
     }
 
     var req UpdateTagRequest
     if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-        writeError(w, http.StatusBadRequest, "invalid request body")
+        respond.Error(w, r, apperror.BadRequest("invalid request body"))
         return
     }
 
-    tag, err := h.service.UpdateTag(id, profileCtx.FamilyID, profileCtx.ProfileID, &req)
+    tag, err := h.service.UpdateTag(r.Context(), id, profileCtx.FamilyID, profileCtx.ProfileID, &req)
     if err != nil {
-        writeError(w, http.StatusInternalServerError, err.Error())
+        respond.Error(w, r, err)
         return
     }
-    writeJSON(w, http.StatusOK, tag)
+    respond.JSON(w, http.StatusOK, tag)
 }
 
 func (h *Handler) handleAssignTags(w http.ResponseWriter, r *http.Request) {
-    profileCtx := r.Context().Value("profile").(*models.ProfileContext)
+    profileCtx, ok := middleware.ProfileFrom(r.Context())
+    if !ok {
+        respond.Error(w, r, apperror.Unauthorized("profile authentication required"))
+        return
+    }
 
     var req AssignTagsRequest
     if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-        writeError(w, http.StatusBadRequest, "invalid request body")
+        respond.Error(w, r, apperror.BadRequest("invalid request body"))
         return
     }
 
-    if err := h.service.AssignTagsToItems(profileCtx.FamilyID, req.TagIDs, req.ItemIDs); err != nil {
-        writeError(w, http.StatusInternalServerError, err.Error())
+    if err := h.service.AssignTagsToItems(r.Context(), profileCtx.FamilyID, &req); err != nil {
+        respond.Error(w, r, err)
         return
     }
 
-    writeJSON(w, http.StatusOK, map[string]string{"message": "tags assigned successfully"})
+    respond.JSON(w, http.StatusOK, map[string]string{"message": "tags assigned successfully"})
 }
 
 func (h *Handler) handleDeleteTag(w http.ResponseWriter, r *http.Request) {
-    profileCtx := r.Context().Value("profile").(*models.ProfileContext)
+    profileCtx, ok := middleware.ProfileFrom(r.Context())
+    if !ok {
+        respond.Error(w, r, apperror.Unauthorized("profile authentication required"))
+        return
+    }
 
     id, err := getIDFromRequest(r)
     if err != nil {
-        writeError(w, http.StatusBadRequest, err.Error())
+        respond.Error(w, r, err)
         return
     }
 
-    if err := h.service.DeleteTag(id, profileCtx.FamilyID, profileCtx.ProfileID); err != nil {
-        writeError(w, http.StatusInternalServerError, err.Error())
+    if err := h.service.DeleteTag(r.Context(), id, profileCtx.FamilyID, profileCtx.ProfileID); err != nil {
+        respond.Error(w, r, err)
         return
     }
-    writeJSON(w, http.StatusOK, map[string]string{"message": "tag deleted successfully"})
+    respond.JSON(w, http.StatusOK, map[string]string{"message": "tag deleted successfully"})
 }
 
 func (h *Handler) handleRestoreTag(w http.ResponseWriter, r *http.Request) {
-    profileCtx := r.Context().Value("profile").(*models.ProfileContext)
+    profileCtx, ok := middleware.ProfileFrom(r.Context())
+    if !ok {
+        respond.Error(w, r, apperror.Unauthorized("profile authentication required"))
+        return
+    }
 
     id, err := getIDFromRequest(r)
     if err != nil {
-        writeError(w, http.StatusBadRequest, err.Error())
+        respond.Error(w, r, err)
         return
     }
 
-    if err := h.service.RestoreTag(id, profileCtx.FamilyID); err != nil {
-        writeError(w, http.StatusInternalServerError, err.Error())
+    if err := h.service.RestoreTag(r.Context(), id, profileCtx.FamilyID); err != nil {
+        respond.Error(w, r, err)
         return
     }
-    writeJSON(w, http.StatusOK, map[string]string{"message": "tag restored successfully"})
+    respond.JSON(w, http.StatusOK, map[string]string{"message": "tag restored successfully"})
 }
 
 func getIDFromRequest(r *http.Request) (int, error) {
     vars := mux.Vars(r)
-    return strconv.Atoi(vars["id"])
-}
-
-func writeJSON(w http.ResponseWriter, status int, v interface{}) {
-    w.Header().Set("Content-Type", "application/json")
-    w.WriteHeader(status)
-    json.NewEncoder(w).Encode(v)
+    id, err := strconv.Atoi(vars["id"])
+    if err != nil {
+        return 0, apperror.BadRequest("invalid id")
+    }
+    return id, nil
 }
-
-func writeError(w http.ResponseWriter, status int, message string) {
-    writeJSON(w, status, map[string]string{"error": message})
-}
\ No newline at end of file
@@ -1,18 +1,18 @@
 package tag
 
 type CreateTagRequest struct {
-	Name   		string `json:"name"`
-	Description string `json:"description"`
-	Colour 		string `json:"colour"`
+	Name   		string `json:"name" validate:"required,max=50"`
+	Description string `json:"description" validate:"max=1000"`
+	Colour 		string `json:"colour" validate:"omitempty,hexcolour"`
 }
 
 type UpdateTagRequest struct {
-	Name        string `json:"name"`
-	Colour      string `json:"colour"`
-	Description string `json:"description"`
+	Name        string `json:"name" validate:"required,max=50"`
+	Colour      string `json:"colour" validate:"omitempty,hexcolour"`
+	Description string `json:"description" validate:"max=1000"`
 }
 
 type AssignTagsRequest struct {
-    TagIDs  []int `json:"tagIds"`
-    ItemIDs []int `json:"itemIds"`
+    TagIDs  []int `json:"tagIds" validate:"required"`
+    ItemIDs []int `json:"itemIds" validate:"required"`
 }
\ No newline at end of file
@@ -1,11 +1,13 @@
 package tag
 
 import (
+	"context"
 	"database/sql"
 	"encoding/json"
 	"fmt"
-	"time"
 
+	"github.com/chrisabs/cadence/internal/apperror"
+	"github.com/chrisabs/cadence/internal/storage/deletion"
 	"github.com/chrisabs/cadence/internal/storage/entities"
 	"github.com/lib/pq"
 )
@@ -18,13 +20,13 @@ func NewRepository(db *sql.DB) *Repository {
     return &Repository{db: db}
 }
 
-func (r *Repository) Create(tag *entities.Tag) error {
+func (r *Repository) Create(ctx context.Context, tag *entities.Tag) error {
     query := `
         INSERT INTO tag (name, description, colour, profile_id, family_id, created_at, updated_at)
         VALUES ($1, $2, $3, $4, $5, $6, $7)
         RETURNING id`
 
-    err := r.db.QueryRow(
+    err := r.db.QueryRowContext(ctx,
         query,
         tag.Name,
         tag.Description,
@@ -36,13 +38,13 @@ func (r *Repository) Create(tag *entities.Tag) error {
     ).Scan(&tag.ID)
 
     if err != nil {
-        return fmt.Errorf("error creating tag: %v", err)
+        return fmt.Errorf("error creating tag: %w", err)
     }
 
     return nil
 }
 
-func (r *Repository) GetByID(id int, familyID int) (*entities.Tag, error) {
+func (r *Repository) GetByID(ctx context.Context, id int, familyID int) (*entities.Tag, error) {
     query := `
         WITH item_images AS (
             SELECT item_id,
@@ -105,7 +107,7 @@ func (r *Repository) GetByID(id int, familyID int) (*entities.Tag, error) {
                    '[]'
                ) as items
         FROM tag t
-        LEFT JOIN item_tag it ON t.id = it.tag_id
+        LEFT JOIN item_tag it ON t.id = it.tag_id AND it.is_deleted = false
         LEFT JOIN item i ON it.item_id = i.id AND i.family_id = t.family_id AND i.is_deleted = false
         LEFT JOIN item_images img ON i.id = img.item_id
         LEFT JOIN container c ON i.container_id = c.id AND c.family_id = t.family_id AND c.is_deleted = false
@@ -116,7 +118,7 @@ func (r *Repository) GetByID(id int, familyID int) (*entities.Tag, error) {
     tag := new(entities.Tag)
     var itemsJSON []byte
 
-    err := r.db.QueryRow(query, id, familyID).Scan(
+    err := r.db.QueryRowContext(ctx, query, id, familyID).Scan(
         &tag.ID, &tag.Name, &tag.Colour, &tag.Description, 
         &tag.ProfileID, &tag.FamilyID,
         &tag.CreatedAt, &tag.UpdatedAt,
@@ -124,20 +126,20 @@ func (r *Repository) GetByID(id int, familyID int) (*entities.Tag, error) {
     )
 
     if err == sql.ErrNoRows {
-        return nil, fmt.Errorf("tag not found")
+        return nil, apperror.NotFound("tag not found")
     }
     if err != nil {
         return nil, err
     }
 
     if err := json.Unmarshal(itemsJSON, &tag.Items); err != nil {
-        return nil, fmt.Errorf("error parsing items: %v", err)
+        return nil, fmt.Errorf("error parsing items: %w", err)
     }
 
     return tag, nil
 }
 
-func (r *Repository) GetByFamilyID(familyID int) ([]*entities.Tag, error) {
+func (r *Repository) GetByFamilyID(ctx context.Context, familyID int) ([]*entities.Tag, error) {
     query := `
         WITH item_images AS (
             SELECT item_id,
@@ -201,7 +203,7 @@ func (r *Repository) GetByFamilyID(familyID int) ([]*entities.Tag, error) {
                    '[]'
                ) as items
         FROM tag t
-        LEFT JOIN item_tag it ON t.id = it.tag_id
+        LEFT JOIN item_tag it ON t.id = it.tag_id AND it.is_deleted = false
         LEFT JOIN item i ON it.item_id = i.id AND i.family_id = t.family_id AND i.is_deleted = false
         LEFT JOIN item_images img ON i.id = img.item_id
         LEFT JOIN container c ON i.container_id = c.id AND c.family_id = t.family_id AND c.is_deleted = false
@@ -210,7 +212,7 @@ func (r *Repository) GetByFamilyID(familyID int) ([]*entities.Tag, error) {
         GROUP BY t.id, t.name, t.colour, t.family_id, t.created_at, t.updated_at
         ORDER BY t.name ASC`
 
-    rows, err := r.db.Query(query, familyID)
+    rows, err := r.db.QueryContext(ctx, query, familyID)
     if err != nil {
         return nil, err
     }
@@ -232,7 +234,7 @@ func (r *Repository) GetByFamilyID(familyID int) ([]*entities.Tag, error) {
         }
 
         if err := json.Unmarshal(itemsJSON, &tag.Items); err != nil {
-            return nil, fmt.Errorf("error parsing items: %v", err)
+            return nil, fmt.Errorf("error parsing items: %w", err)
         }
 
         tags = append(tags, tag)
@@ -241,7 +243,7 @@ func (r *Repository) GetByFamilyID(familyID int) ([]*entities.Tag, error) {
     return tags, nil
 }
 
-func (r *Repository) Update(tag *entities.Tag) error {
+func (r *Repository) Update(ctx context.Context, tag *entities.Tag) error {
     query := `
         UPDATE tag
         SET name = $2, 
@@ -252,7 +254,7 @@ func (r *Repository) Update(tag *entities.Tag) error {
         WHERE id = $1 AND family_id = $6 AND is_deleted = false
         RETURNING updated_at`
             
-    err := r.db.QueryRow(
+    err := r.db.QueryRowContext(ctx,
         query,
         tag.ID,
         tag.Name,
@@ -263,22 +265,22 @@ func (r *Repository) Update(tag *entities.Tag) error {
     ).Scan(&tag.UpdatedAt)
 
     if err != nil {
-        return fmt.Errorf("error updating tag: %v", err)
+        return fmt.Errorf("error updating tag: %w", err)
     }
 
     return nil
 }
 
-func (r *Repository) AssignTagsToItems(familyID int, tagIDs []int, itemIDs []int) error {
-    tx, err := r.db.Begin()
+func (r *Repository) AssignTagsToItems(ctx context.Context, familyID int, tagIDs []int, itemIDs []int) error {
+    tx, err := r.db.BeginTx(ctx, nil)
     if err != nil {
-        return fmt.Errorf("error starting transaction: %v", err)
+        return fmt.Errorf("error starting transaction: %w", err)
     }
     defer tx.Rollback()
 
     insertQuery := `
         INSERT INTO item_tag (tag_id, item_id)
-        SELECT t.id, i.id
+        SELECT DISTINCT t.id, i.id
         FROM unnest($1::int[]) AS t(id)
         CROSS JOIN unnest($2::int[]) AS i(id)
         WHERE EXISTS (
@@ -286,78 +288,90 @@ func (r *Repository) AssignTagsToItems(familyID int, tagIDs []int, itemIDs []int
         ) AND EXISTS (
             SELECT 1 FROM item WHERE id = i.id AND family_id = $3
         )
-        ON CONFLICT (tag_id, item_id) DO NOTHING
+        ON CONFLICT (item_id, tag_id) DO UPDATE
+        SET is_deleted = false, deleted_at = NULL, deleted_by = NULL, deletion_batch = NULL
     `
     
-    _, err = tx.Exec(insertQuery, pq.Array(tagIDs), pq.Array(itemIDs), familyID)
+    _, err = tx.ExecContext(ctx, insertQuery, pq.Array(tagIDs), pq.Array(itemIDs), familyID)
     if err != nil {
-        return fmt.Errorf("error assigning tags: %v", err)
+        return fmt.Errorf("error assigning tags: %w", err)
     }
 
     return tx.Commit()
 }
 
-func (r *Repository) Delete(id int, familyID int, deletedBy int) error {
-    tx, err := r.db.Begin()
+// Delete soft deletes the tag and its links to items.
+func (r *Repository) Delete(ctx context.Context, id int, familyID int, deletedBy int) error {
+    tx, err := r.db.BeginTx(ctx, nil)
     if err != nil {
-        return fmt.Errorf("error starting transaction: %v", err)
+        return fmt.Errorf("error starting transaction: %w", err)
     }
     defer tx.Rollback()
 
-    itemTagQuery := `
-        DELETE FROM item_tag
-        WHERE tag_id = $1 AND is_deleted = false
-        AND EXISTS (
-            SELECT 1 FROM tag
-            WHERE id = $1 AND family_id = $2 AND is_deleted = false
-        )`
-    
-    _, err = tx.Exec(itemTagQuery, id, familyID)
-    if err != nil {
-        return fmt.Errorf("error removing item-tag associations: %v", err)
-    }
-
-    tagQuery := `
-        UPDATE tag 
-        SET is_deleted = true, deleted_at = $3, deleted_by = $4, updated_at = $3
-        WHERE id = $1 AND family_id = $2 AND is_deleted = false`
-        
-    result, err := tx.Exec(tagQuery, id, familyID, time.Now().UTC(), deletedBy)
+    batch, err := deletion.Begin(ctx, tx, familyID, deletedBy)
     if err != nil {
-        return fmt.Errorf("error deleting tag: %v", err)
+        return err
     }
 
-    rowsAffected, err := result.RowsAffected()
+    deleted, err := batch.Tag(ctx, id)
     if err != nil {
-        return fmt.Errorf("error checking delete result: %v", err)
+        return err
     }
-
-    if rowsAffected == 0 {
-        return fmt.Errorf("tag not found")
+    if !deleted {
+        return apperror.NotFound("tag not found")
     }
 
     return tx.Commit()
 }
 
-func (r *Repository) RestoreDeleted(id int, familyID int) error {
-    query := `
-        UPDATE tag
-        SET is_deleted = false, deleted_at = NULL, deleted_by = NULL, updated_at = $3
-        WHERE id = $1 AND family_id = $2 AND is_deleted = true`
-    
-    result, err := r.db.Exec(query, id, familyID, time.Now().UTC())
+// RestoreDeleted brings back the tag and the links deleted with it.
+func (r *Repository) RestoreDeleted(ctx context.Context, id int, familyID int) error {
+    tx, err := r.db.BeginTx(ctx, nil)
     if err != nil {
-        return fmt.Errorf("error restoring tag: %v", err)
+        return fmt.Errorf("error starting transaction: %w", err)
     }
+    defer tx.Rollback()
 
-    rowsAffected, err := result.RowsAffected()
+    restored, err := deletion.Restore(ctx, tx, deletion.Tag, id, familyID)
     if err != nil {
-        return fmt.Errorf("error checking restore result: %v", err)
+        return err
+    }
+    if !restored {
+        return apperror.NotFound("tag not found or not deleted")
     }
 
-    if rowsAffected == 0 {
-        return fmt.Errorf("tag not found or not deleted")
+    return tx.Commit()
+}
+func (r *Repository) AreFamilyTags(ctx context.Context, ids []int, familyID int) (bool, error) {
+    query := `
+        SELECT NOT EXISTS (
+            SELECT 1 FROM unnest($1::int[]) AS requested(id)
+            WHERE NOT EXISTS (
+                SELECT 1 FROM tag
+                WHERE tag.id = requested.id AND tag.family_id = $2 AND tag.is_deleted = false
+            )
+        )`
+
+    var owned bool
+    if err := r.db.QueryRowContext(ctx, query, pq.Array(ids), familyID).Scan(&owned); err != nil {
+        return false, fmt.Errorf("error checking tags: %w", err)
     }
+    return owned, nil
+}
 
-    return nil
-}
\ No newline at end of file
+func (r *Repository) AreFamilyItems(ctx context.Context, ids []int, familyID int) (bool, error) {
+    query := `
+        SELECT NOT EXISTS (
+            SELECT 1 FROM unnest($1::int[]) AS requested(id)
+            WHERE NOT EXISTS (
+                SELECT 1 FROM item
+                WHERE item.id = requested.id AND item.family_id = $2 AND item.is_deleted = false
+            )
+        )`
+
+    var owned bool
+    if err := r.db.QueryRowContext(ctx, query, pq.Array(ids), familyID).Scan(&owned); err != nil {
+        return false, fmt.Errorf("error checking items: %w", err)
+    }
+    return owned, nil
+}
@@ -1,9 +1,12 @@
 package tag
 
 import (
+	"context"
 	"fmt"
 	"time"
 
+	"github.com/chrisabs/cadence/internal/platform/tracing"
+	"github.com/chrisabs/cadence/internal/platform/validate"
 	"github.com/chrisabs/cadence/internal/storage/entities"
 )
 
@@ -15,7 +18,14 @@ func NewService(repo *Repository) *Service {
     return &Service{repo: repo}
 }
 
-func (s *Service) CreateTag(familyID int, profileID int, req *CreateTagRequest) (*entities.Tag, error) {
+func (s *Service) CreateTag(ctx context.Context, familyID int, profileID int, req *CreateTagRequest) (*entities.Tag, error) {
+    ctx, span := tracing.Start(ctx, "tag.Service.CreateTag")
+    defer span.End()
+
+    if err := validate.Struct(req); err != nil {
+        return nil, err
+    }
+
     tag := &entities.Tag{
         Name:        req.Name,
         Description: req.Description,
@@ -27,25 +37,38 @@ func (s *Service) CreateTag(familyID int, profileID int, req *CreateTagRequest)
         UpdatedAt:   time.Now().UTC(),
     }
 
-    if err := s.repo.Create(tag); err != nil {
-        return nil, fmt.Errorf("failed to create tag: %v", err)
+    if err := s.repo.Create(ctx, tag); err != nil {
+        return nil, fmt.Errorf("failed to create tag: %w", err)
     }
 
-    return s.repo.GetByID(tag.ID, familyID)
+    return s.repo.GetByID(ctx, tag.ID, familyID)
 }
 
-func (s *Service) GetTagByID(id int, familyID int) (*entities.Tag, error) {
-    return s.repo.GetByID(id, familyID)
+func (s *Service) GetTagByID(ctx context.Context, id int, familyID int) (*entities.Tag, error) {
+    ctx, span := tracing.Start(ctx, "tag.Service.GetTagByID")
+    defer span.End()
+
+    return s.repo.GetByID(ctx, id, familyID)
 }
 
-func (s *Service) GetAllTags(familyID int) ([]*entities.Tag, error) {
-    return s.repo.GetByFamilyID(familyID)
+func (s *Service) GetAllTags(ctx context.Context, familyID int) ([]*entities.Tag, error) {
+    ctx, span := tracing.Start(ctx, "tag.Service.GetAllTags")
+    defer span.End()
+
+    return s.repo.GetByFamilyID(ctx, familyID)
 }
 
-func (s *Service) UpdateTag(id int, familyID int, profileID int, req *UpdateTagRequest) (*entities.Tag, error) {
-    tag, err := s.repo.GetByID(id, familyID)
+func (s *Service) UpdateTag(ctx context.Context, id int, familyID int, profileID int, req *UpdateTagRequest) (*entities.Tag, error) {
+    ctx, span := tracing.Start(ctx, "tag.Service.UpdateTag")
+    defer span.End()
+
+    if err := validate.Struct(req); err != nil {
+        return nil, err
+    }
+
+    tag, err := s.repo.GetByID(ctx, id, familyID)
     if err != nil {
-        return nil, fmt.Errorf("tag not found: %v", err)
+        return nil, fmt.Errorf("tag not found: %w", err)
     }
 
     tag.Name = req.Name
@@ -54,24 +77,60 @@ func (s *Service) UpdateTag(id int, familyID int, profileID int, req *UpdateTagR
     tag.ProfileID = profileID 
     tag.UpdatedAt = time.Now().UTC()
 
-    if err := s.repo.Update(tag); err != nil {
-        return nil, fmt.Errorf("failed to update tag: %v", err)
+    if err := s.repo.Update(ctx, tag); err != nil {
+        return nil, fmt.Errorf("failed to update tag: %w", err)
     }
 
-    return s.repo.GetByID(id, familyID)
+    return s.repo.GetByID(ctx, id, familyID)
 }
 
-func (s *Service) AssignTagsToItems(familyID int, tagIDs []int, itemIDs []int) error {
-    return s.repo.AssignTagsToItems(familyID, tagIDs, itemIDs)
+func (s *Service) AssignTagsToItems(ctx context.Context, familyID int, req *AssignTagsRequest) error {
+    ctx, span := tracing.Start(ctx, "tag.Service.AssignTagsToItems")
+    defer span.End()
+
+    errs := validate.Errors{}
+    validate.Collect(req, errs)
+
+    if len(req.TagIDs) > 0 {
+        owned, err := s.repo.AreFamilyTags(ctx, req.TagIDs, familyID)
+        if err != nil {
+            return err
+        }
+        if !owned {
+            errs.Add("tagIds", "must only contain tags in this family")
+        }
+    }
+
+    if len(req.ItemIDs) > 0 {
+        owned, err := s.repo.AreFamilyItems(ctx, req.ItemIDs, familyID)
+        if err != nil {
+            return err
+        }
+        if !owned {
+            errs.Add("itemIds", "must only contain items in this family")
+        }
+    }
+
+    if err := errs.Err(); err != nil {
+        return err
+    }
+
+    return s.repo.AssignTagsToItems(ctx, familyID, req.TagIDs, req.ItemIDs)
 }
 
-func (s *Service) DeleteTag(id int, familyID int, deletedBy int) error {
-    return s.repo.Delete(id, familyID, deletedBy)
+func (s *Service) DeleteTag(ctx context.Context, id int, familyID int, deletedBy int) error {
+    ctx, span := tracing.Start(ctx, "tag.Service.DeleteTag")
+    defer span.End()
+
+    return s.repo.Delete(ctx, id, familyID, deletedBy)
 }
 
-func (s *Service) RestoreTag(id int, familyID int) error {
-    if err := s.repo.RestoreDeleted(id, familyID); err != nil {
-        return fmt.Errorf("failed to restore tag: %v", err)
+func (s *Service) RestoreTag(ctx context.Context, id int, familyID int) error {
+    ctx, span := tracing.Start(ctx, "tag.Service.RestoreTag")
+    defer span.End()
+
+    if err := s.repo.RestoreDeleted(ctx, id, familyID); err != nil {
+        return fmt.Errorf("failed to restore tag: %w", err)
     }
     return nil
 }
\ No newline at end of file
@@ -5,8 +5,10 @@ import (
 	"net/http"
 	"strconv"
 
+	"github.com/chrisabs/cadence/internal/apperror"
 	"github.com/chrisabs/cadence/internal/middleware"
 	"github.com/chrisabs/cadence/internal/models"
+	"github.com/chrisabs/cadence/internal/platform/respond"
 	"github.com/gorilla/mux"
 )
 
@@ -23,129 +25,147 @@ func NewHandler(service *Service, authMiddleware *middleware.AuthMiddleware) *Ha
 }
 
 func (h *Handler) RegisterRoutes(router *mux.Router) {
-    router.HandleFunc("/workspaces", h.authMiddleware.ProfileAuthHandler(h.handleGetWorkspaces)).Methods("GET")
-    router.HandleFunc("/workspaces", h.authMiddleware.ProfileAuthHandler(h.handleCreateWorkspace)).Methods("POST")
-    router.HandleFunc("/workspaces/{id}", h.authMiddleware.ProfileAuthHandler(h.handleGetWorkspaceByID)).Methods("GET")
-    router.HandleFunc("/workspaces/{id}", h.authMiddleware.ProfileAuthHandler(h.handleUpdateWorkspace)).Methods("PUT")
-    router.HandleFunc("/workspaces/{id}", h.authMiddleware.ProfileAuthHandler(h.handleDeleteWorkspace)).Methods("DELETE")
+    router.HandleFunc("/workspaces", h.authMiddleware.ModuleMiddleware(models.ModuleStorage, models.PermissionRead)(h.handleGetWorkspaces)).Methods("GET")
+    router.HandleFunc("/workspaces", h.authMiddleware.ModuleMiddleware(models.ModuleStorage, models.PermissionWrite)(h.handleCreateWorkspace)).Methods("POST")
+    router.HandleFunc("/workspaces/{id}", h.authMiddleware.ModuleMiddleware(models.ModuleStorage, models.PermissionRead)(h.handleGetWorkspaceByID)).Methods("GET")
+    router.HandleFunc("/workspaces/{id}", h.authMiddleware.ModuleMiddleware(models.ModuleStorage, models.PermissionWrite)(h.handleUpdateWorkspace)).Methods("PUT")
+    router.HandleFunc("/workspaces/{id}", h.authMiddleware.ModuleMiddleware(models.ModuleStorage, models.PermissionManage)(h.handleDeleteWorkspace)).Methods("DELETE")
 
-    router.HandleFunc("/workspaces/{id}/restore", h.authMiddleware.ProfileAuthHandler(h.handleRestoreWorkspace)).Methods("PUT")
+    router.HandleFunc("/workspaces/{id}/restore", h.authMiddleware.ModuleMiddleware(models.ModuleStorage, models.PermissionManage)(h.handleRestoreWorkspace)).Methods("PUT")
 }
 
 func (h *Handler) handleGetWorkspaces(w http.ResponseWriter, r *http.Request) {
-    profileCtx := r.Context().Value("profile").(*models.ProfileContext)
+    profileCtx, ok := middleware.ProfileFrom(r.Context())
+    if !ok {
+        respond.Error(w, r, apperror.Unauthorized("profile authentication required"))
+        return
+    }
     
-    workspaces, err := h.service.GetWorkspacesByFamilyID(profileCtx.FamilyID, profileCtx.ProfileID)
+    workspaces, err := h.service.GetWorkspacesByFamilyID(r.Context(), profileCtx.FamilyID, profileCtx.ProfileID)
     if err != nil {
-        writeError(w, http.StatusInternalServerError, err.Error())
+        respond.Error(w, r, err)
         return
     }
-    writeJSON(w, http.StatusOK, workspaces)
+    respond.JSON(w, http.StatusOK, workspaces)
 }
 
 func (h *Handler) handleCreateWorkspace(w http.ResponseWriter, r *http.Request) {
-    profileCtx := r.Context().Value("profile").(*models.ProfileContext)
+    profileCtx, ok := middleware.ProfileFrom(r.Context())
+    if !ok {
+        respond.Error(w, r, apperror.Unauthorized("profile authentication required"))
+        return
+    }
 
     var req CreateWorkspaceRequest
     if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-        writeError(w, http.StatusBadRequest, "invalid request body")
+        respond.Error(w, r, apperror.BadRequest("invalid request body"))
         return
     }
 
-    workspace, err := h.service.CreateWorkspace(profileCtx.FamilyID, profileCtx.ProfileID, &req)
+    workspace, err := h.service.CreateWorkspace(r.Context(), profileCtx.FamilyID, profileCtx.ProfileID, &req)
     if err != nil {
-        writeError(w, http.StatusInternalServerError, err.Error())
+        respond.Error(w, r, err)
         return
     }
-    writeJSON(w, http.StatusCreated, workspace)
+    respond.JSON(w, http.StatusCreated, workspace)
 }
 
 func (h *Handler) handleGetWorkspaceByID(w http.ResponseWriter, r *http.Request) {
-    profileCtx := r.Context().Value("profile").(*models.ProfileContext)
+    profileCtx, ok := middleware.ProfileFrom(r.Context())
+    if !ok {
+        respond.Error(w, r, apperror.Unauthorized("profile authentication required"))
+        return
+    }
     
     workspaceID, err := getIDFromRequest(r)
     if err != nil {
-        writeError(w, http.StatusBadRequest, err.Error())
+        respond.Error(w, r, err)
         return
     }
 
-    workspace, err := h.service.GetWorkspaceByID(workspaceID, profileCtx.FamilyID)
+    workspace, err := h.service.GetWorkspaceByID(r.Context(), workspaceID, profileCtx.FamilyID)
     if err != nil {
-        writeError(w, http.StatusNotFound, err.Error())
+        respond.Error(w, r, err)
         return
     }
 
-    writeJSON(w, http.StatusOK, workspace)
+    respond.JSON(w, http.StatusOK, workspace)
 }
 
 func (h *Handler) handleUpdateWorkspace(w http.ResponseWriter, r *http.Request) {
-    profileCtx := r.Context().Value("profile").(*models.ProfileContext)
+    profileCtx, ok := middleware.ProfileFrom(r.Context())
+    if !ok {
+        respond.Error(w, r, apperror.Unauthorized("profile authentication required"))
+        return
+    }
     
     workspaceID, err := getIDFromRequest(r)
     if err != nil {
-        writeError(w, http.StatusBadRequest, err.Error())
+        respond.Error(w, r, err)
         return
     }
 
     var req UpdateWorkspaceRequest
     if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-        writeError(w, http.StatusBadRequest, "invalid request body")
+        respond.Error(w, r, apperror.BadRequest("invalid request body"))
         return
     }
 
-    workspace, err := h.service.UpdateWorkspace(workspaceID, profileCtx.FamilyID, &req)
+    workspace, err := h.service.UpdateWorkspace(r.Context(), workspaceID, profileCtx.FamilyID, &req)
     if err != nil {
-        writeError(w, http.StatusInternalServerError, err.Error())
+        respond.Error(w, r, err)
         return
     }
-    writeJSON(w, http.StatusOK, workspace)
+    respond.JSON(w, http.StatusOK, workspace)
 }
 
 func (h *Handler) handleDeleteWorkspace(w http.ResponseWriter, r *http.Request) {
-    profileCtx := r.Context().Value("profile").(*models.ProfileContext)
+    profileCtx, ok := middleware.ProfileFrom(r.Context())
+    if !ok {
+        respond.Error(w, r, apperror.Unauthorized("profile authentication required"))
+        return
+    }
     
     workspaceID, err := getIDFromRequest(r)
     if err != nil {
-        writeError(w, http.StatusBadRequest, err.Error())
+        respond.Error(w, r, err)
         return
     }
 
-    if err := h.service.DeleteWorkspace(workspaceID, profileCtx.FamilyID, profileCtx.ProfileID); err != nil {
-        writeError(w, http.StatusInternalServerError, err.Error())
+    if err := h.service.DeleteWorkspace(r.Context(), workspaceID, profileCtx.FamilyID, profileCtx.ProfileID); err != nil {
+        respond.Error(w, r, err)
         return
     }
     
-    writeJSON(w, http.StatusOK, map[string]int{"deleted": workspaceID})
+    respond.JSON(w, http.StatusOK, map[string]int{"deleted": workspaceID})
 }
 
 func (h *Handler) handleRestoreWorkspace(w http.ResponseWriter, r *http.Request) {
-    profileCtx := r.Context().Value("profile").(*models.ProfileContext)
+    profileCtx, ok := middleware.ProfileFrom(r.Context())
+    if !ok {
+        respond.Error(w, r, apperror.Unauthorized("profile authentication required"))
+        return
+    }
     
     workspaceID, err := getIDFromRequest(r)
     if err != nil {
-        writeError(w, http.StatusBadRequest, err.Error())
+        respond.Error(w, r, err)
         return
     }
 
-    if err := h.service.RestoreWorkspace(workspaceID, profileCtx.FamilyID); err != nil {
-        writeError(w, http.StatusInternalServerError, err.Error())
+    if err := h.service.RestoreWorkspace(r.Context(), workspaceID, profileCtx.FamilyID); err != nil {
+        respond.Error(w, r, err)
         return
     }
     
-    writeJSON(w, http.StatusOK, map[string]int{"restored": workspaceID})
+    respond.JSON(w, http.StatusOK, map[string]int{"restored": workspaceID})
 }
 
 func getIDFromRequest(r *http.Request) (int, error) {
     vars := mux.Vars(r)
-    return strconv.Atoi(vars["id"])
-}
-
-func writeJSON(w http.ResponseWriter, status int, v interface{}) {
-    w.Header().Set("Content-Type", "application/json")
-    w.WriteHeader(status)
-    json.NewEncoder(w).Encode(v)
+    id, err := strconv.Atoi(vars["id"])
+    if err != nil {
+        return 0, apperror.BadRequest("invalid id")
+    }
+    return id, nil
 }
-
-func writeError(w http.ResponseWriter, status int, message string) {
-    writeJSON(w, status, map[string]string{"error": message})
-}
\ No newline at end of file
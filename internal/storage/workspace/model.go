@@ -1,12 +1,12 @@
 package workspace
 
 type CreateWorkspaceRequest struct {
-    Name        string `json:"name"`
-    Description string `json:"description"`
+    Name        string `json:"name" validate:"required,max=100"`
+    Description string `json:"description" validate:"max=1000"`
 }
 
 type UpdateWorkspaceRequest struct {
-    Name        string `json:"name"`
-    Description string `json:"description"`
+    Name        string `json:"name" validate:"required,max=100"`
+    Description string `json:"description" validate:"max=1000"`
     ContainerIDs []int  `json:"containerIds,omitempty"`
 }
\ No newline at end of file
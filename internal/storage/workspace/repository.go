@@ -1,11 +1,15 @@
 package workspace
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"time"
 
+	"github.com/chrisabs/cadence/internal/apperror"
+	"github.com/chrisabs/cadence/internal/storage/deletion"
 	"github.com/chrisabs/cadence/internal/storage/entities"
+	"github.com/lib/pq"
 )
 
 type Repository struct {
@@ -16,13 +20,13 @@ func NewRepository(db *sql.DB) *Repository {
     return &Repository{db: db}
 }
 
-func (r *Repository) Create(workspace *entities.Workspace) error {
+func (r *Repository) Create(ctx context.Context, workspace *entities.Workspace) error {
     query := `
         INSERT INTO workspace (id, name, description, profile_id, family_id, created_at, updated_at)
         VALUES ($1, $2, $3, $4, $5, $6, $7)
         RETURNING id`
 
-    err := r.db.QueryRow(
+    err := r.db.QueryRowContext(ctx,
         query,
         workspace.ID,
         workspace.Name,
@@ -34,20 +38,20 @@ func (r *Repository) Create(workspace *entities.Workspace) error {
     ).Scan(&workspace.ID)
 
     if err != nil {
-        return fmt.Errorf("error creating workspace: %v", err)
+        return fmt.Errorf("error creating workspace: %w", err)
     }
 
     return nil
 }
 
-func (r *Repository) GetByID(id int, familyID int) (*entities.Workspace, error) {
+func (r *Repository) GetByID(ctx context.Context, id int, familyID int) (*entities.Workspace, error) {
     workspaceQuery := `
         SELECT w.id, w.name, w.description, w.profile_id, w.family_id, w.created_at, w.updated_at
         FROM workspace w
         WHERE w.id = $1 AND w.family_id = $2 AND w.is_deleted = false`
 
     workspace := new(entities.Workspace)
-    err := r.db.QueryRow(workspaceQuery, id, familyID).Scan(
+    err := r.db.QueryRowContext(ctx, workspaceQuery, id, familyID).Scan(
         &workspace.ID,
         &workspace.Name,
         &workspace.Description,
@@ -58,7 +62,7 @@ func (r *Repository) GetByID(id int, familyID int) (*entities.Workspace, error)
     )
 
     if err == sql.ErrNoRows {
-        return nil, fmt.Errorf("workspace not found")
+        return nil, apperror.NotFound("workspace not found")
     }
     if err != nil {
         return nil, err
@@ -72,7 +76,7 @@ func (r *Repository) GetByID(id int, familyID int) (*entities.Workspace, error)
         WHERE workspace_id = $1 AND family_id = $2 AND is_deleted = false
         ORDER BY created_at DESC`
 
-    rows, err := r.db.Query(containersQuery, id, familyID)
+    rows, err := r.db.QueryContext(ctx, containersQuery, id, familyID)
     if err != nil {
         return nil, err
     }
@@ -111,16 +115,16 @@ func (r *Repository) GetByID(id int, familyID int) (*entities.Workspace, error)
     return workspace, nil
 }
 
-func (r *Repository) GetByFamilyID(familyID int, profileID int) ([]*entities.Workspace, error) {
+func (r *Repository) GetByFamilyID(ctx context.Context, familyID int, profileID int) ([]*entities.Workspace, error) {
     query := `
         SELECT id, name, description, profile_id, family_id, created_at, updated_at 
         FROM workspace
         WHERE family_id = $1 AND is_deleted = false
         ORDER BY created_at DESC`
 
-    rows, err := r.db.Query(query, familyID)
+    rows, err := r.db.QueryContext(ctx, query, familyID)
     if err != nil {
-        return nil, fmt.Errorf("error querying workspaces: %v", err)
+        return nil, fmt.Errorf("error querying workspaces: %w", err)
     }
     defer rows.Close()
 
@@ -137,7 +141,7 @@ func (r *Repository) GetByFamilyID(familyID int, profileID int) ([]*entities.Wor
             &workspace.UpdatedAt,
         )
         if err != nil {
-            return nil, fmt.Errorf("error scanning workspace: %v", err)
+            return nil, fmt.Errorf("error scanning workspace: %w", err)
         }
 
         containersQuery := `
@@ -148,9 +152,9 @@ func (r *Repository) GetByFamilyID(familyID int, profileID int) ([]*entities.Wor
             WHERE workspace_id = $1 AND family_id = $2 AND is_deleted = false
             ORDER BY created_at DESC`
 
-        containerRows, err := r.db.Query(containersQuery, workspace.ID, familyID)
+        containerRows, err := r.db.QueryContext(ctx, containersQuery, workspace.ID, familyID)
         if err != nil {
-            return nil, fmt.Errorf("error querying containers: %v", err)
+            return nil, fmt.Errorf("error querying containers: %w", err)
         }
 
         workspace.Containers = make([]entities.Container, 0)
@@ -192,13 +196,13 @@ func (r *Repository) GetByFamilyID(familyID int, profileID int) ([]*entities.Wor
     return workspaces, nil
 }
 
-func (r *Repository) Update(workspace *entities.Workspace) error {
+func (r *Repository) Update(ctx context.Context, workspace *entities.Workspace) error {
     query := `
         UPDATE workspace
         SET name = $2, description = $3, updated_at = $4
         WHERE id = $1 AND family_id = $5 AND is_deleted = false`
 
-    result, err := r.db.Exec(
+    result, err := r.db.ExecContext(ctx,
         query,
         workspace.ID,
         workspace.Name,
@@ -207,129 +211,127 @@ func (r *Repository) Update(workspace *entities.Workspace) error {
         workspace.FamilyID,
     )
     if err != nil {
-        return fmt.Errorf("error updating workspace: %v", err)
+        return fmt.Errorf("error updating workspace: %w", err)
     }
 
     rowsAffected, err := result.RowsAffected()
     if err != nil {
-        return fmt.Errorf("error checking update result: %v", err)
+        return fmt.Errorf("error checking update result: %w", err)
     }
 
     if rowsAffected == 0 {
-        return fmt.Errorf("workspace not found")
+        return apperror.NotFound("workspace not found")
     }
 
     return nil
 }
 
-func (r *Repository) UpdateContainers(workspaceID int, familyID int, containerIDs []int) error {
-    tx, err := r.db.Begin()
+func (r *Repository) UpdateContainers(ctx context.Context, workspaceID int, familyID int, containerIDs []int) error {
+    tx, err := r.db.BeginTx(ctx, nil)
     if err != nil {
-        return fmt.Errorf("error starting transaction: %v", err)
+        return fmt.Errorf("error starting transaction: %w", err)
     }
     defer tx.Rollback()
 
-    if err := r.clearWorkspaceContainers(tx, workspaceID, familyID); err != nil {
+    if err := r.clearWorkspaceContainers(ctx, tx, workspaceID, familyID); err != nil {
         return err
     }
 
-    if err := r.assignContainersToWorkspace(tx, workspaceID, familyID, containerIDs); err != nil {
+    if err := r.assignContainersToWorkspace(ctx, tx, workspaceID, familyID, containerIDs); err != nil {
         return err
     }
 
     if err := tx.Commit(); err != nil {
-        return fmt.Errorf("error committing transaction: %v", err)
+        return fmt.Errorf("error committing transaction: %w", err)
     }
 
     return nil
 }
 
-func (r *Repository) clearWorkspaceContainers(tx *sql.Tx, workspaceID int, familyID int) error {
+func (r *Repository) clearWorkspaceContainers(ctx context.Context, tx *sql.Tx, workspaceID int, familyID int) error {
     query := `
         UPDATE container 
         SET workspace_id = NULL, updated_at = $3
         WHERE workspace_id = $1 AND family_id = $2`
 
-    _, err := tx.Exec(query, workspaceID, familyID, time.Now().UTC())
+    _, err := tx.ExecContext(ctx, query, workspaceID, familyID, time.Now().UTC())
     if err != nil {
-        return fmt.Errorf("error clearing workspace containers: %v", err)
+        return fmt.Errorf("error clearing workspace containers: %w", err)
     }
 
     return nil
 }
 
-func (r *Repository) assignContainersToWorkspace(tx *sql.Tx, workspaceID int, familyID int, containerIDs []int) error {
+func (r *Repository) assignContainersToWorkspace(ctx context.Context, tx *sql.Tx, workspaceID int, familyID int, containerIDs []int) error {
     query := `
         UPDATE container 
         SET workspace_id = $1, updated_at = $3
         WHERE id = ANY($2) AND family_id = $4`
 
-    _, err := tx.Exec(query, workspaceID, containerIDs, time.Now().UTC(), familyID)
+    _, err := tx.ExecContext(ctx, query, workspaceID, containerIDs, time.Now().UTC(), familyID)
     if err != nil {
-        return fmt.Errorf("error assigning containers to workspace: %v", err)
+        return fmt.Errorf("error assigning containers to workspace: %w", err)
     }
 
     return nil
 }
 
-func (r *Repository) Delete(id int, familyID int, deletedBy int) error {
-    tx, err := r.db.Begin()
+// Delete soft deletes the workspace together with its containers, their
+// items and the items' images and tag links.
+func (r *Repository) Delete(ctx context.Context, id int, familyID int, deletedBy int) error {
+    tx, err := r.db.BeginTx(ctx, nil)
     if err != nil {
-        return fmt.Errorf("error starting transaction: %v", err)
+        return fmt.Errorf("error starting transaction: %w", err)
     }
     defer tx.Rollback()
 
-    orphanQuery := `
-        UPDATE container 
-        SET workspace_id = NULL, updated_at = $3
-        WHERE workspace_id = $1 AND family_id = $2 AND is_deleted = false`
-    
-    _, err = tx.Exec(orphanQuery, id, familyID, time.Now().UTC())
-    if err != nil {
-        return fmt.Errorf("error orphaning containers: %v", err)
-    }
-    
-    deleteQuery := `
-        UPDATE workspace
-        SET is_deleted = true, deleted_at = $3, deleted_by = $4, updated_at = $3
-        WHERE id = $1 AND family_id = $2 AND is_deleted = false`
-    
-    result, err := tx.Exec(deleteQuery, id, familyID, time.Now().UTC(), deletedBy)
+    batch, err := deletion.Begin(ctx, tx, familyID, deletedBy)
     if err != nil {
-        return fmt.Errorf("error soft deleting workspace: %v", err)
+        return err
     }
 
-    rowsAffected, err := result.RowsAffected()
+    deleted, err := batch.Workspace(ctx, id)
     if err != nil {
-        return fmt.Errorf("error checking delete result: %v", err)
+        return err
     }
-
-    if rowsAffected == 0 {
-        return fmt.Errorf("workspace not found or already deleted")
+    if !deleted {
+        return apperror.NotFound("workspace not found or already deleted")
     }
 
     return tx.Commit()
 }
 
-func (r *Repository) RestoreDeleted(id int, familyID int) error {
-    query := `
-        UPDATE workspace
-        SET is_deleted = false, deleted_at = NULL, deleted_by = NULL, updated_at = $3
-        WHERE id = $1 AND family_id = $2 AND is_deleted = true`
-    
-    result, err := r.db.Exec(query, id, familyID, time.Now().UTC())
+// RestoreDeleted brings back the workspace and everything deleted with it.
+func (r *Repository) RestoreDeleted(ctx context.Context, id int, familyID int) error {
+    tx, err := r.db.BeginTx(ctx, nil)
     if err != nil {
-        return fmt.Errorf("error restoring workspace: %v", err)
+        return fmt.Errorf("error starting transaction: %w", err)
     }
+    defer tx.Rollback()
 
-    rowsAffected, err := result.RowsAffected()
+    restored, err := deletion.Restore(ctx, tx, deletion.Workspace, id, familyID)
     if err != nil {
-        return fmt.Errorf("error checking restore result: %v", err)
+        return err
     }
-
-    if rowsAffected == 0 {
-        return fmt.Errorf("workspace not found or not deleted")
+    if !restored {
+        return apperror.NotFound("workspace not found or not deleted")
     }
 
-    return nil
-}
\ No newline at end of file
+    return tx.Commit()
+}
+func (r *Repository) AreFamilyContainers(ctx context.Context, ids []int, familyID int) (bool, error) {
+    query := `
+        SELECT NOT EXISTS (
+            SELECT 1 FROM unnest($1::int[]) AS requested(id)
+            WHERE NOT EXISTS (
+                SELECT 1 FROM container
+                WHERE container.id = requested.id AND container.family_id = $2 AND container.is_deleted = false
+            )
+        )`
+
+    var owned bool
+    if err := r.db.QueryRowContext(ctx, query, pq.Array(ids), familyID).Scan(&owned); err != nil {
+        return false, fmt.Errorf("error checking containers: %w", err)
+    }
+    return owned, nil
+}
@@ -1,10 +1,13 @@
 package workspace
 
 import (
+	"context"
 	"fmt"
 	"math/rand"
 	"time"
 
+	"github.com/chrisabs/cadence/internal/platform/tracing"
+	"github.com/chrisabs/cadence/internal/platform/validate"
 	"github.com/chrisabs/cadence/internal/storage/entities"
 )
 
@@ -16,7 +19,14 @@ func NewService(repo *Repository) *Service {
     return &Service{repo: repo}
 }
 
-func (s *Service) CreateWorkspace(familyID int, profileID int, req *CreateWorkspaceRequest) (*entities.Workspace, error) {
+func (s *Service) CreateWorkspace(ctx context.Context, familyID int, profileID int, req *CreateWorkspaceRequest) (*entities.Workspace, error) {
+    ctx, span := tracing.Start(ctx, "workspace.Service.CreateWorkspace")
+    defer span.End()
+
+    if err := validate.Struct(req); err != nil {
+        return nil, err
+    }
+
     workspace := &entities.Workspace{
         ID:          rand.Intn(10000),
         Name:        req.Name,
@@ -28,58 +38,90 @@ func (s *Service) CreateWorkspace(familyID int, profileID int, req *CreateWorksp
         Containers:  make([]entities.Container, 0),
     }
 
-    if err := s.repo.Create(workspace); err != nil {
-        return nil, fmt.Errorf("failed to create workspace: %v", err)
+    if err := s.repo.Create(ctx, workspace); err != nil {
+        return nil, fmt.Errorf("failed to create workspace: %w", err)
     }
 
-    return s.repo.GetByID(workspace.ID, familyID)
+    return s.repo.GetByID(ctx, workspace.ID, familyID)
 }
 
-func (s *Service) GetWorkspaceByID(id int, familyID int) (*entities.Workspace, error) {
-    workspace, err := s.repo.GetByID(id, familyID)
+func (s *Service) GetWorkspaceByID(ctx context.Context, id int, familyID int) (*entities.Workspace, error) {
+    ctx, span := tracing.Start(ctx, "workspace.Service.GetWorkspaceByID")
+    defer span.End()
+
+    workspace, err := s.repo.GetByID(ctx, id, familyID)
     if err != nil {
-        return nil, fmt.Errorf("error getting workspace: %v", err)
+        return nil, fmt.Errorf("error getting workspace: %w", err)
     }
     return workspace, nil
 }
 
-func (s *Service) GetWorkspacesByFamilyID(familyID int, profileID int) ([]*entities.Workspace, error) {
-    return s.repo.GetByFamilyID(familyID, profileID)
+func (s *Service) GetWorkspacesByFamilyID(ctx context.Context, familyID int, profileID int) ([]*entities.Workspace, error) {
+    ctx, span := tracing.Start(ctx, "workspace.Service.GetWorkspacesByFamilyID")
+    defer span.End()
+
+    return s.repo.GetByFamilyID(ctx, familyID, profileID)
 }
 
-func (s *Service) UpdateWorkspace(id int, familyID int, req *UpdateWorkspaceRequest) (*entities.Workspace, error) {
-    workspace, err := s.repo.GetByID(id, familyID)
+func (s *Service) UpdateWorkspace(ctx context.Context, id int, familyID int, req *UpdateWorkspaceRequest) (*entities.Workspace, error) {
+    ctx, span := tracing.Start(ctx, "workspace.Service.UpdateWorkspace")
+    defer span.End()
+
+    errs := validate.Errors{}
+    validate.Collect(req, errs)
+
+    if len(req.ContainerIDs) > 0 {
+        owned, err := s.repo.AreFamilyContainers(ctx, req.ContainerIDs, familyID)
+        if err != nil {
+            return nil, err
+        }
+        if !owned {
+            errs.Add("containerIds", "must only contain containers in this family")
+        }
+    }
+
+    if err := errs.Err(); err != nil {
+        return nil, err
+    }
+
+    workspace, err := s.repo.GetByID(ctx, id, familyID)
     if err != nil {
-        return nil, fmt.Errorf("workspace not found: %v", err)
+        return nil, fmt.Errorf("workspace not found: %w", err)
     }
 
     workspace.Name = req.Name
     workspace.Description = req.Description
     workspace.UpdatedAt = time.Now().UTC()
 
-    if err := s.repo.Update(workspace); err != nil {
-        return nil, fmt.Errorf("failed to update workspace: %v", err)
+    if err := s.repo.Update(ctx, workspace); err != nil {
+        return nil, fmt.Errorf("failed to update workspace: %w", err)
     }
 
     if len(req.ContainerIDs) > 0 {
-        if err := s.repo.UpdateContainers(workspace.ID, familyID, req.ContainerIDs); err != nil {
-            return nil, fmt.Errorf("failed to update container assignments: %v", err)
+        if err := s.repo.UpdateContainers(ctx, workspace.ID, familyID, req.ContainerIDs); err != nil {
+            return nil, fmt.Errorf("failed to update container assignments: %w", err)
         }
     }
 
-    return s.repo.GetByID(workspace.ID, familyID)
+    return s.repo.GetByID(ctx, workspace.ID, familyID)
 }
 
-func (s *Service) DeleteWorkspace(id int, familyID int, deletedBy int) error {
-    if err := s.repo.Delete(id, familyID, deletedBy); err != nil {
-        return fmt.Errorf("failed to delete workspace: %v", err)
+func (s *Service) DeleteWorkspace(ctx context.Context, id int, familyID int, deletedBy int) error {
+    ctx, span := tracing.Start(ctx, "workspace.Service.DeleteWorkspace")
+    defer span.End()
+
+    if err := s.repo.Delete(ctx, id, familyID, deletedBy); err != nil {
+        return fmt.Errorf("failed to delete workspace: %w", err)
     }
     return nil
 }
 
-func (s *Service) RestoreWorkspace(id int, familyID int) error {
-    if err := s.repo.RestoreDeleted(id, familyID); err != nil {
-        return fmt.Errorf("failed to restore workspace: %v", err)
+func (s *Service) RestoreWorkspace(ctx context.Context, id int, familyID int) error {
+    ctx, span := tracing.Start(ctx, "workspace.Service.RestoreWorkspace")
+    defer span.End()
+
+    if err := s.repo.RestoreDeleted(ctx, id, familyID); err != nil {
+        return fmt.Errorf("failed to restore workspace: %w", err)
     }
     return nil
 }
\ No newline at end of file
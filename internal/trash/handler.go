@@ -0,0 +1,116 @@
+package trash
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/chrisabs/cadence/internal/apperror"
+	"github.com/chrisabs/cadence/internal/middleware"
+	"github.com/chrisabs/cadence/internal/models"
+	"github.com/chrisabs/cadence/internal/platform/respond"
+	"github.com/gorilla/mux"
+)
+
+type Handler struct {
+	service        *Service
+	authMiddleware *middleware.AuthMiddleware
+}
+
+func NewHandler(service *Service, authMiddleware *middleware.AuthMiddleware) *Handler {
+	return &Handler{
+		service:        service,
+		authMiddleware: authMiddleware,
+	}
+}
+
+// RegisterRoutes guards each trash like the restore endpoints of its module,
+// except that emptying it always needs the strongest permission there.
+func (h *Handler) RegisterRoutes(router *mux.Router) {
+	router.HandleFunc("/trash/settings", h.authMiddleware.ProfileAuthHandler(h.authMiddleware.RequireRole(models.RoleParent)(h.handleGetSettings))).Methods("GET")
+	router.HandleFunc("/trash/settings", h.authMiddleware.ProfileAuthHandler(h.authMiddleware.RequireOwner(h.authMiddleware.RequireVerifiedEmail(h.handleUpdateSettings)))).Methods("PUT")
+
+	router.HandleFunc("/trash/storage", h.authMiddleware.ModuleMiddleware(models.ModuleStorage, models.PermissionManage)(h.handleGetTrash(ModuleStorage))).Methods("GET")
+	router.HandleFunc("/trash/storage", h.authMiddleware.ModuleMiddleware(models.ModuleStorage, models.PermissionManage)(h.handleEmptyTrash(ModuleStorage))).Methods("DELETE")
+
+	router.HandleFunc("/trash/chores", h.authMiddleware.ModuleMiddleware(models.ModuleChores, models.PermissionWrite)(h.handleGetTrash(ModuleChores))).Methods("GET")
+	router.HandleFunc("/trash/chores", h.authMiddleware.ModuleMiddleware(models.ModuleChores, models.PermissionManage)(h.handleEmptyTrash(ModuleChores))).Methods("DELETE")
+
+	router.HandleFunc("/trash/profiles", h.authMiddleware.ProfileAuthHandler(h.authMiddleware.RequireRole(models.RoleParent)(h.handleGetTrash(ModuleProfiles)))).Methods("GET")
+	router.HandleFunc("/trash/profiles", h.authMiddleware.ProfileAuthHandler(h.authMiddleware.RequireOwner(h.authMiddleware.RequireVerifiedEmail(h.handleEmptyTrash(ModuleProfiles))))).Methods("DELETE")
+}
+
+func (h *Handler) handleGetTrash(module Module) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		profileCtx, ok := middleware.ProfileFrom(r.Context())
+		if !ok {
+			respond.Error(w, r, apperror.Unauthorized("profile authentication required"))
+			return
+		}
+
+		trash, err := h.service.GetTrash(r.Context(), module, profileCtx.FamilyID)
+		if err != nil {
+			respond.Error(w, r, err)
+			return
+		}
+
+		respond.JSON(w, http.StatusOK, trash)
+	}
+}
+
+// handleEmptyTrash permanently deletes everything in a module's trash. It
+// cannot be undone.
+func (h *Handler) handleEmptyTrash(module Module) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		profileCtx, ok := middleware.ProfileFrom(r.Context())
+		if !ok {
+			respond.Error(w, r, apperror.Unauthorized("profile authentication required"))
+			return
+		}
+
+		result, err := h.service.EmptyTrash(r.Context(), module, profileCtx.FamilyID)
+		if err != nil {
+			respond.Error(w, r, err)
+			return
+		}
+
+		respond.JSON(w, http.StatusOK, result)
+	}
+}
+
+func (h *Handler) handleGetSettings(w http.ResponseWriter, r *http.Request) {
+	profileCtx, ok := middleware.ProfileFrom(r.Context())
+	if !ok {
+		respond.Error(w, r, apperror.Unauthorized("profile authentication required"))
+		return
+	}
+
+	settings, err := h.service.GetSettings(r.Context(), profileCtx.FamilyID)
+	if err != nil {
+		respond.Error(w, r, err)
+		return
+	}
+
+	respond.JSON(w, http.StatusOK, settings)
+}
+
+func (h *Handler) handleUpdateSettings(w http.ResponseWriter, r *http.Request) {
+	profileCtx, ok := middleware.ProfileFrom(r.Context())
+	if !ok {
+		respond.Error(w, r, apperror.Unauthorized("profile authentication required"))
+		return
+	}
+
+	var req UpdateSettingsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respond.Error(w, r, apperror.BadRequest("invalid request body"))
+		return
+	}
+
+	settings, err := h.service.UpdateSettings(r.Context(), profileCtx.FamilyID, &req)
+	if err != nil {
+		respond.Error(w, r, err)
+		return
+	}
+
+	respond.JSON(w, http.StatusOK, settings)
+}
@@ -0,0 +1,61 @@
+package trash
+
+import "time"
+
+// Module is a part of the app with its own trash. Profiles are not a module
+// in the permissions sense but are soft deleted and restored the same way.
+type Module string
+
+const (
+	ModuleStorage  Module = "storage"
+	ModuleChores   Module = "chores"
+	ModuleProfiles Module = "profiles"
+)
+
+type EntityType string
+
+const (
+	EntityWorkspace     EntityType = "workspace"
+	EntityContainer     EntityType = "container"
+	EntityItem          EntityType = "item"
+	EntityItemImage     EntityType = "item_image"
+	EntityTag           EntityType = "tag"
+	EntityChore         EntityType = "chore"
+	EntityChoreInstance EntityType = "chore_instance"
+	EntityProfile       EntityType = "profile"
+)
+
+// Entry is one soft-deleted entity. PurgeAt is when the retention window
+// runs out and the entity is deleted for good.
+type Entry struct {
+	Type          EntityType `json:"type"`
+	ID            int        `json:"id"`
+	Name          string     `json:"name"`
+	DeletedAt     time.Time  `json:"deletedAt"`
+	DeletedBy     *int       `json:"deletedBy,omitempty"`
+	DeletedByName string     `json:"deletedByName,omitempty"`
+	PurgeAt       time.Time  `json:"purgeAt"`
+}
+
+type Trash struct {
+	Module        Module  `json:"module"`
+	RetentionDays int     `json:"retentionDays"`
+	Entries       []Entry `json:"entries"`
+}
+
+// PurgeResult counts what a purge permanently removed. Image files are
+// deleted from S3 after the rows, so ImagesDeleted can be lower than the
+// number of image rows if S3 refused some.
+type PurgeResult struct {
+	Module        Module             `json:"module"`
+	Purged        map[EntityType]int `json:"purged"`
+	ImagesDeleted int                `json:"imagesDeleted"`
+}
+
+type Settings struct {
+	RetentionDays int `json:"retentionDays"`
+}
+
+type UpdateSettingsRequest struct {
+	RetentionDays int `json:"retentionDays" validate:"required,min=1,max=365"`
+}
@@ -0,0 +1,473 @@
+package trash
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/chrisabs/cadence/internal/apperror"
+	"github.com/lib/pq"
+)
+
+// listQueries return the soft-deleted entities of a module as (type, id,
+// name, deleted at, deleted by, deleted by name). Children that were deleted
+// along with their parent, such as the images of a deleted item or the
+// containers in the same deletion batch as their workspace, are left out
+// because they come back or go with it.
+var listQueries = map[Module]string{
+	ModuleStorage: `
+		SELECT 'workspace', w.id, w.name, COALESCE(w.deleted_at, w.updated_at), w.deleted_by, COALESCE(p.name, '')
+		FROM workspace w
+		LEFT JOIN profile p ON p.id = w.deleted_by
+		WHERE w.family_id = $1 AND w.is_deleted = true
+
+		UNION ALL
+
+		SELECT 'container', c.id, COALESCE(c.name, ''), COALESCE(c.deleted_at, c.updated_at), c.deleted_by, COALESCE(p.name, '')
+		FROM container c
+		LEFT JOIN profile p ON p.id = c.deleted_by
+		WHERE c.family_id = $1 AND c.is_deleted = true
+		AND NOT EXISTS (
+			SELECT 1 FROM workspace pw
+			WHERE pw.id = c.workspace_id AND pw.deletion_batch = c.deletion_batch
+		)
+		AND NOT EXISTS (
+			SELECT 1 FROM container pc
+			WHERE pc.id = c.parent_container_id AND pc.deletion_batch = c.deletion_batch
+		)
+
+		UNION ALL
+
+		SELECT 'item', i.id, COALESCE(i.name, ''), COALESCE(i.deleted_at, i.updated_at), i.deleted_by, COALESCE(p.name, '')
+		FROM item i
+		LEFT JOIN profile p ON p.id = i.deleted_by
+		WHERE i.family_id = $1 AND i.is_deleted = true
+		AND NOT EXISTS (
+			SELECT 1 FROM container pc
+			WHERE pc.id = i.container_id AND pc.deletion_batch = i.deletion_batch
+		)
+
+		UNION ALL
+
+		SELECT 'item_image', img.id, COALESCE(i.name, ''), COALESCE(img.deleted_at, img.updated_at), img.deleted_by, COALESCE(p.name, '')
+		FROM item_image img
+		JOIN item i ON i.id = img.item_id AND i.is_deleted = false
+		LEFT JOIN profile p ON p.id = img.deleted_by
+		WHERE i.family_id = $1 AND img.is_deleted = true
+
+		UNION ALL
+
+		SELECT 'tag', t.id, COALESCE(t.name, ''), COALESCE(t.deleted_at, t.updated_at), t.deleted_by, COALESCE(p.name, '')
+		FROM tag t
+		LEFT JOIN profile p ON p.id = t.deleted_by
+		WHERE t.family_id = $1 AND t.is_deleted = true
+
+		ORDER BY 4 DESC, 1, 2`,
+
+	ModuleChores: `
+		SELECT 'chore', c.id, c.name, COALESCE(c.deleted_at, c.updated_at), c.deleted_by, COALESCE(p.name, '')
+		FROM chore c
+		LEFT JOIN profile p ON p.id = c.deleted_by
+		WHERE c.family_id = $1 AND c.is_deleted = true
+
+		UNION ALL
+
+		SELECT 'chore_instance', ci.id, c.name, COALESCE(ci.deleted_at, ci.updated_at), ci.deleted_by, COALESCE(p.name, '')
+		FROM chore_instance ci
+		JOIN chore c ON c.id = ci.chore_id AND c.is_deleted = false
+		LEFT JOIN profile p ON p.id = ci.deleted_by
+		WHERE ci.family_id = $1 AND ci.is_deleted = true
+
+		ORDER BY 4 DESC, 1, 2`,
+
+	ModuleProfiles: `
+		SELECT 'profile', pr.id, pr.name, COALESCE(pr.deleted_at, pr.updated_at), pr.deleted_by, COALESCE(p.name, '')
+		FROM profile pr
+		LEFT JOIN profile p ON p.id = pr.deleted_by
+		WHERE pr.family_id = $1 AND pr.is_deleted = true AND pr.purged_at IS NULL
+		ORDER BY 4 DESC, 2`,
+}
+
+type Repository struct {
+	db *sql.DB
+}
+
+func NewRepository(db *sql.DB) *Repository {
+	return &Repository{db: db}
+}
+
+func (r *Repository) GetRetentionDays(ctx context.Context, familyID int) (int, error) {
+	var days int
+	err := r.db.QueryRowContext(ctx, `
+		SELECT trash_retention_days FROM family_settings
+		WHERE family_id = $1 AND is_deleted = false`, familyID).Scan(&days)
+	if err == sql.ErrNoRows {
+		return 0, apperror.NotFound("family settings not found")
+	}
+	if err != nil {
+		return 0, fmt.Errorf("error getting trash retention: %w", err)
+	}
+	return days, nil
+}
+
+func (r *Repository) UpdateRetentionDays(ctx context.Context, familyID int, days int) error {
+	result, err := r.db.ExecContext(ctx, `
+		UPDATE family_settings
+		SET trash_retention_days = $2, updated_at = $3
+		WHERE family_id = $1 AND is_deleted = false`, familyID, days, time.Now().UTC())
+	if err != nil {
+		return fmt.Errorf("error updating trash retention: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("error checking update result: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return apperror.NotFound("family settings not found")
+	}
+
+	return nil
+}
+
+// familyRetention is one family's retention window, as read by the purge
+// job.
+type familyRetention struct {
+	familyID int
+	days     int
+}
+
+func (r *Repository) GetRetentions(ctx context.Context) ([]familyRetention, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT family_id, trash_retention_days FROM family_settings
+		WHERE is_deleted = false
+		ORDER BY family_id`)
+	if err != nil {
+		return nil, fmt.Errorf("error getting trash retentions: %w", err)
+	}
+	defer rows.Close()
+
+	var retentions []familyRetention
+	for rows.Next() {
+		var retention familyRetention
+		if err := rows.Scan(&retention.familyID, &retention.days); err != nil {
+			return nil, fmt.Errorf("error scanning trash retention: %w", err)
+		}
+		retentions = append(retentions, retention)
+	}
+
+	return retentions, rows.Err()
+}
+
+func (r *Repository) List(ctx context.Context, module Module, familyID int) ([]Entry, error) {
+	rows, err := r.db.QueryContext(ctx, listQueries[module], familyID)
+	if err != nil {
+		return nil, fmt.Errorf("error listing %s trash: %w", module, err)
+	}
+	defer rows.Close()
+
+	entries := make([]Entry, 0)
+	for rows.Next() {
+		var entry Entry
+		err := rows.Scan(
+			&entry.Type,
+			&entry.ID,
+			&entry.Name,
+			&entry.DeletedAt,
+			&entry.DeletedBy,
+			&entry.DeletedByName,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning trash entry: %w", err)
+		}
+		entries = append(entries, entry)
+	}
+
+	return entries, rows.Err()
+}
+
+// Purge permanently removes the module's entities that were soft deleted at
+// or before cutoff. It returns how many of each type went and the image URLs
+// that no row refers to any more, which the caller deletes from S3 once the
+// transaction has committed. Without an image store the purge is rolled back
+// if it would leave images behind.
+func (r *Repository) Purge(ctx context.Context, module Module, familyID int, cutoff time.Time, canDeleteImages bool) (map[EntityType]int, []string, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error starting transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `SELECT pg_advisory_xact_lock(hashtext('trash_purge'), $1)`, familyID); err != nil {
+		return nil, nil, fmt.Errorf("error locking family trash: %w", err)
+	}
+
+	purged := make(map[EntityType]int)
+	var urls []string
+
+	switch module {
+	case ModuleStorage:
+		urls, err = purgeStorage(ctx, tx, familyID, cutoff, purged)
+	case ModuleChores:
+		err = purgeChores(ctx, tx, familyID, cutoff, purged)
+	case ModuleProfiles:
+		urls, err = purgeProfiles(ctx, tx, familyID, cutoff, purged)
+	default:
+		err = apperror.NotFound("module not found")
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	urls, err = unreferenced(ctx, tx, urls)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if len(urls) > 0 && !canDeleteImages {
+		return nil, nil, fmt.Errorf("file storage not configured")
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, nil, fmt.Errorf("error committing purge: %w", err)
+	}
+
+	return purged, urls, nil
+}
+
+// purgeStorage deletes from the leaves up. Live rows that still point at a
+// purged container or workspace are detached first so nothing live is
+// cascaded away with it.
+func purgeStorage(ctx context.Context, tx *sql.Tx, familyID int, cutoff time.Time, purged map[EntityType]int) ([]string, error) {
+	itemIDs, err := queryIDs(ctx, tx, `
+		SELECT id FROM item
+		WHERE family_id = $1 AND is_deleted = true AND COALESCE(deleted_at, updated_at) <= $2`,
+		familyID, cutoff)
+	if err != nil {
+		return nil, fmt.Errorf("error finding expired items: %w", err)
+	}
+
+	urls, err := queryStrings(ctx, tx, `
+		SELECT img.url FROM item_image img
+		JOIN item i ON i.id = img.item_id
+		WHERE i.family_id = $1
+		AND (img.item_id = ANY($3) OR (img.is_deleted = true AND COALESCE(img.deleted_at, img.updated_at) <= $2))`,
+		familyID, cutoff, pq.Array(itemIDs))
+	if err != nil {
+		return nil, fmt.Errorf("error finding expired images: %w", err)
+	}
+
+	steps := []struct {
+		entity EntityType
+		query  string
+		args   []any
+	}{
+		{EntityItemImage, `
+			DELETE FROM item_image img
+			USING item i
+			WHERE i.id = img.item_id AND i.family_id = $1 AND NOT (img.item_id = ANY($3))
+			AND img.is_deleted = true AND COALESCE(img.deleted_at, img.updated_at) <= $2`,
+			[]any{familyID, cutoff, pq.Array(itemIDs)}},
+		{EntityItem, `DELETE FROM item WHERE id = ANY($1)`, []any{pq.Array(itemIDs)}},
+		{EntityTag, `
+			DELETE FROM tag
+			WHERE family_id = $1 AND is_deleted = true AND COALESCE(deleted_at, updated_at) <= $2`,
+			[]any{familyID, cutoff}},
+	}
+
+	for _, step := range steps {
+		result, err := tx.ExecContext(ctx, step.query, step.args...)
+		if err != nil {
+			return nil, fmt.Errorf("error purging %ss: %w", step.entity, err)
+		}
+		if purged[step.entity], err = rowsAffected(result); err != nil {
+			return nil, err
+		}
+	}
+
+	containerIDs, err := queryIDs(ctx, tx, `
+		SELECT id FROM container
+		WHERE family_id = $1 AND is_deleted = true AND COALESCE(deleted_at, updated_at) <= $2`,
+		familyID, cutoff)
+	if err != nil {
+		return nil, fmt.Errorf("error finding expired containers: %w", err)
+	}
+
+	if _, err := tx.ExecContext(ctx, `
+		UPDATE item SET container_id = NULL
+		WHERE container_id = ANY($1)`, pq.Array(containerIDs)); err != nil {
+		return nil, fmt.Errorf("error detaching items: %w", err)
+	}
+
+	if _, err := tx.ExecContext(ctx, `
+		UPDATE container SET parent_container_id = NULL
+		WHERE parent_container_id = ANY($1) AND NOT (id = ANY($1))`, pq.Array(containerIDs)); err != nil {
+		return nil, fmt.Errorf("error detaching child containers: %w", err)
+	}
+
+	result, err := tx.ExecContext(ctx, `DELETE FROM container WHERE id = ANY($1)`, pq.Array(containerIDs))
+	if err != nil {
+		return nil, fmt.Errorf("error purging containers: %w", err)
+	}
+	if purged[EntityContainer], err = rowsAffected(result); err != nil {
+		return nil, err
+	}
+
+	workspaceIDs, err := queryIDs(ctx, tx, `
+		SELECT id FROM workspace
+		WHERE family_id = $1 AND is_deleted = true AND COALESCE(deleted_at, updated_at) <= $2`,
+		familyID, cutoff)
+	if err != nil {
+		return nil, fmt.Errorf("error finding expired workspaces: %w", err)
+	}
+
+	if _, err := tx.ExecContext(ctx, `
+		UPDATE container SET workspace_id = NULL
+		WHERE workspace_id = ANY($1)`, pq.Array(workspaceIDs)); err != nil {
+		return nil, fmt.Errorf("error detaching containers: %w", err)
+	}
+
+	result, err = tx.ExecContext(ctx, `DELETE FROM workspace WHERE id = ANY($1)`, pq.Array(workspaceIDs))
+	if err != nil {
+		return nil, fmt.Errorf("error purging workspaces: %w", err)
+	}
+	if purged[EntityWorkspace], err = rowsAffected(result); err != nil {
+		return nil, err
+	}
+
+	return urls, nil
+}
+
+// purgeChores deletes instances deleted on their own, then chores, whose
+// remaining instances go with them.
+func purgeChores(ctx context.Context, tx *sql.Tx, familyID int, cutoff time.Time, purged map[EntityType]int) error {
+	result, err := tx.ExecContext(ctx, `
+		DELETE FROM chore_instance
+		WHERE family_id = $1 AND is_deleted = true AND COALESCE(deleted_at, updated_at) <= $2`,
+		familyID, cutoff)
+	if err != nil {
+		return fmt.Errorf("error purging chore instances: %w", err)
+	}
+	if purged[EntityChoreInstance], err = rowsAffected(result); err != nil {
+		return err
+	}
+
+	result, err = tx.ExecContext(ctx, `
+		DELETE FROM chore
+		WHERE family_id = $1 AND is_deleted = true AND COALESCE(deleted_at, updated_at) <= $2`,
+		familyID, cutoff)
+	if err != nil {
+		return fmt.Errorf("error purging chores: %w", err)
+	}
+	purged[EntityChore], err = rowsAffected(result)
+	return err
+}
+
+// purgeProfiles scrubs expired profiles rather than deleting them. Everything
+// a profile created refers to it, some of it with ON DELETE CASCADE, so the
+// row stays as an anonymous tombstone that can no longer be restored.
+func purgeProfiles(ctx context.Context, tx *sql.Tx, familyID int, cutoff time.Time, purged map[EntityType]int) ([]string, error) {
+	profileIDs, err := queryIDs(ctx, tx, `
+		SELECT id FROM profile
+		WHERE family_id = $1 AND is_deleted = true AND purged_at IS NULL
+		AND COALESCE(deleted_at, updated_at) <= $2`,
+		familyID, cutoff)
+	if err != nil {
+		return nil, fmt.Errorf("error finding expired profiles: %w", err)
+	}
+
+	urls, err := queryStrings(ctx, tx, `
+		SELECT image_url FROM profile
+		WHERE id = ANY($1) AND image_url IS NOT NULL AND image_url <> ''`, pq.Array(profileIDs))
+	if err != nil {
+		return nil, fmt.Errorf("error finding profile images: %w", err)
+	}
+
+	for _, query := range []string{
+		`DELETE FROM refresh_token WHERE profile_id = ANY($1)`,
+		`DELETE FROM device_session WHERE profile_id = ANY($1)`,
+	} {
+		if _, err := tx.ExecContext(ctx, query, pq.Array(profileIDs)); err != nil {
+			return nil, fmt.Errorf("error removing profile sessions: %w", err)
+		}
+	}
+
+	result, err := tx.ExecContext(ctx, `
+		UPDATE profile
+		SET name = 'Deleted profile', email = NULL, password = NULL, pin = '', image_url = '',
+		    pin_failed_attempts = 0, pin_locked_until = NULL, purged_at = $2
+		WHERE id = ANY($1)`, pq.Array(profileIDs), time.Now().UTC())
+	if err != nil {
+		return nil, fmt.Errorf("error purging profiles: %w", err)
+	}
+	if purged[EntityProfile], err = rowsAffected(result); err != nil {
+		return nil, err
+	}
+
+	return urls, nil
+}
+
+// unreferenced filters urls down to those no remaining row uses, so a file
+// shared with a live record is never deleted.
+func unreferenced(ctx context.Context, tx *sql.Tx, urls []string) ([]string, error) {
+	if len(urls) == 0 {
+		return nil, nil
+	}
+
+	urls, err := queryStrings(ctx, tx, `
+		SELECT DISTINCT u FROM unnest($1::text[]) AS u
+		WHERE NOT EXISTS (SELECT 1 FROM item_image WHERE url = u)
+		AND NOT EXISTS (SELECT 1 FROM profile WHERE image_url = u)`, pq.Array(urls))
+	if err != nil {
+		return nil, fmt.Errorf("error checking image references: %w", err)
+	}
+	return urls, nil
+}
+
+func queryIDs(ctx context.Context, tx *sql.Tx, query string, args ...any) ([]int64, error) {
+	rows, err := tx.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ids := make([]int64, 0)
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+
+	return ids, rows.Err()
+}
+
+func queryStrings(ctx context.Context, tx *sql.Tx, query string, args ...any) ([]string, error) {
+	rows, err := tx.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var values []string
+	for rows.Next() {
+		var value string
+		if err := rows.Scan(&value); err != nil {
+			return nil, err
+		}
+		values = append(values, value)
+	}
+
+	return values, rows.Err()
+}
+
+func rowsAffected(result sql.Result) (int, error) {
+	n, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("error checking purge result: %w", err)
+	}
+	return int(n), nil
+}
@@ -0,0 +1,176 @@
+package trash
+
+import (
+	"context"
+	"time"
+
+	"github.com/chrisabs/cadence/internal/platform/logging"
+	"github.com/chrisabs/cadence/internal/platform/tracing"
+	"github.com/chrisabs/cadence/internal/platform/validate"
+)
+
+// purgeInterval is how often the background job purges expired trash.
+const purgeInterval = time.Hour
+
+var modules = []Module{ModuleStorage, ModuleChores, ModuleProfiles}
+
+// ObjectStore holds the uploaded images deleted along with their rows.
+type ObjectStore interface {
+	DeleteObject(ctx context.Context, key string) error
+	KeyFromURL(url string) (string, bool)
+}
+
+type Service struct {
+	repo  *Repository
+	store ObjectStore
+}
+
+func NewService(repo *Repository) *Service {
+	return &Service{repo: repo}
+}
+
+func (s *Service) SetObjectStore(store ObjectStore) {
+	s.store = store
+}
+
+// GetTrash lists a module's soft-deleted entities, newest first, with when
+// each will be purged.
+func (s *Service) GetTrash(ctx context.Context, module Module, familyID int) (*Trash, error) {
+	ctx, span := tracing.Start(ctx, "trash.Service.GetTrash")
+	defer span.End()
+
+	days, err := s.repo.GetRetentionDays(ctx, familyID)
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := s.repo.List(ctx, module, familyID)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range entries {
+		entries[i].PurgeAt = entries[i].DeletedAt.AddDate(0, 0, days)
+	}
+
+	return &Trash{Module: module, RetentionDays: days, Entries: entries}, nil
+}
+
+// EmptyTrash purges everything in a module's trash now, whatever its age.
+func (s *Service) EmptyTrash(ctx context.Context, module Module, familyID int) (*PurgeResult, error) {
+	ctx, span := tracing.Start(ctx, "trash.Service.EmptyTrash")
+	defer span.End()
+
+	return s.purge(ctx, module, familyID, time.Now().UTC())
+}
+
+func (s *Service) GetSettings(ctx context.Context, familyID int) (*Settings, error) {
+	ctx, span := tracing.Start(ctx, "trash.Service.GetSettings")
+	defer span.End()
+
+	days, err := s.repo.GetRetentionDays(ctx, familyID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Settings{RetentionDays: days}, nil
+}
+
+// UpdateSettings changes the retention window. Shortening it takes effect on
+// the next run of the purge job, including for entities already in the
+// trash.
+func (s *Service) UpdateSettings(ctx context.Context, familyID int, req *UpdateSettingsRequest) (*Settings, error) {
+	ctx, span := tracing.Start(ctx, "trash.Service.UpdateSettings")
+	defer span.End()
+
+	if err := validate.Struct(req); err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.UpdateRetentionDays(ctx, familyID, req.RetentionDays); err != nil {
+		return nil, err
+	}
+
+	return &Settings{RetentionDays: req.RetentionDays}, nil
+}
+
+// Run purges trash older than each family's retention window every
+// purgeInterval until ctx is cancelled.
+func (s *Service) Run(ctx context.Context) {
+	ticker := time.NewTicker(purgeInterval)
+	defer ticker.Stop()
+
+	for {
+		s.purgeExpired(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *Service) purgeExpired(ctx context.Context) {
+	logger := logging.FromContext(ctx)
+
+	retentions, err := s.repo.GetRetentions(ctx)
+	if err != nil {
+		logger.Error("failed to get trash retentions", "error", err)
+		return
+	}
+
+	now := time.Now().UTC()
+	for _, retention := range retentions {
+		cutoff := now.AddDate(0, 0, -retention.days)
+
+		for _, module := range modules {
+			if ctx.Err() != nil {
+				return
+			}
+
+			result, err := s.purge(ctx, module, retention.familyID, cutoff)
+			if err != nil {
+				logger.Error("failed to purge trash", "family_id", retention.familyID, "module", module, "error", err)
+				continue
+			}
+
+			if total(result.Purged) > 0 {
+				logger.Info("purged trash", "family_id", retention.familyID, "module", module,
+					"purged", result.Purged, "images_deleted", result.ImagesDeleted)
+			}
+		}
+	}
+}
+
+// purge removes the rows first and then their images, so a failure to reach
+// S3 leaves an orphaned file rather than a row pointing at nothing.
+func (s *Service) purge(ctx context.Context, module Module, familyID int, cutoff time.Time) (*PurgeResult, error) {
+	purged, urls, err := s.repo.Purge(ctx, module, familyID, cutoff, s.store != nil)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &PurgeResult{Module: module, Purged: purged}
+	for _, url := range urls {
+		key, ok := s.store.KeyFromURL(url)
+		if !ok {
+			continue
+		}
+		if err := s.store.DeleteObject(ctx, key); err != nil {
+			logging.FromContext(ctx).Error("failed to delete purged image", "family_id", familyID, "key", key, "error", err)
+			continue
+		}
+		result.ImagesDeleted++
+	}
+
+	return result, nil
+}
+
+func total(counts map[EntityType]int) int {
+	n := 0
+	for _, count := range counts {
+		n += count
+	}
+	return n
+}
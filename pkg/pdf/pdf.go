@@ -0,0 +1,262 @@
+// Package pdf writes simple PDF documents made of text in the standard
+// Helvetica fonts and grayscale images. It covers what the label sheets need
+// without pulling in a full PDF library.
+package pdf
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Points per millimetre and per inch, the units page layouts are given in.
+const (
+	MM   = 72 / 25.4
+	Inch = 72.0
+)
+
+type Document struct {
+	width  float64
+	height float64
+	pages  []*Page
+	images []*Image
+}
+
+type Page struct {
+	content bytes.Buffer
+}
+
+// Image is an 8-bit grayscale image added to a document once and drawn on any
+// number of pages.
+type Image struct {
+	name   string
+	width  int
+	height int
+	pixels []byte
+}
+
+// New starts a document whose pages are width x height points.
+func New(width, height float64) *Document {
+	return &Document{width: width, height: height}
+}
+
+func (d *Document) AddPage() *Page {
+	page := &Page{}
+	d.pages = append(d.pages, page)
+	return page
+}
+
+// AddGrayImage registers a width x height image with one byte per pixel,
+// 0 being black.
+func (d *Document) AddGrayImage(width, height int, pixels []byte) (*Image, error) {
+	if len(pixels) != width*height {
+		return nil, fmt.Errorf("pdf: image has %d pixels, want %d", len(pixels), width*height)
+	}
+
+	img := &Image{
+		name:   fmt.Sprintf("Im%d", len(d.images)+1),
+		width:  width,
+		height: height,
+		pixels: pixels,
+	}
+	d.images = append(d.images, img)
+	return img, nil
+}
+
+// Text draws s with its baseline starting at x, y, measured from the bottom
+// left of the page.
+func (p *Page) Text(x, y, size float64, bold bool, s string) {
+	font := "F1"
+	if bold {
+		font = "F2"
+	}
+	fmt.Fprintf(&p.content, "BT /%s %.2f Tf %.2f %.2f Td (%s) Tj ET\n", font, size, x, y, escape(s))
+}
+
+// Image draws img scaled to w x h points with its bottom left corner at x, y.
+func (p *Page) Image(img *Image, x, y, w, h float64) {
+	fmt.Fprintf(&p.content, "q %.2f 0 0 %.2f %.2f %.2f cm /%s Do Q\n", w, h, x, y, img.name)
+}
+
+// Write renders the document.
+func (d *Document) Write(w io.Writer) error {
+	var buf bytes.Buffer
+	var offsets []int
+
+	newObject := func() int {
+		offsets = append(offsets, buf.Len())
+		id := len(offsets)
+		fmt.Fprintf(&buf, "%d 0 obj\n", id)
+		return id
+	}
+	endObject := func() {
+		buf.WriteString("endobj\n")
+	}
+	writeStream := func(dict string, data []byte) error {
+		compressed, err := deflate(data)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(&buf, "<< %s /Filter /FlateDecode /Length %d >>\nstream\n", dict, len(compressed))
+		buf.Write(compressed)
+		buf.WriteString("\nendstream\n")
+		return nil
+	}
+
+	buf.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+
+	// Object numbers are fixed up front: catalog, page tree, two fonts, the
+	// images and then a page and content stream per page.
+	const catalogID, pagesID, fontID, boldFontID = 1, 2, 3, 4
+	firstImageID := 5
+	firstPageID := firstImageID + len(d.images)
+
+	newObject()
+	fmt.Fprintf(&buf, "<< /Type /Catalog /Pages %d 0 R >>\n", pagesID)
+	endObject()
+
+	newObject()
+	kids := make([]string, len(d.pages))
+	for i := range d.pages {
+		kids[i] = fmt.Sprintf("%d 0 R", firstPageID+2*i)
+	}
+	fmt.Fprintf(&buf, "<< /Type /Pages /Kids [%s] /Count %d >>\n", strings.Join(kids, " "), len(d.pages))
+	endObject()
+
+	newObject()
+	buf.WriteString("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>\n")
+	endObject()
+
+	newObject()
+	buf.WriteString("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica-Bold /Encoding /WinAnsiEncoding >>\n")
+	endObject()
+
+	var xObjects []string
+	for _, img := range d.images {
+		id := newObject()
+		dict := fmt.Sprintf("/Type /XObject /Subtype /Image /Width %d /Height %d /ColorSpace /DeviceGray /BitsPerComponent 8", img.width, img.height)
+		if err := writeStream(dict, img.pixels); err != nil {
+			return err
+		}
+		endObject()
+		xObjects = append(xObjects, fmt.Sprintf("/%s %d 0 R", img.name, id))
+	}
+
+	resources := fmt.Sprintf("<< /Font << /F1 %d 0 R /F2 %d 0 R >> /XObject << %s >> >>", fontID, boldFontID, strings.Join(xObjects, " "))
+
+	for _, page := range d.pages {
+		pageID := newObject()
+		fmt.Fprintf(&buf, "<< /Type /Page /Parent %d 0 R /MediaBox [0 0 %.2f %.2f] /Resources %s /Contents %d 0 R >>\n",
+			pagesID, d.width, d.height, resources, pageID+1)
+		endObject()
+
+		newObject()
+		if err := writeStream("", page.content.Bytes()); err != nil {
+			return err
+		}
+		endObject()
+	}
+
+	xrefOffset := buf.Len()
+	fmt.Fprintf(&buf, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&buf, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&buf, "trailer\n<< /Size %d /Root %d 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, catalogID, xrefOffset)
+
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+// TextWidth returns the width of s in points when drawn at size. Bold text
+// is estimated from the regular metrics, which is close enough for fitting
+// labels.
+func TextWidth(s string, size float64, bold bool) float64 {
+	var units int
+	for _, r := range toWinAnsi(s) {
+		if r >= 32 && int(r-32) < len(helveticaWidths) {
+			units += helveticaWidths[r-32]
+		} else {
+			units += 556
+		}
+	}
+
+	width := float64(units) * size / 1000
+	if bold {
+		width *= 1.08
+	}
+	return width
+}
+
+// Truncate shortens s with an ellipsis so it fits in maxWidth points.
+func Truncate(s string, size float64, bold bool, maxWidth float64) string {
+	if TextWidth(s, size, bold) <= maxWidth {
+		return s
+	}
+
+	runes := []rune(s)
+	for len(runes) > 0 {
+		runes = runes[:len(runes)-1]
+		candidate := strings.TrimSpace(string(runes)) + "..."
+		if TextWidth(candidate, size, bold) <= maxWidth {
+			return candidate
+		}
+	}
+	return ""
+}
+
+func escape(s string) string {
+	var b strings.Builder
+	for _, r := range toWinAnsi(s) {
+		switch r {
+		case '(', ')', '\\':
+			b.WriteByte('\\')
+			b.WriteByte(byte(r))
+		default:
+			b.WriteByte(byte(r))
+		}
+	}
+	return b.String()
+}
+
+// toWinAnsi maps s onto the printable Latin-1 range the standard fonts cover,
+// replacing anything else with '?'.
+func toWinAnsi(s string) []rune {
+	out := make([]rune, 0, len(s))
+	for _, r := range s {
+		switch {
+		case r >= 32 && r <= 126, r >= 160 && r <= 255:
+			out = append(out, r)
+		case r == '\n' || r == '\t':
+			out = append(out, ' ')
+		default:
+			out = append(out, '?')
+		}
+	}
+	return out
+}
+
+func deflate(data []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	zw := zlib.NewWriter(&buf)
+	if _, err := zw.Write(data); err != nil {
+		return nil, err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// helveticaWidths are the Helvetica advance widths for characters 32 to 126,
+// in thousandths of the font size.
+var helveticaWidths = []int{
+	278, 278, 355, 556, 556, 889, 667, 191, 333, 333, 389, 584, 278, 333, 278, 278,
+	556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 278, 278, 584, 584, 584, 556,
+	1015, 667, 667, 722, 722, 667, 611, 778, 722, 278, 500, 667, 556, 833, 722, 778,
+	667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 278, 278, 278, 469, 556,
+	333, 556, 556, 500, 556, 556, 278, 556, 556, 222, 222, 500, 222, 833, 556, 556,
+	556, 556, 333, 500, 278, 556, 500, 722, 500, 500, 500, 334, 260, 334, 584,
+}
@@ -1,22 +1,54 @@
 package utils
 
 import (
+	"crypto/rand"
+	"encoding/base32"
 	"encoding/base64"
 	"fmt"
-	"time"
+	"strings"
 
 	"github.com/skip2/go-qrcode"
 )
 
-func GenerateQRCode(containerID int) (string, string, error) {
-	qrString := fmt.Sprintf("STQRAGE-CONTAINER-%d-%d", containerID, time.Now().Unix())
+const qrCodePrefix = "STQRAGE-"
 
-	qr, err := qrcode.Encode(qrString, qrcode.Medium, 256)
+// GenerateQRCode returns a new random QR payload and its PNG image as a data
+// URL. The payload carries no container details so it cannot be guessed from
+// other labels.
+func GenerateQRCode() (string, string, error) {
+	b := make([]byte, 15)
+	if _, err := rand.Read(b); err != nil {
+		return "", "", fmt.Errorf("failed to generate QR token: %v", err)
+	}
+
+	qrString := qrCodePrefix + strings.ToLower(base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(b))
+
+	qrImage, err := RenderQRCode(qrString)
+	if err != nil {
+		return "", "", err
+	}
+
+	return qrString, qrImage, nil
+}
+
+// RenderQRCode encodes payload as a PNG QR code data URL.
+func RenderQRCode(payload string) (string, error) {
+	qr, err := qrcode.Encode(payload, qrcode.Medium, 256)
 	if err != nil {
-		return "", "", fmt.Errorf("failed to generate QR code: %v", err)
+		return "", fmt.Errorf("failed to generate QR code: %v", err)
 	}
 
-	qrBase64 := fmt.Sprintf("data:image/png;base64,%s", base64.StdEncoding.EncodeToString(qr))
+	return fmt.Sprintf("data:image/png;base64,%s", base64.StdEncoding.EncodeToString(qr)), nil
+}
+
+// QRCodeBitmap returns the modules of payload's QR code, including the quiet
+// zone, with true for black. Label sheets draw it at print resolution instead
+// of scaling the PNG.
+func QRCodeBitmap(payload string) ([][]bool, error) {
+	qr, err := qrcode.New(payload, qrcode.Medium)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate QR code: %v", err)
+	}
 
-	return qrString, qrBase64, nil
+	return qr.Bitmap(), nil
 }